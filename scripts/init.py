@@ -115,6 +115,9 @@ port = $redis_port
 usr = $redis_user
 pwd = $redis_pass
 database = 0
+
+[hostsnap]
+agentStaleMinutes = 30
 '''
 
     template = FileTemplate(datacollection_file_template_str)
@@ -14,6 +14,7 @@ package host
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"configcenter/src/common/metadata"
@@ -93,3 +94,63 @@ func (h *host) DeleteHost(ctx context.Context, header http.Header, input *metada
 		Into(resp)
 	return
 }
+
+// CreateHostSnapMapping create the mapping from host snapshot to host attribute
+func (h *host) CreateHostSnapMapping(ctx context.Context, header http.Header, input *metadata.HostSnapMapping) (resp *metadata.HostSnapMappingResult, err error) {
+	resp = new(metadata.HostSnapMappingResult)
+	subPath := "/create/hostsnap/mapping"
+
+	err = h.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+// UpdateHostSnapMapping update the host snapshot mapping
+func (h *host) UpdateHostSnapMapping(ctx context.Context, header http.Header, id int64, input *metadata.HostSnapMapping) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/update/hostsnap/mapping/%d", id)
+
+	err = h.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+// DeleteHostSnapMapping delete the host snapshot mapping
+func (h *host) DeleteHostSnapMapping(ctx context.Context, header http.Header, id int64) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/delete/hostsnap/mapping/%d", id)
+
+	err = h.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+// SearchHostSnapMapping search the host snapshot mappings
+func (h *host) SearchHostSnapMapping(ctx context.Context, header http.Header, input *metadata.QueryCondition) (resp *metadata.SearchHostSnapMappingResult, err error) {
+	resp = new(metadata.SearchHostSnapMappingResult)
+	subPath := "/read/hostsnap/mapping"
+
+	err = h.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
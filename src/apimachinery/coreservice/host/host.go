@@ -26,6 +26,11 @@ type HostClientInterface interface {
 	TransferHostCrossBusiness(ctx context.Context, header http.Header, input *metadata.TransferHostsCrossBusinessRequest) (resp *metadata.OperaterException, err error)
 	GetHostModuleRelation(ctx context.Context, header http.Header, input *metadata.HostModuleRelationRequest) (resp *metadata.HostConfig, err error)
 	DeleteHost(ctx context.Context, header http.Header, input *metadata.DeleteHostRequest) (resp *metadata.OperaterException, err error)
+
+	CreateHostSnapMapping(ctx context.Context, header http.Header, input *metadata.HostSnapMapping) (resp *metadata.HostSnapMappingResult, err error)
+	UpdateHostSnapMapping(ctx context.Context, header http.Header, id int64, input *metadata.HostSnapMapping) (resp *metadata.Response, err error)
+	DeleteHostSnapMapping(ctx context.Context, header http.Header, id int64) (resp *metadata.Response, err error)
+	SearchHostSnapMapping(ctx context.Context, header http.Header, input *metadata.QueryCondition) (resp *metadata.SearchHostSnapMappingResult, err error)
 }
 
 func NewHostClientInterface(client rest.ClientInterface) HostClientInterface {
@@ -103,3 +103,213 @@ func (e *eventServer) Rebook(ctx context.Context, ownerID string, appID string,
 		Into(resp)
 	return
 }
+
+func (e *eventServer) SearchDeadLetter(ctx context.Context, h http.Header, dat metadata.ParamSubscriptionSearch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/deadletter/search"
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) GetDeadLetter(ctx context.Context, deadLetterID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/deadletter/%s", deadLetterID)
+
+	err = e.client.Get().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) ReplayDeadLetter(ctx context.Context, subscribeID string, h http.Header, dat *metadata.ParamEventDeadLetter) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/deadletter/replay/%s", subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) PurgeDeadLetter(ctx context.Context, subscribeID string, h http.Header, dat *metadata.ParamEventDeadLetter) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/deadletter/purge/%s", subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) CreateEventCursor(ctx context.Context, subscribeID string, h http.Header, dat *metadata.ParamEventCursorCreate) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/feed/cursor/%s", subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) GetEventCursor(ctx context.Context, subscribeID string, cursorID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/feed/cursor/%s/%s", subscribeID, cursorID)
+
+	err = e.client.Get().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) DeleteEventCursor(ctx context.Context, subscribeID string, cursorID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/feed/cursor/%s/%s", subscribeID, cursorID)
+
+	err = e.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) FetchEventFeed(ctx context.Context, subscribeID string, cursorID string, h http.Header, dat *metadata.ParamEventFeedFetch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/feed/fetch/%s/%s", subscribeID, cursorID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) AckEventFeed(ctx context.Context, subscribeID string, cursorID string, h http.Header, dat *metadata.ParamEventFeedAck) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/feed/ack/%s/%s", subscribeID, cursorID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) CreateEventReplay(ctx context.Context, subscribeID string, h http.Header, dat *metadata.EventReplayRange) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/replay/%s", subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) SearchEventReplay(ctx context.Context, h http.Header, dat metadata.ParamSubscriptionSearch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/replay/search"
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) GetEventReplay(ctx context.Context, replayID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/replay/%s", replayID)
+
+	err = e.client.Get().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) CancelEventReplay(ctx context.Context, subscribeID string, replayID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/replay/cancel/%s/%s", subscribeID, replayID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) PauseSubscription(ctx context.Context, subscribeID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/subscription/pause/%s", subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) ResumeSubscription(ctx context.Context, subscribeID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/subscription/resume/%s", subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -29,6 +29,22 @@ type EventServerClientInterface interface {
 	Subscribe(ctx context.Context, ownerID string, appID string, h http.Header, subscription *metadata.Subscription) (resp *metadata.Response, err error)
 	UnSubscribe(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header) (resp *metadata.Response, err error)
 	Rebook(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, subscription *metadata.Subscription) (resp *metadata.Response, err error)
+	SearchDeadLetter(ctx context.Context, h http.Header, dat metadata.ParamSubscriptionSearch) (resp *metadata.Response, err error)
+	GetDeadLetter(ctx context.Context, deadLetterID string, h http.Header) (resp *metadata.Response, err error)
+	ReplayDeadLetter(ctx context.Context, subscribeID string, h http.Header, dat *metadata.ParamEventDeadLetter) (resp *metadata.Response, err error)
+	PurgeDeadLetter(ctx context.Context, subscribeID string, h http.Header, dat *metadata.ParamEventDeadLetter) (resp *metadata.Response, err error)
+	CreateEventCursor(ctx context.Context, subscribeID string, h http.Header, dat *metadata.ParamEventCursorCreate) (resp *metadata.Response, err error)
+	GetEventCursor(ctx context.Context, subscribeID string, cursorID string, h http.Header) (resp *metadata.Response, err error)
+	DeleteEventCursor(ctx context.Context, subscribeID string, cursorID string, h http.Header) (resp *metadata.Response, err error)
+	FetchEventFeed(ctx context.Context, subscribeID string, cursorID string, h http.Header, dat *metadata.ParamEventFeedFetch) (resp *metadata.Response, err error)
+	AckEventFeed(ctx context.Context, subscribeID string, cursorID string, h http.Header, dat *metadata.ParamEventFeedAck) (resp *metadata.Response, err error)
+	CreateEventReplay(ctx context.Context, subscribeID string, h http.Header, dat *metadata.EventReplayRange) (resp *metadata.Response, err error)
+	SearchEventReplay(ctx context.Context, h http.Header, dat metadata.ParamSubscriptionSearch) (resp *metadata.Response, err error)
+	GetEventReplay(ctx context.Context, replayID string, h http.Header) (resp *metadata.Response, err error)
+	CancelEventReplay(ctx context.Context, subscribeID string, replayID string, h http.Header) (resp *metadata.Response, err error)
+
+	PauseSubscription(ctx context.Context, subscribeID string, h http.Header) (resp *metadata.Response, err error)
+	ResumeSubscription(ctx context.Context, subscribeID string, h http.Header) (resp *metadata.Response, err error)
 }
 
 func NewEventServerClientInterface(c *util.Capability, version string) EventServerClientInterface {
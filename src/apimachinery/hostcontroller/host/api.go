@@ -117,3 +117,171 @@ func (host *hostctrl) QueryHostLock(ctx context.Context, h http.Header, input *m
 		Into(resp)
 	return
 }
+
+func (host *hostctrl) AddHostTransferPlan(ctx context.Context, h http.Header, input *metadata.HostTransferPlan) (resp *metadata.HostTransferPlanResult, err error) {
+	resp = new(metadata.HostTransferPlanResult)
+	subPath := "/transfer/plan"
+
+	err = host.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) UpdateHostTransferPlan(ctx context.Context, planID int64, h http.Header, input *metadata.UpdateHostTransferPlan) (resp *metadata.HostTransferPlanResult, err error) {
+	resp = new(metadata.HostTransferPlanResult)
+	subPath := fmt.Sprintf("/transfer/plan/%d", planID)
+
+	err = host.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) DeleteHostTransferPlan(ctx context.Context, planID int64, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/transfer/plan/%d", planID)
+
+	err = host.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) SearchHostTransferPlan(ctx context.Context, h http.Header, input *metadata.QueryInput) (resp *metadata.SearchHostTransferPlanResult, err error) {
+	resp = new(metadata.SearchHostTransferPlanResult)
+	subPath := "/transfer/plan/search"
+
+	err = host.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) AddResourceDirectory(ctx context.Context, h http.Header, input *metadata.ResourceDirectory) (resp *metadata.ResourceDirectoryResult, err error) {
+	resp = new(metadata.ResourceDirectoryResult)
+	subPath := "/resource/directory"
+
+	err = host.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) UpdateResourceDirectory(ctx context.Context, dirID int64, h http.Header, input map[string]interface{}) (resp *metadata.ResourceDirectoryResult, err error) {
+	resp = new(metadata.ResourceDirectoryResult)
+	subPath := fmt.Sprintf("/resource/directory/%d", dirID)
+
+	err = host.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) DeleteResourceDirectory(ctx context.Context, dirID int64, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/resource/directory/%d", dirID)
+
+	err = host.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) SearchResourceDirectory(ctx context.Context, h http.Header, input *metadata.QueryInput) (resp *metadata.SearchResourceDirectoryResult, err error) {
+	resp = new(metadata.SearchResourceDirectoryResult)
+	subPath := "/resource/directory/search"
+
+	err = host.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) MoveHostToResourceDirectory(ctx context.Context, h http.Header, input *metadata.MoveHostToResourceDirectory) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/resource/directory/host/move"
+
+	err = host.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) SaveHostDuplicates(ctx context.Context, h http.Header, input *metadata.SaveHostDuplicates) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/duplicate/host/save"
+
+	err = host.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) UpdateHostDuplicateStatus(ctx context.Context, duplicateID int64, h http.Header, status string) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/duplicate/host/%d", duplicateID)
+
+	err = host.client.Put().
+		WithContext(ctx).
+		Body(map[string]interface{}{"status": status}).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (host *hostctrl) SearchHostDuplicates(ctx context.Context, h http.Header, input *metadata.QueryInput) (resp *metadata.SearchHostDuplicateResult, err error) {
+	resp = new(metadata.SearchHostDuplicateResult)
+	subPath := "/duplicate/host/search"
+
+	err = host.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -29,6 +29,21 @@ type HostInterface interface {
 	LockHost(ctx context.Context, h http.Header, input *metadata.HostLockRequest) (resp *metadata.HostLockResponse, err error)
 	UnlockHost(ctx context.Context, h http.Header, input *metadata.HostLockRequest) (resp *metadata.HostLockResponse, err error)
 	QueryHostLock(ctx context.Context, h http.Header, input *metadata.QueryHostLockRequest) (resp *metadata.HostLockQueryResponse, err error)
+
+	AddHostTransferPlan(ctx context.Context, h http.Header, input *metadata.HostTransferPlan) (resp *metadata.HostTransferPlanResult, err error)
+	UpdateHostTransferPlan(ctx context.Context, planID int64, h http.Header, input *metadata.UpdateHostTransferPlan) (resp *metadata.HostTransferPlanResult, err error)
+	DeleteHostTransferPlan(ctx context.Context, planID int64, h http.Header) (resp *metadata.Response, err error)
+	SearchHostTransferPlan(ctx context.Context, h http.Header, input *metadata.QueryInput) (resp *metadata.SearchHostTransferPlanResult, err error)
+
+	AddResourceDirectory(ctx context.Context, h http.Header, input *metadata.ResourceDirectory) (resp *metadata.ResourceDirectoryResult, err error)
+	UpdateResourceDirectory(ctx context.Context, dirID int64, h http.Header, input map[string]interface{}) (resp *metadata.ResourceDirectoryResult, err error)
+	DeleteResourceDirectory(ctx context.Context, dirID int64, h http.Header) (resp *metadata.Response, err error)
+	SearchResourceDirectory(ctx context.Context, h http.Header, input *metadata.QueryInput) (resp *metadata.SearchResourceDirectoryResult, err error)
+	MoveHostToResourceDirectory(ctx context.Context, h http.Header, input *metadata.MoveHostToResourceDirectory) (resp *metadata.Response, err error)
+
+	SaveHostDuplicates(ctx context.Context, h http.Header, input *metadata.SaveHostDuplicates) (resp *metadata.Response, err error)
+	UpdateHostDuplicateStatus(ctx context.Context, duplicateID int64, h http.Header, status string) (resp *metadata.Response, err error)
+	SearchHostDuplicates(ctx context.Context, h http.Header, input *metadata.QueryInput) (resp *metadata.SearchHostDuplicateResult, err error)
 }
 
 func NewHostInterface(client rest.ClientInterface) HostInterface {
@@ -90,6 +90,48 @@ func (u *user) GetUserConfigDetail(ctx context.Context, businessID string, id st
 	return
 }
 
+func (u *user) SearchScheduledUserConfig(ctx context.Context, h http.Header) (resp *metadata.ScheduledUserConfigResult, err error) {
+	resp = new(metadata.ScheduledUserConfigResult)
+	subPath := "/userapi/scheduled/search"
+
+	err = u.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (u *user) SaveUserConfigSnapshot(ctx context.Context, businessID string, id string, h http.Header, dat *metadata.SaveDynamicGroupSnapshot) (resp *metadata.DynamicGroupSnapshotResult, err error) {
+	resp = new(metadata.DynamicGroupSnapshotResult)
+	subPath := fmt.Sprintf("/userapi/snapshot/%s/%s", businessID, id)
+
+	err = u.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (u *user) SearchUserConfigSnapshot(ctx context.Context, businessID string, id string, h http.Header, page *metadata.BasePage) (resp *metadata.SearchDynamicGroupSnapshotResult, err error) {
+	resp = new(metadata.SearchDynamicGroupSnapshotResult)
+	subPath := fmt.Sprintf("/userapi/snapshot/search/%s/%s", businessID, id)
+
+	err = u.client.Post().
+		WithContext(ctx).
+		Body(page).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (u *user) AddUserCustom(ctx context.Context, user string, h http.Header, dat map[string]interface{}) (resp *metadata.BaseResp, err error) {
 	resp = new(metadata.BaseResp)
 	subPath := fmt.Sprintf("/usercustom/%s", user)
@@ -108,6 +108,8 @@ func convertResourceType(resourceType meta.ResourceType, businessID int64) (*Res
 		iamResourceType = BizProcessInstance
 	case meta.EventPushing:
 		iamResourceType = SysEventPushing
+	case meta.ResourcePoolDirectory:
+		iamResourceType = SysResourceDir
 	case meta.DynamicGrouping:
 		iamResourceType = BizCustomQuery
 	case meta.AuditLog:
@@ -143,6 +145,7 @@ const (
 	SysInstance         ResourceTypeID = "sys_instance"
 	SysAssociationType  ResourceTypeID = "sys_association_type"
 	SysAuditLog         ResourceTypeID = "sys_audit_log"
+	SysResourceDir      ResourceTypeID = "sys_resource_directory"
 )
 
 // Business Resource
@@ -172,6 +175,7 @@ var ResourceTypeIDMap = map[ResourceTypeID]string{
 	SysInstance:         "实例",
 	SysAssociationType:  "关联类型",
 	SysAuditLog:         "操作审计",
+	SysResourceDir:      "资源池目录",
 	BizCustomQuery:      "动态分组",
 	BizHostInstance:     "业务主机",
 	BizProcessInstance:  "进程",
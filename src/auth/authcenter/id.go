@@ -75,6 +75,8 @@ func GenerateResourceID(resourceType ResourceTypeID, attribute *meta.ResourceAtt
 		return make([]RscTypeAndID, 0), nil
 	case meta.Plat:
 		return platID(resourceType, attribute)
+	case meta.ResourcePoolDirectory:
+		return resourceDirectoryID(resourceType, attribute)
 	default:
 		return nil, fmt.Errorf("gen id failed: unsupported resource type: %s", attribute.Type)
 	}
@@ -372,3 +374,15 @@ func platID(resourceType ResourceTypeID, attribute *meta.ResourceAttribute) ([]R
 		},
 	}, nil
 }
+
+func resourceDirectoryID(resourceType ResourceTypeID, attribute *meta.ResourceAttribute) ([]RscTypeAndID, error) {
+	if attribute.InstanceID <= 0 {
+		return make([]RscTypeAndID, 0), nil
+	}
+	return []RscTypeAndID{
+		{
+			ResourceType: resourceType,
+			ResourceID:   fmt.Sprintf("directory:%d", attribute.InstanceID),
+		},
+	}, nil
+}
@@ -151,6 +151,29 @@ var expectSystemResourceType = []ResourceType{
 			},
 		},
 	},
+	{
+		ResourceTypeID:       SysResourceDir,
+		ResourceTypeName:     "资源池目录",
+		ParentResourceTypeID: "",
+		Share:                false,
+		Actions: []Action{
+			{
+				ActionID:          Create,
+				ActionName:        "新建",
+				IsRelatedResource: false,
+			},
+			{
+				ActionID:          Edit,
+				ActionName:        "编辑",
+				IsRelatedResource: true,
+			},
+			{
+				ActionID:          Delete,
+				ActionName:        "删除",
+				IsRelatedResource: true,
+			},
+		},
+	},
 	{
 		ResourceTypeID:       SysEventPushing,
 		ResourceTypeName:     "事件推送",
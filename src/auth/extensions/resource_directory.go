@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+/*
+ * resource directory represent the directory of idle hosts in resource pool
+ */
+
+func (am *AuthManager) collectResourceDirectoryByIDs(ctx context.Context, header http.Header, dirIDs ...int64) ([]metadata.ResourceDirectory, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	// unique ids so that we can be aware of invalid id if query result length not equal ids's length
+	dirIDs = util.IntArrayUnique(dirIDs)
+
+	input := &metadata.QueryInput{
+		Condition: map[string]interface{}{
+			common.BKResourceDirIDField: map[string]interface{}{common.BKDBIN: dirIDs},
+		},
+	}
+	result, err := am.clientSet.HostController().Host().SearchResourceDirectory(ctx, header, input)
+	if err != nil {
+		blog.V(3).Infof("get resource directories by id failed, err: %+v, rid: %s", err, rid)
+		return nil, fmt.Errorf("get resource directories by id failed, err: %+v", err)
+	}
+	if !result.Result {
+		blog.V(3).Infof("get resource directories by id failed, err: %s, rid: %s", result.ErrMsg, rid)
+		return nil, fmt.Errorf("get resource directories by id failed, err: %s", result.ErrMsg)
+	}
+	return result.Data.Info, nil
+}
+
+func (am *AuthManager) makeResourcesByResourceDirectory(header http.Header, action meta.Action, dirs ...metadata.ResourceDirectory) []meta.ResourceAttribute {
+	resources := make([]meta.ResourceAttribute, 0)
+	for _, dir := range dirs {
+		resource := meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Action:     action,
+				Type:       meta.ResourcePoolDirectory,
+				Name:       dir.DirName,
+				InstanceID: dir.DirID,
+			},
+			SupplierAccount: util.GetOwnerID(header),
+		}
+
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+func (am *AuthManager) AuthorizeByResourceDirectory(ctx context.Context, header http.Header, action meta.Action, dirs ...metadata.ResourceDirectory) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	// make auth resources
+	resources := am.makeResourcesByResourceDirectory(header, action, dirs...)
+
+	return am.authorize(ctx, header, 0, resources...)
+}
+
+func (am *AuthManager) AuthorizeByResourceDirectoryIDs(ctx context.Context, header http.Header, action meta.Action, dirIDs ...int64) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(dirIDs) == 0 {
+		return nil
+	}
+
+	dirs, err := am.collectResourceDirectoryByIDs(ctx, header, dirIDs...)
+	if err != nil {
+		return fmt.Errorf("get resource directory by id failed, err: %+v", err)
+	}
+	return am.AuthorizeByResourceDirectory(ctx, header, action, dirs...)
+}
+
+func (am *AuthManager) RegisterResourceDirectory(ctx context.Context, header http.Header, dirs ...metadata.ResourceDirectory) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	// make auth resources
+	resources := am.makeResourcesByResourceDirectory(header, meta.EmptyAction, dirs...)
+
+	return am.Authorize.RegisterResource(ctx, resources...)
+}
+
+func (am *AuthManager) UpdateRegisteredResourceDirectory(ctx context.Context, header http.Header, dirs ...metadata.ResourceDirectory) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	// make auth resources
+	resources := am.makeResourcesByResourceDirectory(header, meta.EmptyAction, dirs...)
+
+	for _, resource := range resources {
+		if err := am.Authorize.UpdateResource(ctx, &resource); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (am *AuthManager) DeregisterResourceDirectory(ctx context.Context, header http.Header, dirs ...metadata.ResourceDirectory) error {
+	if am.Enabled() == false {
+		return nil
+	}
+
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	// make auth resources
+	resources := am.makeResourcesByResourceDirectory(header, meta.EmptyAction, dirs...)
+
+	return am.Authorize.DeregisterResource(ctx, resources...)
+}
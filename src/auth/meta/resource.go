@@ -52,6 +52,9 @@ const (
 	ResourceSync             ResourceType = "resourceSync" // 云资源发现
 	UserCustom               ResourceType = "usercustom"   // 用户自定义
 	SystemBase               ResourceType = "systemBase"
+
+	// ResourcePoolDirectory 资源池目录
+	ResourcePoolDirectory ResourceType = "resourcePoolDirectory"
 )
 
 const (
@@ -61,10 +64,15 @@ const (
 	ProcessBoundConfig           = "processBoundConfig"
 	SystemFunctionality          = "systemFunctionality"
 
-	NetCollector = "netCollector"
-	NetDevice    = "netDevice"
-	NetProperty  = "netProperty"
-	NetReport    = "netReport"
+	NetCollector  = "netCollector"
+	NetDevice     = "netDevice"
+	NetProperty   = "netProperty"
+	NetReport     = "netReport"
+	NetReportRule = "netReportRule"
+
+	HostSnapMapping = "hostSnapMapping"
+
+	DiscoverSchemaPolicy = "discoverSchemaPolicy"
 )
 
 type ResourceDescribe struct {
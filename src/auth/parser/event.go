@@ -26,7 +26,11 @@ func (ps *parseStream) eventRelated() *parseStream {
 		return ps
 	}
 
-	ps.subscribe()
+	ps.subscribe().
+		deadLetter().
+		feed().
+		replay().
+		subscriptionStatus()
 
 	return ps
 }
@@ -140,3 +144,175 @@ func (ps *parseStream) subscribe() *parseStream {
 
 	return ps
 }
+
+var (
+	findDeadLetterPattern  = "/api/v3/event/deadletter/search"
+	getDeadLetterRegexp    = regexp.MustCompile(`^/api/v3/event/deadletter/\d+/?$`)
+	replayDeadLetterRegexp = regexp.MustCompile(`^/api/v3/event/deadletter/replay/\d+/?$`)
+	purgeDeadLetterRegexp  = regexp.MustCompile(`^/api/v3/event/deadletter/purge/\d+/?$`)
+)
+
+// deadLetter the dead letters are the events of subscriptions failed to deliver,
+// replaying or purging them needs to edit the subscription.
+func (ps *parseStream) deadLetter() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(findDeadLetterPattern, http.MethodPost) ||
+		ps.hitRegexp(getDeadLetterRegexp, http.MethodGet) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitRegexp(replayDeadLetterRegexp, http.MethodPost) ||
+		ps.hitRegexp(purgeDeadLetterRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[5], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("handle dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[5])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+var (
+	feedCursorRegexp = regexp.MustCompile(`^/api/v3/event/feed/cursor/\d+(/\d+)?/?$`)
+	feedFetchRegexp  = regexp.MustCompile(`^/api/v3/event/feed/fetch/\d+/\d+/?$`)
+	feedAckRegexp    = regexp.MustCompile(`^/api/v3/event/feed/ack/\d+/\d+/?$`)
+)
+
+// feed the consumers pull the events of subscription by cursors, it needs to find the subscription,
+// and to edit the subscription when the cursors are created, deleted or moved by ack.
+func (ps *parseStream) feed() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	var action meta.Action
+	switch {
+	case ps.hitRegexp(feedCursorRegexp, http.MethodGet), ps.hitRegexp(feedFetchRegexp, http.MethodPost):
+		action = meta.Find
+	case ps.hitRegexp(feedCursorRegexp, http.MethodPost), ps.hitRegexp(feedCursorRegexp, http.MethodDelete),
+		ps.hitRegexp(feedAckRegexp, http.MethodPost):
+		action = meta.Update
+	default:
+		return ps
+	}
+
+	subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[5], 10, 64)
+	if err != nil {
+		ps.err = fmt.Errorf("handle event feed, but got invalid subscription id: %s", ps.RequestCtx.Elements[5])
+		return ps
+	}
+	ps.Attribute.Resources = []meta.ResourceAttribute{
+		meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Type:       meta.EventPushing,
+				Action:     action,
+				InstanceID: subscribeID,
+			},
+		},
+	}
+	return ps
+}
+
+var (
+	findReplayPattern  = "/api/v3/event/replay/search"
+	getReplayRegexp    = regexp.MustCompile(`^/api/v3/event/replay/\d+/?$`)
+	createReplayRegexp = regexp.MustCompile(`^/api/v3/event/replay/\d+/?$`)
+	cancelReplayRegexp = regexp.MustCompile(`^/api/v3/event/replay/cancel/\d+/\d+/?$`)
+)
+
+// replay the replay tasks deliver the events to the subscription again, it needs to edit the subscription.
+func (ps *parseStream) replay() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(findReplayPattern, http.MethodPost) ||
+		ps.hitRegexp(getReplayRegexp, http.MethodGet) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	subscribeIndex := 0
+	if ps.hitRegexp(createReplayRegexp, http.MethodPost) {
+		subscribeIndex = 4
+	} else if ps.hitRegexp(cancelReplayRegexp, http.MethodPost) {
+		subscribeIndex = 5
+	}
+	if subscribeIndex > 0 {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[subscribeIndex], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("handle event replay, but got invalid subscription id: %s", ps.RequestCtx.Elements[subscribeIndex])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+var pauseSubscriptionRegexp = regexp.MustCompile(`^/api/v3/event/subscription/(pause|resume)/\d+/?$`)
+
+func (ps *parseStream) subscriptionStatus() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// pause or resume a subscription
+	if ps.hitRegexp(pauseSubscriptionRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[5], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("pause or resume subscription, but got invalid subscription id: %s", ps.RequestCtx.Elements[5])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
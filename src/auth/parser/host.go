@@ -25,6 +25,9 @@ func (ps *parseStream) hostRelated() *parseStream {
 		hostFavorite().
 		cloudResourceSync().
 		hostSnapshot().
+		hostTransferPlan().
+		resourceDirectory().
+		hostDuplicate().
 		findObjectIdentifier()
 
 	return ps
@@ -37,6 +40,8 @@ var (
 	findUserAPIRegexp        = regexp.MustCompile(`^/api/v3/userapi/search/[0-9]+/?$`)
 	findUserAPIDetailsRegexp = regexp.MustCompile(`^/api/v3/userapi/detail/[0-9]+/[^\s/]+/?$`)
 	findWithUserAPIRegexp    = regexp.MustCompile(`^/api/v3/userapi/data/[0-9]+/[^\s/]+/[0-9]+/[0-9]+/?$`)
+	evaluateUserAPIRegexp    = regexp.MustCompile(`^/api/v3/userapi/evaluate/[0-9]+/[^\s/]+/?$`)
+	findUserAPISnapRegexp    = regexp.MustCompile(`^/api/v3/userapi/snapshot/search/[0-9]+/[^\s/]+/?$`)
 )
 
 func (ps *parseStream) parseBusinessID() (int64, error) {
@@ -199,6 +204,58 @@ func (ps *parseStream) userAPI() *parseStream {
 		return ps
 	}
 
+	// evaluate the members of user custom query immediately.
+	if ps.hitRegexp(evaluateUserAPIRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 6 {
+			ps.err = errors.New("evaluate host user custom query, but got invalid uri")
+			return ps
+		}
+
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[4], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("evaluate host user custom query failed, err: %v", err)
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.DynamicGrouping,
+					Action: meta.Execute,
+					Name:   ps.RequestCtx.Elements[5],
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the member snapshots of user custom query.
+	if ps.hitRegexp(findUserAPISnapRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 7 {
+			ps.err = errors.New("find host user custom query snapshot, but got invalid uri")
+			return ps
+		}
+
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[5], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find host user custom query snapshot failed, err: %v", err)
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.DynamicGrouping,
+					Action: meta.Find,
+					Name:   ps.RequestCtx.Elements[6],
+				},
+			},
+		}
+		return ps
+	}
+
 	return ps
 }
 
@@ -713,6 +770,104 @@ func (ps *parseStream) hostSnapshot() *parseStream {
 	return ps
 }
 
+var (
+	createHostTransferPlanPattern = "/api/v3/hosts/transfer/plan"
+	findHostTransferPlanPattern   = "/api/v3/hosts/transfer/plan/search"
+	hostTransferPlanRegexp        = regexp.MustCompile(`^/api/v3/hosts/transfer/plan/[0-9]+/?$`)
+	operateHostTransferPlanRegexp = regexp.MustCompile(`^/api/v3/hosts/transfer/plan/[0-9]+/(submit|approve|reject)/?$`)
+)
+
+// hostTransferPlan the permissions of the plan are checked by host server,
+// the hosts are authorized when the plan is saved or submitted, and the
+// business is authorized when the plan is approved, rejected or deleted.
+func (ps *parseStream) hostTransferPlan() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(createHostTransferPlanPattern, http.MethodPost) ||
+		ps.hitPattern(findHostTransferPlanPattern, http.MethodPost) ||
+		ps.hitRegexp(hostTransferPlanRegexp, http.MethodPut) ||
+		ps.hitRegexp(hostTransferPlanRegexp, http.MethodDelete) ||
+		ps.hitRegexp(operateHostTransferPlanRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+	return ps
+}
+
+var (
+	createResourceDirPattern  = "/api/v3/hosts/resource/directory"
+	findResourceDirPattern    = "/api/v3/hosts/resource/directory/search"
+	moveHostToResourceDirPath = "/api/v3/hosts/resource/directory/host/move"
+	assignResourceDirHostPath = "/api/v3/hosts/resource/directory/host/assign"
+	resourceDirRegexp         = regexp.MustCompile(`^/api/v3/hosts/resource/directory/[0-9]+/?$`)
+)
+
+// resourceDirectory the directories and the hosts in them are authorized by host server,
+// as the resources to check depend on where the hosts are now.
+func (ps *parseStream) resourceDirectory() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(createResourceDirPattern, http.MethodPost) ||
+		ps.hitPattern(findResourceDirPattern, http.MethodPost) ||
+		ps.hitPattern(moveHostToResourceDirPath, http.MethodPost) ||
+		ps.hitPattern(assignResourceDirHostPath, http.MethodPost) ||
+		ps.hitRegexp(resourceDirRegexp, http.MethodPut) ||
+		ps.hitRegexp(resourceDirRegexp, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ResourcePoolDirectory,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+	return ps
+}
+
+var (
+	detectHostDuplicatePattern = "/api/v3/hosts/duplicate/detect"
+	findHostDuplicatePattern   = "/api/v3/hosts/duplicate/search"
+	mergeHostDuplicatePattern  = "/api/v3/hosts/duplicate/merge"
+	ignoreHostDuplicateRegexp  = regexp.MustCompile(`^/api/v3/hosts/duplicate/[0-9]+/ignore/?$`)
+)
+
+// hostDuplicate the hosts to merge are authorized by host server, the survivor
+// may be picked there.
+func (ps *parseStream) hostDuplicate() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(detectHostDuplicatePattern, http.MethodPost) ||
+		ps.hitPattern(findHostDuplicatePattern, http.MethodPost) ||
+		ps.hitPattern(mergeHostDuplicatePattern, http.MethodPost) ||
+		ps.hitRegexp(ignoreHostDuplicateRegexp, http.MethodPut) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+	return ps
+}
+
 var (
 	findIdentifierAPIRegexp = regexp.MustCompile(`^/api/v3/identifier/[^\s/]+/search/?$`)
 )
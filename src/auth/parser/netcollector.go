@@ -15,7 +15,10 @@ func (ps *parseStream) netCollectorRelated() *parseStream {
 	ps.netCollector().
 		netDevice().
 		netProperty().
-		netReport()
+		netReport().
+		netReportRule().
+		hostSnapMapping().
+		discoverSchema()
 
 	return ps
 }
@@ -24,6 +27,12 @@ const (
 	findNetCollectorsPattern  = "/api/v3/collector/netcollect/collector/action/search"
 	updateNetCollectorPattern = "/api/v3/collector/netcollect/collector/action/update"
 	startNetCollectorPattern  = "/api/v3/collector/netcollect/collector/action/discover"
+
+	findStreamPorterMetricsPattern = "/api/v3/collector/porter/stream/action/search"
+)
+
+var (
+	replayPorterMessagesRegexp = regexp.MustCompile(`^/api/v3/collector/porter/[^\s/]+/action/replay$`)
 )
 
 func (ps *parseStream) netCollector() *parseStream {
@@ -59,6 +68,34 @@ func (ps *parseStream) netCollector() *parseStream {
 		return ps
 	}
 
+	// find the consuming metrics of the porters reading from redis stream.
+	if ps.hitPattern(findStreamPorterMetricsPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetCollector,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// replay the recorded messages through a porter's analyzer, which writes the analyzed data.
+	if ps.hitRegexp(replayPorterMessagesRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetCollector,
+					Action: meta.UpdateMany,
+				},
+			},
+		}
+		return ps
+	}
+
 	// start one/many net collector to collector data.
 	if ps.hitPattern(startNetCollectorPattern, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
@@ -265,6 +302,7 @@ const (
 	findNetDeviceDetailReportPattern        = "/api/v3/collector/netcollect/report/action/search"
 	findNetDeviceReportConfirmPattern       = "/api/v3/collector/netcollect/report/action/search"
 	findNetDeviceReportConfirmDetailPattern = "/api/v3/collector/netcollect/report/action/confirm"
+	findNetDeviceLinkPattern                = "/api/v3/collector/netcollect/link/action/search"
 )
 
 func (ps *parseStream) netReport() *parseStream {
@@ -327,5 +365,300 @@ func (ps *parseStream) netReport() *parseStream {
 		return ps
 	}
 
+	// find the links between net devices discovered from the neighbor tables.
+	if ps.hitPattern(findNetDeviceLinkPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetReport,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	createNetReportRulePattern = "/api/v3/collector/netcollect/rule/action/create"
+	findNetReportRulePattern   = "/api/v3/collector/netcollect/rule/action/search"
+)
+
+var (
+	updateNetReportRuleRegexp = regexp.MustCompile(`^/api/v3/collector/netcollect/rule/[0-9]+/action/update$`)
+	deleteNetReportRuleRegexp = regexp.MustCompile(`^/api/v3/collector/netcollect/rule/[0-9]+/action/delete$`)
+)
+
+func (ps *parseStream) netReportRule() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create a net report auto confirm rule
+	if ps.hitPattern(createNetReportRulePattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetReportRule,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// update a net report auto confirm rule
+	if ps.hitRegexp(updateNetReportRuleRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetReportRule,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// delete a net report auto confirm rule
+	if ps.hitRegexp(deleteNetReportRuleRegexp, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetReportRule,
+					Action: meta.Delete,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find net report auto confirm rules
+	if ps.hitPattern(findNetReportRulePattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.NetReportRule,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	createHostSnapMappingPattern = "/api/v3/collector/hostsnap/mapping/action/create"
+	findHostSnapMappingPattern   = "/api/v3/collector/hostsnap/mapping/action/search"
+	testHostSnapMappingPattern   = "/api/v3/collector/hostsnap/mapping/action/test"
+
+	findHostHardwareHistoryPattern = "/api/v3/collector/hostsnap/hardware/history/action/search"
+	findStaleHostPattern           = "/api/v3/collector/hostsnap/stale/action/search"
+)
+
+var (
+	updateHostSnapMappingRegexp = regexp.MustCompile(`^/api/v3/collector/hostsnap/mapping/[0-9]+/action/update$`)
+	deleteHostSnapMappingRegexp = regexp.MustCompile(`^/api/v3/collector/hostsnap/mapping/[0-9]+/action/delete$`)
+)
+
+func (ps *parseStream) hostSnapMapping() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create a host snapshot attribute mapping
+	if ps.hitPattern(createHostSnapMappingPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.HostSnapMapping,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// update a host snapshot attribute mapping
+	if ps.hitRegexp(updateHostSnapMappingRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.HostSnapMapping,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// delete a host snapshot attribute mapping
+	if ps.hitRegexp(deleteHostSnapMappingRegexp, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.HostSnapMapping,
+					Action: meta.Delete,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find host snapshot attribute mappings
+	if ps.hitPattern(findHostSnapMappingPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.HostSnapMapping,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// test the mappings with a sample snapshot, nothing is saved.
+	if ps.hitPattern(testHostSnapMappingPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.HostSnapMapping,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the hardware change history of host
+	if ps.hitPattern(findHostHardwareHistoryPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the hosts whose agent stops reporting snapshot
+	if ps.hitPattern(findStaleHostPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	createDiscoverSchemaPolicyPattern = "/api/v3/collector/discover/schema/policy/action/create"
+	findDiscoverSchemaPolicyPattern   = "/api/v3/collector/discover/schema/policy/action/search"
+	findDiscoverSchemaDriftPattern    = "/api/v3/collector/discover/schema/drift/action/search"
+)
+
+var (
+	updateDiscoverSchemaPolicyRegexp = regexp.MustCompile(`^/api/v3/collector/discover/schema/policy/[0-9]+/action/update$`)
+	deleteDiscoverSchemaPolicyRegexp = regexp.MustCompile(`^/api/v3/collector/discover/schema/policy/[0-9]+/action/delete$`)
+)
+
+func (ps *parseStream) discoverSchema() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create a schema policy of the discovered middleware model
+	if ps.hitPattern(createDiscoverSchemaPolicyPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.DiscoverSchemaPolicy,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// update a schema policy of the discovered middleware model
+	if ps.hitRegexp(updateDiscoverSchemaPolicyRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.DiscoverSchemaPolicy,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// delete a schema policy of the discovered middleware model
+	if ps.hitRegexp(deleteDiscoverSchemaPolicyRegexp, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.DiscoverSchemaPolicy,
+					Action: meta.Delete,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the schema policies of the discovered middleware models
+	if ps.hitPattern(findDiscoverSchemaPolicyPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.DiscoverSchemaPolicy,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the schema drifts between the discovered data and the models
+	if ps.hitPattern(findDiscoverSchemaDriftPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.NetDataCollector,
+					Name:   meta.DiscoverSchemaPolicy,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
 	return ps
 }
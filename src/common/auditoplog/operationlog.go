@@ -24,6 +24,8 @@ const (
 	AuditOpTypeDel AuditOpType = 3
 	// AuditOpTypeHostModule host  change module
 	AuditOpTypeHostModule AuditOpType = 100
+	// AuditOpTypeHostLockOverride change host locked by other user
+	AuditOpTypeHostLockOverride AuditOpType = 101
 )
 
 // 操作类型代码分两部分， 前2位表示大类入，后1位表示操作类型，1增加，2.修改，3，删除， 列入100
@@ -169,6 +169,9 @@ const (
 	// BKDBNot the db opeartor
 	BKDBNot = "$not"
 
+	// BKDBNOR the db operator
+	BKDBNOR = "$nor"
+
 	// BKDBCount the db opeartor
 	BKDBCount = "$count"
 
@@ -187,6 +190,13 @@ const (
 	// BKDBUNSET the db opeartor
 	BKDBUNSET = "$unset"
 
+	// BKDBElemMatch the db operator
+	BKDBElemMatch = "$elemMatch"
+
+	// BKDBCIDR the cidr containment operator of host ip fields, it's not a db
+	// operator, and is converted to a range query of the host ip keys
+	BKDBCIDR = "$cidr"
+
 	// BKDBSortFieldSep the db sort field split char
 	BKDBSortFieldSep = ","
 )
@@ -224,6 +234,18 @@ const (
 	// BKHostInnerIPField the host innerip field
 	BKHostInnerIPField = "bk_host_innerip"
 
+	// BKHostIPv4Field the normalized ipv4 addresses of the host, maintained by coreservice
+	BKHostIPv4Field = "bk_host_ipv4"
+
+	// BKHostIPv6Field the normalized ipv6 addresses of the host, maintained by coreservice
+	BKHostIPv6Field = "bk_host_ipv6"
+
+	// BKHostIPKeyField the fixed width keys of all the host addresses, used for cidr query
+	BKHostIPKeyField = "bk_host_ip_key"
+
+	// BKResourceDirIDField the resource pool directory the host is placed in, 0 when not in any directory
+	BKResourceDirIDField = "bk_dir_id"
+
 	// BKHostCloudRegionField the host cloud region field
 	BKHostCloudRegionField = "bk_cloud_region"
 
@@ -248,6 +270,18 @@ const (
 	// BKAttrChangedHost the cloud sync attr changed hosts
 	BKAttrChangedHost = "attr_changed"
 
+	// BKVanishedHost the cloud sync vanished hosts
+	BKVanishedHost = "vanished"
+
+	// BKCloudSyncedIPField the inner ip of hosts synced by the cloud sync task
+	BKCloudSyncedIPField = "bk_synced_ip"
+
+	// BKCloudConflictPolicyField the conflict policy of cloud sync task
+	BKCloudConflictPolicyField = "bk_conflict_policy"
+
+	// BKCloudVanishedPolicyField the vanished host policy of cloud sync task
+	BKCloudVanishedPolicyField = "bk_vanished_policy"
+
 	// BKCloudConfirm whether new add cloud hosts need confirm
 	BKCloudConfirm = "bk_confirm"
 
@@ -260,12 +294,18 @@ const (
 	// BKCloudAccountType the cloud account type field
 	BKCloudAccountType = "bk_account_type"
 
+	// BKCloudEndpointField the api address of cloud account
+	BKCloudEndpointField = "bk_cloud_endpoint"
+
 	// BKCloudSyncAccountAdmin the cloud sync account admin
 	BKCloudSyncAccountAdmin = "bk_account_admin"
 
 	// BKResourceType the cloud sync resource type
 	BKResourceType = "bk_resource_type"
 
+	// BKCloudHostAttrField the host attributes mapped from cloud instance, saved in resource confirm
+	BKCloudHostAttrField = "bk_cloud_host_attr"
+
 	// BKImportFrom the host import from field
 	BKImportFrom = "import_from"
 
@@ -835,6 +875,12 @@ const (
 	BKHTTPOtherRequestID  = "X-Bkapi-Request-Id"
 	BKHTTPCCRequestTime   = "Cc_Request_Time"
 	BKHTTPCCTransactionID = "Cc_Txn_Id"
+	// BKHTTPHostLockOverride change the hosts locked by other user when it's true, the change is audited
+	BKHTTPHostLockOverride = "Bk_Host_Lock_Override"
+	// BKHTTPEventTimestamp the unix timestamp when the event callback is sent
+	BKHTTPEventTimestamp = "X-Bkcmdb-Timestamp"
+	// BKHTTPEventSignature the signature of event callback, see metadata.SignEventCallback
+	BKHTTPEventSignature = "X-Bkcmdb-Signature"
 )
 
 type CCContextKey string
@@ -938,6 +984,19 @@ const (
 	HostFieldDockerServerVersion = "docker_server_version"
 )
 
+const (
+	// BKAgentStatusField the status of host agent, it's stale when the agent stops reporting snapshot
+	BKAgentStatusField = "bk_agent_status"
+	// BKAgentStaleMinutesField the minutes after which the silent hosts of the business are stale
+	BKAgentStaleMinutesField = "bk_agent_stale_minutes"
+)
+
+// Host agent status enumeration value
+const (
+	HostAgentStatusNormal = "normal"
+	HostAgentStatusStale  = "stale"
+)
+
 const TemplateStatusField = "status"
 const BKStatusField = "status"
 
@@ -971,6 +1030,9 @@ const (
 	RedisCloudSyncInstanceStarted             = BKCacheKeyV3Prefix + "cloudsyncinstancestarted:list"
 	RedisCloudSyncInstancePendingStop         = BKCacheKeyV3Prefix + "cloudsyncinstancependingstop:list"
 	RedisCloudSyncStartLockKey                = BKCacheKeyV3Prefix + "lock:cloudsyncstart"
+	RedisDynamicGroupEvalLockPrefix           = BKCacheKeyV3Prefix + "lock:dynamicgroupeval:"
+	RedisHostTransferPlanLockPrefix           = BKCacheKeyV3Prefix + "lock:hosttransferplan:"
+	RedisHostDuplicateDetectLockKey           = BKCacheKeyV3Prefix + "lock:hostduplicatedetect"
 )
 
 // association fields
@@ -424,6 +424,18 @@ const (
 	CCErrEventSubscribeTelnetFailed = 1103005
 	// CCErrEventOperateSuccessBUtSentEventFailed failed to sent event
 	CCErrEventPushEventFailed = 1103006
+	// CCErrEventDeadLetterReplayFailed failed to replay the dead letters
+	CCErrEventDeadLetterReplayFailed = 1103007
+	// CCErrEventDeadLetterPurgeFailed failed to purge the dead letters
+	CCErrEventDeadLetterPurgeFailed = 1103008
+	// CCErrEventSubscriptionNotPull the feed can only be pulled from the subscription in pull mode
+	CCErrEventSubscriptionNotPull = 1103009
+	// CCErrEventFeedFetchFailed failed to fetch the events from feed
+	CCErrEventFeedFetchFailed = 1103010
+	// CCErrEventSubscriptionNotPush the events can only be replayed to the subscription in push mode
+	CCErrEventSubscriptionNotPush = 1103011
+	// CCErrEventReplayEnded the replay task is finished or canceled already
+	CCErrEventReplayEnded = 1103012
 
 	// host 1104XXX
 	CCErrHostModuleRelationAddFailed = 1104000
@@ -574,6 +586,34 @@ const (
 	// CCErrHostMulueIDNotFoundORHasMutliInnerModuleIDFailed Module does not exist or there are multiple built-in modules
 	CCErrHostMulueIDNotFoundORHasMutliInnerModuleIDFailed = 1110057
 	CCErrHostSearchNeedObjectInstIDErr                    = 1110058
+	// CCErrHostSearchExpressionInvalid invalid host search expression: %s
+	CCErrHostSearchExpressionInvalid = 1110059
+	// CCErrHostLockedByOtherUser host %s is locked by %s, reason: %s
+	CCErrHostLockedByOtherUser = 1110060
+	// CCErrHostLockExpireTimeInvalid host lock expire time must be later than now
+	CCErrHostLockExpireTimeInvalid = 1110061
+	// CCErrCloudProviderNotSupported cloud account type %s is not supported
+	CCErrCloudProviderNotSupported = 1110062
+	// CCErrCloudCredentialInvalid cloud account validate failed: %s
+	CCErrCloudCredentialInvalid = 1110063
+	// CCErrCloudSyncPolicyInvalid invalid cloud sync policy: %s
+	CCErrCloudSyncPolicyInvalid = 1110064
+	// CCErrHostTransferPlanStatusInvalid host transfer plan in status %s can not be %s
+	CCErrHostTransferPlanStatusInvalid = 1110065
+	// CCErrResourceDirNotEmpty the resource directory has sub directories or hosts
+	CCErrResourceDirNotEmpty = 1110066
+	// CCErrResourceDirNameDuplicate directory %s already exists in the parent directory
+	CCErrResourceDirNameDuplicate = 1110067
+	// CCErrResourceDirParentInvalid invalid parent directory
+	CCErrResourceDirParentInvalid = 1110068
+	// CCErrHostNotInResourceDir host %s is not in the resource directory
+	CCErrHostNotInResourceDir = 1110069
+	// CCErrHostMergeCrossBusiness the hosts belong to different businesses and can't be merged: %s
+	CCErrHostMergeCrossBusiness = 1110070
+	// CCErrHostMergeSurvivorInvalid the survivor host %d is not one of the hosts to merge
+	CCErrHostMergeSurvivorInvalid = 1110071
+	// CCErrCloudEndpointInvalid invalid cloud account endpoint: %s
+	CCErrCloudEndpointInvalid = 1110072
 
 	//web  1111XXX
 	CCErrWebFileNoFound                 = 1111001
@@ -609,6 +649,23 @@ const (
 	CCErrCollectNetHistorySearchFail           = 1112016
 	CCErrCollectNetDeviceUpdateFail            = 1112017
 	CCErrCollectNetPropertyUpdateFail          = 1112018
+	CCErrCollectHostSnapMappingCreateFail      = 1112019
+	CCErrCollectHostSnapMappingUpdateFail      = 1112020
+	CCErrCollectHostSnapMappingDeleteFail      = 1112021
+	CCErrCollectHostSnapMappingSearchFail      = 1112022
+	CCErrCollectHostHardwareHistorySearchFail  = 1112023
+	CCErrCollectStaleHostSearchFail            = 1112024
+	CCErrCollectNetRuleCreateFail              = 1112025
+	CCErrCollectNetRuleUpdateFail              = 1112026
+	CCErrCollectNetRuleDeleteFail              = 1112027
+	CCErrCollectNetRuleSearchFail              = 1112028
+	CCErrCollectNetLinkSearchFail              = 1112029
+	CCErrCollectDiscoverPolicyCreateFail       = 1112030
+	CCErrCollectDiscoverPolicyUpdateFail       = 1112031
+	CCErrCollectDiscoverPolicyDeleteFail       = 1112032
+	CCErrCollectDiscoverPolicySearchFail       = 1112033
+	CCErrCollectDiscoverDriftSearchFail        = 1112034
+	CCErrCollectReplayFail                     = 1112035
 
 	// coreservice 1113xxx
 
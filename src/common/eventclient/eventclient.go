@@ -50,6 +50,8 @@ type ClientViaRedis struct {
 	queue     chan *eventtmp
 	pending   *eventtmp
 	queueLock sync.Mutex
+	// sync the events are pushed to redis before Push returns
+	sync bool
 }
 
 func NewClientViaRedis(cache *redis.Client, rdb dal.RDB) *ClientViaRedis {
@@ -65,6 +67,16 @@ func NewClientViaRedis(cache *redis.Client, rdb dal.RDB) *ClientViaRedis {
 	return ec
 }
 
+// NewSyncClientViaRedis the events are pushed to redis before Push returns instead of in background,
+// so that the pushes are in order with the other writes of the caller
+func NewSyncClientViaRedis(cache *redis.Client, rdb dal.RDB) *ClientViaRedis {
+	return &ClientViaRedis{
+		rdb:   rdb,
+		cache: cache,
+		sync:  true,
+	}
+}
+
 func (c *ClientViaRedis) Push(ctx context.Context, events ...*metadata.EventInst) error {
 	c.queueLock.Lock()
 	for i := range events {
@@ -98,6 +110,13 @@ func (c *ClientViaRedis) Push(ctx context.Context, events ...*metadata.EventInst
 				return fmt.Errorf("[event] marshal json error: %v, raw: %#v", err, events[i])
 			}
 			et := &eventtmp{EventInst: events[i], data: value}
+			if c.sync {
+				if err := c.pushToRedis(et); err != nil {
+					c.queueLock.Unlock()
+					return fmt.Errorf("[event] push event to redis failed: %v", err)
+				}
+				continue
+			}
 			select {
 			case c.queue <- et:
 			default:
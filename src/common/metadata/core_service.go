@@ -232,6 +232,7 @@ type TransferHostsCrossBusinessRequest struct {
 // HostModuleRelationRequest gethost module relation request parameter
 type HostModuleRelationRequest struct {
 	ApplicationID int64   `json:"bk_biz_id"`
+	AppIDArr      []int64 `json:"bk_biz_ids"`
 	SetIDArr      []int64 `json:"bk_set_ids"`
 	HostIDArr     []int64 `json:"bk_host_ids"`
 	ModuleIDArr   []int64 `json:"bk_module_ids"`
@@ -242,6 +243,9 @@ func (h *HostModuleRelationRequest) Empty() bool {
 	if h.ApplicationID != 0 {
 		return false
 	}
+	if len(h.AppIDArr) != 0 {
+		return false
+	}
 	if len(h.SetIDArr) != 0 {
 		return false
 	}
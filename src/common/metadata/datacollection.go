@@ -73,3 +73,63 @@ type SearchNetPropertyResult struct {
 type DeleteNetPropertyBatchOpt struct {
 	NetcollectPropertyIDs []uint64 `json:"netcollect_property_id"`
 }
+
+// StreamPorterMetrics the consuming status of a datacollection porter which reads messages from redis stream
+type StreamPorterMetrics struct {
+	// Name the porter name, e.g. hostsnap, middleware, netcollect
+	Name     string `json:"name"`
+	Stream   string `json:"stream"`
+	Group    string `json:"group"`
+	Consumer string `json:"consumer"`
+	// Length the number of entries in the stream
+	Length int64 `json:"length"`
+	// Pending the number of messages delivered to the group but not acknowledged yet
+	Pending int64 `json:"pending"`
+	// Lag the number of messages not delivered to the group yet
+	Lag             int64  `json:"lag"`
+	Consumers       int64  `json:"consumers"`
+	LastDeliveredID string `json:"last_delivered_id"`
+	// Acked, Failed, Claimed, Dropped are counted by this consumer since it started
+	Acked      uint64 `json:"acked"`
+	Failed     uint64 `json:"failed"`
+	Claimed    uint64 `json:"claimed"`
+	Dropped    uint64 `json:"dropped"`
+	UpdateTime Time   `json:"update_time"`
+}
+
+type RspStreamPorterMetrics struct {
+	BaseResp `json:",inline"`
+	Data     []StreamPorterMetrics `json:"data"`
+}
+
+// ReplayWrite a write the analyzer would cause when a message is replayed in dry run mode
+type ReplayWrite struct {
+	// Kind where the write goes to, one of db, api, redis
+	Kind string `json:"kind"`
+	// Target the table name of db, the request path of api, or the key of redis
+	Target    string      `json:"target"`
+	Operation string      `json:"operation"`
+	Detail    interface{} `json:"detail,omitempty"`
+}
+
+// ReplayMessageResult the result of a replayed message, Line is the line number in the replayed file
+type ReplayMessageResult struct {
+	Line   int           `json:"line"`
+	Error  string        `json:"error,omitempty"`
+	Writes []ReplayWrite `json:"writes,omitempty"`
+}
+
+// ReplayResult the result of feeding the recorded messages through an analyzer
+type ReplayResult struct {
+	Name   string `json:"name"`
+	DryRun bool   `json:"dry_run"`
+	Total  int    `json:"total"`
+	Failed int    `json:"failed"`
+	// Messages contains every message in dry run mode, otherwise only the failed ones
+	Messages []ReplayMessageResult `json:"messages"`
+}
+
+type RspReplayResult struct {
+	BaseResp `json:",inline"`
+	Data     ReplayResult `json:"data"`
+}
@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+)
+
+// the ways to handle the reported field whose type conflicts with the attribute
+const (
+	// DiscoverTypeConflictCoerce convert the value to the attribute type, the field is rejected when it can't
+	DiscoverTypeConflictCoerce = "coerce"
+	// DiscoverTypeConflictReject drop the field from the reported data
+	DiscoverTypeConflictReject = "reject"
+	// DiscoverTypeConflictAdd save the field to a new attribute of the reported type, named as <field>_<type>
+	DiscoverTypeConflictAdd = "add"
+)
+
+// the kinds of the schema drift between the discovered data and the model
+const (
+	DiscoverDriftUnknownField = "unknown_field"
+	DiscoverDriftTypeConflict = "type_conflict"
+	DiscoverDriftDeprecated   = "deprecated"
+)
+
+// the actions taken for the schema drift
+const (
+	DiscoverDriftActionCreated    = "created"
+	DiscoverDriftActionRejected   = "rejected"
+	DiscoverDriftActionCoerced    = "coerced"
+	DiscoverDriftActionAdded      = "added"
+	DiscoverDriftActionDeprecated = "deprecated"
+)
+
+// DiscoverSchemaPolicy decide how the model of the discovered middleware evolves with the reported data.
+// the models without policy accept unknown fields, coerce the conflicting values and never deprecate attributes
+type DiscoverSchemaPolicy struct {
+	ID       int64  `json:"id" bson:"id"`
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// AllowUnknownField create the attribute for the reported field not in the model, or drop the field
+	AllowUnknownField bool   `json:"allow_unknown_field" bson:"allow_unknown_field"`
+	TypeConflict      string `json:"type_conflict" bson:"type_conflict"`
+	// DeprecateDays the attributes not reported for these days are marked as deprecated, 0 means never
+	DeprecateDays int64  `json:"deprecate_days" bson:"deprecate_days"`
+	OwnerID       string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime    Time   `json:"create_time" bson:"create_time"`
+	LastTime      Time   `json:"last_time" bson:"last_time"`
+}
+
+// DefaultDiscoverSchemaPolicy the policy of the models without policy configured
+func DefaultDiscoverSchemaPolicy(objID string) DiscoverSchemaPolicy {
+	return DiscoverSchemaPolicy{
+		ObjectID:          objID,
+		AllowUnknownField: true,
+		TypeConflict:      DiscoverTypeConflictCoerce,
+	}
+}
+
+// Validate the type conflict handling is known and the deprecate days is not negative
+func (p DiscoverSchemaPolicy) Validate() error {
+	switch p.TypeConflict {
+	case DiscoverTypeConflictCoerce, DiscoverTypeConflictReject, DiscoverTypeConflictAdd:
+	case "":
+		return errors.New("type_conflict is required")
+	default:
+		return fmt.Errorf("unknown type_conflict %s", p.TypeConflict)
+	}
+	if p.DeprecateDays < 0 {
+		return errors.New("deprecate_days should not be negative")
+	}
+	return nil
+}
+
+type ParamSearchDiscoverSchemaPolicy struct {
+	ObjectID string   `json:"bk_obj_id"`
+	Page     BasePage `json:"page"`
+}
+
+type RspDiscoverSchemaPolicy struct {
+	Count uint64                 `json:"count"`
+	Info  []DiscoverSchemaPolicy `json:"info"`
+}
+
+// DiscoverSchemaDrift the difference found between the discovered data and the model,
+// one record for each field and kind
+type DiscoverSchemaDrift struct {
+	ObjectID   string `json:"bk_obj_id" bson:"bk_obj_id"`
+	PropertyID string `json:"bk_property_id" bson:"bk_property_id"`
+	Kind       string `json:"kind" bson:"kind"`
+	Action     string `json:"action" bson:"action"`
+	// ReportedType the field type in the discovered data
+	ReportedType string `json:"reported_type" bson:"reported_type"`
+	// PropertyType the attribute type in the model
+	PropertyType string `json:"bk_property_type" bson:"bk_property_type"`
+	// NewPropertyID the attribute the field is saved to when the conflict is handled by adding attribute
+	NewPropertyID string      `json:"new_property_id,omitempty" bson:"new_property_id"`
+	SampleValue   interface{} `json:"sample_value" bson:"sample_value"`
+	// Count the times the drift is found
+	Count      int64  `json:"count" bson:"count"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime Time   `json:"create_time" bson:"create_time"`
+	LastTime   Time   `json:"last_time" bson:"last_time"`
+}
+
+type ParamSearchDiscoverSchemaDrift struct {
+	ObjectID string   `json:"bk_obj_id"`
+	Kind     string   `json:"kind"`
+	Page     BasePage `json:"page"`
+}
+
+type RspDiscoverSchemaDrift struct {
+	Count uint64                `json:"count"`
+	Info  []DiscoverSchemaDrift `json:"info"`
+}
+
+// DiscoverFieldStatus the time the field of the discovered model is reported last time
+type DiscoverFieldStatus struct {
+	ObjectID       string `json:"bk_obj_id" bson:"bk_obj_id"`
+	PropertyID     string `json:"bk_property_id" bson:"bk_property_id"`
+	OwnerID        string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastReportTime Time   `json:"last_report_time" bson:"last_report_time"`
+	Deprecated     bool   `json:"deprecated" bson:"deprecated"`
+}
@@ -13,9 +13,11 @@
 package metadata
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -45,6 +47,8 @@ type ParamSubscriptionTelnet struct {
 type ParamSubscriptionTestCallback struct {
 	ParamSubscriptionTelnet `json:",inline"`
 	Data                    string `json:"data"`
+	// SecretKey the test callback is signed with it when it's set
+	SecretKey string `json:"secret_key"`
 }
 
 type RspSubscriptionTestCallback struct {
@@ -66,14 +70,133 @@ type Subscription struct {
 	OwnerID          string      `bson:"bk_supplier_account" json:"bk_supplier_account"`
 	LastTime         Time        `bson:"last_time" json:"last_time"`
 	Statistics       *Statistics `bson:"-" json:"statistics"`
+	// RetryTimes the max retry times after the first delivery failed, the event
+	// is moved to the dead letters when all the retries failed.
+	RetryTimes int64 `bson:"retry_times" json:"retry_times"`
+	// RetryInterval the wait before the first retry in second, it's doubled for every retry
+	RetryInterval int64 `bson:"retry_interval" json:"retry_interval"`
+	// SecretKey the callbacks are signed with it when it's set, see SignEventCallback
+	SecretKey string `bson:"secret_key" json:"secret_key"`
+	// TLSEnable the callbacks are sent with the client certificate of event server,
+	// the callback url must be https.
+	TLSEnable bool `bson:"tls_enable" json:"tls_enable"`
+	// Condition only the events whose instance data matches all the items are delivered
+	Condition []ConditionItem `bson:"condition" json:"condition"`
+	// WatchFields the update events are delivered only when one of the fields changed
+	WatchFields []string `bson:"watch_fields" json:"watch_fields"`
+	// DeliveryMode the events are pushed to the callback url, or kept in the feed to be pulled
+	DeliveryMode string `bson:"delivery_mode" json:"delivery_mode"`
+	// BatchSize the pushed events are gathered and sent as a json array of at most so many
+	// events when it's larger than 0, the batch is confirmed and retried as a whole.
+	BatchSize int64 `bson:"batch_size" json:"batch_size"`
+	// BatchWait the max wait in second to gather a batch
+	BatchWait int64 `bson:"batch_wait" json:"batch_wait"`
+	// EventFormat the events are sent in cmdb format, or wrapped in CloudEvents envelope
+	EventFormat string `bson:"event_format" json:"event_format"`
+	// CloudEventsMode the http content mode of CloudEvents, structured or binary
+	CloudEventsMode string `bson:"cloudevents_mode" json:"cloudevents_mode"`
+	// SinkType the pushed events are sent to the callback url, a redis stream or a local file
+	SinkType string `bson:"sink_type" json:"sink_type"`
+	// SinkTarget the redis stream name, or the file name in the sink directory of event server
+	SinkTarget string `bson:"sink_target" json:"sink_target"`
+	// Paused the events are kept in queue but not delivered, the subscription is paused
+	// when the delivery keeps failing, or by the operator.
+	Paused       bool   `bson:"paused" json:"paused"`
+	PausedReason string `bson:"paused_reason" json:"paused_reason"`
 }
 
+// the delivery mode of subscription, it's push when not set
+const (
+	DeliveryModePush = "push"
+	DeliveryModePull = "pull"
+)
+
+// IsPull the events of subscription are pulled from the feed by the consumers
+func (s Subscription) IsPull() bool {
+	return s.DeliveryMode == DeliveryModePull
+}
+
+// IsBatch the events of subscription are pushed in batch
+func (s Subscription) IsBatch() bool {
+	return s.BatchSize > 0 && !s.IsPull()
+}
+
+// GetBatchSize the max events in one batch
+func (s Subscription) GetBatchSize() int64 {
+	if s.BatchSize > MaxEventBatchSize {
+		return MaxEventBatchSize
+	}
+	return s.BatchSize
+}
+
+// GetBatchWait the max wait to gather a batch, the default is used when it's not set
+func (s Subscription) GetBatchWait() time.Duration {
+	wait := s.BatchWait
+	if wait <= 0 {
+		wait = DefaultEventBatchWait
+	}
+	if wait > MaxEventBatchWait {
+		wait = MaxEventBatchWait
+	}
+	return time.Second * time.Duration(wait)
+}
+
+// the limits of batch delivery, the wait is in second
+const (
+	MaxEventBatchSize     = 1000
+	DefaultEventBatchWait = 1
+	MaxEventBatchWait     = 60
+)
+
+// the default retry policy of subscription
+const (
+	DefaultEventRetryTimes    = 5
+	DefaultEventRetryInterval = 1
+	MaxEventRetryInterval     = 5 * time.Minute
+)
+
 // Report define sending statistic
 type Statistics struct {
 	Total   int64 `json:"total"`
 	Failure int64 `json:"failure"`
+	// Status the health status of subscription
+	Status string `json:"status"`
+	// Backlog the events waiting in queue
+	Backlog int64 `json:"backlog"`
+	// ConsecutiveFailure the failed attempts since the last success
+	ConsecutiveFailure int64  `json:"consecutive_failure"`
+	LastSuccessTime    *Time  `json:"last_success_time"`
+	LastErrorTime      *Time  `json:"last_error_time"`
+	LastError          string `json:"last_error"`
+	// Latency the latency of the delivery attempts
+	Latency EventLatency `json:"latency"`
 }
 
+// the health status of subscription
+const (
+	SubscriptionStatusHealthy = "healthy"
+	SubscriptionStatusFailing = "failing"
+	SubscriptionStatusPaused  = "paused"
+)
+
+// EventLatency the latency histogram of the delivery attempts
+type EventLatency struct {
+	Count int64 `json:"count"`
+	// SumMs the sum of the latency in millisecond
+	SumMs int64 `json:"sum_ms"`
+	// Buckets the cumulative counts of the attempts not longer than the bounds
+	Buckets []EventLatencyBucket `json:"buckets"`
+}
+
+// EventLatencyBucket the bound is in millisecond, the last one is +Inf
+type EventLatencyBucket struct {
+	LE    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+// EventLatencyBounds the upper bounds of latency buckets in millisecond
+var EventLatencyBounds = []int64{50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
 func (Subscription) TableName() string {
 	return "cc_Subscription"
 }
@@ -89,6 +212,22 @@ func (s Subscription) GetCacheKey() string {
 		ConfirmPattern:   s.ConfirmPattern,
 		SubscriptionForm: s.SubscriptionForm,
 		TimeOut:          s.TimeOut,
+		RetryTimes:       s.RetryTimes,
+		RetryInterval:    s.RetryInterval,
+		TLSEnable:        s.TLSEnable,
+		OwnerID:          s.OwnerID,
+		DeliveryMode:     s.DeliveryMode,
+		BatchSize:        s.BatchSize,
+		BatchWait:        s.BatchWait,
+		EventFormat:      s.EventFormat,
+		CloudEventsMode:  s.CloudEventsMode,
+		SinkType:         s.SinkType,
+		SinkTarget:       s.SinkTarget,
+		Paused:           s.Paused,
+	}
+	// the cache key is logged, so only the digest of secret is kept
+	if s.SecretKey != "" {
+		ns.SecretKey = fmt.Sprintf("%x", sha256.Sum256([]byte(s.SecretKey)))
 	}
 	b, _ := json.Marshal(ns)
 	return string(b)
@@ -98,6 +237,30 @@ func (s Subscription) GetTimeout() time.Duration {
 	return time.Second * time.Duration(s.TimeOut)
 }
 
+// GetRetryTimes the max retry times, the default is used when it's not set
+func (s Subscription) GetRetryTimes() int64 {
+	if s.RetryTimes <= 0 {
+		return DefaultEventRetryTimes
+	}
+	return s.RetryTimes
+}
+
+// GetRetryBackoff the wait before the retry, retry starts from 1
+func (s Subscription) GetRetryBackoff(retry int64) time.Duration {
+	interval := s.RetryInterval
+	if interval <= 0 {
+		interval = DefaultEventRetryInterval
+	}
+	backoff := time.Second * time.Duration(interval)
+	for i := int64(1); i < retry && backoff < MaxEventRetryInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxEventRetryInterval {
+		return MaxEventRetryInterval
+	}
+	return backoff
+}
+
 type EventInst struct {
 	ID          int64       `json:"event_id,omitempty"`
 	TxnID       string      `json:"txn_id"`
@@ -139,6 +302,9 @@ type DistInst struct {
 	EventInst
 	DstbID         int64 `json:"distribution_id"`
 	SubscriptionID int64 `json:"subscription_id"`
+	// Replay the event is delivered again by the replay task
+	Replay   bool  `json:"replay,omitempty"`
+	ReplayID int64 `json:"replay_id,omitempty"`
 }
 
 type DistInstCtx struct {
@@ -162,12 +328,20 @@ const (
 	EventTypeRelation           = "relation"
 	EventTypeAssociation        = "association"
 	EventTypeResourcePoolModule = "resource"
+	EventTypeDynamicGroup       = "dynamicgroup"
+	EventTypeHostHardware       = "hosthardware"
 )
 
 // Event object type
 const (
 	EventObjTypeProcModule     = "processmodule"
 	EventObjTypeModuleTransfer = "moduletransfer"
+	// EventObjTypeDynamicGroup the members of dynamic group changed,
+	// action create means joined and delete means left
+	EventObjTypeDynamicGroup = "dynamicgroup"
+	// EventObjTypeHostHardware the host attributes are changed by the snapshot
+	// of agent, the event data is the HostHardwareHistory
+	EventObjTypeHostHardware = "hosthardware"
 )
 
 // ConfirmMode define
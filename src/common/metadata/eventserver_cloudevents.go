@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// the payload format of subscription, it's cmdb when not set
+const (
+	EventFormatCMDB        = "cmdb"
+	EventFormatCloudEvents = "cloudevents"
+)
+
+// the http content modes of CloudEvents, it's structured when not set. the whole envelope is
+// the body in structured mode, and the attributes are put in the ce- headers in binary mode.
+const (
+	CloudEventsModeStructured = "structured"
+	CloudEventsModeBinary     = "binary"
+)
+
+// the CloudEvents attributes and content types
+const (
+	CloudEventsSpecVersion      = "1.0"
+	CloudEventsSourcePrefix     = "/bk-cmdb/"
+	CloudEventsTypePrefix       = "com.tencent.bkcmdb."
+	CloudEventsContentType      = "application/cloudevents+json"
+	CloudEventsBatchContentType = "application/cloudevents-batch+json"
+	CloudEventsHeaderPrefix     = "ce-"
+)
+
+// the sinks the events of subscription are delivered to, it's http when not set
+const (
+	SinkTypeHTTP  = "http"
+	SinkTypeRedis = "redis"
+	SinkTypeFile  = "file"
+)
+
+// CloudEvent the CloudEvents 1.0 envelope of the distributed event, the data is the event
+// in cmdb format.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewCloudEvent wrap the distributed event, the id is unique in the source of the subscription
+func NewCloudEvent(dist *DistInst, raw string) *CloudEvent {
+	event := &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              fmt.Sprintf("%d-%d", dist.SubscriptionID, dist.DstbID),
+		Source:          fmt.Sprintf("%ssubscription/%d", CloudEventsSourcePrefix, dist.SubscriptionID),
+		Type:            CloudEventsTypePrefix + dist.EventType + "." + dist.Action,
+		Subject:         dist.ObjType,
+		DataContentType: "application/json",
+		Data:            json.RawMessage(raw),
+	}
+	if !dist.ActionTime.IsZero() {
+		event.Time = dist.ActionTime.UTC().Format(time.RFC3339Nano)
+	}
+	return event
+}
+
+// Attributes the context attributes of the event, they are the ce- headers in binary mode
+func (e *CloudEvent) Attributes() map[string]string {
+	attrs := map[string]string{
+		"specversion": e.SpecVersion,
+		"id":          e.ID,
+		"source":      e.Source,
+		"type":        e.Type,
+	}
+	if e.Subject != "" {
+		attrs["subject"] = e.Subject
+	}
+	if e.Time != "" {
+		attrs["time"] = e.Time
+	}
+	return attrs
+}
+
+// IsCloudEvents the events of subscription are wrapped in CloudEvents envelope
+func (s Subscription) IsCloudEvents() bool {
+	return s.EventFormat == EventFormatCloudEvents
+}
+
+// IsCloudEventsBinary the CloudEvents attributes are sent in headers
+func (s Subscription) IsCloudEventsBinary() bool {
+	return s.IsCloudEvents() && s.CloudEventsMode == CloudEventsModeBinary
+}
+
+// GetSinkType the sink the events are delivered to
+func (s Subscription) GetSinkType() string {
+	if s.SinkType == "" {
+		return SinkTypeHTTP
+	}
+	return s.SinkType
+}
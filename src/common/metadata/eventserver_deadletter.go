@@ -0,0 +1,40 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+// EventDeadLetter the event failed to deliver to the subscriber after all the retries
+type EventDeadLetter struct {
+	DeadLetterID   int64  `bson:"dead_letter_id" json:"dead_letter_id"`
+	SubscriptionID int64  `bson:"subscription_id" json:"subscription_id"`
+	DistributionID int64  `bson:"distribution_id" json:"distribution_id"`
+	EventType      string `bson:"event_type" json:"event_type"`
+	ObjType        string `bson:"obj_type" json:"obj_type"`
+	Action         string `bson:"action" json:"action"`
+	// Event the raw event sent to the subscriber
+	Event      string `bson:"event" json:"event"`
+	Attempts   int64  `bson:"attempts" json:"attempts"`
+	LastError  string `bson:"last_error" json:"last_error"`
+	OwnerID    string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CreateTime Time   `bson:"create_time" json:"create_time"`
+}
+
+type RspEventDeadLetterSearch struct {
+	Count uint64            `json:"count"`
+	Info  []EventDeadLetter `json:"info"`
+}
+
+// ParamEventDeadLetter the dead letters of the subscription to replay or purge,
+// all the dead letters of the subscription are chosen when the ids are empty.
+type ParamEventDeadLetter struct {
+	DeadLetterIDs []int64 `json:"dead_letter_id"`
+}
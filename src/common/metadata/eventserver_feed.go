@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventFeed the event distributed to the subscription, it's kept until the retention of feed
+// expires. the pull subscriptions are fetched from it, and the push ones are replayed from it.
+type EventFeed struct {
+	SubscriptionID int64  `json:"subscription_id" bson:"subscription_id"`
+	DistributionID int64  `json:"distribution_id" bson:"distribution_id"`
+	ActionTime     Time   `json:"action_time" bson:"action_time"`
+	Event          string `json:"event" bson:"event"`
+	OwnerID        string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime     Time   `json:"create_time" bson:"create_time"`
+}
+
+// EventCursor the position of a consumer in the feed of pull subscription, the events
+// after the position are not acknowledged yet.
+type EventCursor struct {
+	CursorID       int64  `json:"cursor_id" bson:"cursor_id"`
+	SubscriptionID int64  `json:"subscription_id" bson:"subscription_id"`
+	Position       int64  `json:"position" bson:"position"`
+	Operator       string `json:"operator" bson:"operator"`
+	OwnerID        string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime     Time   `json:"create_time" bson:"create_time"`
+	LastTime       Time   `json:"last_time" bson:"last_time"`
+}
+
+// where a new cursor starts from
+const (
+	EventCursorFromLatest   = "latest"
+	EventCursorFromEarliest = "earliest"
+)
+
+// the limits of fetching the feed
+const (
+	DefaultEventFeedLimit     = 100
+	MaxEventFeedLimit         = 1000
+	DefaultEventFeedTimeout   = 30 * time.Second
+	MaxEventFeedTimeout       = 60 * time.Second
+	DefaultEventFeedRetention = 72 * time.Hour
+)
+
+// ParamEventCursorCreate create a cursor, the cursor starts from the latest event by default
+type ParamEventCursorCreate struct {
+	From string `json:"from"`
+}
+
+// ParamEventFeedFetch fetch the events after the position, the acknowledged position of
+// cursor is used when it's not set. it waits at most timeout seconds for the new events.
+type ParamEventFeedFetch struct {
+	Position *int64 `json:"position"`
+	Limit    int64  `json:"limit"`
+	Timeout  int64  `json:"timeout"`
+}
+
+// GetLimit the limit of events in a batch
+func (p ParamEventFeedFetch) GetLimit() int64 {
+	if p.Limit <= 0 {
+		return DefaultEventFeedLimit
+	}
+	if p.Limit > MaxEventFeedLimit {
+		return MaxEventFeedLimit
+	}
+	return p.Limit
+}
+
+// GetTimeout the longest wait when there is no new event
+func (p ParamEventFeedFetch) GetTimeout() time.Duration {
+	if p.Timeout < 0 {
+		return 0
+	}
+	if p.Timeout == 0 {
+		return DefaultEventFeedTimeout
+	}
+	timeout := time.Duration(p.Timeout) * time.Second
+	if timeout > MaxEventFeedTimeout {
+		return MaxEventFeedTimeout
+	}
+	return timeout
+}
+
+// RspEventFeedFetch the batch of events, position is the position to fetch the next batch
+type RspEventFeedFetch struct {
+	CursorID int64             `json:"cursor_id"`
+	Position int64             `json:"position"`
+	Events   []json.RawMessage `json:"events"`
+}
+
+// ParamEventFeedAck acknowledge the events until the position
+type ParamEventFeedAck struct {
+	Position int64 `json:"position"`
+}
@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+
+	"configcenter/src/common"
+)
+
+// EventReplayStatus the status of replay task
+type EventReplayStatus string
+
+// the replay task is pending until a event server picks it up
+const (
+	EventReplayStatusPending  EventReplayStatus = "pending"
+	EventReplayStatusRunning  EventReplayStatus = "running"
+	EventReplayStatusFinished EventReplayStatus = "finished"
+	EventReplayStatusCanceled EventReplayStatus = "canceled"
+)
+
+// IsActive the replay task is not finished or canceled
+func (s EventReplayStatus) IsActive() bool {
+	return s == EventReplayStatusPending || s == EventReplayStatusRunning
+}
+
+// EventReplayRange the events to replay, all the given bounds are matched, they are inclusive.
+// the distribution id is the id of event the subscriber receives.
+type EventReplayRange struct {
+	StartTime           *Time `json:"start_time,omitempty" bson:"start_time,omitempty"`
+	EndTime             *Time `json:"end_time,omitempty" bson:"end_time,omitempty"`
+	StartDistributionID int64 `json:"start_distribution_id,omitempty" bson:"start_distribution_id,omitempty"`
+	EndDistributionID   int64 `json:"end_distribution_id,omitempty" bson:"end_distribution_id,omitempty"`
+}
+
+// Validate at least one bound should be given, and the start should not be after the end
+func (r EventReplayRange) Validate() error {
+	if r.StartTime == nil && r.EndTime == nil && r.StartDistributionID <= 0 && r.EndDistributionID <= 0 {
+		return errors.New("the time or event id range is required")
+	}
+	if r.StartTime != nil && r.EndTime != nil && r.StartTime.After(r.EndTime.Time) {
+		return errors.New("start_time is after end_time")
+	}
+	if r.StartDistributionID > 0 && r.EndDistributionID > 0 && r.StartDistributionID > r.EndDistributionID {
+		return errors.New("start_distribution_id is greater than end_distribution_id")
+	}
+	return nil
+}
+
+// Condition the condition of the events in feed
+func (r EventReplayRange) Condition() map[string]interface{} {
+	cond := make(map[string]interface{})
+	timeCond := make(map[string]interface{})
+	if r.StartTime != nil {
+		timeCond[common.BKDBGTE] = r.StartTime.Time
+	}
+	if r.EndTime != nil {
+		timeCond[common.BKDBLTE] = r.EndTime.Time
+	}
+	if len(timeCond) > 0 {
+		cond["action_time"] = timeCond
+	}
+	idCond := make(map[string]interface{})
+	if r.StartDistributionID > 0 {
+		idCond[common.BKDBGTE] = r.StartDistributionID
+	}
+	if r.EndDistributionID > 0 {
+		idCond[common.BKDBLTE] = r.EndDistributionID
+	}
+	if len(idCond) > 0 {
+		cond["distribution_id"] = idCond
+	}
+	return cond
+}
+
+// EventReplay the task to deliver the events in feed to the subscription again, the events
+// are delivered after the ones in queue, Position is the distribution id of the last
+// replayed event in feed.
+type EventReplay struct {
+	ReplayID         int64 `json:"replay_id" bson:"replay_id"`
+	SubscriptionID   int64 `json:"subscription_id" bson:"subscription_id"`
+	EventReplayRange `json:",inline" bson:",inline"`
+	Status           EventReplayStatus `json:"status" bson:"status"`
+	Total            int64             `json:"total" bson:"total"`
+	Replayed         int64             `json:"replayed" bson:"replayed"`
+	Position         int64             `json:"position" bson:"position"`
+	Operator         string            `json:"operator" bson:"operator"`
+	OwnerID          string            `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime       Time              `json:"create_time" bson:"create_time"`
+	LastTime         Time              `json:"last_time" bson:"last_time"`
+}
+
+// RspEventReplaySearch the result of searching replay tasks
+type RspEventReplaySearch struct {
+	Count uint64        `json:"count"`
+	Info  []EventReplay `json:"info"`
+}
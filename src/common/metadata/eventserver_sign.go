@@ -0,0 +1,43 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// EventSignaturePrefix the algorithm prefix of the callback signature
+const EventSignaturePrefix = "sha256="
+
+// EventSecretKeyMask replaces the secret key when the subscriptions are searched,
+// the secret key is kept when a subscription is updated with it.
+const EventSecretKeyMask = "******"
+
+// SignEventCallback sign the callback body, the signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" with the subscription's secret key.
+func SignEventCallback(secretKey string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return EventSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyEventCallback check the signature of the callback in constant time,
+// the subscribers should reject the timestamp too old as well.
+func VerifyEventCallback(secretKey string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignEventCallback(secretKey, timestamp, body)), []byte(signature))
+}
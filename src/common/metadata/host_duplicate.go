@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+// the status of the host duplicate candidates
+const (
+	HostDuplicateStatusPending = "pending"
+	HostDuplicateStatusIgnored = "ignored"
+	HostDuplicateStatusMerged  = "merged"
+)
+
+// HostDuplicateKey a host field used to detect the duplicate hosts, the hosts
+// having the same value of the field get the weight as score.
+type HostDuplicateKey struct {
+	Field  string `json:"field"`
+	Weight int64  `json:"weight"`
+}
+
+// DefaultHostDuplicateKeys the asset id and sn identify a machine, while the mac and
+// ip may be reused, so a single mac or ip match is not enough to be duplicate.
+var DefaultHostDuplicateKeys = []HostDuplicateKey{
+	{Field: common.BKAssetIDField, Weight: 10},
+	{Field: "bk_sn", Weight: 10},
+	{Field: "bk_mac", Weight: 6},
+	{Field: common.BKHostInnerIPField, Weight: 4},
+}
+
+// DefaultHostDuplicateThreshold the min score of the duplicate hosts
+const DefaultHostDuplicateThreshold = 10
+
+// HostDuplicateConfig the config of duplicate host detection
+type HostDuplicateConfig struct {
+	Keys      []HostDuplicateKey
+	Threshold int64
+	// Interval the detection is disabled when it's 0
+	Interval time.Duration
+}
+
+// HostDuplicate a group of hosts which may be the same machine
+type HostDuplicate struct {
+	DuplicateID int64   `json:"bk_duplicate_id" bson:"bk_duplicate_id"`
+	HostIDs     []int64 `json:"bk_host_id" bson:"bk_host_id"`
+	Score       int64   `json:"score" bson:"score"`
+	// MatchedKeys the fields having the same value in the hosts
+	MatchedKeys []string `json:"matched_keys" bson:"matched_keys"`
+	// Fingerprint identify the same group in different detections, the ignored group is not reported again
+	Fingerprint string    `json:"fingerprint" bson:"fingerprint"`
+	Status      string    `json:"status" bson:"status"`
+	OwnerID     string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	DetectTime  time.Time `json:"detect_time" bson:"detect_time"`
+	LastTime    time.Time `json:"last_time" bson:"last_time"`
+}
+
+// HostDuplicateFingerprint the fingerprint of the host group, it's irrelevant to the order of hosts
+func HostDuplicateFingerprint(hostIDs []int64) string {
+	ids := make([]int64, len(hostIDs))
+	copy(ids, hostIDs)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// SaveHostDuplicates replace the pending duplicates of the supplier account with the detected ones
+type SaveHostDuplicates struct {
+	Duplicates []HostDuplicate `json:"duplicates"`
+}
+
+// MergeHostDuplicate merge the hosts into the survivor host, the merged hosts are deleted
+type MergeHostDuplicate struct {
+	// DuplicateID the candidate is marked as merged when it's set
+	DuplicateID int64 `json:"bk_duplicate_id"`
+	// SurvivorID the earliest created host is picked when it's 0
+	SurvivorID int64   `json:"bk_host_id"`
+	HostIDs    []int64 `json:"bk_host_ids"`
+	// Fields take the value of the field from the given host, the empty fields of the
+	// survivor are filled with the latest updated host which has the value.
+	Fields map[string]int64 `json:"fields"`
+}
+
+type MergeHostDuplicateResult struct {
+	SurvivorID int64         `json:"bk_host_id"`
+	MergedIDs  []int64       `json:"merged_host_ids"`
+	Changed    mapstr.MapStr `json:"changed"`
+}
+
+type SearchHostDuplicate struct {
+	Count uint64          `json:"count"`
+	Info  []HostDuplicate `json:"info"`
+}
+
+type SearchHostDuplicateResult struct {
+	BaseResp `json:",inline"`
+	Data     SearchHostDuplicate `json:"data"`
+}
@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+)
+
+// the logic operators of host search expression
+const (
+	ExpressionLogicAnd = "and"
+	ExpressionLogicOr  = "or"
+	ExpressionLogicNot = "not"
+)
+
+// HostSearchExpressionMaxDepth the max nested level of a host search expression
+const HostSearchExpressionMaxDepth = 10
+
+// HostSearchExpression a boolean expression tree used to search hosts by host
+// fields and topology fields, for example:
+// (env=prod OR env=staging) AND NOT module in (idle)
+// {
+//     "logic": "and",
+//     "children": [
+//         {"logic": "or", "children": [
+//             {"bk_obj_id": "host", "condition": {"field": "env", "operator": "$eq", "value": "prod"}},
+//             {"bk_obj_id": "host", "condition": {"field": "env", "operator": "$eq", "value": "staging"}}
+//         ]},
+//         {"logic": "not", "children": [
+//             {"bk_obj_id": "module", "condition": {"field": "bk_module_name", "operator": "$in", "value": ["idle"]}}
+//         ]}
+//     ]
+// }
+// a node with logic is a branch node, a node without logic is a leaf node.
+type HostSearchExpression struct {
+	Logic     string                  `json:"logic,omitempty"`
+	Children  []*HostSearchExpression `json:"children,omitempty"`
+	ObjectID  string                  `json:"bk_obj_id,omitempty"`
+	Condition *ConditionItem          `json:"condition,omitempty"`
+}
+
+// IsLeaf check whether the expression is a leaf node
+func (e *HostSearchExpression) IsLeaf() bool {
+	return e.Logic == ""
+}
+
+// Validate check whether the expression is well formed,
+// return an error describe which part is invalid.
+func (e *HostSearchExpression) Validate() error {
+	return e.validate(1)
+}
+
+func (e *HostSearchExpression) validate(depth int) error {
+	if depth > HostSearchExpressionMaxDepth {
+		return fmt.Errorf("expression exceed max depth %d", HostSearchExpressionMaxDepth)
+	}
+
+	if e.IsLeaf() {
+		if len(e.Children) != 0 {
+			return fmt.Errorf("leaf expression can not have children")
+		}
+		if e.ObjectID == "" {
+			return fmt.Errorf("leaf expression need %s", common.BKObjIDField)
+		}
+		if e.Condition == nil || e.Condition.Field == "" {
+			return fmt.Errorf("leaf expression of %s need condition field", e.ObjectID)
+		}
+		return nil
+	}
+
+	if e.Condition != nil {
+		return fmt.Errorf("%s expression can not have condition", e.Logic)
+	}
+	switch e.Logic {
+	case ExpressionLogicAnd, ExpressionLogicOr:
+		if len(e.Children) == 0 {
+			return fmt.Errorf("%s expression need at least one child", e.Logic)
+		}
+	case ExpressionLogicNot:
+		if len(e.Children) != 1 {
+			return fmt.Errorf("%s expression need exactly one child", e.Logic)
+		}
+	default:
+		return fmt.Errorf("unknown expression logic %s", e.Logic)
+	}
+
+	for _, child := range e.Children {
+		if child == nil {
+			return fmt.Errorf("%s expression has empty child", e.Logic)
+		}
+		if err := child.validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common/mapstr"
+)
+
+// the status of host transfer plan
+const (
+	HostTransferPlanStatusDraft           = "draft"
+	HostTransferPlanStatusPendingApproval = "pending_approval"
+	HostTransferPlanStatusApproved        = "approved"
+	HostTransferPlanStatusExecuting       = "executing"
+	HostTransferPlanStatusDone            = "done"
+	HostTransferPlanStatusFailed          = "failed"
+)
+
+// hostTransferPlanTransitions the status a plan can move to from each status,
+// a rejected plan goes back to draft so that it can be modified and submitted again.
+var hostTransferPlanTransitions = map[string][]string{
+	HostTransferPlanStatusDraft:           {HostTransferPlanStatusPendingApproval},
+	HostTransferPlanStatusPendingApproval: {HostTransferPlanStatusApproved, HostTransferPlanStatusDraft},
+	HostTransferPlanStatusApproved:        {HostTransferPlanStatusExecuting},
+	HostTransferPlanStatusExecuting:       {HostTransferPlanStatusDone, HostTransferPlanStatusFailed},
+}
+
+// HostTransferPlan a transfer of hosts prepared in advance, which is executed
+// by host server at execute time after it's approved by the business maintainer.
+type HostTransferPlan struct {
+	PlanID   int64  `json:"bk_plan_id" bson:"bk_plan_id"`
+	PlanName string `json:"bk_plan_name" bson:"bk_plan_name"`
+	// SrcAppID the business the hosts belong to
+	SrcAppID int64 `json:"src_bk_biz_id" bson:"src_bk_biz_id"`
+	// DstAppID the target business, same as SrcAppID when transfer in the business
+	DstAppID    int64   `json:"dst_bk_biz_id" bson:"dst_bk_biz_id"`
+	HostIDs     []int64 `json:"bk_host_id" bson:"bk_host_id"`
+	ModuleIDs   []int64 `json:"bk_module_id" bson:"bk_module_id"`
+	IsIncrement bool    `json:"is_increment" bson:"is_increment"`
+	// ExecuteTime the start of the maintenance window, the plan is executed
+	// as soon as it's approved when it's empty
+	ExecuteTime  *time.Time           `json:"execute_time" bson:"execute_time"`
+	Status       string               `json:"status" bson:"status"`
+	Creator      string               `json:"creator" bson:"creator"`
+	Approver     string               `json:"approver" bson:"approver"`
+	ApproveTime  *time.Time           `json:"approve_time" bson:"approve_time"`
+	RejectReason string               `json:"reject_reason" bson:"reject_reason"`
+	StartTime    *time.Time           `json:"start_time" bson:"start_time"`
+	FinishTime   *time.Time           `json:"finish_time" bson:"finish_time"`
+	Results      []HostTransferResult `json:"results" bson:"results"`
+	OwnerID      string               `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime   time.Time            `json:"create_time" bson:"create_time"`
+	LastTime     time.Time            `json:"last_time" bson:"last_time"`
+}
+
+// IsCrossBusiness check whether the plan transfer hosts to another business
+func (p HostTransferPlan) IsCrossBusiness() bool {
+	return p.SrcAppID != p.DstAppID
+}
+
+// IsDue check whether the approved plan should be executed at the time
+func (p HostTransferPlan) IsDue(now time.Time) bool {
+	return HostTransferPlanStatusApproved == p.Status && (nil == p.ExecuteTime || !p.ExecuteTime.After(now))
+}
+
+// CanTransferTo check whether the plan can move to the status
+func (p HostTransferPlan) CanTransferTo(status string) bool {
+	for _, next := range hostTransferPlanTransitions[p.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// HostTransferResult the transfer result of a host in the plan
+type HostTransferResult struct {
+	HostID  int64  `json:"bk_host_id" bson:"bk_host_id"`
+	Success bool   `json:"success" bson:"success"`
+	Message string `json:"message" bson:"message"`
+}
+
+// RejectHostTransferPlan the parameter of reject plan
+type RejectHostTransferPlan struct {
+	Reason string `json:"reason"`
+}
+
+// UpdateHostTransferPlan update the plan only when it's in one of the status,
+// the status is not checked when it's empty
+type UpdateHostTransferPlan struct {
+	Status []string      `json:"status"`
+	Data   mapstr.MapStr `json:"data"`
+}
+
+type SearchHostTransferPlan struct {
+	Count uint64             `json:"count"`
+	Info  []HostTransferPlan `json:"info"`
+}
+
+type HostTransferPlanResult struct {
+	BaseResp `json:",inline"`
+	Data     HostTransferPlan `json:"data"`
+}
+
+type SearchHostTransferPlanResult struct {
+	BaseResp `json:",inline"`
+	Data     SearchHostTransferPlan `json:"data"`
+}
@@ -15,6 +15,7 @@ package metadata
 import (
 	"time"
 
+	"configcenter/src/common"
 	"configcenter/src/common/mapstr"
 )
 
@@ -142,6 +143,10 @@ type UserConfig struct {
 	AppID      int64     `json:"bk_biz_id" bson:"bk_biz_id"`
 	CreateUser string    `json:"create_user" bson:"create_user"`
 	ModifyUser string    `json:"modify_user" bson:"modify_user"`
+	// ObjectID the model the query targets, empty means host
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// EvalInterval evaluate the members every EvalInterval minutes, 0 means never
+	EvalInterval int64 `json:"eval_interval" bson:"eval_interval"`
 }
 
 type UserConfigResult struct {
@@ -196,13 +201,73 @@ type UserConfigMeta struct {
 	ModifyUser string    `json:"modify_user" bson:"modify_user,omitempty"`
 	UpdateTime time.Time `json:"last_time" bson:"last_time,omitempty"`
 	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	ObjectID   string    `json:"bk_obj_id,omitempty" bson:"bk_obj_id,omitempty"`
+	// EvalInterval is a pointer so that it can be updated to 0
+	EvalInterval *int64     `json:"eval_interval,omitempty" bson:"eval_interval,omitempty"`
+	LastEvalTime *time.Time `json:"last_eval_time,omitempty" bson:"last_eval_time,omitempty"`
+}
+
+// GetObjectID returns the model the user config targets
+func (u UserConfigMeta) GetObjectID() string {
+	if "" == u.ObjectID {
+		return common.BKInnerObjIDHost
+	}
+	return u.ObjectID
+}
+
+// GetEvalInterval returns the evaluate interval of the user config
+func (u UserConfigMeta) GetEvalInterval() time.Duration {
+	if nil == u.EvalInterval || 0 >= *u.EvalInterval {
+		return 0
+	}
+	return time.Duration(*u.EvalInterval) * time.Minute
 }
 
 type AddConfigQuery struct {
-	AppID      int64  `json:"bk_biz_id,omitempty"`
-	Info       string `json:"info,omitempty"`
-	Name       string `json:"name,omitempty"`
-	CreateUser string `json:"create_user,omitempty"`
+	AppID        int64  `json:"bk_biz_id,omitempty"`
+	Info         string `json:"info,omitempty"`
+	Name         string `json:"name,omitempty"`
+	CreateUser   string `json:"create_user,omitempty"`
+	ObjectID     string `json:"bk_obj_id,omitempty"`
+	EvalInterval int64  `json:"eval_interval,omitempty"`
+}
+
+type ScheduledUserConfigResult struct {
+	BaseResp `json:",inline"`
+	Data     []UserConfigMeta `json:"data"`
+}
+
+// DynamicGroupSnapshot the members of a dynamic group at an evaluation,
+// the first evaluation is always saved, the later ones only when the members changed
+type DynamicGroupSnapshot struct {
+	ID       string    `json:"id" bson:"id"`
+	AppID    int64     `json:"bk_biz_id" bson:"bk_biz_id"`
+	ObjectID string    `json:"bk_obj_id" bson:"bk_obj_id"`
+	Members  []int64   `json:"members" bson:"members"`
+	Joined   []int64   `json:"joined" bson:"joined"`
+	Left     []int64   `json:"left" bson:"left"`
+	EvalTime time.Time `json:"eval_time" bson:"eval_time"`
+	OwnerID  string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// SaveDynamicGroupSnapshot the instances matched by the dynamic group
+type SaveDynamicGroupSnapshot struct {
+	Members []mapstr.MapStr `json:"members"`
+}
+
+type DynamicGroupSnapshotResult struct {
+	BaseResp `json:",inline"`
+	Data     DynamicGroupSnapshot `json:"data"`
+}
+
+type SearchDynamicGroupSnapshot struct {
+	Count uint64                 `json:"count"`
+	Info  []DynamicGroupSnapshot `json:"info"`
+}
+
+type SearchDynamicGroupSnapshotResult struct {
+	BaseResp `json:",inline"`
+	Data     SearchDynamicGroupSnapshot `json:"data"`
 }
 
 type CloudTaskSearch struct {
@@ -229,6 +294,13 @@ type CloudTaskInfo struct {
 	NewAdd          int64  `json:"new_add" bson:"new_add"`
 	AttrChanged     int64  `json:"attr_changed" bson:"attr_changed"`
 	OwnerID         string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Endpoint        string `json:"bk_cloud_endpoint" bson:"bk_cloud_endpoint"`
+	// AttrMapping map the cloud instance attribute to host field
+	AttrMapping    map[string]string `json:"bk_attr_mapping" bson:"bk_attr_mapping"`
+	ConflictPolicy map[string]string `json:"bk_conflict_policy" bson:"bk_conflict_policy"`
+	VanishedPolicy string            `json:"bk_vanished_policy" bson:"bk_vanished_policy"`
+	// SyncedIPs the inner ip of the hosts synced by the last run, used to find the vanished hosts
+	SyncedIPs []string `json:"bk_synced_ip" bson:"bk_synced_ip"`
 }
 
 // TransferHostToInnerModule transfer host to inner module eg:idle module ,fault module
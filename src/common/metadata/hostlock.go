@@ -19,8 +19,17 @@ import (
 )
 
 type HostLockRequest struct {
+	// IPS the addresses of the hosts, any address of a host can be used,
+	// the lock is saved with the inner ip of the host
 	IPS     []string `json:"ip_list"`
 	CloudID int64    `json:"bk_cloud_id"`
+	// Reason why lock the hosts, used by lock request
+	Reason string `json:"reason"`
+	// ExpireTime the lock will be released automatically after expire time,
+	// lock never expire when it's empty, used by lock request
+	ExpireTime *time.Time `json:"expire_time,omitempty"`
+	// Force release the locks owned by other user, used by unlock request
+	Force bool `json:"force"`
 }
 
 type QueryHostLockRequest struct {
@@ -34,11 +43,24 @@ type HostLockResultResponse struct {
 }
 
 type HostLockData struct {
-	User       string    `json:"bk_user" bson:"bk_user"`
-	IP         string    `json:"bk_host_innerip" bson:"bk_host_innerip"`
-	CloudID    int64     `json:"bk_cloud_id" bson:"bk_cloud_id"`
-	CreateTime time.Time `json:"create_time" bson:"create_time"`
-	OwnerID    string    `json:"-" bson:"bk_supplier_account"`
+	User       string     `json:"bk_user" bson:"bk_user"`
+	IP         string     `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	CloudID    int64      `json:"bk_cloud_id" bson:"bk_cloud_id"`
+	Reason     string     `json:"reason" bson:"reason"`
+	ExpireTime *time.Time `json:"expire_time" bson:"expire_time"`
+	CreateTime time.Time  `json:"create_time" bson:"create_time"`
+	OwnerID    string     `json:"-" bson:"bk_supplier_account"`
+}
+
+// IsExpired check whether the lock is expired at the time
+func (h HostLockData) IsExpired(now time.Time) bool {
+	return h.ExpireTime != nil && !h.ExpireTime.After(now)
+}
+
+// HostLockDetailResponse the response of host lock detail query, key is ip
+type HostLockDetailResponse struct {
+	BaseResp `json:",inline"`
+	Data     map[string]*HostLockData `json:"data"`
 }
 
 type HostLockQueryResponse struct {
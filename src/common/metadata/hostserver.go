@@ -59,6 +59,8 @@ type HostList struct {
 	HostInfo      map[int64]map[string]interface{} `json:"host_info"`
 	SupplierID    int64                            `json:"bk_supplier_id"`
 	InputType     HostInputType                    `json:"input_type"`
+	// DirID the resource pool directory the imported hosts are placed in
+	DirID int64 `json:"bk_dir_id"`
 }
 
 type AddHostFromAgentHostList struct {
@@ -98,6 +100,10 @@ type HostCommonSearch struct {
 	Condition []SearchCondition `json:"condition"`
 	Page      BasePage          `json:"page"`
 	Pattern   string            `json:"pattern,omitempty"`
+	// Expression optional boolean expression, AND with the condition above
+	Expression *HostSearchExpression `json:"expression,omitempty"`
+	// DirID optional, only the hosts in the resource pool directory and its sub directories
+	DirID int64 `json:"bk_dir_id,omitempty"`
 }
 
 type HostModuleFind struct {
@@ -187,6 +193,65 @@ type CloudTaskList struct {
 	AttrConfirm     bool   `json:"bk_attr_confirm"`
 	SecretID        string `json:"bk_secret_id"`
 	SecretKey       string `json:"bk_secret_key"`
+	// Endpoint the api address of private cloud, empty means the default address of the account type
+	Endpoint string `json:"bk_cloud_endpoint"`
+	// AttrMapping map the cloud instance attribute to host field, eg: {"cpu": "bk_cpu"}
+	AttrMapping map[string]string `json:"bk_attr_mapping"`
+	// ConflictPolicy the conflict policy of host field, eg: {"bk_os_name": "cmdb"}
+	ConflictPolicy map[string]string `json:"bk_conflict_policy"`
+	// VanishedPolicy how to handle the host vanished from cloud
+	VanishedPolicy string `json:"bk_vanished_policy"`
+}
+
+// the conflict policy of a host field which is different between cloud and cmdb
+const (
+	// CloudConflictPolicyProvider overwrite the cmdb value with cloud value, it's the default policy
+	CloudConflictPolicyProvider = "provider"
+	// CloudConflictPolicyCMDB keep the cmdb value, the cloud value is only used when the host is added
+	CloudConflictPolicyCMDB = "cmdb"
+	// CloudConflictPolicyReview add the change to resource confirm list, apply it after confirmed
+	CloudConflictPolicyReview = "review"
+)
+
+// the policy of the host which is synced before, but vanished from cloud
+const (
+	// CloudVanishedPolicyIgnore do nothing, it's the default policy
+	CloudVanishedPolicyIgnore = ""
+	// CloudVanishedPolicyMark add the host to resource confirm list as vanished
+	CloudVanishedPolicyMark = "mark"
+	// CloudVanishedPolicyRecycle move the host to the fault module of it's business
+	CloudVanishedPolicyRecycle = "recycle"
+	// CloudVanishedPolicyDelete delete the host, only the host in resource pool can be deleted,
+	// the others are marked instead.
+	CloudVanishedPolicyDelete = "delete"
+)
+
+// CloudSyncPreview the changes a cloud sync task will make
+type CloudSyncPreview struct {
+	Added    []mapstr.MapStr `json:"added"`
+	Updated  []CloudHostDiff `json:"updated"`
+	Vanished []mapstr.MapStr `json:"vanished"`
+}
+
+// CloudHostDiff the different fields of a host between cloud and cmdb
+type CloudHostDiff struct {
+	HostID  int64            `json:"bk_host_id"`
+	InnerIP string           `json:"bk_host_innerip"`
+	Fields  []CloudFieldDiff `json:"fields"`
+}
+
+// CloudFieldDiff a different field, the policy decide how the sync handle it
+type CloudFieldDiff struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+	Policy string      `json:"policy"`
+}
+
+// CloudSyncPreviewResult the result of cloud sync preview
+type CloudSyncPreviewResult struct {
+	BaseResp `json:",inline"`
+	Data     CloudSyncPreview `json:"data"`
 }
 
 type ResourceConfirm struct {
@@ -207,10 +272,13 @@ type CloudHistory struct {
 	TimeConsume string `json:"bk_time_consume"`
 	NewAdd      int    `json:"new_add"`
 	AttrChanged int    `json:"attr_changed"`
+	Vanished    int    `json:"vanished"`
 	StartTime   string `json:"bk_start_time"`
 	TaskID      int64  `json:"bk_task_id"`
 	HistoryID   int64  `json:"bk_history_id"`
 	FailReason  string `json:"fail_reason"`
+	// LockedHosts the inner ip of the hosts skipped by the sync, as they are locked
+	LockedHosts []string `json:"locked_hosts"`
 }
 
 type DeleteCloudTask struct {
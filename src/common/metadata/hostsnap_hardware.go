@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+// the change types of the host attribute
+const (
+	// HostHardwareChangeAdded the attribute was empty, eg: the disk is added
+	HostHardwareChangeAdded = "added"
+	// HostHardwareChangeRemoved the attribute becomes empty
+	HostHardwareChangeRemoved  = "removed"
+	HostHardwareChangeIncrease = "increased"
+	HostHardwareChangeDecrease = "decreased"
+	HostHardwareChangeModified = "changed"
+)
+
+// HostHardwareChange the change of one host attribute
+type HostHardwareChange struct {
+	PropertyID string      `json:"bk_property_id" bson:"bk_property_id"`
+	ChangeType string      `json:"change_type" bson:"change_type"`
+	PreData    interface{} `json:"pre_data" bson:"pre_data"`
+	CurData    interface{} `json:"cur_data" bson:"cur_data"`
+}
+
+// HostHardwareHistory the host attributes changed by one snapshot
+type HostHardwareHistory struct {
+	HostID     int64                `json:"bk_host_id" bson:"bk_host_id"`
+	InnerIP    string               `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	OwnerID    string               `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Changes    []HostHardwareChange `json:"changes" bson:"changes"`
+	ChangeTime Time                 `json:"change_time" bson:"change_time"`
+}
+
+// HostHardwareHistoryParams search the hardware timeline of the host, the latest first by default
+type HostHardwareHistoryParams struct {
+	HostID     int64    `json:"bk_host_id"`
+	PropertyID string   `json:"bk_property_id,omitempty"`
+	StartTime  *Time    `json:"start_time,omitempty"`
+	EndTime    *Time    `json:"end_time,omitempty"`
+	Page       BasePage `json:"page,omitempty"`
+}
+
+type SearchHostHardwareHistory struct {
+	Count uint64                `json:"count"`
+	Info  []HostHardwareHistory `json:"info"`
+}
@@ -0,0 +1,226 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"configcenter/src/common"
+)
+
+// the transforms of the value picked from snapshot before it is set to the host attribute
+const (
+	// HostSnapTransformUnit convert the number from one unit to another, eg: byte to GB
+	HostSnapTransformUnit = "unit"
+	// HostSnapTransformJoin join the array items with the separator
+	HostSnapTransformJoin = "join"
+	// HostSnapTransformRegex extract the first submatch, or the whole match if the pattern has no group
+	HostSnapTransformRegex = "regex"
+)
+
+// hostSnapReservedFields the host fields used to match the snapshot to the host,
+// or maintained by cmdb itself, they can not be the target of a mapping
+var hostSnapReservedFields = map[string]bool{
+	common.BKHostIDField:        true,
+	common.BKHostInnerIPField:   true,
+	common.BKHostOuterIPField:   true,
+	common.BKCloudIDField:       true,
+	common.BKOwnerIDField:       true,
+	common.BKResourceDirIDField: true,
+	common.CreateTimeField:      true,
+	common.LastTimeField:        true,
+}
+
+// hostSnapUnits the units supported by the unit transform, the units of
+// the same kind can be converted to each other
+var hostSnapUnits = map[string]struct {
+	kind  string
+	ratio float64
+}{
+	"B":   {kind: "size", ratio: 1},
+	"KB":  {kind: "size", ratio: 1 << 10},
+	"MB":  {kind: "size", ratio: 1 << 20},
+	"GB":  {kind: "size", ratio: 1 << 30},
+	"TB":  {kind: "size", ratio: 1 << 40},
+	"ms":  {kind: "time", ratio: 1},
+	"s":   {kind: "time", ratio: 1000},
+	"min": {kind: "time", ratio: 60 * 1000},
+	"h":   {kind: "time", ratio: 60 * 60 * 1000},
+	"d":   {kind: "time", ratio: 24 * 60 * 60 * 1000},
+}
+
+// HostSnapTransform how to transform the value picked from snapshot
+type HostSnapTransform struct {
+	Type      string `json:"type,omitempty" bson:"type"`
+	FromUnit  string `json:"from_unit,omitempty" bson:"from_unit"`
+	ToUnit    string `json:"to_unit,omitempty" bson:"to_unit"`
+	Separator string `json:"separator,omitempty" bson:"separator"`
+	Pattern   string `json:"pattern,omitempty" bson:"pattern"`
+}
+
+// Validate the transform type is known and its arguments are valid
+func (t HostSnapTransform) Validate() error {
+	switch t.Type {
+	case "", HostSnapTransformJoin:
+	case HostSnapTransformUnit:
+		from, ok := hostSnapUnits[t.FromUnit]
+		if !ok {
+			return fmt.Errorf("unknown from_unit %s", t.FromUnit)
+		}
+		to, ok := hostSnapUnits[t.ToUnit]
+		if !ok {
+			return fmt.Errorf("unknown to_unit %s", t.ToUnit)
+		}
+		if from.kind != to.kind {
+			return fmt.Errorf("can not convert %s to %s", t.FromUnit, t.ToUnit)
+		}
+	case HostSnapTransformRegex:
+		if t.Pattern == "" {
+			return errors.New("pattern is required")
+		}
+		if _, err := regexp.Compile(t.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern, %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown transform type %s", t.Type)
+	}
+	return nil
+}
+
+// UnitRatio the multiplier to convert the value from FromUnit to ToUnit
+func (t HostSnapTransform) UnitRatio() float64 {
+	return hostSnapUnits[t.FromUnit].ratio / hostSnapUnits[t.ToUnit].ratio
+}
+
+// HostSnapMapping map the value at the json path of the host snapshot to the host attribute.
+// the path is in gjson syntax, eg: data.system.info.kernelVersion, data.net.interface.#.name
+type HostSnapMapping struct {
+	ID         int64  `json:"id" bson:"id"`
+	Path       string `json:"bk_snap_path" bson:"bk_snap_path"`
+	PropertyID string `json:"bk_property_id" bson:"bk_property_id"`
+	// PropertyType the type of the target attribute, it is filled when the mapping is saved
+	PropertyType string            `json:"bk_property_type,omitempty" bson:"bk_property_type"`
+	Transform    HostSnapTransform `json:"transform" bson:"transform"`
+	OwnerID      string            `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime   *Time             `json:"create_time,omitempty" bson:"create_time"`
+	LastTime     *Time             `json:"last_time,omitempty" bson:"last_time"`
+}
+
+// Validate the path and target attribute are required, and the transform should be valid
+func (m HostSnapMapping) Validate() error {
+	if m.Path == "" {
+		return errors.New("bk_snap_path is required")
+	}
+	if m.PropertyID == "" {
+		return errors.New("bk_property_id is required")
+	}
+	if hostSnapReservedFields[m.PropertyID] {
+		return fmt.Errorf("%s can not be the target of a mapping", m.PropertyID)
+	}
+	if err := m.Transform.Validate(); err != nil {
+		return fmt.Errorf("invalid transform, %v", err)
+	}
+	return nil
+}
+
+// ValidateType the transform should output a value of the target attribute type,
+// only the text, number and bool attributes can be the target
+func (m HostSnapMapping) ValidateType(propertyType string) error {
+	switch propertyType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+		if m.Transform.Type == HostSnapTransformUnit {
+			return fmt.Errorf("unit transform outputs a number, can not be set to %s attribute", propertyType)
+		}
+	case common.FieldTypeInt, common.FieldTypeFloat:
+		if m.Transform.Type == HostSnapTransformJoin || m.Transform.Type == HostSnapTransformRegex {
+			return fmt.Errorf("%s transform outputs a string, can not be set to %s attribute", m.Transform.Type, propertyType)
+		}
+	case common.FieldTypeBool:
+		if m.Transform.Type != "" {
+			return fmt.Errorf("%s transform can not be set to %s attribute", m.Transform.Type, propertyType)
+		}
+	default:
+		return fmt.Errorf("%s attribute can not be the target of a mapping", propertyType)
+	}
+	return nil
+}
+
+// CheckValue the value got from the snapshot should fit the type of the target attribute
+func (m HostSnapMapping) CheckValue(value interface{}) error {
+	if hostSnapReservedFields[m.PropertyID] {
+		return fmt.Errorf("%s can not be the target of a mapping", m.PropertyID)
+	}
+	switch m.PropertyType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%v is not a string", value)
+		}
+		limit := common.FieldTypeSingleLenChar
+		if m.PropertyType == common.FieldTypeLongChar {
+			limit = common.FieldTypeLongLenChar
+		}
+		if len(s) > limit {
+			return fmt.Errorf("the length of the value exceeds %d", limit)
+		}
+	case common.FieldTypeInt:
+		if _, ok := value.(int64); !ok {
+			return fmt.Errorf("%v is not an integer", value)
+		}
+	case common.FieldTypeFloat:
+		switch value.(type) {
+		case int64, float64:
+		default:
+			return fmt.Errorf("%v is not a number", value)
+		}
+	case common.FieldTypeBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%v is not a bool", value)
+		}
+	default:
+		return fmt.Errorf("unsupported attribute type %s", m.PropertyType)
+	}
+	return nil
+}
+
+type HostSnapMappingResult struct {
+	BaseResp `json:",inline"`
+	Data     HostSnapMapping `json:"data"`
+}
+
+type SearchHostSnapMapping struct {
+	Count uint64            `json:"count"`
+	Info  []HostSnapMapping `json:"info"`
+}
+
+type SearchHostSnapMappingResult struct {
+	BaseResp `json:",inline"`
+	Data     SearchHostSnapMapping `json:"data"`
+}
+
+// HostSnapMappingTestParams test the mappings against the sample snapshot,
+// the saved mappings are tested if no mapping is given.
+type HostSnapMappingTestParams struct {
+	Snapshot interface{}       `json:"snapshot"`
+	Mappings []HostSnapMapping `json:"mappings"`
+}
+
+// HostSnapMappingTestResult the value a mapping gets from the sample snapshot
+type HostSnapMappingTestResult struct {
+	PropertyID string      `json:"bk_property_id"`
+	Path       string      `json:"bk_snap_path"`
+	Value      interface{} `json:"value"`
+	ErrMsg     string      `json:"error_msg,omitempty"`
+}
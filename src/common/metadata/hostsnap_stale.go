@@ -0,0 +1,51 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+// HostSnapStatus the time the host reports its last snapshot, used to find the hosts whose agent stops reporting
+type HostSnapStatus struct {
+	HostID       int64  `json:"bk_host_id" bson:"bk_host_id"`
+	OwnerID      string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastSnapTime Time   `json:"last_snap_time" bson:"last_snap_time"`
+	AgentStatus  string `json:"bk_agent_status" bson:"bk_agent_status"`
+	// StaleTime the time the host is marked as stale
+	StaleTime *Time `json:"stale_time,omitempty" bson:"stale_time,omitempty"`
+}
+
+// StaleHostParams search the stale hosts, all the businesses when AppID is 0
+type StaleHostParams struct {
+	AppID int64    `json:"bk_biz_id,omitempty"`
+	Page  BasePage `json:"page,omitempty"`
+}
+
+// StaleHostTopo one module the stale host belongs to
+type StaleHostTopo struct {
+	AppID      int64  `json:"bk_biz_id"`
+	AppName    string `json:"bk_biz_name"`
+	SetID      int64  `json:"bk_set_id"`
+	SetName    string `json:"bk_set_name"`
+	ModuleID   int64  `json:"bk_module_id"`
+	ModuleName string `json:"bk_module_name"`
+}
+
+type StaleHost struct {
+	Host         map[string]interface{} `json:"host"`
+	LastSnapTime Time                   `json:"last_snap_time"`
+	StaleTime    *Time                  `json:"stale_time,omitempty"`
+	Topo         []StaleHostTopo        `json:"topo"`
+}
+
+type SearchStaleHost struct {
+	Count uint64      `json:"count"`
+	Info  []StaleHost `json:"info"`
+}
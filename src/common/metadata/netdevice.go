@@ -144,6 +144,9 @@ type NetcollectReport struct {
 	LastTime     Time                          `json:"last_time" bson:"last_time"`
 	Attributes   []NetcollectReportAttribute   `json:"attributes" bson:"attributes"`
 	Associations []NetcollectReportAssociation `json:"associations" bson:"associations"`
+	// Neighbors the LLDP/CDP neighbor table of the device, turned into the associations when the report is received.
+	// nil means the collector doesn't report neighbors, while empty means the device has no neighbor
+	Neighbors []NetcollectReportNeighbor `json:"neighbors,omitempty" bson:"-"`
 }
 
 type NetcollectHistory struct {
@@ -164,7 +167,7 @@ type NetcollectReportAttribute struct {
 }
 
 type NetcollectReportAssociation struct {
-	Action       string `json:"action" bson:"-"`
+	Action       string `json:"action" bson:"action"`
 	AsstInstName string `json:"bk_asst_inst_name" bson:"bk_asst_inst_name"`
 	// AsstPropertyID string `json:"bk_asst_property_id" bson:"bk_asst_property_id"`
 	AsstObjectID   string `json:"bk_asst_obj_id" bson:"bk_asst_obj_id"`
@@ -172,6 +175,49 @@ type NetcollectReportAssociation struct {
 
 	ObjectAsstID  string `json:"bk_obj_asst_id" bson:"bk_obj_asst_id"`
 	Configuration string `json:"configuration" bson:"configuration"`
+
+	// the link discovered from the neighbor table
+	AsstInstID int64  `json:"bk_asst_inst_id,omitempty" bson:"bk_asst_inst_id,omitempty"`
+	LocalPort  string `json:"local_port,omitempty" bson:"local_port,omitempty"`
+	RemotePort string `json:"remote_port,omitempty" bson:"remote_port,omitempty"`
+	Protocol   string `json:"protocol,omitempty" bson:"protocol,omitempty"`
+}
+
+// NetcollectReportNeighbor one entry of the LLDP/CDP neighbor table
+type NetcollectReportNeighbor struct {
+	Protocol  string `json:"protocol"`
+	LocalPort string `json:"local_port"`
+	// RemoteChassisID the chassis id of LLDP, usually the mac address, or the device id of CDP
+	RemoteChassisID string `json:"remote_chassis_id"`
+	RemotePort      string `json:"remote_port"`
+	RemoteSysName   string `json:"remote_sys_name"`
+}
+
+// NetcollectLink the link between the ports of two instances, the association of them is saved in cc_InstAsst
+type NetcollectLink struct {
+	ObjectID     string `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID       int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	LocalPort    string `json:"local_port" bson:"local_port"`
+	AsstObjectID string `json:"bk_asst_obj_id" bson:"bk_asst_obj_id"`
+	AsstInstID   int64  `json:"bk_asst_inst_id" bson:"bk_asst_inst_id"`
+	RemotePort   string `json:"remote_port" bson:"remote_port"`
+	Protocol     string `json:"protocol" bson:"protocol"`
+	ObjectAsstID string `json:"bk_obj_asst_id" bson:"bk_obj_asst_id"`
+	// InstAsstID the instance association created for the link, 0 if the association is made by others
+	InstAsstID int64  `json:"inst_asst_id" bson:"inst_asst_id"`
+	OwnerID    string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastTime   Time   `json:"last_time" bson:"last_time"`
+}
+
+type ParamSearchNetcollectLink struct {
+	ObjectID string   `json:"bk_obj_id"`
+	InstID   int64    `json:"bk_inst_id"`
+	Page     BasePage `json:"page"`
+}
+
+type RspNetcollectLink struct {
+	Count uint64           `json:"count"`
+	Info  []NetcollectLink `json:"info"`
 }
 
 type NetcollectReportAsstCond struct {
@@ -197,3 +243,25 @@ const (
 	ReporctMethodAccept = "accept"
 	ReporctMethodIgnore = "ignore"
 )
+
+// NetcollectConfirmRule decides whether the changed attribute in the report is confirmed automatically,
+// the rule with empty PropertyID applies to all the attributes of the object which have no rule of their own
+type NetcollectConfirmRule struct {
+	ID          int64  `json:"id" bson:"id"`
+	ObjectID    string `json:"bk_obj_id" bson:"bk_obj_id"`
+	PropertyID  string `json:"bk_property_id" bson:"bk_property_id"`
+	AutoConfirm bool   `json:"auto_confirm" bson:"auto_confirm"`
+	OwnerID     string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime  Time   `json:"create_time" bson:"create_time"`
+	LastTime    Time   `json:"last_time" bson:"last_time"`
+}
+
+type ParamSearchNetcollectRule struct {
+	ObjectID string   `json:"bk_obj_id"`
+	Page     BasePage `json:"page"`
+}
+
+type RspNetcollectRule struct {
+	Count uint64                  `json:"count"`
+	Info  []NetcollectConfirmRule `json:"info"`
+}
@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// ResourceDirectory a directory in the resource pool, used to group the idle hosts,
+// the directories make up a tree by parent id.
+type ResourceDirectory struct {
+	DirID   int64  `json:"bk_dir_id" bson:"bk_dir_id"`
+	DirName string `json:"bk_dir_name" bson:"bk_dir_name"`
+	// ParentID the parent directory, 0 for the top level directory
+	ParentID   int64     `json:"bk_parent_id" bson:"bk_parent_id"`
+	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+	LastTime   time.Time `json:"last_time" bson:"last_time"`
+}
+
+// SubDirectoryIDs returns the id of the directory and all its sub directories in dirs
+func SubDirectoryIDs(dirs []ResourceDirectory, dirID int64) []int64 {
+	children := make(map[int64][]int64)
+	for _, dir := range dirs {
+		children[dir.ParentID] = append(children[dir.ParentID], dir.DirID)
+	}
+
+	result := []int64{dirID}
+	visited := map[int64]bool{dirID: true}
+	for index := 0; index < len(result); index++ {
+		for _, child := range children[result[index]] {
+			if !visited[child] {
+				visited[child] = true
+				result = append(result, child)
+			}
+		}
+	}
+	return result
+}
+
+// MoveHostToResourceDirectory move the hosts of resource pool to the directory,
+// the hosts are removed from any directory when DirID is 0
+type MoveHostToResourceDirectory struct {
+	DirID   int64   `json:"bk_dir_id"`
+	HostIDs []int64 `json:"bk_host_id"`
+}
+
+// AssignHostFromResourceDirectory assign the hosts in the directory to the idle module of
+// the business, all the hosts directly in the directory are assigned when HostIDs is empty
+type AssignHostFromResourceDirectory struct {
+	DirID         int64   `json:"bk_dir_id"`
+	ApplicationID int64   `json:"bk_biz_id"`
+	HostIDs       []int64 `json:"bk_host_id"`
+}
+
+type SearchResourceDirectory struct {
+	Count uint64              `json:"count"`
+	Info  []ResourceDirectory `json:"info"`
+}
+
+type ResourceDirectoryResult struct {
+	BaseResp `json:",inline"`
+	Data     ResourceDirectory `json:"data"`
+}
+
+type SearchResourceDirectoryResult struct {
+	BaseResp `json:",inline"`
+	Data     SearchResourceDirectory `json:"data"`
+}
@@ -13,6 +13,9 @@
 package params
 
 import (
+	"fmt"
+	"strings"
+
 	"configcenter/src/common"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
@@ -69,6 +72,14 @@ func ParseHostParams(input []metadata.ConditionItem, output map[string]interface
 			regex := make(map[string]interface{})
 			regex[common.BKDBLIKE] = i.Value
 			output[i.Field] = regex
+		case common.BKDBCIDR:
+			// the cidr is matched with all the addresses of host, whatever the field is
+			cidrCond, err := ParseCIDRCondition(util.GetIPsByInterface(i.Value))
+			if nil != err {
+				return err
+			}
+			andCond, _ := output[common.BKDBAND].([]interface{})
+			output[common.BKDBAND] = append(andCond, cidrCond)
 		default:
 			queryCondItem, ok := output[i.Field].(map[string]interface{})
 			if !ok {
@@ -94,17 +105,35 @@ func ParseHostIPParams(ipCond metadata.IPInfo, output map[string]interface{}) er
 	if 0 == len(ipArr) {
 		return nil
 	}
+	// the cidr in ip data matches the host which has any address in it
+	ipArr, cidrArr := splitIPAndCIDR(ipArr)
+	if 0 != len(cidrArr) && INNERONLY == flag {
+		cidrCond, err := ParseCIDRCondition(cidrArr)
+		if nil != err {
+			return err
+		}
+		andCond, _ := output[common.BKDBAND].([]interface{})
+		output[common.BKDBAND] = append(andCond, cidrCond)
+	} else if 0 != len(cidrArr) {
+		return fmt.Errorf("cidr %v is only supported by inner ip search", cidrArr)
+	}
+	if 0 == len(ipArr) {
+		return nil
+	}
+
 	if 1 == exact {
 		//exact search
 		c := make(map[string]interface{})
 		c[common.BKDBIN] = ipArr
+		// the inner ip matches any address of the host
+		keyCond := map[string]interface{}{common.BKHostIPKeyField: map[string]interface{}{common.BKDBIN: util.IPKeys(ipArr)}}
 		if INNERONLY == flag {
-			output[common.BKHostInnerIPField] = c
-
+			ic := map[string]interface{}{common.BKHostInnerIPField: c}
+			output[common.BKDBOR] = []map[string]interface{}{ic, keyCond}
 		} else if OUTERONLY == flag {
 			output[common.BKHostOuterIPField] = c
 		} else if IOBOTH == flag {
-			io := make([]map[string]interface{}, 2)
+			io := make([]map[string]interface{}, 3)
 			i := make(map[string]interface{})
 			o := make(map[string]interface{})
 			ic := make(map[string]interface{})
@@ -115,6 +144,7 @@ func ParseHostIPParams(ipCond metadata.IPInfo, output map[string]interface{}) er
 			oc[common.BKHostOuterIPField] = o
 			io[0] = ic
 			io[1] = oc
+			io[2] = keyCond
 			output[common.BKDBOR] = io
 		}
 	} else {
@@ -145,3 +175,37 @@ func ParseHostIPParams(ipCond metadata.IPInfo, output map[string]interface{}) er
 	}
 	return nil
 }
+
+// ParseCIDRCondition returns the condition of hosts which have any address in the cidrs
+func ParseCIDRCondition(cidrs []string) (map[string]interface{}, error) {
+	if 0 == len(cidrs) {
+		return nil, fmt.Errorf("cidr is empty")
+	}
+	orCond := make([]interface{}, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		first, last, err := util.CIDRKeyRange(cidr)
+		if nil != err {
+			return nil, fmt.Errorf("invalid cidr %s, err: %v", cidr, err)
+		}
+		orCond = append(orCond, map[string]interface{}{
+			common.BKHostIPKeyField: map[string]interface{}{
+				common.BKDBElemMatch: map[string]interface{}{common.BKDBGTE: first, common.BKDBLTE: last},
+			},
+		})
+	}
+	if 1 == len(orCond) {
+		return orCond[0].(map[string]interface{}), nil
+	}
+	return map[string]interface{}{common.BKDBOR: orCond}, nil
+}
+
+func splitIPAndCIDR(data []string) (ips, cidrs []string) {
+	for _, item := range data {
+		if strings.Contains(item, "/") {
+			cidrs = append(cidrs, item)
+			continue
+		}
+		ips = append(ips, item)
+	}
+	return ips, cidrs
+}
@@ -21,9 +21,16 @@ const (
 	PatternIP = `^(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|[1-9])\.((1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d)\.){2}(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d)$`
 	// PatternMultipleIP regular pattern for Multiple ip
 	PatternMultipleIP = `^(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|[1-9])\.((1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d)\.){2}(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d)(,(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|[1-9])\.((1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d)\.){2}(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d))*$`
+	// PatternIPv6 loose regular pattern for ipv6, the address is validated strictly when it's saved
+	PatternIPv6 = `[0-9a-fA-F:]*:[0-9a-fA-F:]*((\d{1,3}\.){3}\d{1,3})?`
+	// patternIPv4 regular pattern for ipv4 without anchor
+	patternIPv4 = `(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|[1-9])\.((1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d)\.){2}(1\d{2}|2[0-4]\d|25[0-5]|[1-9]\d|\d)`
 	// PatternPort regular pattern for port range
 	PatternPort = `(([1-9][0-9]{0,3})|([1-5][0-9]{4})|(6[0-4][0-9]{3})|(65[0-4][0-9]{2})|(655[0-2][0-9])|(6553[0-5]))`
 )
 
 // PatternMultiplePortRange regular pattern for multiple port range
 var PatternMultiplePortRange = fmt.Sprintf(`^((%s-%s)|(%s))(,((%s)|(%s-%s)))*$`, PatternPort, PatternPort, PatternPort, PatternPort, PatternPort, PatternPort)
+
+// PatternMultipleIPv4v6 regular pattern for Multiple ip, both ipv4 and ipv6 are allowed
+var PatternMultipleIPv4v6 = fmt.Sprintf(`^(%s|%s)(,(%s|%s))*$`, patternIPv4, PatternIPv6, patternIPv4, PatternIPv6)
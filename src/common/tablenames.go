@@ -75,6 +75,7 @@ const (
 	BKTableNameSubscription     = "cc_Subscription"
 	BKTableNameUserAPI          = "cc_UserAPI"
 	BKTableNameUserCustom       = "cc_UserCustom"
+	BKTableNameUserAPISnapshot  = "cc_UserAPISnapshot"
 	BKTableNameObjAsst          = "cc_ObjAsst"
 	BKTableNameTopoGraphics     = "cc_TopoGraphics"
 	BKTableNameTransaction      = "cc_Transaction"
@@ -86,14 +87,33 @@ const (
 	BKTableNameNetcollectConfig  = "cc_NetcollectConfig"
 	BKTableNameNetcollectReport  = "cc_NetcollectReport"
 	BKTableNameNetcollectHistory = "cc_NetcollectHistory"
+	BKTableNameNetcollectRule    = "cc_NetcollectConfirmRule"
+	BKTableNameNetcollectLink    = "cc_NetcollectLink"
 
-	BKTableNameHostLock = "cc_HostLock"
+	BKTableNameDiscoverSchemaPolicy = "cc_DiscoverSchemaPolicy"
+	BKTableNameDiscoverSchemaDrift  = "cc_DiscoverSchemaDrift"
+	BKTableNameDiscoverFieldStatus  = "cc_DiscoverFieldStatus"
+
+	BKTableNameHostSnapMapping     = "cc_HostSnapMapping"
+	BKTableNameHostHardwareHistory = "cc_HostHardwareHistory"
+	BKTableNameHostSnapStatus      = "cc_HostSnapStatus"
+
+	BKTableNameHostLock          = "cc_HostLock"
+	BKTableNameHostTransferPlan  = "cc_HostTransferPlan"
+	BKTableNameResourceDirectory = "cc_ResourceDirectory"
+	BKTableNameHostDuplicate     = "cc_HostDuplicate"
 
 	// Cloud sync tables
 	BKTableNameCloudTask              = "cc_CloudTask"
 	BKTableNameCloudSyncHistory       = "cc_CloudSyncHistory"
 	BKTableNameCloudResourceConfirm   = "cc_CloudResourceConfirm"
 	BKTableNameResourceConfirmHistory = "cc_ResourceConfirmHistory"
+
+	// Event tables
+	BKTableNameEventDeadLetter = "cc_EventDeadLetter"
+	BKTableNameEventFeed       = "cc_EventFeed"
+	BKTableNameEventCursor     = "cc_EventCursor"
+	BKTableNameEventReplay     = "cc_EventReplay"
 )
 
 // AllTables alltables
@@ -126,6 +146,7 @@ var AllTables = []string{
 	BKTableNameSubscription,
 	BKTableNameUserAPI,
 	BKTableNameUserCustom,
+	BKTableNameUserAPISnapshot,
 	BKTableNameObjAsst,
 	BKTableNameTopoGraphics,
 	BKTableNameNetcollectConfig,
@@ -133,13 +154,28 @@ var AllTables = []string{
 	BKTableNameNetcollectProperty,
 	BKTableNameNetcollectReport,
 	BKTableNameNetcollectHistory,
+	BKTableNameNetcollectRule,
+	BKTableNameNetcollectLink,
+	BKTableNameDiscoverSchemaPolicy,
+	BKTableNameDiscoverSchemaDrift,
+	BKTableNameDiscoverFieldStatus,
+	BKTableNameHostSnapMapping,
+	BKTableNameHostHardwareHistory,
+	BKTableNameHostSnapStatus,
 	BKTableNameTransaction,
 	BKTableNameIDgenerator,
 	BKTableNameHostLock,
+	BKTableNameHostTransferPlan,
+	BKTableNameResourceDirectory,
+	BKTableNameHostDuplicate,
 	BKTableNameCloudTask,
 	BKTableNameCloudSyncHistory,
 	BKTableNameCloudResourceConfirm,
 	BKTableNameResourceConfirmHistory,
+	BKTableNameEventDeadLetter,
+	BKTableNameEventFeed,
+	BKTableNameEventCursor,
+	BKTableNameEventReplay,
 	BKTableNameObjUnique,
 	BKTableNameAsstDes,
 }
@@ -0,0 +1,170 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"configcenter/src/common"
+)
+
+// NormalizeIP returns the canonical form of ip, the ipv4-mapped ipv6 address
+// is converted to ipv4, ok is false when ip is not a valid address.
+func NormalizeIP(ip string) (string, bool) {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if nil == parsed {
+		return "", false
+	}
+	if v4 := parsed.To4(); nil != v4 {
+		return v4.String(), true
+	}
+	return parsed.String(), true
+}
+
+// IsIPv6 check whether ip is a valid ipv6 address which is not ipv4-mapped
+func IsIPv6(ip string) bool {
+	normalized, ok := NormalizeIP(ip)
+	return ok && strings.Contains(normalized, ":")
+}
+
+// SplitIPs split the multiple ip string separated by comma, semicolon or blank
+func SplitIPs(ips string) []string {
+	return strings.FieldsFunc(ips, func(r rune) bool {
+		return ',' == r || ';' == r || ' ' == r || '\t' == r || '\n' == r
+	})
+}
+
+// NormalizeIPs normalize and remove the duplicate ips, the ipv4 and ipv6
+// addresses are returned separately, keep the order of input.
+// invalid is the ips which are not valid address.
+func NormalizeIPs(ips []string) (ipv4, ipv6, invalid []string) {
+	ipv4, ipv6, invalid = make([]string, 0), make([]string, 0), make([]string, 0)
+	exists := make(map[string]bool)
+	for _, ip := range ips {
+		if "" == strings.TrimSpace(ip) {
+			continue
+		}
+		normalized, ok := NormalizeIP(ip)
+		if !ok {
+			invalid = append(invalid, ip)
+			continue
+		}
+		if exists[normalized] {
+			continue
+		}
+		exists[normalized] = true
+		if strings.Contains(normalized, ":") {
+			ipv6 = append(ipv6, normalized)
+		} else {
+			ipv4 = append(ipv4, normalized)
+		}
+	}
+	return ipv4, ipv6, invalid
+}
+
+// GetIPsByInterface get the ips from a multiple ip string or a string array
+func GetIPsByInterface(val interface{}) []string {
+	switch ips := val.(type) {
+	case string:
+		return SplitIPs(ips)
+	case []string:
+		return ips
+	case []interface{}:
+		result := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			if str, ok := ip.(string); ok {
+				result = append(result, SplitIPs(str)...)
+			}
+		}
+		return result
+	}
+	return []string{}
+}
+
+// GetHostAddresses get all the addresses of host, include the inner ip and the ipv4
+// and ipv6 address lists. the valid addresses are normalized and returned first, the
+// invalid ones are kept at the end, so that the host with legacy inner ip can be matched.
+func GetHostAddresses(host map[string]interface{}) []string {
+	ips := GetIPsByInterface(host[common.BKHostInnerIPField])
+	ips = append(ips, GetIPsByInterface(host[common.BKHostIPv4Field])...)
+	ips = append(ips, GetIPsByInterface(host[common.BKHostIPv6Field])...)
+	ipv4, ipv6, invalid := NormalizeIPs(ips)
+	addresses := append(ipv4, ipv6...)
+	return append(addresses, invalid...)
+}
+
+// IPKey returns the 16 bytes form of ip in fixed width hex, the ipv4 address
+// is in ipv4-mapped form, so the order of keys is the same as the addresses,
+// and cidr containment can be done by range query. empty for invalid ip.
+func IPKey(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if nil == parsed {
+		return ""
+	}
+	return hex.EncodeToString(parsed.To16())
+}
+
+// IPKeys returns the keys of the valid ips
+func IPKeys(ips []string) []string {
+	keys := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if key := IPKey(ip); "" != key {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// CIDRKeyRange returns the first and the last IPKey of the addresses in cidr,
+// a single ip address is treated as a cidr only contains itself.
+func CIDRKeyRange(cidr string) (first, last string, err error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		key := IPKey(cidr)
+		if "" == key {
+			return "", "", fmt.Errorf("invalid cidr %s", cidr)
+		}
+		return key, key, nil
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if nil != err {
+		return "", "", err
+	}
+	ip := network.IP.To16()
+	mask := network.Mask
+	if len(mask) == net.IPv4len {
+		// align the ipv4 mask to the ipv4-mapped address
+		mask = append(net.CIDRMask(96, 128)[:12], mask...)
+	}
+	start := make(net.IP, net.IPv6len)
+	end := make(net.IP, net.IPv6len)
+	for i := 0; i < net.IPv6len; i++ {
+		start[i] = ip[i] & mask[i]
+		end[i] = ip[i] | ^mask[i]
+	}
+	return hex.EncodeToString(start), hex.EncodeToString(end), nil
+}
+
+// CIDRContains check whether the ip is in the cidr
+func CIDRContains(cidr, ip string) bool {
+	first, last, err := CIDRKeyRange(cidr)
+	if nil != err {
+		return false
+	}
+	key := IPKey(ip)
+	return "" != key && key >= first && key <= last
+}
@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+// the methods errif in valid_test.go misses, so that it implements errors.DefaultCCErrorIf
+// and the package tests build
+
+func (ei errif) CCError(errCode int) errors.CCErrorCoder {
+	return nil
+}
+
+func (ei errif) CCErrorf(errCode int, args ...interface{}) errors.CCErrorCoder {
+	return nil
+}
+
+func TestNormalizeIPs(t *testing.T) {
+	ipv4, ipv6, invalid := NormalizeIPs(SplitIPs("10.0.0.1, ::ffff:10.0.0.1;2001:DB8::0:1 bad 10.0.0.2"))
+	if !reflect.DeepEqual(ipv4, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("NormalizeIPs() ipv4 = %v", ipv4)
+	}
+	if !reflect.DeepEqual(ipv6, []string{"2001:db8::1"}) {
+		t.Errorf("NormalizeIPs() ipv6 = %v", ipv6)
+	}
+	if !reflect.DeepEqual(invalid, []string{"bad"}) {
+		t.Errorf("NormalizeIPs() invalid = %v", invalid)
+	}
+}
+
+func TestGetHostAddresses(t *testing.T) {
+	host := map[string]interface{}{
+		common.BKHostInnerIPField: "10.0.0.1,legacy",
+		common.BKHostIPv4Field:    []interface{}{"10.0.0.1", "10.0.0.2"},
+		common.BKHostIPv6Field:    []string{"2001:DB8::1"},
+	}
+	want := []string{"10.0.0.1", "10.0.0.2", "2001:db8::1", "legacy"}
+	if got := GetHostAddresses(host); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetHostAddresses() = %v, want %v", got, want)
+	}
+}
+
+func TestCIDRContains(t *testing.T) {
+	tests := []struct {
+		cidr string
+		ip   string
+		want bool
+	}{
+		{"10.0.0.0/8", "10.1.2.3", true},
+		{"10.0.0.0/8", "11.0.0.1", false},
+		{"10.0.0.1", "10.0.0.1", true},
+		{"0.0.0.0/0", "2001:db8::1", false},
+		{"2001:db8::/32", "2001:db8:1::1", true},
+		{"2001:db8::/32", "2001:db9::1", false},
+		{"bad", "10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := CIDRContains(tt.cidr, tt.ip); got != tt.want {
+			t.Errorf("CIDRContains(%s, %s) = %v, want %v", tt.cidr, tt.ip, got, tt.want)
+		}
+	}
+}
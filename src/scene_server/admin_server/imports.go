@@ -41,4 +41,18 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.01"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.02"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.03"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.10.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.16.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.20.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.22.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.24.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.27.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.28.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.29.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.30.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.31.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.06.01.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.06.03.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.06.04.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.06.05.01"
 )
@@ -0,0 +1,85 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_10_01
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// updateHostIPPattern allow ipv6 address in the ip attributes of host
+func updateHostIPPattern(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKObjIDField).Eq(common.BKInnerObjIDHost)
+	cond.Field(common.BKPropertyIDField).In([]string{common.BKHostInnerIPField, common.BKHostOuterIPField})
+	return db.Table(common.BKTableNameObjAttDes).Update(ctx, cond.ToMapStr(), mapstr.MapStr{common.BKOptionField: common.PatternMultipleIPv4v6})
+}
+
+func addHostIPIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	indexs := []dal.Index{
+		dal.Index{Name: "", Keys: map[string]int32{common.BKHostIPKeyField: 1}, Background: true},
+		dal.Index{Name: "", Keys: map[string]int32{common.BKHostIPv4Field: 1}, Background: true},
+		dal.Index{Name: "", Keys: map[string]int32{common.BKHostIPv6Field: 1}, Background: true},
+	}
+
+	for _, index := range indexs {
+		if err := db.Table(common.BKTableNameBaseHost).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillHostIPList fill the address lists of the exist hosts with their inner ip
+func fillHostIPList(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	type Host struct {
+		ID      int64  `bson:"bk_host_id"`
+		InnerIP string `bson:"bk_host_innerip"`
+	}
+
+	const limit = uint64(500)
+	var start uint64
+	for {
+		hosts := []Host{}
+		err := db.Table(common.BKTableNameBaseHost).Find(nil).Fields(common.BKHostIDField, common.BKHostInnerIPField).
+			Sort(common.BKHostIDField).Start(start).Limit(limit).All(ctx, &hosts)
+		if err != nil {
+			return err
+		}
+		if len(hosts) <= 0 {
+			break
+		}
+		start += limit
+
+		for _, host := range hosts {
+			ipv4, ipv6, _ := util.NormalizeIPs(util.SplitIPs(host.InnerIP))
+			data := mapstr.MapStr{
+				common.BKHostIPv4Field:  ipv4,
+				common.BKHostIPv6Field:  ipv6,
+				common.BKHostIPKeyField: util.IPKeys(append(ipv4, ipv6...)),
+			}
+			cond := condition.CreateCondition()
+			cond.Field(common.BKHostIDField).Eq(host.ID)
+			if err := db.Table(common.BKTableNameBaseHost).Update(ctx, cond.ToMapStr(), data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
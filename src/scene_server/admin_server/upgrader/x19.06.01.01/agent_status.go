@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_06_01_01
+
+import (
+	"context"
+
+	"gopkg.in/mgo.v2"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	mCommon "configcenter/src/scene_server/admin_server/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/scene_server/validator"
+	"configcenter/src/storage/dal"
+)
+
+// addAgentStatusAttribute add the agent status of host, and the minutes after which
+// the silent hosts of the business are stale
+func addAgentStatusAttribute(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	now := metadata.Now()
+	attrs := []metadata.Attribute{
+		{
+			ObjectID:      common.BKInnerObjIDHost,
+			PropertyID:    common.BKAgentStatusField,
+			PropertyName:  "Agent状态",
+			PropertyGroup: mCommon.HostAutoFields,
+			PropertyType:  common.FieldTypeEnum,
+			Option: []validator.EnumVal{
+				{ID: common.HostAgentStatusNormal, Name: "正常", Type: "text", IsDefault: true},
+				{ID: common.HostAgentStatusStale, Name: "失联", Type: "text"},
+			},
+			Description: "主机超过所属业务的失联时间未上报快照时为失联",
+		},
+		{
+			ObjectID:      common.BKInnerObjIDApp,
+			PropertyID:    common.BKAgentStaleMinutesField,
+			PropertyName:  "主机失联时间",
+			PropertyGroup: mCommon.BaseInfo,
+			PropertyType:  common.FieldTypeInt,
+			Unit:          "分钟",
+			IsEditable:    true,
+			Option:        validator.MinMaxOption{Min: "0", Max: "43200"},
+			Description:   "主机超过该时间未上报快照时标记为失联，为0时使用默认值",
+		},
+	}
+
+	for _, attr := range attrs {
+		filter := map[string]interface{}{
+			common.BKObjIDField:      attr.ObjectID,
+			common.BKPropertyIDField: attr.PropertyID,
+			common.BKOwnerIDField:    conf.OwnerID,
+		}
+		cnt, err := db.Table(common.BKTableNameObjAttDes).Find(filter).Count(ctx)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			continue
+		}
+
+		id, err := db.NextSequence(ctx, common.BKTableNameObjAttDes)
+		if err != nil {
+			return err
+		}
+		attr.ID = int64(id)
+		attr.OwnerID = conf.OwnerID
+		attr.Creator = conf.User
+		attr.IsPre = true
+		attr.CreateTime = &now
+		attr.LastTime = &now
+		if err = db.Table(common.BKTableNameObjAttDes).Insert(ctx, attr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addHostSnapStatusTable add the table of the last snapshot time of hosts
+func addHostSnapStatusTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableName := common.BKTableNameHostSnapStatus
+	exists, err := db.HasTable(tableName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = db.CreateTable(tableName); err != nil && !mgo.IsDup(err) {
+			return err
+		}
+	}
+
+	indexs := []dal.Index{
+		dal.Index{Name: "", Keys: map[string]int32{common.BKHostIDField: 1}, Unique: true, Background: true},
+		dal.Index{Name: "", Keys: map[string]int32{"last_snap_time": 1}, Background: true},
+	}
+	for _, index := range indexs {
+		if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -47,9 +47,25 @@ type Config struct {
 	DiscoverRedis   SnapRedis
 	NetcollectRedis SnapRedis
 	Esb             esbutil.EsbConfig
+	// AgentStaleMinutes the default minutes after which the silent hosts are stale
+	AgentStaleMinutes int64
 }
 
 type SnapRedis struct {
 	redis.Config
 	Enable string
+	// Porter the porter type reading messages from this redis, PorterChannel by default
+	Porter string
+	// Stream the redis stream key consumed when Porter is PorterStream
+	Stream string
 }
+
+const (
+	// PorterChannel reads messages by subscribing the redis pub/sub channels
+	PorterChannel = "channel"
+	// PorterStream reads messages from a redis stream by consumer group and acknowledges them after analyzed
+	PorterStream = "stream"
+)
+
+// DefaultAgentStaleMinutes the default stale minutes when it's not configured
+const DefaultAgentStaleMinutes = 30
@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -70,6 +71,14 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		if err != nil {
 			return fmt.Errorf("new mongo client failed, err: %s", err.Error())
 		}
+		process.Service.SetDB(instance)
+
+		cache, err := redis.NewFromConfig(process.Config.CCRedis)
+		if err != nil {
+			return fmt.Errorf("new redis client failed, err: %s", err.Error())
+		}
+		process.Service.SetCache(cache)
+		process.Service.SetReplayer(datacollection.NewReplayer(ctx, engine, instance, process.Config.CCRedis))
 
 		esbChan := make(chan esbutil.EsbConfig, 1)
 		esbChan <- process.Config.Esb
@@ -124,24 +133,32 @@ func (h *DCServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.CCRedis = redisConf
 
 		snapPrefix := "snap-redis"
-		snapredisConf := redis.ParseConfigFromKV(snapPrefix, current.ConfigMap)
-		h.Config.SnapRedis.Config = snapredisConf
-		h.Config.SnapRedis.Enable = current.ConfigMap[snapPrefix+".enable"]
+		h.Config.SnapRedis = parseSnapRedis(snapPrefix, current.ConfigMap)
 
 		discoverPrefix := "discover-redis"
-		discoverRedisConf := redis.ParseConfigFromKV(discoverPrefix, current.ConfigMap)
-		h.Config.DiscoverRedis.Config = discoverRedisConf
-		h.Config.SnapRedis.Enable = current.ConfigMap[discoverPrefix+".enable"]
+		h.Config.DiscoverRedis = parseSnapRedis(discoverPrefix, current.ConfigMap)
 
 		netcollectPrefix := "netcollect-redis"
-		netcollectRedisConf := redis.ParseConfigFromKV(netcollectPrefix, current.ConfigMap)
-		h.Config.NetcollectRedis.Config = netcollectRedisConf
-		h.Config.SnapRedis.Enable = current.ConfigMap[netcollectPrefix+".enable"]
+		h.Config.NetcollectRedis = parseSnapRedis(netcollectPrefix, current.ConfigMap)
 
 		esbPrefix := "esb"
 		h.Config.Esb.Addrs = current.ConfigMap[esbPrefix+".addr"]
 		h.Config.Esb.AppCode = current.ConfigMap[esbPrefix+".appCode"]
 		h.Config.Esb.AppSecret = current.ConfigMap[esbPrefix+".appSecret"]
+
+		h.Config.AgentStaleMinutes = options.DefaultAgentStaleMinutes
+		if minutes, err := strconv.ParseInt(current.ConfigMap["hostsnap.agentStaleMinutes"], 10, 64); err == nil && minutes > 0 {
+			h.Config.AgentStaleMinutes = minutes
+		}
+	}
+}
+
+func parseSnapRedis(prefix string, configMap map[string]string) options.SnapRedis {
+	return options.SnapRedis{
+		Config: redis.ParseConfigFromKV(prefix, configMap),
+		Enable: configMap[prefix+".enable"],
+		Porter: configMap[prefix+".porter"],
+		Stream: configMap[prefix+".stream"],
 	}
 }
 
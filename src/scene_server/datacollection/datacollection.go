@@ -15,6 +15,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -39,6 +40,10 @@ func main() {
 	var collector string
 	pflag.CommandLine.BoolVar(&mock, "mock", false, "send mock message")
 	pflag.CommandLine.StringVar(&collector, "collector", "", "collector name that send mock message send to")
+	var replayFile string
+	var dryRun bool
+	pflag.CommandLine.StringVar(&replayFile, "replay", "", "the NDJSON file of recorded messages, which are replayed through the collector's analyzer by the process listening on addrport")
+	pflag.CommandLine.BoolVar(&dryRun, "dry-run", false, "report the writes each replayed message would cause without applying them")
 
 	op := options.NewServerOption()
 	op.AddFlags(pflag.CommandLine)
@@ -53,6 +58,14 @@ func main() {
 		return
 	}
 
+	if replayFile != "" {
+		if err := replay(op.ServConf.AddrPort, collector, replayFile, dryRun); err != nil {
+			fmt.Printf("replay failed %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	blog.InitLogs()
 	defer blog.CloseLogs()
 	if err := common.SavePid(); err != nil {
@@ -82,3 +95,41 @@ func sigmock(collector string) error {
 	}
 	return nil
 }
+
+// replay posts the recorded messages to the datacollection process and prints the replay result
+func replay(addrport, collector, file string, dryRun bool) error {
+	body, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	url := fmt.Sprintf("http://%s/collector/v3/porter/%s/action/replay?dry_run=%v", addrport, collector, dryRun)
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
+	req.Header.Set(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respbody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s", respbody)
+	}
+
+	out := bytes.Buffer{}
+	if err := json.Indent(&out, respbody, "", "  "); err != nil {
+		return err
+	}
+	fmt.Println(out.String())
+	return nil
+}
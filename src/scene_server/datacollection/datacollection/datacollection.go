@@ -81,9 +81,10 @@ func (d *DataCollection) Run() error {
 		}
 		blog.Infof("[datacollect][RUN]connected to snap-redis %+v", d.Config.SnapRedis.Config)
 		snapChanName := d.getSnapChanName(defaultAppID)
-		hostsnapCollector := hostsnap.NewHostSnap(d.ctx, rediscli, db)
-		snapPorter := BuildChanPorter("hostsnap", hostsnapCollector, rediscli, snapcli, snapChanName, hostsnap.MockMessage)
+		hostsnapCollector := hostsnap.NewHostSnap(d.ctx, rediscli, db, d.Engine)
+		snapPorter := buildPorter(PorterHostsnap, hostsnapCollector, rediscli, snapcli, d.Config.SnapRedis, snapChanName, hostsnap.MockMessage)
 		man.AddPorter(snapPorter)
+		go staleCheckLoop(rediscli, hostsnapCollector, d.Config.AgentStaleMinutes)
 	}
 
 	if d.Config.DiscoverRedis.Enable != "false" {
@@ -95,9 +96,10 @@ func (d *DataCollection) Run() error {
 		}
 		blog.Infof("[datacollect][RUN]connected to discover-redis %+v", d.Config.DiscoverRedis.Config)
 		discoverChanName := d.getDiscoverChanName(defaultAppID)
-		middlewareCollector := middleware.NewDiscover(d.ctx, rediscli, d.Engine)
-		middlewarePorter := BuildChanPorter("middleware", middlewareCollector, rediscli, discli, discoverChanName, middleware.MockMessage)
+		middlewareCollector := middleware.NewDiscover(d.ctx, rediscli, db, d.Engine)
+		middlewarePorter := buildPorter(PorterMiddleware, middlewareCollector, rediscli, discli, d.Config.DiscoverRedis, discoverChanName, middleware.MockMessage)
 		man.AddPorter(middlewarePorter)
+		go deprecateCheckLoop(rediscli, middlewareCollector)
 	}
 
 	if d.Config.NetcollectRedis.Enable != "false" {
@@ -109,8 +111,8 @@ func (d *DataCollection) Run() error {
 		}
 		blog.Infof("[datacollect][RUN]connected to netcollect-redis %+v", d.Config.NetcollectRedis.Config)
 		netdevChanName := d.getNetcollectChanName(defaultAppID)
-		netcollector := netcollect.NewNetcollect(d.ctx, db)
-		netcollectPorter := BuildChanPorter("netcollect", netcollector, rediscli, netcli, netdevChanName, netcollect.MockMessage)
+		netcollector := netcollect.NewNetcollect(d.ctx, db, d.Engine)
+		netcollectPorter := buildPorter(PorterNetcollect, netcollector, rediscli, netcli, d.Config.NetcollectRedis, netdevChanName, netcollect.MockMessage)
 		man.AddPorter(netcollectPorter)
 	}
 
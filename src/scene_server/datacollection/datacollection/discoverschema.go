@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/datacollection/datacollection/middleware"
+
+	"github.com/rs/xid"
+	redis "gopkg.in/redis.v5"
+)
+
+var (
+	deprecateCheckInterval = time.Hour
+)
+
+// deprecateCheckLoop deprecate the attributes of the discovered models which stop being reported,
+// only the master of all the processes does it
+func deprecateCheckLoop(redisCli *redis.Client, collector *middleware.Discover) {
+	name := "discoverschema"
+	pid := xid.New().String()
+	for range time.Tick(deprecateCheckInterval) {
+		if err := loginMaster(redisCli, name, pid); err != nil {
+			if !strings.HasPrefix(err.Error(), "there is other master") {
+				blog.Errorf("[datacollect][%s] %v", name, err)
+			}
+			continue
+		}
+		if err := collector.CheckDeprecated(); err != nil {
+			blog.Errorf("[datacollect][%s] check deprecated attributes failed, %v", name, err)
+		}
+	}
+}
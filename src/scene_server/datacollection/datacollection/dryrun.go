@@ -0,0 +1,211 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/flowctrl"
+	"configcenter/src/apimachinery/util"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+
+	"gopkg.in/redis.v5"
+)
+
+// the kinds of the writes recorded in dry run mode
+const (
+	writeKindDB    = "db"
+	writeKindAPI   = "api"
+	writeKindRedis = "redis"
+)
+
+// writeRecorder records the writes of the dry run backends instead of applying them
+type writeRecorder struct {
+	lock   sync.Mutex
+	writes []metadata.ReplayWrite
+}
+
+func (r *writeRecorder) record(write metadata.ReplayWrite) {
+	r.lock.Lock()
+	r.writes = append(r.writes, write)
+	r.lock.Unlock()
+}
+
+// take returns the recorded writes and resets the recorder
+func (r *writeRecorder) take() []metadata.ReplayWrite {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	writes := r.writes
+	r.writes = nil
+	return writes
+}
+
+// dryRunDB passes the queries through to the real db and records the writes
+type dryRunDB struct {
+	dal.RDB
+	recorder *writeRecorder
+}
+
+func (d *dryRunDB) Table(collection string) dal.Table {
+	return &dryRunTable{Table: d.RDB.Table(collection), name: collection, recorder: d.recorder}
+}
+
+// NextSequence the sequence is not increased in dry run mode, so 0 is returned
+func (d *dryRunDB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	d.recorder.record(metadata.ReplayWrite{Kind: writeKindDB, Target: sequenceName, Operation: "next_sequence"})
+	return 0, nil
+}
+
+type dryRunTable struct {
+	dal.Table
+	name     string
+	recorder *writeRecorder
+}
+
+func (t *dryRunTable) Insert(ctx context.Context, docs interface{}) error {
+	t.recorder.record(metadata.ReplayWrite{Kind: writeKindDB, Target: t.name, Operation: "insert", Detail: docs})
+	return nil
+}
+
+func (t *dryRunTable) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	detail := map[string]interface{}{"filter": filter, "doc": doc}
+	t.recorder.record(metadata.ReplayWrite{Kind: writeKindDB, Target: t.name, Operation: "update", Detail: detail})
+	return nil
+}
+
+func (t *dryRunTable) Delete(ctx context.Context, filter dal.Filter) error {
+	detail := map[string]interface{}{"filter": filter}
+	t.recorder.record(metadata.ReplayWrite{Kind: writeKindDB, Target: t.name, Operation: "delete", Detail: detail})
+	return nil
+}
+
+// dryRunResponse the response of the recorded api requests, it fits the BaseResp of all the apis
+const dryRunResponse = `{"result":true,"bk_error_code":0,"bk_error_msg":"success","data":{}}`
+
+// dryRunClient sends the read requests and records the others
+type dryRunClient struct {
+	client   util.HttpClient
+	recorder *writeRecorder
+}
+
+func (c *dryRunClient) Do(req *http.Request) (*http.Response, error) {
+	if isReadRequest(req) {
+		return c.client.Do(req)
+	}
+
+	var detail interface{}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &detail); err != nil {
+			detail = string(body)
+		}
+	}
+	c.recorder.record(metadata.ReplayWrite{Kind: writeKindAPI, Target: req.URL.Path, Operation: req.Method, Detail: detail})
+
+	return &http.Response{
+		Status:     http.StatusText(http.StatusOK),
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(dryRunResponse)),
+		Request:    req,
+	}, nil
+}
+
+// isReadRequest judges by the api paths, the reading apis are named with read, search or find
+func isReadRequest(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	for _, word := range []string{"/read/", "/search", "/find"} {
+		if strings.Contains(req.URL.Path, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// newDryRunEngine returns an engine whose CoreAPI records the writing requests
+func newDryRunEngine(engine *backbone.Engine, recorder *writeRecorder) (*backbone.Engine, error) {
+	conf := engine.ApiMachineryConfig()
+	client, err := util.NewClient(conf.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	dryRunClient := &dryRunClient{client: client, recorder: recorder}
+
+	return &backbone.Engine{
+		CoreAPI:    apimachinery.NewClientSet(dryRunClient, engine.Discovery(), flowctrl.NewRateLimiter(conf.QPS, conf.Burst)),
+		ServerInfo: engine.ServerInfo,
+		Language:   engine.Language,
+		CCErr:      engine.CCErr,
+		CCCtx:      engine.CCCtx,
+	}, nil
+}
+
+// redisWriteCommands the redis commands used by the analyzers which change the data
+var redisWriteCommands = map[string]bool{
+	"set": true, "setex": true, "setnx": true, "del": true, "expire": true,
+	"lpush": true, "rpush": true, "zadd": true, "hset": true, "hmset": true, "hdel": true,
+}
+
+// wrapDryRunRedis makes the client record the writing commands instead of sending them
+func wrapDryRunRedis(cli *redis.Client, recorder *writeRecorder) {
+	cli.WrapProcess(func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			write, ok := parseRedisWrite(cmd)
+			if !ok {
+				return oldProcess(cmd)
+			}
+			recorder.record(write)
+			return nil
+		}
+	})
+}
+
+// parseRedisWrite parses the command which is not processed yet, its string looks like
+// "set key value ex 600: " with the zero value of the result appended
+func parseRedisWrite(cmd redis.Cmder) (metadata.ReplayWrite, bool) {
+	command := cmd.String()
+	switch cmd.(type) {
+	case *redis.StatusCmd:
+		command = strings.TrimSuffix(command, ": ")
+	case *redis.IntCmd:
+		command = strings.TrimSuffix(command, ": 0")
+	case *redis.BoolCmd:
+		command = strings.TrimSuffix(command, ": false")
+	}
+
+	fields := strings.SplitN(command, " ", 3)
+	name := strings.ToLower(fields[0])
+	if !redisWriteCommands[name] || len(fields) < 2 {
+		return metadata.ReplayWrite{}, false
+	}
+	write := metadata.ReplayWrite{Kind: writeKindRedis, Target: fields[1], Operation: name}
+	if len(fields) == 3 {
+		write.Detail = fields[2]
+	}
+	return write, true
+}
@@ -0,0 +1,126 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// diffHardware get the changes of the host attributes the snapshot updates
+func diffHardware(setter map[string]interface{}, host *HostInst) []metadata.HostHardwareChange {
+	changes := make([]metadata.HostHardwareChange, 0)
+	for field, cur := range setter {
+		pre := host.get(field)
+		changeType := hardwareChangeType(pre, cur)
+		if changeType == "" {
+			continue
+		}
+		changes = append(changes, metadata.HostHardwareChange{
+			PropertyID: field,
+			ChangeType: changeType,
+			PreData:    pre,
+			CurData:    cur,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].PropertyID < changes[j].PropertyID })
+	return changes
+}
+
+// hardwareChangeType how the value changes, empty if it's not changed.
+// the numbers are compared by value, as the type saved in db may differ from the snapshot
+func hardwareChangeType(pre, cur interface{}) string {
+	preEmpty, curEmpty := isEmptyValue(pre), isEmptyValue(cur)
+	switch {
+	case preEmpty && curEmpty:
+		return ""
+	case preEmpty:
+		return metadata.HostHardwareChangeAdded
+	case curEmpty:
+		return metadata.HostHardwareChangeRemoved
+	}
+
+	preNum, preOK := toNumber(pre)
+	curNum, curOK := toNumber(cur)
+	if preOK && curOK {
+		switch {
+		case curNum > preNum:
+			return metadata.HostHardwareChangeIncrease
+		case curNum < preNum:
+			return metadata.HostHardwareChangeDecrease
+		default:
+			return ""
+		}
+	}
+
+	if pre == cur {
+		return ""
+	}
+	return metadata.HostHardwareChangeModified
+}
+
+func isEmptyValue(val interface{}) bool {
+	if val == nil || val == "" {
+		return true
+	}
+	num, ok := toNumber(val)
+	return ok && num == 0
+}
+
+// toNumber the numeric value, the strings like version are not numbers
+func toNumber(val interface{}) (float64, bool) {
+	if _, ok := val.(string); ok {
+		return 0, false
+	}
+	num, err := util.GetFloat64ByInterface(val)
+	return num, err == nil
+}
+
+// saveHardwareChange save the changes to history and send the host hardware event
+func (h *HostSnap) saveHardwareChange(host *HostInst, changes []metadata.HostHardwareChange) {
+	hostID, err := util.GetInt64ByInterface(host.get(common.BKHostIDField))
+	if err != nil {
+		blog.Errorf("[datacollect][hostsnap] invalid host id %v, %v", host.get(common.BKHostIDField), err)
+		return
+	}
+	innerIP, _ := host.get(common.BKHostInnerIPField).(string)
+	history := metadata.HostHardwareHistory{
+		HostID:     hostID,
+		InnerIP:    innerIP,
+		OwnerID:    util.GetStrByInterface(host.get(common.BKOwnerIDField)),
+		Changes:    changes,
+		ChangeTime: metadata.Now(),
+	}
+	if err := h.db.Table(common.BKTableNameHostHardwareHistory).Insert(h.ctx, history); err != nil {
+		blog.Errorf("[datacollect][hostsnap] save hardware history of host %d failed, %v", hostID, err)
+	}
+
+	pheader := http.Header{}
+	pheader.Add(common.BKHTTPOwnerID, history.OwnerID)
+	pheader.Add(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
+	event := eventclient.NewEventWithHeader(pheader)
+	event.EventType = metadata.EventTypeHostHardware
+	event.ObjType = metadata.EventObjTypeHostHardware
+	event.Action = metadata.EventActionUpdate
+	event.Data = []metadata.EventData{{CurData: history}}
+	if err := h.eventC.Push(context.Background(), event); err != nil {
+		blog.Errorf("[datacollect][hostsnap] send hardware event of host %d failed, %v", hostID, err)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestDiffHardware(t *testing.T) {
+	host := &HostInst{data: map[string]interface{}{
+		"bk_mem":        int64(2048),
+		"bk_disk":       int64(0),
+		"bk_cpu":        int32(4),
+		"bk_os_version": "6.2",
+		"bk_mac":        "52:54:00:19:2e:e8",
+	}}
+	setter := map[string]interface{}{
+		"bk_mem":        int64(1024),
+		"bk_disk":       int64(49),
+		"bk_cpu":        int64(4),
+		"bk_os_version": "7.2",
+		"bk_mac":        "",
+		"bk_host_name":  "",
+	}
+
+	changes := diffHardware(setter, host)
+	want := map[string]string{
+		"bk_disk":       metadata.HostHardwareChangeAdded,
+		"bk_mac":        metadata.HostHardwareChangeRemoved,
+		"bk_mem":        metadata.HostHardwareChangeDecrease,
+		"bk_os_version": metadata.HostHardwareChangeModified,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("diffHardware() got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for _, change := range changes {
+		if want[change.PropertyID] != change.ChangeType {
+			t.Errorf("change of %s is %s, want %s", change.PropertyID, change.ChangeType, want[change.PropertyID])
+		}
+	}
+}
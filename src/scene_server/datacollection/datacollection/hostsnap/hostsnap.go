@@ -15,13 +15,18 @@ package hostsnap
 import (
 	"context"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"configcenter/src/common"
+	"configcenter/src/common/backbone"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/storage/dal"
 
 	"github.com/tidwall/gjson"
@@ -39,6 +44,15 @@ type HostSnap struct {
 	cachelock sync.RWMutex
 	ctx       context.Context
 	db        dal.RDB
+
+	// mappings the attribute mappings of each owner
+	mappings    map[string][]metadata.HostSnapMapping
+	mappingLock sync.RWMutex
+	*backbone.Engine
+
+	eventC eventclient.Client
+	// snapTime the last snapshot time of the hosts
+	snapTime snapTimeRecorder
 }
 
 type Cache struct {
@@ -46,25 +60,49 @@ type Cache struct {
 	flag  bool
 }
 
-func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB) *HostSnap {
-	h := &HostSnap{
+func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine *backbone.Engine) *HostSnap {
+	h := newHostSnap(ctx, redisCli, db, engine, eventclient.NewClientViaRedis(redisCli, db))
+	go h.fetchDBLoop()
+	go h.fetchMappingLoop()
+	return h
+}
+
+// NewReplayHostSnap returns the analyzer to replay the recorded messages, the hosts and mappings are
+// loaded at once instead of refreshed in background, and the events are pushed before Analyze returns
+func NewReplayHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine *backbone.Engine) *HostSnap {
+	h := newHostSnap(ctx, redisCli, db, engine, eventclient.NewSyncClientViaRedis(redisCli, db))
+	h.cache.cache[h.cache.flag] = h.fetch()
+	h.fetchMapping()
+	return h
+}
+
+func newHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine *backbone.Engine, eventC eventclient.Client) *HostSnap {
+	return &HostSnap{
 		redisCli: redisCli,
 		ctx:      ctx,
 		db:       db,
 		cache: &Cache{
-			cache: map[bool]*HostCache{},
+			// the empty cache is used until the hosts are fetched from db
+			cache: map[bool]*HostCache{false: {data: map[string]*HostInst{}}},
 			flag:  false,
 		},
+		mappings: map[string][]metadata.HostSnapMapping{},
+		Engine:   engine,
+		eventC:   eventC,
+		snapTime: snapTimeRecorder{recorded: map[int64]time.Time{}},
 	}
-	go h.fetchDBLoop()
-	return h
 }
 
-func (h *HostSnap) Analyze(mesg string) error {
-	var data = mesg
+// SnapshotData the snapshot reported by agent, the message of old agent wraps it in the data field
+func SnapshotData(mesg string) string {
 	if !gjson.Get(mesg, "cloudid").Exists() {
-		data = gjson.Get(mesg, "data").String()
+		return gjson.Get(mesg, "data").String()
 	}
+	return mesg
+}
+
+func (h *HostSnap) Analyze(mesg string) error {
+	data := SnapshotData(mesg)
 	val := gjson.Parse(data)
 	host := h.getHostByVal(&val)
 	if host == nil {
@@ -76,6 +114,7 @@ func (h *HostSnap) Analyze(mesg string) error {
 		blog.Warnf("[datacollect][hostsnap] host id not found, continue, %s", val.String())
 		return nil
 	}
+	h.recordSnapTime(host)
 
 	if err := h.redisCli.Set(common.RedisSnapKeyPrefix+hostid, data, time.Minute*10).Err(); err != nil {
 		blog.Errorf("[datacollect][hostsnap] save snapshot %s to redis faile: %s", common.RedisSnapKeyPrefix+hostid, err.Error())
@@ -92,11 +131,15 @@ func (h *HostSnap) Analyze(mesg string) error {
 		blog.Warnf("[datacollect][hostsnap] outip is not string, %s", val.String())
 	}
 	setter := parseSetter(&val, innerip, outip)
+	h.applyMappings(&val, fmt.Sprint(host.get(common.BKOwnerIDField)), setter)
 	if needToUpdate(setter, host) {
 		blog.Infof("[datacollect][hostsnap] update host by %v, to %v", condition, setter)
 		if err := h.db.Table(common.BKTableNameBaseHost).Update(h.ctx, condition, setter); err != nil {
 			return fmt.Errorf("update host error: %v", err)
 		}
+		if changes := diffHardware(setter, host); len(changes) > 0 {
+			h.saveHardwareChange(host, changes)
+		}
 		copyVal(setter, host)
 	}
 	return nil
@@ -151,12 +194,16 @@ func parseSetter(val *gjson.Result, innerIP, outerIP string) map[string]interfac
 		osname = fmt.Sprintf("%s", platform)
 	}
 	var OuterMAC, InnerMAC string
+	innerIPs, outerIPs := normalizeIPs(util.SplitIPs(innerIP)), normalizeIPs(util.SplitIPs(outerIP))
 	for _, inter := range val.Get("data.net.interface").Array() {
 		for _, addr := range inter.Get("addrs.#.addr").Array() {
-			ip := strings.Split(addr.String(), "/")[0]
-			if ip == innerIP {
+			ip, _ := util.NormalizeIP(strings.Split(addr.String(), "/")[0])
+			if "" == ip {
+				continue
+			}
+			if util.InStrArr(innerIPs, ip) {
 				InnerMAC = inter.Get("hardwareaddr").String()
-			} else if ip == outerIP {
+			} else if util.InStrArr(outerIPs, ip) {
 				OuterMAC = inter.Get("hardwareaddr").String()
 			}
 		}
@@ -242,8 +289,9 @@ func (h *HostSnap) getHostByVal(val *gjson.Result) *HostInst {
 		}
 		condition := map[string]interface{}{
 			common.BKCloudIDField: clouidInt,
-			common.BKHostInnerIPField: map[string]interface{}{
-				common.BKDBIN: ips,
+			common.BKDBOR: []map[string]interface{}{
+				{common.BKHostInnerIPField: map[string]interface{}{common.BKDBIN: ips}},
+				{common.BKHostIPKeyField: map[string]interface{}{common.BKDBIN: util.IPKeys(ips)}},
 			},
 			common.BKOwnerIDField: ownerID,
 		}
@@ -254,9 +302,10 @@ func (h *HostSnap) getHostByVal(val *gjson.Result) *HostInst {
 		}
 		for index := range result {
 			cloudid := fmt.Sprint(result[index][common.BKCloudIDField])
-			innerip := fmt.Sprint(result[index][common.BKHostInnerIPField])
 			inst := &HostInst{data: result[index]}
-			h.setCache(cloudid+"::"+innerip, inst)
+			for _, ip := range util.GetHostAddresses(result[index]) {
+				h.setCache(cloudid+"::"+ip, inst)
+			}
 			return inst
 		}
 		blog.Infof("[datacollect][hostsnap] ips not in cache and db, clouid: %v, ip: %v", cloudid, ips)
@@ -266,23 +315,35 @@ func (h *HostSnap) getHostByVal(val *gjson.Result) *HostInst {
 	return nil
 }
 
+// getIPS get the normalized addresses of the message, the loopback and link
+// local addresses are skipped, as they are not unique among hosts.
 func getIPS(val *gjson.Result) (ips []string) {
-	if !strings.HasPrefix(val.Get("ip").String(), "127.0.0.") {
-		ips = append(ips, val.Get("ip").String())
+	addIP := func(addr string) {
+		ip := net.ParseIP(strings.Split(addr, "/")[0])
+		if nil == ip || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			return
+		}
+		normalized, _ := util.NormalizeIP(ip.String())
+		if !util.InStrArr(ips, normalized) {
+			ips = append(ips, normalized)
+		}
 	}
+
+	addIP(val.Get("ip").String())
 	interfaces := val.Get("data.net.interface.#.addrs.#.addr").Array()
 	for _, addrs := range interfaces {
 		for _, addr := range addrs.Array() {
-			ip := strings.Split(addr.String(), "/")[0]
-			if strings.HasPrefix(ip, "127.0.0.") {
-				continue
-			}
-			ips = append(ips, ip)
+			addIP(addr.String())
 		}
 	}
 	return ips
 }
 
+func normalizeIPs(ips []string) []string {
+	ipv4, ipv6, _ := util.NormalizeIPs(ips)
+	return append(ipv4, ipv6...)
+}
+
 func (h *HostSnap) getCache() *HostCache {
 	h.cachelock.RLock()
 	defer h.cachelock.RUnlock()
@@ -322,8 +383,10 @@ func (h *HostSnap) fetch() *HostCache {
 		}
 		for index := range result {
 			cloudid := fmt.Sprint(result[index][common.BKCloudIDField])
-			innerip := fmt.Sprint(result[index][common.BKHostInnerIPField])
-			hostcache.data[cloudid+"::"+innerip] = &HostInst{data: result[index]}
+			inst := &HostInst{data: result[index]}
+			for _, ip := range util.GetHostAddresses(result[index]) {
+				hostcache.data[cloudid+"::"+ip] = inst
+			}
 		}
 		if uint64(len(result)) < limit {
 			break
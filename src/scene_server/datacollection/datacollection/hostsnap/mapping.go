@@ -0,0 +1,182 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	fetchMappingInterval = time.Minute
+)
+
+// regexps the compiled patterns of the regex transforms
+var regexps = struct {
+	sync.RWMutex
+	data map[string]*regexp.Regexp
+}{data: map[string]*regexp.Regexp{}}
+
+// ApplyMapping get the value of host attribute from the snapshot by the mapping
+func ApplyMapping(val *gjson.Result, mapping metadata.HostSnapMapping) (interface{}, error) {
+	result := val.Get(mapping.Path)
+	if !result.Exists() {
+		return nil, fmt.Errorf("path %s not found in snapshot", mapping.Path)
+	}
+
+	transform := mapping.Transform
+	switch transform.Type {
+	case metadata.HostSnapTransformUnit:
+		if result.Type != gjson.Number {
+			return nil, fmt.Errorf("value of %s is not a number", mapping.Path)
+		}
+		// keep two decimals, so that 1.5GB is not rounded to 1 or kept as 1.4999999
+		return normalizeNumber(math.Round(result.Float()*transform.UnitRatio()*100) / 100), nil
+	case metadata.HostSnapTransformJoin:
+		if !result.IsArray() {
+			return nil, fmt.Errorf("value of %s is not an array", mapping.Path)
+		}
+		items := make([]string, 0)
+		for _, item := range result.Array() {
+			items = append(items, item.String())
+		}
+		return strings.Join(items, transform.Separator), nil
+	case metadata.HostSnapTransformRegex:
+		re, err := compileRegexp(transform.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		match := re.FindStringSubmatch(result.String())
+		if match == nil {
+			return nil, fmt.Errorf("value of %s does not match %s", mapping.Path, transform.Pattern)
+		}
+		if len(match) > 1 {
+			return match[1], nil
+		}
+		return match[0], nil
+	default:
+		return resultValue(result), nil
+	}
+}
+
+// resultValue the value of the json result, the array and object are kept as json
+func resultValue(result gjson.Result) interface{} {
+	switch result.Type {
+	case gjson.Number:
+		return normalizeNumber(result.Float())
+	case gjson.String:
+		return result.String()
+	case gjson.True, gjson.False:
+		return result.Bool()
+	case gjson.Null:
+		return nil
+	default:
+		return result.Raw
+	}
+}
+
+// normalizeNumber the integral number is saved as int64, like the hardcoded fields
+func normalizeNumber(f float64) interface{} {
+	if f == math.Trunc(f) && math.Abs(f) < math.MaxInt64 {
+		return int64(f)
+	}
+	return f
+}
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexps.RLock()
+	re, ok := regexps.data[pattern]
+	regexps.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s, %v", pattern, err)
+	}
+	regexps.Lock()
+	regexps.data[pattern] = re
+	regexps.Unlock()
+	return re, nil
+}
+
+// applyMappings set the values of the mappings of the host's owner to setter,
+// the mappings of the default owner are applied unless the owner has its own one of the same attribute.
+func (h *HostSnap) applyMappings(val *gjson.Result, ownerID string, setter map[string]interface{}) {
+	h.mappingLock.RLock()
+	mappings := make([]metadata.HostSnapMapping, 0)
+	if ownerID != common.BKDefaultOwnerID {
+		mappings = append(mappings, h.mappings[common.BKDefaultOwnerID]...)
+	}
+	mappings = append(mappings, h.mappings[ownerID]...)
+	h.mappingLock.RUnlock()
+
+	for _, mapping := range mappings {
+		value, err := ApplyMapping(val, mapping)
+		if err != nil {
+			blog.V(4).Infof("[datacollect][hostsnap] apply mapping %d to %s failed, %v", mapping.ID, mapping.PropertyID, err)
+			continue
+		}
+		// the identity fields are written only by the hardcoded parsing, and the value
+		// of the wrong type is not written, in case the mapping is saved before they are checked
+		if err := mapping.CheckValue(value); err != nil {
+			blog.V(4).Infof("[datacollect][hostsnap] skip the value of mapping %d to %s, %v", mapping.ID, mapping.PropertyID, err)
+			continue
+		}
+		setter[mapping.PropertyID] = value
+	}
+}
+
+func (h *HostSnap) fetchMappingLoop() {
+	for {
+		h.fetchMapping()
+		time.Sleep(fetchMappingInterval)
+	}
+}
+
+func (h *HostSnap) fetchMapping() {
+	pheader := http.Header{}
+	pheader.Add(common.BKHTTPOwnerID, common.BKSuperOwnerID)
+	pheader.Add(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
+
+	resp, err := h.CoreAPI.CoreService().Host().SearchHostSnapMapping(h.ctx, pheader, &metadata.QueryCondition{})
+	if err != nil {
+		blog.Errorf("[datacollect][hostsnap] fetch mappings failed, %v", err)
+		return
+	}
+	if !resp.Result {
+		blog.Errorf("[datacollect][hostsnap] fetch mappings failed, %s", resp.ErrMsg)
+		return
+	}
+
+	mappings := make(map[string][]metadata.HostSnapMapping)
+	for _, mapping := range resp.Data.Info {
+		mappings[mapping.OwnerID] = append(mappings[mapping.OwnerID], mapping)
+	}
+	h.mappingLock.Lock()
+	h.mappings = mappings
+	h.mappingLock.Unlock()
+	blog.V(4).Infof("[datacollect][hostsnap] success fetch %d mappings", len(resp.Data.Info))
+}
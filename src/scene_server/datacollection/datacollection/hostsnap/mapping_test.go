@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyMapping(t *testing.T) {
+	val := gjson.Parse(SnapshotData(MockMessage))
+
+	tests := []struct {
+		name    string
+		mapping metadata.HostSnapMapping
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:    "plain number",
+			mapping: metadata.HostSnapMapping{Path: "data.system.info.uptime"},
+			want:    int64(348315),
+		},
+		{
+			name:    "plain string",
+			mapping: metadata.HostSnapMapping{Path: "data.system.info.kernelVersion"},
+			want:    "2.6.32-504.30.3.el6.x86_64",
+		},
+		{
+			name: "unit",
+			mapping: metadata.HostSnapMapping{Path: "data.mem.meminfo.total",
+				Transform: metadata.HostSnapTransform{Type: metadata.HostSnapTransformUnit, FromUnit: "B", ToUnit: "MB"}},
+			want: 996.43,
+		},
+		{
+			name: "join",
+			mapping: metadata.HostSnapMapping{Path: "data.net.interface.#.name",
+				Transform: metadata.HostSnapTransform{Type: metadata.HostSnapTransformJoin, Separator: ","}},
+			want: "lo,eth0",
+		},
+		{
+			name: "regex",
+			mapping: metadata.HostSnapMapping{Path: "data.system.info.kernelVersion",
+				Transform: metadata.HostSnapTransform{Type: metadata.HostSnapTransformRegex, Pattern: `^(\d+\.\d+)`}},
+			want: "2.6",
+		},
+		{
+			name: "regex not match",
+			mapping: metadata.HostSnapMapping{Path: "data.system.info.kernelVersion",
+				Transform: metadata.HostSnapTransform{Type: metadata.HostSnapTransformRegex, Pattern: `^windows`}},
+			wantErr: true,
+		},
+		{
+			name:    "path not found",
+			mapping: metadata.HostSnapMapping{Path: "data.system.info.notexist"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyMapping(&val, tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ApplyMapping() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTransform(t *testing.T) {
+	invalid := []metadata.HostSnapTransform{
+		{Type: "upper"},
+		{Type: metadata.HostSnapTransformUnit, FromUnit: "B", ToUnit: "h"},
+		{Type: metadata.HostSnapTransformUnit, FromUnit: "PB", ToUnit: "GB"},
+		{Type: metadata.HostSnapTransformRegex, Pattern: "("},
+	}
+	for _, transform := range invalid {
+		if err := transform.Validate(); err == nil {
+			t.Errorf("transform %+v should be invalid", transform)
+		}
+	}
+}
+
+func TestValidateMappingType(t *testing.T) {
+	tests := []struct {
+		name         string
+		mapping      metadata.HostSnapMapping
+		propertyType string
+		wantErr      bool
+	}{
+		{
+			name:         "plain to string",
+			mapping:      metadata.HostSnapMapping{Path: "data.system.info.kernelVersion", PropertyID: "kernel"},
+			propertyType: common.FieldTypeSingleChar,
+		},
+		{
+			name: "unit to int",
+			mapping: metadata.HostSnapMapping{Path: "data.mem.meminfo.total", PropertyID: "mem",
+				Transform: metadata.HostSnapTransform{Type: metadata.HostSnapTransformUnit, FromUnit: "B", ToUnit: "MB"}},
+			propertyType: common.FieldTypeInt,
+		},
+		{
+			name: "unit to string",
+			mapping: metadata.HostSnapMapping{Path: "data.mem.meminfo.total", PropertyID: "mem",
+				Transform: metadata.HostSnapTransform{Type: metadata.HostSnapTransformUnit, FromUnit: "B", ToUnit: "MB"}},
+			propertyType: common.FieldTypeLongChar,
+			wantErr:      true,
+		},
+		{
+			name: "join to float",
+			mapping: metadata.HostSnapMapping{Path: "data.net.interface.#.name", PropertyID: "nics",
+				Transform: metadata.HostSnapTransform{Type: metadata.HostSnapTransformJoin, Separator: ","}},
+			propertyType: common.FieldTypeFloat,
+			wantErr:      true,
+		},
+		{
+			name:         "plain to enum",
+			mapping:      metadata.HostSnapMapping{Path: "data.system.info.os", PropertyID: "bk_os_type"},
+			propertyType: common.FieldTypeEnum,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mapping.ValidateType(tt.propertyType); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMappingReservedField(t *testing.T) {
+	for _, field := range []string{common.BKHostIDField, common.BKHostInnerIPField, common.BKCloudIDField, common.BKOwnerIDField} {
+		mapping := metadata.HostSnapMapping{Path: "data.system.info.hostname", PropertyID: field}
+		if err := mapping.Validate(); err == nil {
+			t.Errorf("mapping to %s should be invalid", field)
+		}
+	}
+}
+
+func TestApplyMappings(t *testing.T) {
+	val := gjson.Parse(SnapshotData(MockMessage))
+	h := &HostSnap{mappings: map[string][]metadata.HostSnapMapping{
+		common.BKDefaultOwnerID: {
+			{ID: 1, Path: "data.system.info.kernelVersion", PropertyID: "kernel", PropertyType: common.FieldTypeSingleChar},
+			{ID: 2, Path: "data.system.info.uptime", PropertyID: "uptime", PropertyType: common.FieldTypeInt},
+			// saved before the target and type are checked
+			{ID: 3, Path: "data.system.info.hostname", PropertyID: common.BKHostInnerIPField, PropertyType: common.FieldTypeSingleChar},
+			{ID: 4, Path: "data.system.info.kernelVersion", PropertyID: "boot_time", PropertyType: common.FieldTypeInt},
+			{ID: 5, Path: "data.system.info.uptime", PropertyID: "uptime_text"},
+		},
+	}}
+
+	setter := map[string]interface{}{common.BKHostInnerIPField: "127.0.0.1"}
+	h.applyMappings(&val, common.BKDefaultOwnerID, setter)
+
+	want := map[string]interface{}{
+		common.BKHostInnerIPField: "127.0.0.1",
+		"kernel":                  "2.6.32-504.30.3.el6.x86_64",
+		"uptime":                  int64(348315),
+	}
+	if !reflect.DeepEqual(setter, want) {
+		t.Errorf("applyMappings() = %#v, want %#v", setter, want)
+	}
+}
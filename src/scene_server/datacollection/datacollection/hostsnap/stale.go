@@ -0,0 +1,299 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+var (
+	// recordSnapTimeInterval the last snapshot time of one host is saved at most once in the interval
+	recordSnapTimeInterval = time.Minute * 2
+)
+
+// snapTimeRecorder throttles saving the last snapshot time, the agent reports every minute
+type snapTimeRecorder struct {
+	lock sync.Mutex
+	// recorded the time the snapshot time of the host saved last time
+	recorded map[int64]time.Time
+}
+
+func (r *snapTimeRecorder) shouldRecord(hostID int64, now time.Time) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	last, ok := r.recorded[hostID]
+	if ok && now.Sub(last) < recordSnapTimeInterval {
+		return false
+	}
+	r.recorded[hostID] = now
+	return true
+}
+
+// forget drop the recorded time of the hosts
+func (r *snapTimeRecorder) forget(hostIDs []int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, hostID := range hostIDs {
+		delete(r.recorded, hostID)
+	}
+}
+
+// recordSnapTime save the time the host reports the snapshot
+func (h *HostSnap) recordSnapTime(host *HostInst) {
+	hostID, err := util.GetInt64ByInterface(host.get(common.BKHostIDField))
+	if err != nil {
+		blog.Errorf("[datacollect][hostsnap] invalid host id %v, %v", host.get(common.BKHostIDField), err)
+		return
+	}
+	now := metadata.Now()
+	if !h.snapTime.shouldRecord(hostID, now.Time) {
+		return
+	}
+
+	cond := map[string]interface{}{common.BKHostIDField: hostID}
+	cnt, err := h.db.Table(common.BKTableNameHostSnapStatus).Find(cond).Count(h.ctx)
+	if err != nil {
+		blog.Errorf("[datacollect][hostsnap] get snap status of host %d failed, %v", hostID, err)
+		return
+	}
+	if cnt > 0 {
+		data := map[string]interface{}{"last_snap_time": now}
+		if err := h.db.Table(common.BKTableNameHostSnapStatus).Update(h.ctx, cond, data); err != nil {
+			blog.Errorf("[datacollect][hostsnap] update snap time of host %d failed, %v", hostID, err)
+		}
+		return
+	}
+
+	status := metadata.HostSnapStatus{
+		HostID:       hostID,
+		OwnerID:      util.GetStrByInterface(host.get(common.BKOwnerIDField)),
+		LastSnapTime: now,
+		AgentStatus:  common.HostAgentStatusNormal,
+	}
+	if err := h.db.Table(common.BKTableNameHostSnapStatus).Insert(h.ctx, status); err != nil && !h.db.IsDuplicatedError(err) {
+		blog.Errorf("[datacollect][hostsnap] save snap status of host %d failed, %v", hostID, err)
+	}
+}
+
+// staleThreshold the silent duration after which the host is stale,
+// the host belongs to several businesses takes the longest one
+func staleThreshold(bizIDs []int64, bizMinutes map[int64]int64, defaultMinutes int64) time.Duration {
+	var minutes int64
+	for _, bizID := range bizIDs {
+		m := bizMinutes[bizID]
+		if m <= 0 {
+			m = defaultMinutes
+		}
+		if m > minutes {
+			minutes = m
+		}
+	}
+	if minutes <= 0 {
+		minutes = defaultMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// CheckStale mark the hosts which stop reporting snapshot longer than the threshold of the business as stale,
+// and recover the stale ones reporting again
+func (h *HostSnap) CheckStale(defaultMinutes int64) error {
+	bizMinutes, err := h.getBizStaleMinutes()
+	if err != nil {
+		return err
+	}
+	minMinutes := defaultMinutes
+	for _, m := range bizMinutes {
+		if m > 0 && m < minMinutes {
+			minMinutes = m
+		}
+	}
+
+	now := time.Now().UTC()
+	cond := map[string]interface{}{
+		"last_snap_time":          map[string]interface{}{common.BKDBLT: now.Add(-time.Duration(minMinutes) * time.Minute)},
+		common.BKAgentStatusField: map[string]interface{}{common.BKDBNE: common.HostAgentStatusStale},
+	}
+	candidates := make([]metadata.HostSnapStatus, 0)
+	if err := h.db.Table(common.BKTableNameHostSnapStatus).Find(cond).All(h.ctx, &candidates); err != nil {
+		return err
+	}
+
+	candidates, err = h.cleanDeletedHosts(candidates)
+	if err != nil {
+		return err
+	}
+	if len(candidates) > 0 {
+		hostBiz, err := h.getHostBiz(candidates)
+		if err != nil {
+			return err
+		}
+		stales := make([]metadata.HostSnapStatus, 0)
+		for _, status := range candidates {
+			if now.Sub(status.LastSnapTime.Time) >= staleThreshold(hostBiz[status.HostID], bizMinutes, defaultMinutes) {
+				stales = append(stales, status)
+			}
+		}
+		h.setAgentStatus(stales, common.HostAgentStatusStale)
+	}
+
+	cond = map[string]interface{}{common.BKAgentStatusField: common.HostAgentStatusStale}
+	stales := make([]metadata.HostSnapStatus, 0)
+	if err := h.db.Table(common.BKTableNameHostSnapStatus).Find(cond).All(h.ctx, &stales); err != nil {
+		return err
+	}
+	stales, err = h.cleanDeletedHosts(stales)
+	if err != nil {
+		return err
+	}
+	recovers := make([]metadata.HostSnapStatus, 0)
+	for _, status := range stales {
+		if status.StaleTime == nil || status.LastSnapTime.After(status.StaleTime.Time) {
+			recovers = append(recovers, status)
+		}
+	}
+	h.setAgentStatus(recovers, common.HostAgentStatusNormal)
+	return nil
+}
+
+// cleanDeletedHosts remove the snapshot status of the deleted hosts, the rest are returned
+func (h *HostSnap) cleanDeletedHosts(statuses []metadata.HostSnapStatus) ([]metadata.HostSnapStatus, error) {
+	if len(statuses) == 0 {
+		return statuses, nil
+	}
+	hostIDs := make([]int64, 0, len(statuses))
+	for _, status := range statuses {
+		hostIDs = append(hostIDs, status.HostID)
+	}
+	cond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+	hosts := make([]map[string]interface{}, 0)
+	if err := h.db.Table(common.BKTableNameBaseHost).Find(cond).Fields(common.BKHostIDField).All(h.ctx, &hosts); err != nil {
+		return nil, err
+	}
+	exists := make(map[int64]bool, len(hosts))
+	for _, host := range hosts {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			continue
+		}
+		exists[hostID] = true
+	}
+
+	remains := make([]metadata.HostSnapStatus, 0, len(statuses))
+	deleted := make([]int64, 0)
+	for _, status := range statuses {
+		if exists[status.HostID] {
+			remains = append(remains, status)
+			continue
+		}
+		deleted = append(deleted, status.HostID)
+	}
+	if len(deleted) == 0 {
+		return remains, nil
+	}
+	cond = map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: deleted}}
+	if err := h.db.Table(common.BKTableNameHostSnapStatus).Delete(h.ctx, cond); err != nil {
+		return nil, err
+	}
+	h.snapTime.forget(deleted)
+	blog.Infof("[datacollect][hostsnap] removed the snap status of the deleted hosts %v", deleted)
+	return remains, nil
+}
+
+// getBizStaleMinutes the stale minutes of each business, 0 means the default one
+func (h *HostSnap) getBizStaleMinutes() (map[int64]int64, error) {
+	bizs := make([]map[string]interface{}, 0)
+	fields := []string{common.BKAppIDField, common.BKAgentStaleMinutesField}
+	if err := h.db.Table(common.BKTableNameBaseApp).Find(nil).Fields(fields...).All(h.ctx, &bizs); err != nil {
+		return nil, err
+	}
+	bizMinutes := make(map[int64]int64, len(bizs))
+	for _, biz := range bizs {
+		bizID, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if err != nil {
+			continue
+		}
+		minutes, _ := util.GetInt64ByInterface(biz[common.BKAgentStaleMinutesField])
+		bizMinutes[bizID] = minutes
+	}
+	return bizMinutes, nil
+}
+
+// getHostBiz the businesses of the hosts
+func (h *HostSnap) getHostBiz(statuses []metadata.HostSnapStatus) (map[int64][]int64, error) {
+	hostIDs := make([]int64, 0, len(statuses))
+	for _, status := range statuses {
+		hostIDs = append(hostIDs, status.HostID)
+	}
+	cond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+	relations := make([]metadata.ModuleHost, 0)
+	if err := h.db.Table(common.BKTableNameModuleHostConfig).Find(cond).All(h.ctx, &relations); err != nil {
+		return nil, err
+	}
+	hostBiz := make(map[int64][]int64, len(statuses))
+	for _, relation := range relations {
+		hostBiz[relation.HostID] = append(hostBiz[relation.HostID], relation.AppID)
+	}
+	return hostBiz, nil
+}
+
+// setAgentStatus save the agent status, and update the host attribute by core service,
+// so that the host update event is sent to the subscribers
+func (h *HostSnap) setAgentStatus(statuses []metadata.HostSnapStatus, agentStatus string) {
+	if len(statuses) == 0 {
+		return
+	}
+	now := metadata.Now()
+	ownerHosts := make(map[string][]int64)
+	for _, status := range statuses {
+		ownerHosts[status.OwnerID] = append(ownerHosts[status.OwnerID], status.HostID)
+	}
+
+	for ownerID, hostIDs := range ownerHosts {
+		data := map[string]interface{}{common.BKAgentStatusField: agentStatus}
+		if agentStatus == common.HostAgentStatusStale {
+			data["stale_time"] = now
+		}
+		pheader := http.Header{}
+		pheader.Add(common.BKHTTPOwnerID, ownerID)
+		pheader.Add(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
+		option := &metadata.UpdateOption{
+			Data:      mapstr.MapStr{common.BKAgentStatusField: agentStatus},
+			Condition: mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}},
+		}
+		resp, err := h.CoreAPI.CoreService().Instance().UpdateInstance(h.ctx, pheader, common.BKInnerObjIDHost, option)
+		if err != nil {
+			blog.Errorf("[datacollect][hostsnap] set agent status of hosts %v to %s failed, %v", hostIDs, agentStatus, err)
+			continue
+		}
+		if !resp.Result {
+			blog.Errorf("[datacollect][hostsnap] set agent status of hosts %v to %s failed, %s", hostIDs, agentStatus, resp.ErrMsg)
+			continue
+		}
+
+		// the status is saved after the host is updated, so that the failed ones are tried again next time
+		cond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+		if err := h.db.Table(common.BKTableNameHostSnapStatus).Update(h.ctx, cond, data); err != nil {
+			blog.Errorf("[datacollect][hostsnap] set snap status of hosts %v to %s failed, %v", hostIDs, agentStatus, err)
+			continue
+		}
+		blog.Infof("[datacollect][hostsnap] set agent status of hosts %v to %s", hostIDs, agentStatus)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleThreshold(t *testing.T) {
+	bizMinutes := map[int64]int64{2: 10, 3: 0, 4: 60}
+	tests := []struct {
+		bizIDs []int64
+		want   time.Duration
+	}{
+		{bizIDs: []int64{2}, want: 10 * time.Minute},
+		{bizIDs: []int64{3}, want: 30 * time.Minute},
+		{bizIDs: []int64{2, 4}, want: 60 * time.Minute},
+		{bizIDs: []int64{2, 3}, want: 30 * time.Minute},
+		{bizIDs: nil, want: 30 * time.Minute},
+		{bizIDs: []int64{5}, want: 30 * time.Minute},
+	}
+	for _, test := range tests {
+		if got := staleThreshold(test.bizIDs, bizMinutes, 30); got != test.want {
+			t.Errorf("staleThreshold(%v) = %v, want %v", test.bizIDs, got, test.want)
+		}
+	}
+}
+
+func TestSnapTimeRecorder(t *testing.T) {
+	r := snapTimeRecorder{recorded: map[int64]time.Time{}}
+	now := time.Now()
+	if !r.shouldRecord(1, now) {
+		t.Errorf("the first snapshot should be recorded")
+	}
+	if r.shouldRecord(1, now.Add(time.Minute)) {
+		t.Errorf("the snapshot in the interval should not be recorded")
+	}
+	if !r.shouldRecord(2, now.Add(time.Minute)) {
+		t.Errorf("the snapshot of another host should be recorded")
+	}
+	if !r.shouldRecord(1, now.Add(recordSnapTimeInterval)) {
+		t.Errorf("the snapshot after the interval should be recorded")
+	}
+	r.forget([]int64{1})
+	if !r.shouldRecord(1, now.Add(recordSnapTimeInterval)) {
+		t.Errorf("the snapshot of the forgotten host should be recorded")
+	}
+}
@@ -16,9 +16,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 
 	bkc "configcenter/src/common"
 	"configcenter/src/common/backbone"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
 
 	"gopkg.in/redis.v5"
 )
@@ -28,20 +31,43 @@ type Discover struct {
 	pheader http.Header
 
 	redisCli *redis.Client
+	db       dal.RDB
 	*backbone.Engine
+
+	// policies the schema policies of the discovered models, the key is built by policyKey
+	policies   map[string]metadata.DiscoverSchemaPolicy
+	policyLock sync.RWMutex
+	recorder   *schemaRecorder
 }
 
 var msgHandlerCnt = int64(0)
 
-func NewDiscover(ctx context.Context, redisCli *redis.Client, backbone *backbone.Engine) *Discover {
+func NewDiscover(ctx context.Context, redisCli *redis.Client, db dal.RDB, backbone *backbone.Engine) *Discover {
+	discover := newDiscover(ctx, redisCli, db, backbone)
+	go discover.fetchPolicyLoop()
+	return discover
+}
+
+// NewReplayDiscover returns the analyzer to replay the recorded messages, the schema policies
+// are loaded at once instead of refreshed in background
+func NewReplayDiscover(ctx context.Context, redisCli *redis.Client, db dal.RDB, backbone *backbone.Engine) *Discover {
+	discover := newDiscover(ctx, redisCli, db, backbone)
+	discover.fetchPolicy()
+	return discover
+}
+
+func newDiscover(ctx context.Context, redisCli *redis.Client, db dal.RDB, backbone *backbone.Engine) *Discover {
 	pheader := http.Header{}
 	pheader.Add(bkc.BKHTTPOwnerID, bkc.BKDefaultOwnerID)
 	pheader.Add(bkc.BKHTTPHeaderUser, bkc.CCSystemCollectorUserName)
 
 	discover := &Discover{
 		redisCli: redisCli,
+		db:       db,
 		ctx:      ctx,
 		pheader:  pheader,
+		policies: map[string]metadata.DiscoverSchemaPolicy{},
+		recorder: newSchemaRecorder(),
 	}
 	discover.Engine = backbone
 	return discover
@@ -53,12 +79,12 @@ func (d *Discover) Analyze(msg string) error {
 		return fmt.Errorf("create model err: %v, raw: %s", err, msg)
 	}
 
-	err = d.UpdateOrAppendAttrs(msg)
+	drifts, err := d.UpdateOrAppendAttrs(msg)
 	if err != nil {
 		return fmt.Errorf("create property err: %v, raw: %s", err, msg)
 	}
 
-	err = d.UpdateOrCreateInst(msg)
+	err = d.UpdateOrCreateInst(msg, drifts)
 	if err != nil {
 		return fmt.Errorf("create inst err: %v, raw: %s", err, msg)
 	}
@@ -225,25 +225,29 @@ func (d *Discover) parseOwnerId(msg string) string {
 	return ownerId
 }
 
+// GetAttrs returns the attributes of the model, the cached ones are used when they contain all the expected fields
 func (d *Discover) GetAttrs(ownerID, objID, modelAttrKey string, attrs map[string]metadata.ObjAttDes) ([]metadata.Attribute, error) {
 
 	cachedAttrs, err := d.GetModelAttrsFromRedis(modelAttrKey)
 
-	if err == nil && len(cachedAttrs) == len(attrs) {
+	if err == nil {
 		blog.Infof("attr exist in redis: %s", modelAttrKey)
 
-		var attrMap = make([]metadata.Attribute, len(cachedAttrs))
 		totalEqual := true
-		for i, cachedAttr := range cachedAttrs {
-			attrMap[i] = metadata.Attribute{PropertyID: cachedAttr}
-			if _, ok := attrs[cachedAttr]; !ok {
+		for propertyID := range attrs {
+			if _, ok := cachedAttrs[propertyID]; !ok {
 				totalEqual = false
+				break
 			}
 		}
 
 		if totalEqual {
 			blog.Infof("attr exist in redis, and equal: %s", modelAttrKey)
-			return attrMap, nil
+			var attrList = make([]metadata.Attribute, 0, len(cachedAttrs))
+			for propertyID, propertyType := range cachedAttrs {
+				attrList = append(attrList, metadata.Attribute{PropertyID: propertyID, PropertyType: propertyType})
+			}
+			return attrList, nil
 		}
 		blog.Infof("attr exist in redis, but not equal: %s", modelAttrKey)
 	}
@@ -260,13 +264,48 @@ func (d *Discover) GetAttrs(ownerID, objID, modelAttrKey string, attrs map[strin
 	}
 	if !resp.Result {
 		blog.Errorf("SelectObjectAttWithParams error %s", resp.ErrMsg)
-		return nil, err
+		return nil, fmt.Errorf("search model attr failed: %s", resp.ErrMsg)
 	}
 
+	d.cacheAttrs(modelAttrKey, resp.Data.Info)
 	return resp.Data.Info, nil
 }
 
-func (d *Discover) UpdateOrAppendAttrs(msg string) error {
+// cacheAttrs cache the type of the model attributes
+func (d *Discover) cacheAttrs(modelAttrKey string, attrs []metadata.Attribute) {
+	cacheData := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		cacheData[attr.PropertyID] = attr.PropertyType
+	}
+	attrJs, err := json.Marshal(cacheData)
+	if err != nil {
+		blog.Warnf("%s: flush to redis marshal failed: %s", modelAttrKey, err)
+		return
+	}
+	d.TrySetRedis(modelAttrKey, attrJs, cacheTime)
+}
+
+func (d *Discover) createAttr(ownerID, objID string, property metadata.ObjAttDes) error {
+	property.ObjectID = objID
+	property.OwnerID = ownerID
+	property.PropertyGroup = bkc.BKDefaultField
+	property.Creator = bkc.CCSystemCollectorUserName
+
+	resp, err := d.CoreAPI.TopoServer().Object().CreateObjectAtt(d.ctx, d.pheader, &property)
+	if err != nil {
+		blog.Errorf("create model attr failed %s", err.Error())
+		return fmt.Errorf("create model attr failed: %s", err.Error())
+	}
+	if !resp.Result {
+		blog.Errorf("create model attr failed %s", resp.ErrMsg)
+		return fmt.Errorf("create model attr failed: %s", resp.ErrMsg)
+	}
+	return nil
+}
+
+// UpdateOrAppendAttrs create the attributes of the reported fields according to the schema policy of the model,
+// and returns the schema drifts found, keyed by the reported field
+func (d *Discover) UpdateOrAppendAttrs(msg string) (map[string]metadata.DiscoverSchemaDrift, error) {
 
 	ownerID := d.parseOwnerId(msg)
 
@@ -274,35 +313,72 @@ func (d *Discover) UpdateOrAppendAttrs(msg string) error {
 
 	model, err := d.parseModel(msg)
 	if err != nil {
-		return fmt.Errorf("parse model error: %s", err)
+		return nil, fmt.Errorf("parse model error: %s", err)
 	}
 
 	attrs, err := d.parseAttrs(msg)
 	if err != nil {
 		blog.Errorf("create model attr unmarshal error: %s", err)
-		return err
+		return nil, err
 	}
 
+	policy := d.getPolicy(ownerID, objID)
+
 	modelAttrKey := d.CreateModelAttrKey(*model, ownerID)
 
-	existAttrs, err := d.GetAttrs(ownerID, objID, modelAttrKey, attrs)
+	// the unknown fields are rejected, so the cached attributes are enough
+	expectAttrs := attrs
+	if !policy.AllowUnknownField {
+		expectAttrs = nil
+	}
+	existAttrs, err := d.GetAttrs(ownerID, objID, modelAttrKey, expectAttrs)
 	if nil != err {
-		return fmt.Errorf("get attr error: %s", err)
+		return nil, fmt.Errorf("get attr error: %s", err)
 	}
 
-	existAttrHash := make(map[string]bool, len(existAttrs))
+	existAttrHash := make(map[string]metadata.Attribute, len(existAttrs))
 	for _, existAttr := range existAttrs {
-		existAttrHash[existAttr.PropertyID] = true
+		existAttrHash[existAttr.PropertyID] = existAttr
 	}
 
-	finalAttrs := make([]string, 0)
+	drifts := make(map[string]metadata.DiscoverSchemaDrift)
 
 	hasDiff := false
 	for propertyId, property := range attrs {
 
-		finalAttrs = append(finalAttrs, propertyId)
+		drift := metadata.DiscoverSchemaDrift{
+			ObjectID:     objID,
+			PropertyID:   propertyId,
+			ReportedType: property.PropertyType,
+		}
+
+		if existAttr, ok := existAttrHash[propertyId]; ok {
+			if property.PropertyType == "" || existAttr.PropertyType == "" || property.PropertyType == existAttr.PropertyType {
+				continue
+			}
 
-		if existAttrHash[propertyId] {
+			drift.Kind = metadata.DiscoverDriftTypeConflict
+			drift.PropertyType = existAttr.PropertyType
+			switch policy.TypeConflict {
+			case metadata.DiscoverTypeConflictReject:
+				drift.Action = metadata.DiscoverDriftActionRejected
+			case metadata.DiscoverTypeConflictAdd:
+				drift.Action = metadata.DiscoverDriftActionAdded
+				drift.NewPropertyID = conflictPropertyID(propertyId, property.PropertyType)
+				if _, ok := existAttrHash[drift.NewPropertyID]; !ok {
+					property.PropertyID = drift.NewPropertyID
+					property.PropertyName = fmt.Sprintf("%s(%s)", property.PropertyName, property.PropertyType)
+					if err := d.createAttr(ownerID, objID, property); err != nil {
+						return nil, err
+					}
+					existAttrHash[drift.NewPropertyID] = metadata.Attribute{PropertyID: drift.NewPropertyID, PropertyType: property.PropertyType}
+					hasDiff = true
+				}
+			default:
+				drift.Action = metadata.DiscoverDriftActionCoerced
+			}
+			blog.Infof("attr %s type %s conflicts with %s, %s", propertyId, property.PropertyType, existAttr.PropertyType, drift.Action)
+			drifts[propertyId] = drift
 			continue
 		}
 
@@ -311,38 +387,39 @@ func (d *Discover) UpdateOrAppendAttrs(msg string) error {
 			continue
 		}
 
+		drift.Kind = metadata.DiscoverDriftUnknownField
+		if !policy.AllowUnknownField && !isProtectedField(propertyId) {
+			blog.Infof("reject unknown attr: %s -> %v", propertyId, property)
+			drift.Action = metadata.DiscoverDriftActionRejected
+			drifts[propertyId] = drift
+			continue
+		}
+
 		blog.Infof("attr: %s -> %v", propertyId, property)
 
-		property.ObjectID = objID
-		property.OwnerID = ownerID
 		property.PropertyID = propertyId
-		property.PropertyGroup = bkc.BKDefaultField
-		property.Creator = bkc.CCSystemCollectorUserName
-
-		resp, err := d.CoreAPI.TopoServer().Object().CreateObjectAtt(d.ctx, d.pheader, &property)
-		if err != nil {
-			blog.Errorf("create model attr failed %s", err.Error())
-			return fmt.Errorf("create model attr failed: %s", err.Error())
-		}
-		if !resp.Result {
-			blog.Errorf("create model attr failed %s", resp.ErrMsg)
-			return fmt.Errorf("create model attr failed: %s", resp.ErrMsg)
+		if err := d.createAttr(ownerID, objID, property); err != nil {
+			return nil, err
 		}
+		existAttrHash[propertyId] = metadata.Attribute{PropertyID: propertyId, PropertyType: property.PropertyType}
+
+		drift.Action = metadata.DiscoverDriftActionCreated
+		drift.PropertyType = property.PropertyType
+		drifts[propertyId] = drift
 
 		hasDiff = true
 
 	}
 
 	if hasDiff {
-		attrJs, err := json.Marshal(finalAttrs)
-		if err != nil {
-			blog.Warnf("%s: flush to redis marshal failed: %s", modelAttrKey, err)
-			return nil
+		finalAttrs := make([]metadata.Attribute, 0, len(existAttrHash))
+		for _, attr := range existAttrHash {
+			finalAttrs = append(finalAttrs, attr)
 		}
-		d.TrySetRedis(modelAttrKey, attrJs, cacheTime)
+		d.cacheAttrs(modelAttrKey, finalAttrs)
 	}
 
-	return nil
+	return drifts, nil
 }
 
 func (d *Discover) GetModelFromRedis(modelKey string) (MapData, error) {
@@ -364,9 +441,10 @@ func (d *Discover) GetModelFromRedis(modelKey string) (MapData, error) {
 
 }
 
-func (d *Discover) GetModelAttrsFromRedis(modelAttrKey string) ([]string, error) {
+// GetModelAttrsFromRedis returns the cached attribute types of the model, keyed by the property id
+func (d *Discover) GetModelAttrsFromRedis(modelAttrKey string) (map[string]string, error) {
 
-	var cacheData = make([]string, 0)
+	var cacheData = make(map[string]string)
 
 	val, err := d.redisCli.Get(modelAttrKey).Result()
 	if err != nil {
@@ -555,7 +633,8 @@ func (d *Discover) GetInst(ownerID, objID string, keys []string, instKey string)
 
 }
 
-func (d *Discover) UpdateOrCreateInst(msg string) error {
+// UpdateOrCreateInst save the reported data to the instance after it's changed to fit the model by the schema drifts
+func (d *Discover) UpdateOrCreateInst(msg string, drifts map[string]metadata.DiscoverSchemaDrift) error {
 
 	ownerID := d.parseOwnerId(msg)
 
@@ -571,6 +650,9 @@ func (d *Discover) UpdateOrCreateInst(msg string) error {
 		return fmt.Errorf("parse data error: %s", err)
 	}
 
+	d.recordDrifts(ownerID, applySchemaDrifts(bodyData, drifts))
+	d.recordFieldReport(ownerID, objID, bodyData.Keys())
+
 	instKey := bodyData[bkc.BKInstKeyField]
 	instKeyStr, ok := instKey.(string)
 	if !ok || instKeyStr == "" {
@@ -586,7 +668,7 @@ func (d *Discover) UpdateOrCreateInst(msg string) error {
 	blog.Infof("get inst result: %v", inst)
 
 	if len(inst) <= 0 {
-		data, err := mapstr.NewFromInterface(gjson.Get(msg, "data.data").Value())
+		data := mapstr.MapStr(bodyData)
 		resp, err := d.CoreAPI.CoreService().Instance().CreateInstance(d.ctx, d.pheader, objID, &metadata.CreateModelInstance{Data: data})
 		if err != nil {
 			blog.Errorf("search model failed %s", err.Error())
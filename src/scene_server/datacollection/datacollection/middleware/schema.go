@@ -0,0 +1,362 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bkc "configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+var (
+	fetchPolicyInterval = time.Minute
+	// recordFieldInterval the report time of one field is saved at most once in the interval
+	recordFieldInterval = time.Hour
+	// recordDriftInterval the same drift is saved at most once in the interval, the times found are accumulated
+	recordDriftInterval = time.Minute * 10
+)
+
+func policyKey(ownerID, objID string) string {
+	return ownerID + ":" + objID
+}
+
+func (d *Discover) fetchPolicyLoop() {
+	for {
+		d.fetchPolicy()
+		time.Sleep(fetchPolicyInterval)
+	}
+}
+
+func (d *Discover) fetchPolicy() {
+	policies := make([]metadata.DiscoverSchemaPolicy, 0)
+	if err := d.db.Table(bkc.BKTableNameDiscoverSchemaPolicy).Find(nil).All(d.ctx, &policies); err != nil {
+		blog.Errorf("[datacollect][middleware] fetch schema policies failed, %v", err)
+		return
+	}
+	policyMap := make(map[string]metadata.DiscoverSchemaPolicy, len(policies))
+	for _, policy := range policies {
+		policyMap[policyKey(policy.OwnerID, policy.ObjectID)] = policy
+	}
+	d.policyLock.Lock()
+	d.policies = policyMap
+	d.policyLock.Unlock()
+	blog.V(4).Infof("[datacollect][middleware] success fetch %d schema policies", len(policies))
+}
+
+// getPolicy returns the schema policy of the model, or the default one when it's not configured
+func (d *Discover) getPolicy(ownerID, objID string) metadata.DiscoverSchemaPolicy {
+	d.policyLock.RLock()
+	defer d.policyLock.RUnlock()
+	if policy, ok := d.policies[policyKey(ownerID, objID)]; ok {
+		return policy
+	}
+	return metadata.DefaultDiscoverSchemaPolicy(objID)
+}
+
+// isProtectedField the fields used to identify the instance are never rejected
+func isProtectedField(propertyID string) bool {
+	return propertyID == bkc.BKInstNameField || propertyID == bkc.BKInstKeyField
+}
+
+// conflictPropertyID the attribute to save the field of the reported type when the type conflicts
+func conflictPropertyID(propertyID, reportedType string) string {
+	return propertyID + "_" + reportedType
+}
+
+// applySchemaDrifts change the reported data to fit the model according to the actions taken for the drifts,
+// the fields which can't be coerced to the attribute type are rejected. It returns the drifts with the sample value
+func applySchemaDrifts(data map[string]interface{}, drifts map[string]metadata.DiscoverSchemaDrift) []metadata.DiscoverSchemaDrift {
+	result := make([]metadata.DiscoverSchemaDrift, 0, len(drifts))
+	for field, drift := range drifts {
+		val, exists := data[field]
+		if exists {
+			drift.SampleValue = val
+			switch drift.Action {
+			case metadata.DiscoverDriftActionRejected:
+				delete(data, field)
+			case metadata.DiscoverDriftActionAdded:
+				delete(data, field)
+				data[drift.NewPropertyID] = val
+			case metadata.DiscoverDriftActionCoerced:
+				if coerced, ok := coerceValue(val, drift.PropertyType); ok {
+					data[field] = coerced
+				} else {
+					delete(data, field)
+					drift.Action = metadata.DiscoverDriftActionRejected
+				}
+			}
+		}
+		result = append(result, drift)
+	}
+	return result
+}
+
+// coerceValue convert the value to the attribute type, ok is false when it can't be converted
+func coerceValue(val interface{}, propertyType string) (interface{}, bool) {
+	if val == nil {
+		return nil, true
+	}
+	switch propertyType {
+	case bkc.FieldTypeInt:
+		switch v := val.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, false
+			}
+			return int64(v), true
+		case int64, int:
+			return v, true
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return n, true
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f == math.Trunc(f) {
+				return int64(f), true
+			}
+		case bool:
+			if v {
+				return int64(1), true
+			}
+			return int64(0), true
+		}
+	case bkc.FieldTypeFloat:
+		switch v := val.(type) {
+		case float64:
+			return v, true
+		case int64:
+			return float64(v), true
+		case int:
+			return float64(v), true
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, true
+			}
+		}
+	case bkc.FieldTypeBool:
+		switch v := val.(type) {
+		case bool:
+			return v, true
+		case float64:
+			if v == 0 || v == 1 {
+				return v == 1, true
+			}
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, true
+			}
+		}
+	case bkc.FieldTypeSingleChar, bkc.FieldTypeLongChar:
+		switch v := val.(type) {
+		case string:
+			return v, true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case int64:
+			return strconv.FormatInt(v, 10), true
+		case bool:
+			return strconv.FormatBool(v), true
+		case map[string]interface{}, []interface{}:
+			out, err := json.Marshal(v)
+			if err != nil {
+				return nil, false
+			}
+			return string(out), true
+		}
+	default:
+		// the enum, time, user and other types are saved as string
+		if v, ok := val.(string); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// schemaRecorder throttles saving the field report time and the schema drift
+type schemaRecorder struct {
+	lock sync.Mutex
+	// recorded the time the key saved last time
+	recorded map[string]time.Time
+	// hits the times the key found since it's saved last time
+	hits map[string]int64
+}
+
+func newSchemaRecorder() *schemaRecorder {
+	return &schemaRecorder{recorded: map[string]time.Time{}, hits: map[string]int64{}}
+}
+
+// shouldRecord returns whether the key should be saved now, and the times it's found since the last save
+func (r *schemaRecorder) shouldRecord(key string, now time.Time, interval time.Duration) (bool, int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.hits[key]++
+	last, ok := r.recorded[key]
+	if ok && now.Sub(last) < interval {
+		return false, 0
+	}
+	r.recorded[key] = now
+	hits := r.hits[key]
+	delete(r.hits, key)
+	return true, hits
+}
+
+// recordDrifts save the schema drifts, the count of the existing one is accumulated
+func (d *Discover) recordDrifts(ownerID string, drifts []metadata.DiscoverSchemaDrift) {
+	for _, drift := range drifts {
+		now := metadata.Now()
+		key := fmt.Sprintf("drift:%s:%s:%s:%s", ownerID, drift.ObjectID, drift.PropertyID, drift.Kind)
+		ok, hits := d.recorder.shouldRecord(key, now.Time, recordDriftInterval)
+		if !ok {
+			continue
+		}
+		cond := map[string]interface{}{
+			bkc.BKObjIDField:      drift.ObjectID,
+			bkc.BKPropertyIDField: drift.PropertyID,
+			bkc.BKOwnerIDField:    ownerID,
+			"kind":                drift.Kind,
+		}
+		exist := make([]metadata.DiscoverSchemaDrift, 0)
+		if err := d.db.Table(bkc.BKTableNameDiscoverSchemaDrift).Find(cond).Limit(1).All(d.ctx, &exist); err != nil {
+			blog.Errorf("[datacollect][middleware] get schema drift %+v failed, %v", cond, err)
+			continue
+		}
+		if len(exist) > 0 {
+			data := map[string]interface{}{
+				"action":           drift.Action,
+				"reported_type":    drift.ReportedType,
+				"bk_property_type": drift.PropertyType,
+				"new_property_id":  drift.NewPropertyID,
+				"sample_value":     drift.SampleValue,
+				"count":            exist[0].Count + hits,
+				bkc.LastTimeField:  now,
+			}
+			if err := d.db.Table(bkc.BKTableNameDiscoverSchemaDrift).Update(d.ctx, cond, data); err != nil {
+				blog.Errorf("[datacollect][middleware] update schema drift %+v failed, %v", cond, err)
+			}
+			continue
+		}
+		drift.OwnerID = ownerID
+		drift.Count = hits
+		drift.CreateTime = now
+		drift.LastTime = now
+		if err := d.db.Table(bkc.BKTableNameDiscoverSchemaDrift).Insert(d.ctx, drift); err != nil {
+			blog.Errorf("[datacollect][middleware] save schema drift %+v failed, %v", cond, err)
+		}
+	}
+}
+
+// recordFieldReport save the time the fields are reported, the deprecated ones are restored
+func (d *Discover) recordFieldReport(ownerID, objID string, fields []string) {
+	now := metadata.Now()
+	for _, field := range fields {
+		key := fmt.Sprintf("field:%s:%s:%s", ownerID, objID, field)
+		if ok, _ := d.recorder.shouldRecord(key, now.Time, recordFieldInterval); !ok {
+			continue
+		}
+		cond := map[string]interface{}{
+			bkc.BKObjIDField:      objID,
+			bkc.BKPropertyIDField: field,
+			bkc.BKOwnerIDField:    ownerID,
+		}
+		exist := make([]metadata.DiscoverFieldStatus, 0)
+		if err := d.db.Table(bkc.BKTableNameDiscoverFieldStatus).Find(cond).Limit(1).All(d.ctx, &exist); err != nil {
+			blog.Errorf("[datacollect][middleware] get field status %+v failed, %v", cond, err)
+			continue
+		}
+		if len(exist) == 0 {
+			status := metadata.DiscoverFieldStatus{ObjectID: objID, PropertyID: field, OwnerID: ownerID, LastReportTime: now}
+			if err := d.db.Table(bkc.BKTableNameDiscoverFieldStatus).Insert(d.ctx, status); err != nil {
+				blog.Errorf("[datacollect][middleware] save field status %+v failed, %v", cond, err)
+			}
+			continue
+		}
+
+		data := map[string]interface{}{"last_report_time": now, "deprecated": false}
+		if err := d.db.Table(bkc.BKTableNameDiscoverFieldStatus).Update(d.ctx, cond, data); err != nil {
+			blog.Errorf("[datacollect][middleware] update field status %+v failed, %v", cond, err)
+			continue
+		}
+		if exist[0].Deprecated {
+			blog.Infof("[datacollect][middleware] attribute %s of %s is reported again, restore it", field, objID)
+			cond["kind"] = metadata.DiscoverDriftDeprecated
+			if err := d.db.Table(bkc.BKTableNameDiscoverSchemaDrift).Delete(d.ctx, cond); err != nil {
+				blog.Errorf("[datacollect][middleware] delete deprecated drift %+v failed, %v", cond, err)
+			}
+		}
+	}
+}
+
+// CheckDeprecated mark the attributes not reported longer than the deprecate days of the policy as deprecated
+func (d *Discover) CheckDeprecated() error {
+	d.policyLock.RLock()
+	policies := make([]metadata.DiscoverSchemaPolicy, 0, len(d.policies))
+	for _, policy := range d.policies {
+		if policy.DeprecateDays > 0 {
+			policies = append(policies, policy)
+		}
+	}
+	d.policyLock.RUnlock()
+
+	for _, policy := range policies {
+		deadline := time.Now().UTC().Add(-time.Duration(policy.DeprecateDays) * time.Hour * 24)
+		cond := map[string]interface{}{
+			bkc.BKObjIDField:   policy.ObjectID,
+			bkc.BKOwnerIDField: policy.OwnerID,
+			"deprecated":       map[string]interface{}{bkc.BKDBNE: true},
+			"last_report_time": map[string]interface{}{bkc.BKDBLT: deadline},
+		}
+		fields := make([]metadata.DiscoverFieldStatus, 0)
+		if err := d.db.Table(bkc.BKTableNameDiscoverFieldStatus).Find(cond).All(d.ctx, &fields); err != nil {
+			return fmt.Errorf("get the fields of %s not reported since %v failed, %v", policy.ObjectID, deadline, err)
+		}
+		for _, field := range fields {
+			if isProtectedField(field.PropertyID) {
+				continue
+			}
+			fieldCond := map[string]interface{}{
+				bkc.BKObjIDField:      field.ObjectID,
+				bkc.BKPropertyIDField: field.PropertyID,
+				bkc.BKOwnerIDField:    field.OwnerID,
+			}
+			if err := d.db.Table(bkc.BKTableNameDiscoverFieldStatus).Update(d.ctx, fieldCond, map[string]interface{}{"deprecated": true}); err != nil {
+				blog.Errorf("[datacollect][middleware] deprecate attribute %+v failed, %v", fieldCond, err)
+				continue
+			}
+			blog.Infof("[datacollect][middleware] attribute %s of %s is not reported since %v, deprecate it",
+				field.PropertyID, field.ObjectID, field.LastReportTime)
+
+			now := metadata.Now()
+			drift := metadata.DiscoverSchemaDrift{
+				ObjectID:   field.ObjectID,
+				PropertyID: field.PropertyID,
+				Kind:       metadata.DiscoverDriftDeprecated,
+				Action:     metadata.DiscoverDriftActionDeprecated,
+				Count:      1,
+				OwnerID:    field.OwnerID,
+				CreateTime: now,
+				LastTime:   now,
+			}
+			if err := d.db.Table(bkc.BKTableNameDiscoverSchemaDrift).Insert(d.ctx, drift); err != nil {
+				blog.Errorf("[datacollect][middleware] save deprecated drift %+v failed, %v", fieldCond, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	bkc "configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestCoerceValue(t *testing.T) {
+	cases := []struct {
+		val          interface{}
+		propertyType string
+		expected     interface{}
+		ok           bool
+	}{
+		{"8080", bkc.FieldTypeInt, int64(8080), true},
+		{float64(3), bkc.FieldTypeInt, int64(3), true},
+		{float64(3.5), bkc.FieldTypeInt, nil, false},
+		{"abc", bkc.FieldTypeInt, nil, false},
+		{"1.5", bkc.FieldTypeFloat, 1.5, true},
+		{"true", bkc.FieldTypeBool, true, true},
+		{float64(2), bkc.FieldTypeBool, nil, false},
+		{float64(8080), bkc.FieldTypeLongChar, "8080", true},
+		{false, bkc.FieldTypeSingleChar, "false", true},
+		{[]interface{}{"a"}, bkc.FieldTypeLongChar, `["a"]`, true},
+		{float64(1), bkc.FieldTypeEnum, nil, false},
+	}
+	for _, c := range cases {
+		val, ok := coerceValue(c.val, c.propertyType)
+		if ok != c.ok || val != c.expected {
+			t.Errorf("coerce %v to %s, expected %v %v, got %v %v", c.val, c.propertyType, c.expected, c.ok, val, ok)
+		}
+	}
+}
+
+func TestApplySchemaDrifts(t *testing.T) {
+	data := map[string]interface{}{
+		"bk_inst_name": "nginx",
+		"port":         "8080",
+		"version":      float64(1),
+		"workers":      "auto",
+		"extra":        "x",
+		"conf":         float64(2),
+	}
+	drifts := map[string]metadata.DiscoverSchemaDrift{
+		"port":    {PropertyID: "port", Kind: metadata.DiscoverDriftTypeConflict, Action: metadata.DiscoverDriftActionCoerced, PropertyType: bkc.FieldTypeInt},
+		"workers": {PropertyID: "workers", Kind: metadata.DiscoverDriftTypeConflict, Action: metadata.DiscoverDriftActionCoerced, PropertyType: bkc.FieldTypeInt},
+		"extra":   {PropertyID: "extra", Kind: metadata.DiscoverDriftUnknownField, Action: metadata.DiscoverDriftActionRejected},
+		"conf":    {PropertyID: "conf", Kind: metadata.DiscoverDriftTypeConflict, Action: metadata.DiscoverDriftActionAdded, NewPropertyID: "conf_int"},
+		"absent":  {PropertyID: "absent", Kind: metadata.DiscoverDriftUnknownField, Action: metadata.DiscoverDriftActionRejected},
+	}
+
+	result := applySchemaDrifts(data, drifts)
+
+	expected := map[string]interface{}{
+		"bk_inst_name": "nginx",
+		"port":         int64(8080),
+		"version":      float64(1),
+		"conf_int":     float64(2),
+	}
+	if len(data) != len(expected) {
+		t.Fatalf("expected data %v, got %v", expected, data)
+	}
+	for key, val := range expected {
+		if data[key] != val {
+			t.Errorf("expected %s to be %v, got %v", key, val, data[key])
+		}
+	}
+
+	if len(result) != len(drifts) {
+		t.Fatalf("expected %d drifts, got %v", len(drifts), result)
+	}
+	for _, drift := range result {
+		switch drift.PropertyID {
+		case "workers":
+			if drift.Action != metadata.DiscoverDriftActionRejected || drift.SampleValue != "auto" {
+				t.Errorf("expected workers rejected with sample value, got %+v", drift)
+			}
+		case "port":
+			if drift.Action != metadata.DiscoverDriftActionCoerced || drift.SampleValue != "8080" {
+				t.Errorf("expected port coerced with sample value, got %+v", drift)
+			}
+		case "absent":
+			if drift.SampleValue != nil {
+				t.Errorf("expected no sample value of absent field, got %+v", drift)
+			}
+		}
+	}
+}
+
+func TestSchemaRecorder(t *testing.T) {
+	r := newSchemaRecorder()
+	now := time.Now()
+	if ok, hits := r.shouldRecord("k", now, time.Minute); !ok || hits != 1 {
+		t.Errorf("expected first record with 1 hit, got %v %d", ok, hits)
+	}
+	if ok, _ := r.shouldRecord("k", now.Add(time.Second), time.Minute); ok {
+		t.Errorf("expected record throttled")
+	}
+	if ok, hits := r.shouldRecord("k", now.Add(2*time.Minute), time.Minute); !ok || hits != 2 {
+		t.Errorf("expected record with 2 accumulated hits, got %v %d", ok, hits)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// findInst find the instance the report describes, nil if it's not created yet
+func (h *Netcollect) findInst(objID string, cloudID int64, instKey string) (map[string]interface{}, error) {
+	cond := map[string]interface{}{}
+	switch common.GetObjByType(objID) {
+	case common.BKInnerObjIDObject:
+		cond[common.BKObjIDField] = objID
+		cond[common.GetInstNameField(objID)] = instKey
+	case common.BKInnerObjIDHost:
+		cond[common.BKCloudIDField] = cloudID
+		cond[common.BKHostInnerIPField] = instKey
+	default:
+		return nil, nil
+	}
+
+	insts := make([]map[string]interface{}, 0)
+	if err := h.db.Table(common.GetInstTableName(objID)).Find(cond).Limit(1).All(h.ctx, &insts); err != nil {
+		return nil, err
+	}
+	if len(insts) == 0 {
+		return nil, nil
+	}
+	return insts[0], nil
+}
+
+// dropUnchanged remove the attributes equal to the instance and the associations already exist from the report
+func (h *Netcollect) dropUnchanged(report *metadata.NetcollectReport, inst map[string]interface{}) error {
+	attributes := make([]metadata.NetcollectReportAttribute, 0, len(report.Attributes))
+	for _, attr := range report.Attributes {
+		if !valueEqual(inst[attr.PropertyID], attr.CurValue) {
+			attributes = append(attributes, attr)
+		}
+	}
+	report.Attributes = attributes
+
+	if len(report.Associations) == 0 {
+		return nil
+	}
+	instID, err := util.GetInt64ByInterface(inst[common.GetInstIDField(report.ObjectID)])
+	if err != nil {
+		return fmt.Errorf("invalid inst id %v: %v", inst[common.GetInstIDField(report.ObjectID)], err)
+	}
+	associations := make([]metadata.NetcollectReportAssociation, 0, len(report.Associations))
+	for _, asst := range report.Associations {
+		exists, err := h.associationExists(report, instID, asst)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			associations = append(associations, asst)
+		}
+	}
+	report.Associations = associations
+	return nil
+}
+
+func (h *Netcollect) associationExists(report *metadata.NetcollectReport, instID int64, asst metadata.NetcollectReportAssociation) (bool, error) {
+	asstInst, err := h.findInst(asst.AsstObjectID, report.CloudID, asst.AsstInstName)
+	if err != nil {
+		return false, err
+	}
+	if asstInst == nil {
+		return false, nil
+	}
+	asstInstID, err := util.GetInt64ByInterface(asstInst[common.GetInstIDField(asst.AsstObjectID)])
+	if err != nil {
+		return false, fmt.Errorf("invalid inst id %v: %v", asstInst[common.GetInstIDField(asst.AsstObjectID)], err)
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField:      report.ObjectID,
+		common.BKInstIDField:     instID,
+		common.BKAsstObjIDField:  asst.AsstObjectID,
+		common.BKAsstInstIDField: asstInstID,
+	}
+	cnt, err := h.db.Table(common.BKTableNameInstAsst).Find(cond).Count(h.ctx)
+	if err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+// valueEqual compare the reported value with the one saved in db, the numbers are compared by value
+// as the type in db may differ from the one decoded from json
+func valueEqual(pre, cur interface{}) bool {
+	preEmpty, curEmpty := isEmptyValue(pre), isEmptyValue(cur)
+	if preEmpty || curEmpty {
+		return preEmpty == curEmpty
+	}
+	preNum, preOK := toNumber(pre)
+	curNum, curOK := toNumber(cur)
+	if preOK && curOK {
+		return preNum == curNum
+	}
+	return fmt.Sprint(pre) == fmt.Sprint(cur)
+}
+
+func isEmptyValue(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	str, ok := val.(string)
+	return ok && str == ""
+}
+
+// toNumber the numeric value, strings are not taken as numbers
+func toNumber(val interface{}) (float64, bool) {
+	if _, ok := val.(string); ok {
+		return 0, false
+	}
+	num, err := util.GetFloat64ByInterface(val)
+	return num, err == nil
+}
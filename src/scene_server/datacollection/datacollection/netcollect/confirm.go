@@ -0,0 +1,151 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+var (
+	fetchRuleInterval = time.Minute
+)
+
+func ruleKey(ownerID, objID, propertyID string) string {
+	return ownerID + ":" + objID + ":" + propertyID
+}
+
+func (h *Netcollect) fetchRuleLoop() {
+	for {
+		h.fetchRule()
+		time.Sleep(fetchRuleInterval)
+	}
+}
+
+func (h *Netcollect) fetchRule() {
+	rules := make([]metadata.NetcollectConfirmRule, 0)
+	if err := h.db.Table(common.BKTableNameNetcollectRule).Find(nil).All(h.ctx, &rules); err != nil {
+		blog.Errorf("[datacollect][netcollect] fetch confirm rules failed, %v", err)
+		return
+	}
+	h.setRules(rules)
+	blog.V(4).Infof("[datacollect][netcollect] success fetch %d confirm rules", len(rules))
+}
+
+func (h *Netcollect) setRules(rules []metadata.NetcollectConfirmRule) {
+	ruleMap := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		ruleMap[ruleKey(rule.OwnerID, rule.ObjectID, rule.PropertyID)] = rule.AutoConfirm
+	}
+	h.ruleLock.Lock()
+	h.rules = ruleMap
+	h.ruleLock.Unlock()
+}
+
+// shouldAutoConfirm the rule of the property takes precedence over the one of the object,
+// the change needs review when there is no rule
+func (h *Netcollect) shouldAutoConfirm(ownerID, objID, propertyID string) bool {
+	h.ruleLock.RLock()
+	defer h.ruleLock.RUnlock()
+	if autoConfirm, ok := h.rules[ruleKey(ownerID, objID, propertyID)]; ok {
+		return autoConfirm
+	}
+	return h.rules[ruleKey(ownerID, objID, "")]
+}
+
+func reportOwnerID(report *metadata.NetcollectReport) string {
+	if report.OwnerID == "" {
+		return common.BKDefaultOwnerID
+	}
+	return report.OwnerID
+}
+
+// autoConfirm update the instance with the attributes the rules accept, and remove them from the report.
+// the attributes are kept in the report for review when the update fails
+func (h *Netcollect) autoConfirm(report *metadata.NetcollectReport, inst map[string]interface{}) {
+	ownerID := reportOwnerID(report)
+	accepted := make([]metadata.NetcollectReportAttribute, 0)
+	remains := make([]metadata.NetcollectReportAttribute, 0, len(report.Attributes))
+	data := mapstr.MapStr{}
+	for _, attr := range report.Attributes {
+		if h.shouldAutoConfirm(ownerID, report.ObjectID, attr.PropertyID) {
+			accepted = append(accepted, attr)
+			data.Set(attr.PropertyID, attr.CurValue)
+			continue
+		}
+		remains = append(remains, attr)
+	}
+	if len(accepted) == 0 {
+		return
+	}
+
+	instIDField := common.GetInstIDField(report.ObjectID)
+	instID, err := util.GetInt64ByInterface(inst[instIDField])
+	if err != nil {
+		blog.Errorf("[datacollect][netcollect] auto confirm failed, invalid inst id %v, %v", inst[instIDField], err)
+		return
+	}
+
+	pheader := http.Header{}
+	pheader.Add(common.BKHTTPOwnerID, ownerID)
+	pheader.Add(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
+	cond := mapstr.MapStr{instIDField: instID}
+	if common.GetObjByType(report.ObjectID) == common.BKInnerObjIDObject {
+		cond.Set(common.BKObjIDField, report.ObjectID)
+	}
+	option := &metadata.UpdateOption{Data: data, Condition: cond}
+	resp, err := h.CoreAPI.CoreService().Instance().UpdateInstance(h.ctx, pheader, report.ObjectID, option)
+	if err != nil {
+		blog.Errorf("[datacollect][netcollect] auto confirm %s %d failed, %v", report.ObjectID, instID, err)
+		return
+	}
+	if !resp.Result {
+		blog.Errorf("[datacollect][netcollect] auto confirm %s %d failed, %s", report.ObjectID, instID, resp.ErrMsg)
+		return
+	}
+	blog.Infof("[datacollect][netcollect] auto confirm %s %d with %v", report.ObjectID, instID, data)
+	report.Attributes = remains
+
+	curData := mapstr.MapStr{}
+	for key, val := range inst {
+		curData.Set(key, val)
+	}
+	curData.Merge(data)
+	auditLog := metadata.SaveAuditLogParams{
+		ID:      instID,
+		Model:   report.ObjectID,
+		Content: metadata.Content{PreData: inst, CurData: curData},
+		OpDesc:  "netcollect auto confirm",
+		OpType:  auditoplog.AuditOpTypeModify,
+	}
+	auditResp, err := h.CoreAPI.CoreService().Audit().SaveAuditLog(h.ctx, pheader, auditLog)
+	if err != nil || (auditResp != nil && !auditResp.Result) {
+		blog.Errorf("[datacollect][netcollect] save audit log of auto confirm %s %d failed, %v %v", report.ObjectID, instID, auditResp, err)
+	}
+
+	history := metadata.NetcollectHistory{NetcollectReport: *report, Success: true}
+	history.Action = metadata.ReporctActionUpdate
+	history.InstID = instID
+	history.Attributes = accepted
+	history.Associations = []metadata.NetcollectReportAssociation{}
+	if err := h.db.Table(common.BKTableNameNetcollectHistory).Insert(h.ctx, history); err != nil {
+		blog.Errorf("[datacollect][netcollect] save history of auto confirm %s %d failed, %v", report.ObjectID, instID, err)
+	}
+}
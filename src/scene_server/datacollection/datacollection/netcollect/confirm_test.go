@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestValueEqual(t *testing.T) {
+	tests := []struct {
+		pre, cur interface{}
+		want     bool
+	}{
+		{pre: int64(10), cur: json.Number("10"), want: true},
+		{pre: int64(10), cur: float64(10), want: true},
+		{pre: int32(10), cur: float64(11), want: false},
+		{pre: "10", cur: float64(10), want: true},
+		{pre: "010", cur: "10", want: false},
+		{pre: nil, cur: "", want: true},
+		{pre: nil, cur: "switch-1", want: false},
+		{pre: "switch-1", cur: "switch-1", want: true},
+	}
+	for _, test := range tests {
+		if got := valueEqual(test.pre, test.cur); got != test.want {
+			t.Errorf("valueEqual(%#v, %#v) = %v, want %v", test.pre, test.cur, got, test.want)
+		}
+	}
+}
+
+func TestShouldAutoConfirm(t *testing.T) {
+	h := &Netcollect{}
+	h.setRules([]metadata.NetcollectConfirmRule{
+		{ObjectID: "bk_switch", AutoConfirm: true, OwnerID: "0"},
+		{ObjectID: "bk_switch", PropertyID: "bk_host_name", AutoConfirm: false, OwnerID: "0"},
+		{ObjectID: "bk_router", PropertyID: "bk_port_count", AutoConfirm: true, OwnerID: "0"},
+	})
+
+	tests := []struct {
+		ownerID, objID, propertyID string
+		want                       bool
+	}{
+		{ownerID: "0", objID: "bk_switch", propertyID: "bk_in_octets", want: true},
+		{ownerID: "0", objID: "bk_switch", propertyID: "bk_host_name", want: false},
+		{ownerID: "0", objID: "bk_router", propertyID: "bk_port_count", want: true},
+		{ownerID: "0", objID: "bk_router", propertyID: "bk_host_name", want: false},
+		{ownerID: "1", objID: "bk_switch", propertyID: "bk_in_octets", want: false},
+	}
+	for _, test := range tests {
+		if got := h.shouldAutoConfirm(test.ownerID, test.objID, test.propertyID); got != test.want {
+			t.Errorf("shouldAutoConfirm(%s, %s, %s) = %v, want %v", test.ownerID, test.objID, test.propertyID, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// linkKey identify a link by the ports of the two instances
+func linkKey(localPort, asstObjID string, asstInstID int64, remotePort string) string {
+	return fmt.Sprintf("%s|%s|%d|%s", localPort, asstObjID, asstInstID, remotePort)
+}
+
+// resolveNeighbors turn the neighbor table into the association changes of the report,
+// the links not reported any more are removed with the delete action, both need confirm
+func (h *Netcollect) resolveNeighbors(report *metadata.NetcollectReport, inst map[string]interface{}) error {
+	if report.Neighbors == nil {
+		return nil
+	}
+	instIDField := common.GetInstIDField(report.ObjectID)
+	instID, err := util.GetInt64ByInterface(inst[instIDField])
+	if err != nil {
+		return fmt.Errorf("invalid inst id %v: %v", inst[instIDField], err)
+	}
+
+	existLinks := make([]metadata.NetcollectLink, 0)
+	linkCond := map[string]interface{}{common.BKObjIDField: report.ObjectID, common.BKInstIDField: instID}
+	if err := h.db.Table(common.BKTableNameNetcollectLink).Find(linkCond).All(h.ctx, &existLinks); err != nil {
+		return err
+	}
+
+	deviceObjIDs, err := h.getDeviceObjIDs()
+	if err != nil {
+		return err
+	}
+	objAsstIDs := map[string]string{}
+	reported := make([]metadata.NetcollectReportAssociation, 0, len(report.Neighbors))
+	for _, neighbor := range report.Neighbors {
+		asstObjID, asstInst, err := h.findNeighborInst(report.CloudID, deviceObjIDs, neighbor)
+		if err != nil {
+			return err
+		}
+		if asstInst == nil {
+			blog.V(4).Infof("[datacollect][netcollect] neighbor %+v of %s %s not found", neighbor, report.ObjectID, report.InstKey)
+			continue
+		}
+		asstInstID, err := util.GetInt64ByInterface(asstInst[common.GetInstIDField(asstObjID)])
+		if err != nil {
+			return fmt.Errorf("invalid inst id %v: %v", asstInst[common.GetInstIDField(asstObjID)], err)
+		}
+
+		objAsstID, ok := objAsstIDs[asstObjID]
+		if !ok {
+			if objAsstID, err = h.getObjAsstID(report.ObjectID, asstObjID); err != nil {
+				return err
+			}
+			objAsstIDs[asstObjID] = objAsstID
+		}
+		reported = append(reported, metadata.NetcollectReportAssociation{
+			Action:       metadata.ReporctActionCreate,
+			AsstInstName: instKey(asstObjID, asstInst),
+			AsstObjectID: asstObjID,
+			ObjectAsstID: objAsstID,
+			AsstInstID:   asstInstID,
+			LocalPort:    neighbor.LocalPort,
+			RemotePort:   neighbor.RemotePort,
+			Protocol:     neighbor.Protocol,
+		})
+	}
+	report.Associations = append(report.Associations, diffLinks(report.ObjectID, existLinks, reported)...)
+	return nil
+}
+
+// diffLinks the associations to create for the links newly reported, and to delete for the saved links
+// not reported any more. the new link is ignored if there is no association between the two objects.
+func diffLinks(objID string, existLinks []metadata.NetcollectLink, reported []metadata.NetcollectReportAssociation) []metadata.NetcollectReportAssociation {
+	exists := make(map[string]bool, len(existLinks))
+	for _, link := range existLinks {
+		exists[linkKey(link.LocalPort, link.AsstObjectID, link.AsstInstID, link.RemotePort)] = true
+	}
+
+	assts := make([]metadata.NetcollectReportAssociation, 0)
+	reportedKeys := make(map[string]bool, len(reported))
+	for _, asst := range reported {
+		key := linkKey(asst.LocalPort, asst.AsstObjectID, asst.AsstInstID, asst.RemotePort)
+		if reportedKeys[key] {
+			continue
+		}
+		reportedKeys[key] = true
+		if exists[key] {
+			continue
+		}
+		if asst.ObjectAsstID == "" {
+			blog.Warnf("[datacollect][netcollect] no association between %s and %s, ignore link %s", objID, asst.AsstObjectID, key)
+			continue
+		}
+		assts = append(assts, asst)
+	}
+
+	for _, link := range existLinks {
+		if reportedKeys[linkKey(link.LocalPort, link.AsstObjectID, link.AsstInstID, link.RemotePort)] {
+			continue
+		}
+		assts = append(assts, metadata.NetcollectReportAssociation{
+			Action:       metadata.ReporctActionDelete,
+			AsstObjectID: link.AsstObjectID,
+			ObjectAsstID: link.ObjectAsstID,
+			AsstInstID:   link.AsstInstID,
+			LocalPort:    link.LocalPort,
+			RemotePort:   link.RemotePort,
+			Protocol:     link.Protocol,
+		})
+	}
+	return assts
+}
+
+// instKey the key the report uses to identify the instance
+func instKey(objID string, inst map[string]interface{}) string {
+	if objID == common.BKInnerObjIDHost {
+		return util.GetStrByInterface(inst[common.BKHostInnerIPField])
+	}
+	return util.GetStrByInterface(inst[common.GetInstNameField(objID)])
+}
+
+// getDeviceObjIDs the objects of the net devices
+func (h *Netcollect) getDeviceObjIDs() ([]string, error) {
+	devices := make([]metadata.NetcollectDevice, 0)
+	if err := h.db.Table(common.BKTableNameNetcollectDevice).Find(nil).Fields(common.BKObjIDField).All(h.ctx, &devices); err != nil {
+		return nil, err
+	}
+	objIDs := make([]string, 0)
+	for _, device := range devices {
+		if device.ObjectID != "" && !util.InStrArr(objIDs, device.ObjectID) {
+			objIDs = append(objIDs, device.ObjectID)
+		}
+	}
+	return objIDs, nil
+}
+
+// findNeighborInst find the net device by the name or mac, or the host in the same cloud by mac
+func (h *Netcollect) findNeighborInst(cloudID int64, deviceObjIDs []string, neighbor metadata.NetcollectReportNeighbor) (string, map[string]interface{}, error) {
+	macs := macVariants(neighbor.RemoteChassisID)
+	names := make([]string, 0)
+	for _, name := range []string{neighbor.RemoteChassisID, neighbor.RemoteSysName} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	insts := make([]map[string]interface{}, 0)
+	if len(deviceObjIDs) > 0 && (len(names) > 0 || len(macs) > 0) {
+		or := []map[string]interface{}{}
+		if len(names) > 0 {
+			or = append(or, map[string]interface{}{common.BKInstNameField: map[string]interface{}{common.BKDBIN: names}})
+		}
+		if len(macs) > 0 {
+			or = append(or, map[string]interface{}{"bk_mac": map[string]interface{}{common.BKDBIN: macs}})
+		}
+		cond := map[string]interface{}{
+			common.BKObjIDField: map[string]interface{}{common.BKDBIN: deviceObjIDs},
+			common.BKDBOR:       or,
+		}
+		if err := h.db.Table(common.BKTableNameBaseInst).Find(cond).Limit(1).All(h.ctx, &insts); err != nil {
+			return "", nil, err
+		}
+		if len(insts) > 0 {
+			return util.GetStrByInterface(insts[0][common.BKObjIDField]), insts[0], nil
+		}
+	}
+
+	if len(macs) == 0 {
+		return "", nil, nil
+	}
+	cond := map[string]interface{}{
+		common.BKCloudIDField: cloudID,
+		common.BKDBOR: []map[string]interface{}{
+			{"bk_mac": map[string]interface{}{common.BKDBIN: macs}},
+			{"bk_outer_mac": map[string]interface{}{common.BKDBIN: macs}},
+		},
+	}
+	if err := h.db.Table(common.BKTableNameBaseHost).Find(cond).Limit(1).All(h.ctx, &insts); err != nil {
+		return "", nil, err
+	}
+	if len(insts) > 0 {
+		return common.BKInnerObjIDHost, insts[0], nil
+	}
+	return "", nil, nil
+}
+
+// getObjAsstID the association from the object to the neighbor object, the connect kind first
+func (h *Netcollect) getObjAsstID(objID, asstObjID string) (string, error) {
+	assts := make([]metadata.Association, 0)
+	cond := map[string]interface{}{common.BKObjIDField: objID, common.BKAsstObjIDField: asstObjID}
+	if err := h.db.Table(common.BKTableNameObjAsst).Find(cond).All(h.ctx, &assts); err != nil {
+		return "", err
+	}
+	if len(assts) == 0 {
+		return "", nil
+	}
+	for _, asst := range assts {
+		if asst.AsstKindID == common.AssociationTypeConnect {
+			return asst.AssociationName, nil
+		}
+	}
+	return assts[0].AssociationName, nil
+}
+
+// macVariants the common formats of the mac address, empty if it's not a mac address
+func macVariants(mac string) []string {
+	hex := strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.ToLower(mac))
+	if len(hex) != 12 || strings.Trim(hex, "0123456789abcdef") != "" {
+		return nil
+	}
+	octets := make([]string, 0, 6)
+	for i := 0; i < 12; i += 2 {
+		octets = append(octets, hex[i:i+2])
+	}
+	colon, dash := strings.Join(octets, ":"), strings.Join(octets, "-")
+	return []string{colon, strings.ToUpper(colon), dash, strings.ToUpper(dash)}
+}
@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestMacVariants(t *testing.T) {
+	want := []string{"52:54:00:19:2e:e8", "52:54:00:19:2E:E8", "52-54-00-19-2e-e8", "52-54-00-19-2E-E8"}
+	for _, mac := range []string{"52:54:00:19:2e:e8", "52-54-00-19-2E-E8", "5254.0019.2ee8"} {
+		if got := macVariants(mac); !reflect.DeepEqual(got, want) {
+			t.Errorf("macVariants(%s) = %v, want %v", mac, got, want)
+		}
+	}
+	for _, name := range []string{"", "switch-1", "52:54:00:19:2e", "52:54:00:19:2e:zz"} {
+		if got := macVariants(name); got != nil {
+			t.Errorf("macVariants(%s) = %v, want nil", name, got)
+		}
+	}
+}
+
+func TestDiffLinks(t *testing.T) {
+	existLinks := []metadata.NetcollectLink{
+		{AsstObjectID: "bk_switch", AsstInstID: 1, LocalPort: "eth1", RemotePort: "ge-0/0/1", ObjectAsstID: "bk_router_connect_bk_switch"},
+		{AsstObjectID: "bk_switch", AsstInstID: 2, LocalPort: "eth2", RemotePort: "ge-0/0/2", ObjectAsstID: "bk_router_connect_bk_switch", Protocol: "lldp"},
+	}
+	reported := []metadata.NetcollectReportAssociation{
+		// still reported, nothing to do
+		{Action: metadata.ReporctActionCreate, AsstObjectID: "bk_switch", AsstInstID: 1, LocalPort: "eth1", RemotePort: "ge-0/0/1", ObjectAsstID: "bk_router_connect_bk_switch"},
+		// new link
+		{Action: metadata.ReporctActionCreate, AsstObjectID: "bk_switch", AsstInstID: 3, LocalPort: "eth3", RemotePort: "ge-0/0/3", ObjectAsstID: "bk_router_connect_bk_switch"},
+		// the same link reported by another protocol
+		{Action: metadata.ReporctActionCreate, AsstObjectID: "bk_switch", AsstInstID: 3, LocalPort: "eth3", RemotePort: "ge-0/0/3", ObjectAsstID: "bk_router_connect_bk_switch"},
+		// no association between the objects
+		{Action: metadata.ReporctActionCreate, AsstObjectID: "host", AsstInstID: 4, LocalPort: "eth4", RemotePort: "eth0"},
+	}
+
+	want := []metadata.NetcollectReportAssociation{
+		{Action: metadata.ReporctActionCreate, AsstObjectID: "bk_switch", AsstInstID: 3, LocalPort: "eth3", RemotePort: "ge-0/0/3", ObjectAsstID: "bk_router_connect_bk_switch"},
+		{Action: metadata.ReporctActionDelete, AsstObjectID: "bk_switch", AsstInstID: 2, LocalPort: "eth2", RemotePort: "ge-0/0/2", ObjectAsstID: "bk_router_connect_bk_switch", Protocol: "lldp"},
+	}
+	if got := diffLinks("bk_router", existLinks, reported); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffLinks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffLinksNoNeighbor(t *testing.T) {
+	existLinks := []metadata.NetcollectLink{
+		{AsstObjectID: "bk_switch", AsstInstID: 1, LocalPort: "eth1", RemotePort: "ge-0/0/1", ObjectAsstID: "bk_router_connect_bk_switch"},
+	}
+	got := diffLinks("bk_router", existLinks, nil)
+	if len(got) != 1 || got[0].Action != metadata.ReporctActionDelete || got[0].AsstInstID != 1 {
+		t.Errorf("diffLinks() = %+v, want the link deleted", got)
+	}
+	if got := diffLinks("bk_router", nil, nil); len(got) != 0 {
+		t.Errorf("diffLinks() = %+v, want empty", got)
+	}
+}
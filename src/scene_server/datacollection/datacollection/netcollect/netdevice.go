@@ -16,9 +16,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"configcenter/src/common"
+	"configcenter/src/common/backbone"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
 	"configcenter/src/common/metadata"
@@ -29,17 +31,37 @@ import (
 type Netcollect struct {
 	ctx context.Context
 	db  dal.RDB
+	*backbone.Engine
+
+	// rules the auto confirm rules, the key is built by ruleKey
+	rules    map[string]bool
+	ruleLock sync.RWMutex
 }
 
 // NewNetcollect returns a new netcollector
-func NewNetcollect(ctx context.Context, db dal.RDB) *Netcollect {
-	h := &Netcollect{
-		ctx: ctx,
-		db:  db,
-	}
+func NewNetcollect(ctx context.Context, db dal.RDB, engine *backbone.Engine) *Netcollect {
+	h := newNetcollect(ctx, db, engine)
+	go h.fetchRuleLoop()
+	return h
+}
+
+// NewReplayNetcollect returns the netcollector to replay the recorded messages, the confirm
+// rules are loaded at once instead of refreshed in background
+func NewReplayNetcollect(ctx context.Context, db dal.RDB, engine *backbone.Engine) *Netcollect {
+	h := newNetcollect(ctx, db, engine)
+	h.fetchRule()
 	return h
 }
 
+func newNetcollect(ctx context.Context, db dal.RDB, engine *backbone.Engine) *Netcollect {
+	return &Netcollect{
+		ctx:    ctx,
+		db:     db,
+		Engine: engine,
+		rules:  map[string]bool{},
+	}
+}
+
 // Analyze implements the Analyzer interface
 func (h *Netcollect) Analyze(raw string) error {
 	blog.V(4).Infof("[datacollect][netcollect] received message: %s", raw)
@@ -58,7 +80,28 @@ func (h *Netcollect) Analyze(raw string) error {
 }
 
 func (h *Netcollect) handleReport(report *metadata.NetcollectReport) (err error) {
-	// TODO compare 若有变化才插入
+	inst, err := h.findInst(report.ObjectID, report.CloudID, report.InstKey)
+	if err != nil {
+		blog.Errorf("[datacollect][netcollect] find inst of report %s %s error: %v", report.ObjectID, report.InstKey, err)
+		return err
+	}
+	if inst != nil {
+		if err = h.dropUnchanged(report, inst); err != nil {
+			blog.Errorf("[datacollect][netcollect] compare report %s %s error: %v", report.ObjectID, report.InstKey, err)
+			return err
+		}
+		if err = h.resolveNeighbors(report, inst); err != nil {
+			blog.Errorf("[datacollect][netcollect] resolve neighbors of %s %s error: %v", report.ObjectID, report.InstKey, err)
+			return err
+		}
+		h.autoConfirm(report, inst)
+	}
+
+	// nothing to confirm, the previous report is out of date too
+	if len(report.Attributes) == 0 && len(report.Associations) == 0 {
+		return h.deleteReport(report)
+	}
+
 	if err = h.upsertReport(report); err != nil {
 		blog.Errorf("[datacollect][netcollect] upsert association error: %v", err)
 		return err
@@ -85,6 +128,14 @@ func (h *Netcollect) upsertReport(report *metadata.NetcollectReport) error {
 	return h.db.Table(common.BKTableNameNetcollectReport).Update(h.ctx, existCond.ToMapStr(), report)
 }
 
+func (h *Netcollect) deleteReport(report *metadata.NetcollectReport) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKCloudIDField).Eq(report.CloudID)
+	cond.Field(common.BKObjIDField).Eq(report.ObjectID)
+	cond.Field(common.BKInstKeyField).Eq(report.InstKey)
+	return h.db.Table(common.BKTableNameNetcollectReport).Delete(h.ctx, cond.ToMapStr())
+}
+
 // ReportMessage define a netcollect message
 type ReportMessage struct {
 	Timestamp time.Time                   `json:"timestamp"`
@@ -133,7 +184,16 @@ const MockMessage = `{
                     "bk_asst_obj_name": "主机",
                     "bk_asst_property_id": "bk_host_id"
 				}
-			]
+			],
+            "neighbors": [
+                {
+                    "protocol": "lldp",
+                    "local_port": "GigabitEthernet0/0/1",
+                    "remote_chassis_id": "52:54:00:19:2e:e8",
+                    "remote_port": "eth0",
+                    "remote_sys_name": "host-1"
+                }
+            ]
         }
     ]
 }
@@ -0,0 +1,169 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
+	"configcenter/src/scene_server/datacollection/datacollection/middleware"
+	"configcenter/src/scene_server/datacollection/datacollection/netcollect"
+	"configcenter/src/storage/dal"
+	ccredis "configcenter/src/storage/dal/redis"
+)
+
+// maxReplayLineSize the max size of a recorded message, the snapshots of hosts with many
+// processes and connections can be very large
+const maxReplayLineSize = 16 * 1024 * 1024
+
+// ErrUnknownAnalyzer the analyzer to replay messages through is not supported
+var ErrUnknownAnalyzer = errors.New("unknown analyzer")
+
+// Replayer feeds the recorded messages through the analyzers, the messages are read from
+// NDJSON, one message received from redis per line. It's used to reproduce the problems
+// of the analyzers, and to ingest the messages offline.
+type Replayer struct {
+	ctx       context.Context
+	engine    *backbone.Engine
+	db        dal.RDB
+	redisConf ccredis.Config
+
+	// lock makes the replays run one by one, every replay loads the caches of its analyzer
+	lock sync.Mutex
+	// newAnalyzer builds the analyzer of a replay and the function to release it,
+	// the writes are recorded by the recorder in dry run mode
+	newAnalyzer func(name string, dryRun bool, recorder *writeRecorder) (Analyzer, func(), error)
+}
+
+func NewReplayer(ctx context.Context, engine *backbone.Engine, db dal.RDB, redisConf ccredis.Config) *Replayer {
+	r := &Replayer{
+		ctx:       ctx,
+		engine:    engine,
+		db:        db,
+		redisConf: redisConf,
+	}
+	r.newAnalyzer = r.newReplayAnalyzer
+	return r
+}
+
+// Replay analyzes the messages read from reader by the analyzer of the name, in dry run mode
+// the writes each message would cause are returned instead of being applied
+func (r *Replayer) Replay(name string, reader io.Reader, dryRun bool) (*metadata.ReplayResult, error) {
+	if name != PorterHostsnap && name != PorterMiddleware && name != PorterNetcollect {
+		return nil, ErrUnknownAnalyzer
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// a new analyzer and recorder for every replay, so that nothing is carried over from the
+	// previous replays, like the cached hosts and the throttled records
+	recorder := new(writeRecorder)
+	analyzer, release, err := r.newAnalyzer(name, dryRun, recorder)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result := &metadata.ReplayResult{Name: name, DryRun: dryRun, Messages: make([]metadata.ReplayMessageResult, 0)}
+	err = readReplayLines(reader, func(line int, mesg string) {
+		message := metadata.ReplayMessageResult{Line: line}
+		if err := analyzeRecovered(analyzer, mesg); err != nil {
+			blog.Errorf("[datacollect][replay] %s analyze message of line %d failed: %v", name, line, err)
+			message.Error = err.Error()
+			result.Failed++
+		}
+		if dryRun {
+			message.Writes = recorder.take()
+		}
+		result.Total++
+		if dryRun || message.Error != "" {
+			result.Messages = append(result.Messages, message)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blog.Infof("[datacollect][replay] %s replayed %d messages, %d failed, dry run: %v", name, result.Total, result.Failed, dryRun)
+	return result, nil
+}
+
+// newReplayAnalyzer builds the analyzer which loads its caches at once and works without background
+// routines, so that all the writes of a message are done, and recorded, before Analyze returns
+func (r *Replayer) newReplayAnalyzer(name string, dryRun bool, recorder *writeRecorder) (Analyzer, func(), error) {
+	redisCli, err := ccredis.NewFromConfig(r.redisConf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect redis failed, %v", err)
+	}
+	release := func() {
+		if err := redisCli.Close(); err != nil {
+			blog.Warnf("[datacollect][replay] close redis client failed, %v", err)
+		}
+	}
+	engine, db := r.engine, r.db
+	if dryRun {
+		if engine, err = newDryRunEngine(r.engine, recorder); err != nil {
+			release()
+			return nil, nil, fmt.Errorf("build dry run client failed, %v", err)
+		}
+		db = &dryRunDB{RDB: r.db, recorder: recorder}
+		wrapDryRunRedis(redisCli, recorder)
+	}
+
+	switch name {
+	case PorterHostsnap:
+		return hostsnap.NewReplayHostSnap(r.ctx, redisCli, db, engine), release, nil
+	case PorterMiddleware:
+		return middleware.NewReplayDiscover(r.ctx, redisCli, db, engine), release, nil
+	default:
+		return netcollect.NewReplayNetcollect(r.ctx, db, engine), release, nil
+	}
+}
+
+// analyzeRecovered analyzes the message, the panic is returned as error so that the replay goes on
+func analyzeRecovered(analyzer Analyzer, mesg string) (err error) {
+	defer func() {
+		if syserr := recover(); syserr != nil {
+			err = fmt.Errorf("analyze panic: %v", syserr)
+		}
+	}()
+	return analyzer.Analyze(mesg)
+}
+
+// readReplayLines calls handle with every non-blank line and its line number
+func readReplayLines(reader io.Reader, handle func(line int, mesg string)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxReplayLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		mesg := strings.TrimSpace(scanner.Text())
+		if mesg == "" {
+			continue
+		}
+		handle(line, mesg)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read line %d failed, %v", line+1, err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"configcenter/src/common/metadata"
+	ccredis "configcenter/src/storage/dal/redis"
+
+	"gopkg.in/redis.v5"
+)
+
+type fakeAnalyzer struct {
+	recorder *writeRecorder
+}
+
+func (a *fakeAnalyzer) Analyze(mesg string) error {
+	switch mesg {
+	case "fail":
+		return errors.New("analyze failed")
+	case "panic":
+		panic("analyze panic")
+	}
+	a.recorder.record(metadata.ReplayWrite{Kind: writeKindDB, Target: "cc_table", Operation: "insert", Detail: mesg})
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	r := NewReplayer(context.Background(), nil, nil, ccredis.Config{})
+	recorders := make([]*writeRecorder, 0)
+	released := 0
+	r.newAnalyzer = func(name string, dryRun bool, recorder *writeRecorder) (Analyzer, func(), error) {
+		recorders = append(recorders, recorder)
+		return &fakeAnalyzer{recorder: recorder}, func() { released++ }, nil
+	}
+
+	input := "{\"a\":1}\n\nfail\n  panic  \n{\"b\":2}\n"
+	result, err := r.Replay(PorterNetcollect, strings.NewReader(input), true)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if result.Total != 4 || result.Failed != 2 || len(result.Messages) != 4 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Messages[0].Line != 1 || len(result.Messages[0].Writes) != 1 || result.Messages[0].Writes[0].Detail != "{\"a\":1}" {
+		t.Errorf("unexpected first message: %+v", result.Messages[0])
+	}
+	if result.Messages[1].Line != 3 || result.Messages[1].Error == "" || len(result.Messages[1].Writes) != 0 {
+		t.Errorf("unexpected failed message: %+v", result.Messages[1])
+	}
+	if result.Messages[2].Line != 4 || !strings.Contains(result.Messages[2].Error, "panic") {
+		t.Errorf("unexpected panic message: %+v", result.Messages[2])
+	}
+
+	// only the failed messages are returned when the writes are applied
+	result, err = r.Replay(PorterNetcollect, strings.NewReader(input), false)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if result.Total != 4 || result.Failed != 2 || len(result.Messages) != 2 || result.Messages[0].Line != 3 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if _, err = r.Replay("unknown", strings.NewReader(input), true); err != ErrUnknownAnalyzer {
+		t.Errorf("expected unknown analyzer error, got %v", err)
+	}
+
+	// every replay has its own analyzer and recorder, the late writes of a replay are not
+	// attributed to the next one
+	recorders[0].record(metadata.ReplayWrite{Kind: writeKindRedis, Target: "late", Operation: "lpush"})
+	result, err = r.Replay(PorterNetcollect, strings.NewReader("{\"c\":3}\n"), true)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if len(recorders) != 3 || recorders[0] == recorders[2] || released != 3 {
+		t.Errorf("expected a new analyzer for every replay, built %d, released %d", len(recorders), released)
+	}
+	if len(result.Messages) != 1 || len(result.Messages[0].Writes) != 1 || result.Messages[0].Writes[0].Detail != "{\"c\":3}" {
+		t.Errorf("unexpected writes of the new replay: %+v", result.Messages)
+	}
+}
+
+func TestReadReplayLinesTooLong(t *testing.T) {
+	input := "{}\n" + strings.Repeat("a", maxReplayLineSize+1)
+	lines := 0
+	err := readReplayLines(strings.NewReader(input), func(line int, mesg string) { lines++ })
+	if err == nil || lines != 1 {
+		t.Errorf("expected error at line 2, got %v after %d lines", err, lines)
+	}
+}
+
+func TestDryRunTable(t *testing.T) {
+	recorder := new(writeRecorder)
+	db := &dryRunDB{recorder: recorder}
+	table := &dryRunTable{name: "cc_table", recorder: recorder}
+
+	filter := map[string]interface{}{"id": 1}
+	if err := table.Insert(context.Background(), filter); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	if err := table.Update(context.Background(), filter, map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := table.Delete(context.Background(), filter); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := db.NextSequence(context.Background(), "cc_table"); err != nil {
+		t.Fatalf("next sequence failed: %v", err)
+	}
+
+	writes := recorder.take()
+	operations := []string{"insert", "update", "delete", "next_sequence"}
+	if len(writes) != len(operations) {
+		t.Fatalf("expected %d writes, got %v", len(operations), writes)
+	}
+	for i, write := range writes {
+		if write.Kind != writeKindDB || write.Target != "cc_table" || write.Operation != operations[i] {
+			t.Errorf("unexpected write: %+v", write)
+		}
+	}
+	if len(recorder.take()) != 0 {
+		t.Errorf("expected the recorder to be reset")
+	}
+}
+
+type fakeHttpClient struct {
+	requests int
+}
+
+func (c *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func TestDryRunClient(t *testing.T) {
+	recorder := new(writeRecorder)
+	fake := new(fakeHttpClient)
+	client := &dryRunClient{client: fake, recorder: recorder}
+
+	reads := []*http.Request{
+		newRequest(http.MethodPost, "/api/v3/read/model", ""),
+		newRequest(http.MethodPost, "/topo/v3/objectattr/search", ""),
+		newRequest(http.MethodGet, "/api/v3/healthz", ""),
+	}
+	for _, req := range reads {
+		if _, err := client.Do(req); err != nil {
+			t.Fatalf("do request failed: %v", err)
+		}
+	}
+	if fake.requests != len(reads) || len(recorder.take()) != 0 {
+		t.Errorf("expected read requests to be sent")
+	}
+
+	resp, err := client.Do(newRequest(http.MethodPut, "/api/v3/update/model/switch/instance", `{"data":{"name":"x"}}`))
+	if err != nil {
+		t.Fatalf("do request failed: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != dryRunResponse || fake.requests != len(reads) {
+		t.Errorf("expected write request to be recorded, got response %s", body)
+	}
+	writes := recorder.take()
+	if len(writes) != 1 || writes[0].Kind != writeKindAPI || writes[0].Operation != http.MethodPut ||
+		writes[0].Target != "/api/v3/update/model/switch/instance" {
+		t.Fatalf("unexpected writes: %+v", writes)
+	}
+	if detail, ok := writes[0].Detail.(map[string]interface{}); !ok || detail["data"] == nil {
+		t.Errorf("unexpected write detail: %v", writes[0].Detail)
+	}
+}
+
+func TestParseRedisWrite(t *testing.T) {
+	write, ok := parseRedisWrite(redis.NewStatusCmd("set", "cc:snapshot:1", "data value", "ex", 600))
+	if !ok || write.Operation != "set" || write.Target != "cc:snapshot:1" || write.Detail != "data value ex 600" {
+		t.Errorf("unexpected set write: %+v", write)
+	}
+	write, ok = parseRedisWrite(redis.NewIntCmd("del", "cc:discover:key"))
+	if !ok || write.Operation != "del" || write.Target != "cc:discover:key" || write.Detail != nil {
+		t.Errorf("unexpected del write: %+v", write)
+	}
+	if _, ok = parseRedisWrite(redis.NewStringCmd("get", "cc:discover:key")); ok {
+		t.Errorf("expected get not to be a write")
+	}
+}
+
+func newRequest(method, path, body string) *http.Request {
+	req, _ := http.NewRequest(method, "http://127.0.0.1:8080"+path, strings.NewReader(body))
+	return req
+}
@@ -0,0 +1,698 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/app/options"
+
+	"github.com/rs/xid"
+	"gopkg.in/redis.v5"
+)
+
+const (
+	// streamGroup the consumer group shared by all the datacollection processes
+	streamGroup = "cc_datacollection"
+	// streamMessageField the entry field which the producers put the message in
+	streamMessageField = "data"
+	// streamReadCount the max number of messages read from stream at once
+	streamReadCount = 100
+	// streamBlockTime must be less than the read timeout of redis client, which is 3s by default
+	streamBlockTime = time.Second * 2
+	// streamMaxDelivery the messages delivered more than this times are dropped
+	streamMaxDelivery = 5
+	// streamLagLimit the max number of entries counted when calculating lag by XRANGE
+	streamLagLimit = 10000
+)
+
+var (
+	// streamClaimIdle the pending messages idle longer than this are reclaimed and analyzed again
+	streamClaimIdle = time.Minute * 5
+	// streamConsumerIdle the consumers without pending message and idle longer than this are removed
+	streamConsumerIdle = time.Hour
+	// streamReclaimInterval the interval of reclaiming pending messages
+	streamReclaimInterval = time.Minute
+	// streamMetricsInterval the interval of reporting consuming metrics
+	streamMetricsInterval = time.Second * 30
+)
+
+// buildPorter build the porter reading messages from the redis in the configured way
+func buildPorter(name string, analyzer Analyzer, redisCli, srcCli *redis.Client, conf options.SnapRedis, channels []string, mockmesg string) Porter {
+	if conf.Porter != options.PorterStream {
+		return BuildChanPorter(name, analyzer, redisCli, srcCli, channels, mockmesg)
+	}
+	stream := conf.Stream
+	if stream == "" {
+		stream = channels[0]
+	}
+	blog.Infof("[datacollect][%s] messages are consumed from redis stream %s", name, stream)
+	return BuildStreamPorter(name, analyzer, redisCli, srcCli, stream)
+}
+
+// BuildStreamPorter build a porter which consumes the redis stream by consumer group,
+// the message is acknowledged only after it is analyzed successfully.
+func BuildStreamPorter(name string, analyzer Analyzer, redisCli, streamCli *redis.Client, stream string) *streamPorter {
+	return &streamPorter{
+		analyzer:  analyzer,
+		name:      name,
+		consumer:  xid.New().String(),
+		group:     streamGroup,
+		stream:    stream,
+		redisCli:  redisCli,
+		streamCli: streamCli,
+		analyzeC:  make(chan streamMessage, streamReadCount),
+		runed:     util.NewBool(false),
+	}
+}
+
+type streamMessage struct {
+	id   string
+	data string
+}
+
+type streamPending struct {
+	id         string
+	consumer   string
+	idle       time.Duration
+	deliveries int64
+}
+
+type streamPorter struct {
+	analyzer Analyzer
+
+	// porter name, used in log and redis keys
+	name string
+
+	// consumer name of this process in the consumer group
+	consumer string
+	group    string
+	stream   string
+
+	// cc redis, the consuming metrics are saved here
+	redisCli *redis.Client
+
+	// the redis which the stream located in
+	streamCli *redis.Client
+
+	// messages waiting to be analyzed, reading from stream blocks when it's full
+	analyzeC chan streamMessage
+
+	runed *util.AtomicBool
+
+	// counters since this process started
+	acked   uint64
+	failed  uint64
+	claimed uint64
+	dropped uint64
+}
+
+func (p *streamPorter) Name() string {
+	return p.name
+}
+
+// Mock add the message to the stream, so it goes through the whole consuming process
+func (p *streamPorter) Mock(mesg string) error {
+	return p.streamCli.Process(redis.NewCmd("XADD", p.stream, "*", streamMessageField, mesg))
+}
+
+func (p *streamPorter) Run() error {
+	if !p.runed.IsSet() {
+		p.runed.Set()
+		go p.metricsLoop()
+		go p.reclaimLoop()
+		for i := 0; i < runtime.NumCPU(); i++ {
+			go p.analyzeLoop()
+		}
+	}
+	var err error
+	for {
+		if err = p.consume(); err != nil {
+			blog.Errorf("[datacollect][%s] consume stream %s failed: %v, retry 3s later", p.name, p.stream, err)
+		}
+		time.Sleep(time.Second * 3)
+	}
+}
+
+// consume read the new messages of the group from stream, the messages are distributed among
+// all the consumers of the group by redis, so that every process can consume without a master
+func (p *streamPorter) consume() error {
+	if err := p.createGroup(); err != nil {
+		return err
+	}
+	blog.Infof("[datacollect][%s] consuming stream %s as consumer %s of group %s", p.name, p.stream, p.consumer, p.group)
+
+	for {
+		messages, err := p.readGroup()
+		if err != nil {
+			return err
+		}
+		for _, mesg := range messages {
+			p.analyzeC <- mesg
+		}
+	}
+}
+
+// createGroup create the consumer group from the beginning of the stream if it's not exist
+func (p *streamPorter) createGroup() error {
+	err := p.streamCli.Process(redis.NewCmd("XGROUP", "CREATE", p.stream, p.group, "0", "MKSTREAM"))
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create consumer group %s failed: %v", p.group, err)
+	}
+	return nil
+}
+
+func (p *streamPorter) readGroup() ([]streamMessage, error) {
+	cmd := redis.NewCmd("XREADGROUP", "GROUP", p.group, p.consumer, "COUNT", streamReadCount,
+		"BLOCK", int64(streamBlockTime/time.Millisecond), "STREAMS", p.stream, ">")
+	p.streamCli.Process(cmd)
+	reply, err := cmd.Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if timeouterr, ok := err.(net.Error); ok && timeouterr.Timeout() {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read group failed: %v", err)
+	}
+	return parseStreamReply(reply)
+}
+
+func (p *streamPorter) analyzeLoop() {
+	for {
+		p.analyze()
+	}
+}
+
+func (p *streamPorter) analyze() {
+	defer func() {
+		if syserr := recover(); syserr != nil {
+			blog.Errorf("[datacollect][%s] analyzeLoop panic by: %v, stack:\n %s", p.name, syserr, debug.Stack())
+		}
+	}()
+
+	var err error
+	for mesg := range p.analyzeC {
+		if mesg.data != "" {
+			if err = p.analyzer.Analyze(mesg.data); err != nil {
+				// keep it pending, it will be reclaimed and analyzed again later
+				atomic.AddUint64(&p.failed, 1)
+				blog.Errorf("[datacollect][%s] analyze message %s failed: %v, raw mesg: %s", p.name, mesg.id, err, mesg.data)
+				continue
+			}
+		}
+		if err = p.ack(mesg.id); err != nil {
+			blog.Errorf("[datacollect][%s] ack message %s failed: %v", p.name, mesg.id, err)
+			continue
+		}
+		atomic.AddUint64(&p.acked, 1)
+	}
+}
+
+func (p *streamPorter) ack(id string) error {
+	return p.streamCli.Process(redis.NewCmd("XACK", p.stream, p.group, id))
+}
+
+func (p *streamPorter) reclaimLoop() {
+	for range time.Tick(streamReclaimInterval) {
+		if err := p.reclaim(); err != nil {
+			blog.Errorf("[datacollect][%s] reclaim pending messages failed: %v", p.name, err)
+		}
+		if err := p.removeIdleConsumers(); err != nil {
+			blog.Errorf("[datacollect][%s] remove idle consumers failed: %v", p.name, err)
+		}
+		if err := p.trim(); err != nil {
+			blog.Errorf("[datacollect][%s] trim stream %s failed: %v", p.name, p.stream, err)
+		}
+	}
+}
+
+// reclaim claim the messages pending too long, whose consumer has gone or failed to analyze them,
+// and analyze them again. The messages delivered too many times are acknowledged and dropped.
+// The pending messages are paged through, streamReadCount at a time.
+func (p *streamPorter) reclaim() error {
+	start := "-"
+	for {
+		cmd := redis.NewCmd("XPENDING", p.stream, p.group, start, "+", streamReadCount)
+		p.streamCli.Process(cmd)
+		result, err := cmd.Result()
+		if err != nil {
+			return fmt.Errorf("get pending messages failed: %v", err)
+		}
+		pendings, err := parsePendingReply(result)
+		if err != nil {
+			return err
+		}
+		if err = p.reclaimPendings(pendings); err != nil {
+			return err
+		}
+		if len(pendings) < streamReadCount {
+			return nil
+		}
+		if start, err = nextStreamID(pendings[len(pendings)-1].id); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *streamPorter) reclaimPendings(pendings []streamPending) error {
+	var err error
+	ids := make([]interface{}, 0)
+	for _, pending := range pendings {
+		if pending.idle < streamClaimIdle {
+			continue
+		}
+		if pending.deliveries >= streamMaxDelivery {
+			blog.Errorf("[datacollect][%s] message %s has been delivered %d times, drop it", p.name, pending.id, pending.deliveries)
+			if err = p.ack(pending.id); err != nil {
+				blog.Errorf("[datacollect][%s] ack message %s failed: %v", p.name, pending.id, err)
+				continue
+			}
+			atomic.AddUint64(&p.dropped, 1)
+			continue
+		}
+		ids = append(ids, pending.id)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// min-idle-time makes sure that only one consumer claims the message successfully
+	args := []interface{}{"XCLAIM", p.stream, p.group, p.consumer, int64(streamClaimIdle / time.Millisecond)}
+	cmd := redis.NewCmd(append(args, ids...)...)
+	p.streamCli.Process(cmd)
+	result, err := cmd.Result()
+	if err != nil {
+		return fmt.Errorf("claim pending messages failed: %v", err)
+	}
+	messages, err := parseStreamEntries(result)
+	if err != nil {
+		return err
+	}
+	blog.Infof("[datacollect][%s] reclaimed %d pending messages", p.name, len(messages))
+	atomic.AddUint64(&p.claimed, uint64(len(messages)))
+	for _, mesg := range messages {
+		p.analyzeC <- mesg
+	}
+	return nil
+}
+
+// removeIdleConsumers every process joins the group with a new consumer name, remove the
+// consumers left by the exited processes once their pending messages are all reclaimed
+func (p *streamPorter) removeIdleConsumers() error {
+	cmd := redis.NewCmd("XINFO", "CONSUMERS", p.stream, p.group)
+	p.streamCli.Process(cmd)
+	result, err := cmd.Result()
+	if err != nil {
+		return fmt.Errorf("get consumers failed: %v", err)
+	}
+	consumers, ok := result.([]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected consumers reply: %v", result)
+	}
+	for _, item := range consumers {
+		info := parseStreamInfo(item)
+		name := toString(info["name"])
+		if name == "" || name == p.consumer || toInt64(info["pending"]) > 0 ||
+			time.Duration(toInt64(info["idle"]))*time.Millisecond < streamConsumerIdle {
+			continue
+		}
+		if err = p.streamCli.Process(redis.NewCmd("XGROUP", "DELCONSUMER", p.stream, p.group, name)); err != nil {
+			blog.Errorf("[datacollect][%s] delete consumer %s failed: %v", p.name, name, err)
+			continue
+		}
+		blog.Infof("[datacollect][%s] deleted idle consumer %s", p.name, name)
+	}
+	return nil
+}
+
+// trim delete the entries acknowledged by all the groups of the stream, otherwise the stream keeps
+// growing. The entries before the oldest pending one, or after the last delivered one, are kept.
+func (p *streamPorter) trim() error {
+	cmd := redis.NewCmd("XINFO", "GROUPS", p.stream)
+	p.streamCli.Process(cmd)
+	result, err := cmd.Result()
+	if err != nil {
+		return fmt.Errorf("get groups failed: %v", err)
+	}
+	groups, _ := result.([]interface{})
+	minID := ""
+	for _, item := range groups {
+		info := parseStreamInfo(item)
+		keepID, err := nextStreamID(toString(info["last-delivered-id"]))
+		if err != nil {
+			return err
+		}
+		if toInt64(info["pending"]) > 0 {
+			// the summary form of XPENDING: [count, smallest id, greatest id, consumers]
+			cmd = redis.NewCmd("XPENDING", p.stream, toString(info["name"]))
+			p.streamCli.Process(cmd)
+			result, err = cmd.Result()
+			if err != nil {
+				return fmt.Errorf("get pending summary of group %s failed: %v", toString(info["name"]), err)
+			}
+			summary, _ := result.([]interface{})
+			if len(summary) < 2 || summary[1] == nil {
+				return fmt.Errorf("unexpected pending summary of group %s: %v", toString(info["name"]), result)
+			}
+			keepID = toString(summary[1])
+		}
+		if minID == "" || compareStreamID(keepID, minID) < 0 {
+			minID = keepID
+		}
+	}
+	if minID == "" {
+		return nil
+	}
+
+	cmd = redis.NewCmd("XTRIM", p.stream, "MINID", minID)
+	p.streamCli.Process(cmd)
+	result, err = cmd.Result()
+	if err != nil {
+		return fmt.Errorf("trim entries before %s failed: %v", minID, err)
+	}
+	if trimmed := toInt64(result); trimmed > 0 {
+		blog.V(3).Infof("[datacollect][%s] trimmed %d entries before %s", p.name, trimmed, minID)
+	}
+	return nil
+}
+
+func (p *streamPorter) metricsLoop() {
+	for range time.Tick(streamMetricsInterval) {
+		channelstatus := common.CCSuccess
+		metrics, err := p.metrics()
+		if err != nil {
+			channelstatus = common.CCErrHostGetSnapshotChannelClose
+			blog.Errorf("[datacollect][%s] get stream metrics failed: %v", p.name, err)
+		} else {
+			out, _ := json.Marshal(metrics)
+			key := StreamMetricsKey(p.name)
+			if err = p.redisCli.HSet(key, p.consumer, string(out)).Err(); err != nil {
+				blog.Errorf("[datacollect][%s] save stream metrics failed: %v", p.name, err)
+			} else if err = p.redisCli.Expire(key, streamMetricsInterval*4).Err(); err != nil {
+				blog.Errorf("[datacollect][%s] expire stream metrics failed: %v", p.name, err)
+			}
+		}
+		if err = p.redisCli.Set(channelStatusKey(p.name), channelstatus, time.Minute*2).Err(); err != nil {
+			blog.Errorf("[datacollect][%s] set channelstatus failed: %v", p.name, err)
+		}
+	}
+}
+
+func (p *streamPorter) metrics() (*metadata.StreamPorterMetrics, error) {
+	metrics := &metadata.StreamPorterMetrics{
+		Name:       p.name,
+		Stream:     p.stream,
+		Group:      p.group,
+		Consumer:   p.consumer,
+		Acked:      atomic.LoadUint64(&p.acked),
+		Failed:     atomic.LoadUint64(&p.failed),
+		Claimed:    atomic.LoadUint64(&p.claimed),
+		Dropped:    atomic.LoadUint64(&p.dropped),
+		UpdateTime: metadata.Now(),
+	}
+
+	cmd := redis.NewCmd("XLEN", p.stream)
+	p.streamCli.Process(cmd)
+	result, err := cmd.Result()
+	if err != nil {
+		return nil, fmt.Errorf("get stream length failed: %v", err)
+	}
+	metrics.Length = toInt64(result)
+
+	cmd = redis.NewCmd("XINFO", "GROUPS", p.stream)
+	p.streamCli.Process(cmd)
+	result, err = cmd.Result()
+	if err != nil {
+		return nil, fmt.Errorf("get groups failed: %v", err)
+	}
+	groups, _ := result.([]interface{})
+	for _, item := range groups {
+		info := parseStreamInfo(item)
+		if toString(info["name"]) != p.group {
+			continue
+		}
+		metrics.Consumers = toInt64(info["consumers"])
+		metrics.Pending = toInt64(info["pending"])
+		metrics.LastDeliveredID = toString(info["last-delivered-id"])
+		if lag, ok := info["lag"]; ok && lag != nil {
+			// provided by redis 7.0 and later
+			metrics.Lag = toInt64(lag)
+		} else if metrics.Lag, err = p.countAfter(metrics.LastDeliveredID); err != nil {
+			return nil, err
+		}
+		break
+	}
+	return metrics, nil
+}
+
+// countAfter count the entries after the id, at most streamLagLimit
+func (p *streamPorter) countAfter(id string) (int64, error) {
+	if id == "" || id == "0-0" {
+		cmd := redis.NewCmd("XLEN", p.stream)
+		p.streamCli.Process(cmd)
+		result, err := cmd.Result()
+		return toInt64(result), err
+	}
+	cmd := redis.NewCmd("XRANGE", p.stream, id, "+", "COUNT", streamLagLimit+1)
+	p.streamCli.Process(cmd)
+	result, err := cmd.Result()
+	if err != nil {
+		return 0, fmt.Errorf("count entries after %s failed: %v", id, err)
+	}
+	entries, err := parseStreamEntries(result)
+	if err != nil {
+		return 0, err
+	}
+	count := int64(len(entries))
+	if count > 0 && entries[0].id == id {
+		count--
+	}
+	if count > streamLagLimit {
+		count = streamLagLimit
+	}
+	return count, nil
+}
+
+// SearchStreamMetrics get the latest metrics reported by the alive consumers of all the stream porters
+func SearchStreamMetrics(redisCli *redis.Client) ([]metadata.StreamPorterMetrics, error) {
+	result := make([]metadata.StreamPorterMetrics, 0)
+	for _, name := range []string{PorterHostsnap, PorterMiddleware, PorterNetcollect} {
+		values, err := redisCli.HGetAll(StreamMetricsKey(name)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("get stream metrics of %s failed: %v", name, err)
+		}
+		for consumer, value := range values {
+			metrics := metadata.StreamPorterMetrics{}
+			if err = json.Unmarshal([]byte(value), &metrics); err != nil {
+				blog.Warnf("[datacollect][%s] invalid stream metrics of consumer %s: %v", name, consumer, err)
+				continue
+			}
+			// the consumer has exited
+			if time.Since(metrics.UpdateTime.Time) > streamMetricsInterval*4 {
+				continue
+			}
+			result = append(result, metrics)
+		}
+	}
+	return result, nil
+}
+
+// StreamMetricsKey the key of the hash which saves the metrics of each consumer of the porter
+func StreamMetricsKey(name string) string {
+	return common.BKCacheKeyV3Prefix + name + ":streammetrics"
+}
+
+// parseStreamReply parse the reply of XREADGROUP: [[stream, [[id, [field, value...]]...]]...]
+func parseStreamReply(reply interface{}) ([]streamMessage, error) {
+	streams, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected stream reply: %v", reply)
+	}
+	messages := make([]streamMessage, 0)
+	for _, item := range streams {
+		stream, ok := item.([]interface{})
+		if !ok || len(stream) != 2 {
+			return nil, fmt.Errorf("unexpected stream reply: %v", item)
+		}
+		entries, err := parseStreamEntries(stream[1])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, entries...)
+	}
+	return messages, nil
+}
+
+// parseStreamEntries parse the entries replied by XRANGE and XCLAIM: [[id, [field, value...]]...],
+// the entries already deleted from stream are replied as nil by XCLAIM and skipped here
+func parseStreamEntries(reply interface{}) ([]streamMessage, error) {
+	entries, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected stream entries: %v", reply)
+	}
+	messages := make([]streamMessage, 0, len(entries))
+	for _, item := range entries {
+		if item == nil {
+			continue
+		}
+		entry, ok := item.([]interface{})
+		if !ok || len(entry) != 2 {
+			return nil, fmt.Errorf("unexpected stream entry: %v", item)
+		}
+		mesg := streamMessage{id: toString(entry[0])}
+		fields, _ := entry[1].([]interface{})
+		for i := 0; i+1 < len(fields); i += 2 {
+			if toString(fields[i]) == streamMessageField {
+				mesg.data = toString(fields[i+1])
+				break
+			}
+		}
+		// compatible with the producers not using the message field
+		if mesg.data == "" && len(fields) == 2 {
+			mesg.data = toString(fields[1])
+		}
+		messages = append(messages, mesg)
+	}
+	return messages, nil
+}
+
+// parsePendingReply parse the reply of the extended XPENDING: [[id, consumer, idle, deliveries]...]
+func parsePendingReply(reply interface{}) ([]streamPending, error) {
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected pending reply: %v", reply)
+	}
+	pendings := make([]streamPending, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected pending entry: %v", item)
+		}
+		pendings = append(pendings, streamPending{
+			id:         toString(fields[0]),
+			consumer:   toString(fields[1]),
+			idle:       time.Duration(toInt64(fields[2])) * time.Millisecond,
+			deliveries: toInt64(fields[3]),
+		})
+	}
+	return pendings, nil
+}
+
+// parseStreamID parse the stream entry id in the form of ms-seq
+func parseStreamID(id string) (uint64, uint64, error) {
+	parts := strings.SplitN(id, "-", 2)
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid stream id %s", id)
+	}
+	if len(parts) == 1 {
+		return ms, 0, nil
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid stream id %s", id)
+	}
+	return ms, seq, nil
+}
+
+// nextStreamID the smallest id after the id, the ranges of stream commands are inclusive
+func nextStreamID(id string) (string, error) {
+	if id == "" {
+		return "0-1", nil
+	}
+	ms, seq, err := parseStreamID(id)
+	if err != nil {
+		return "", err
+	}
+	if seq == math.MaxUint64 {
+		return strconv.FormatUint(ms+1, 10) + "-0", nil
+	}
+	return strconv.FormatUint(ms, 10) + "-" + strconv.FormatUint(seq+1, 10), nil
+}
+
+// compareStreamID compare the stream ids, the invalid ones are the smallest so nothing is trimmed by them
+func compareStreamID(a, b string) int {
+	ams, aseq, aerr := parseStreamID(a)
+	bms, bseq, berr := parseStreamID(b)
+	switch {
+	case aerr != nil && berr != nil:
+		return 0
+	case aerr != nil:
+		return -1
+	case berr != nil:
+		return 1
+	case ams != bms:
+		if ams < bms {
+			return -1
+		}
+		return 1
+	case aseq != bseq:
+		if aseq < bseq {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// parseStreamInfo parse the flat key value list replied by XINFO to map
+func parseStreamInfo(reply interface{}) map[string]interface{} {
+	info := map[string]interface{}{}
+	fields, _ := reply.([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		info[toString(fields[i])] = fields[i+1]
+	}
+	return info
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toInt64(val interface{}) int64 {
+	switch v := val.(type) {
+	case int64:
+		return v
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
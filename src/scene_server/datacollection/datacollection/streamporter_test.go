@@ -0,0 +1,124 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStreamReply(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{"snapshot0", []interface{}{
+			[]interface{}{"1-0", []interface{}{"data", "mesg1"}},
+			[]interface{}{"2-0", []interface{}{"other", "x", "data", "mesg2"}},
+			[]interface{}{"3-0", []interface{}{"payload", "mesg3"}},
+		}},
+	}
+	messages, err := parseStreamReply(reply)
+	if err != nil {
+		t.Fatalf("parse stream reply failed: %v", err)
+	}
+	expected := []streamMessage{{"1-0", "mesg1"}, {"2-0", "mesg2"}, {"3-0", "mesg3"}}
+	if len(messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %v", len(expected), messages)
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("expected message %v, got %v", expected[i], messages[i])
+		}
+	}
+
+	if _, err = parseStreamReply("OK"); err == nil {
+		t.Errorf("expected error for invalid reply")
+	}
+}
+
+func TestParseStreamEntriesSkipDeleted(t *testing.T) {
+	reply := []interface{}{nil, []interface{}{"5-1", []interface{}{"data", "mesg"}}}
+	messages, err := parseStreamEntries(reply)
+	if err != nil {
+		t.Fatalf("parse stream entries failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].id != "5-1" || messages[0].data != "mesg" {
+		t.Errorf("unexpected messages: %v", messages)
+	}
+}
+
+func TestParsePendingReply(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{"1-0", "consumer1", int64(360000), int64(2)},
+	}
+	pendings, err := parsePendingReply(reply)
+	if err != nil {
+		t.Fatalf("parse pending reply failed: %v", err)
+	}
+	if len(pendings) != 1 {
+		t.Fatalf("expected 1 pending message, got %v", pendings)
+	}
+	pending := pendings[0]
+	if pending.id != "1-0" || pending.consumer != "consumer1" || pending.idle != 6*time.Minute || pending.deliveries != 2 {
+		t.Errorf("unexpected pending message: %+v", pending)
+	}
+
+	if _, err = parsePendingReply([]interface{}{[]interface{}{"1-0"}}); err == nil {
+		t.Errorf("expected error for invalid pending entry")
+	}
+}
+
+func TestParseStreamInfo(t *testing.T) {
+	info := parseStreamInfo([]interface{}{"name", "cc_datacollection", "pending", int64(3), "lag", nil})
+	if toString(info["name"]) != "cc_datacollection" || toInt64(info["pending"]) != 3 {
+		t.Errorf("unexpected info: %v", info)
+	}
+	if lag, ok := info["lag"]; !ok || lag != nil {
+		t.Errorf("expected nil lag, got %v", lag)
+	}
+}
+
+func TestNextStreamID(t *testing.T) {
+	cases := map[string]string{
+		"":                       "0-1",
+		"0-0":                    "0-1",
+		"1558000000000-5":        "1558000000000-6",
+		"1558000000000":          "1558000000000-1",
+		"1-18446744073709551615": "2-0",
+	}
+	for id, want := range cases {
+		next, err := nextStreamID(id)
+		if err != nil || next != want {
+			t.Errorf("expect next id of %s is %s, got %s, err: %v", id, want, next, err)
+		}
+	}
+	if _, err := nextStreamID("abc"); err == nil {
+		t.Errorf("expected error for invalid stream id")
+	}
+}
+
+func TestCompareStreamID(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1-0", b: "1-0", want: 0},
+		{a: "1-1", b: "1-2", want: -1},
+		{a: "2-0", b: "1-9", want: 1},
+		{a: "10-0", b: "9-0", want: 1},
+		{a: "abc", b: "1-0", want: -1},
+	}
+	for _, c := range cases {
+		if got := compareStreamID(c.a, c.b); got != c.want {
+			t.Errorf("compare %s with %s, expect %d, got %d", c.a, c.b, c.want, got)
+		}
+	}
+}
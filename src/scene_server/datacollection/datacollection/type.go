@@ -35,6 +35,13 @@ const (
 
 var masterProcLockLiveTime = time.Second * 10
 
+// the porter names
+const (
+	PorterHostsnap   = "hostsnap"
+	PorterMiddleware = "middleware"
+	PorterNetcollect = "netcollect"
+)
+
 const (
 	DiscoverChan = "discover"
 	SnapShotChan = "snapshot"
@@ -0,0 +1,314 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
+
+	"github.com/tidwall/gjson"
+)
+
+// CreateHostSnapMapping create the mapping from host snapshot to host attribute
+func (lgc *Logics) CreateHostSnapMapping(pheader http.Header, mapping meta.HostSnapMapping) (*meta.HostSnapMapping, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	resp, err := lgc.CoreAPI.CoreService().Host().CreateHostSnapMapping(context.Background(), pheader, &mapping)
+	if nil != err {
+		blog.Errorf("[HostSnap] create mapping %+v failed, err: %v", mapping, err)
+		return nil, defErr.Error(common.CCErrCollectHostSnapMappingCreateFail)
+	}
+	if !resp.Result {
+		blog.Errorf("[HostSnap] create mapping %+v failed, errors: %s", mapping, resp.ErrMsg)
+		return nil, defErr.New(resp.Code, resp.ErrMsg)
+	}
+	return &resp.Data, nil
+}
+
+// UpdateHostSnapMapping update the host snapshot mapping
+func (lgc *Logics) UpdateHostSnapMapping(pheader http.Header, id int64, mapping meta.HostSnapMapping) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	resp, err := lgc.CoreAPI.CoreService().Host().UpdateHostSnapMapping(context.Background(), pheader, id, &mapping)
+	if nil != err {
+		blog.Errorf("[HostSnap] update mapping %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectHostSnapMappingUpdateFail)
+	}
+	if !resp.Result {
+		blog.Errorf("[HostSnap] update mapping %d failed, errors: %s", id, resp.ErrMsg)
+		return defErr.New(resp.Code, resp.ErrMsg)
+	}
+	return nil
+}
+
+// DeleteHostSnapMapping delete the host snapshot mapping
+func (lgc *Logics) DeleteHostSnapMapping(pheader http.Header, id int64) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	resp, err := lgc.CoreAPI.CoreService().Host().DeleteHostSnapMapping(context.Background(), pheader, id)
+	if nil != err {
+		blog.Errorf("[HostSnap] delete mapping %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectHostSnapMappingDeleteFail)
+	}
+	if !resp.Result {
+		blog.Errorf("[HostSnap] delete mapping %d failed, errors: %s", id, resp.ErrMsg)
+		return defErr.New(resp.Code, resp.ErrMsg)
+	}
+	return nil
+}
+
+// SearchHostSnapMapping search the host snapshot mappings
+func (lgc *Logics) SearchHostSnapMapping(pheader http.Header, cond meta.QueryCondition) (*meta.SearchHostSnapMapping, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	resp, err := lgc.CoreAPI.CoreService().Host().SearchHostSnapMapping(context.Background(), pheader, &cond)
+	if nil != err {
+		blog.Errorf("[HostSnap] search mapping failed, condition: %+v, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectHostSnapMappingSearchFail)
+	}
+	if !resp.Result {
+		blog.Errorf("[HostSnap] search mapping failed, condition: %+v, errors: %s", cond, resp.ErrMsg)
+		return nil, defErr.New(resp.Code, resp.ErrMsg)
+	}
+	return &resp.Data, nil
+}
+
+// TestHostSnapMapping apply the mappings to the sample snapshot, the saved mappings are
+// tested if none is given. the snapshot can be the json object or the raw message of agent.
+func (lgc *Logics) TestHostSnapMapping(pheader http.Header, params meta.HostSnapMappingTestParams) ([]meta.HostSnapMappingTestResult, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	mesg, ok := params.Snapshot.(string)
+	if !ok {
+		raw, err := json.Marshal(params.Snapshot)
+		if err != nil {
+			return nil, defErr.Errorf(common.CCErrCommParamsInvalid, "snapshot")
+		}
+		mesg = string(raw)
+	}
+	data := hostsnap.SnapshotData(mesg)
+	val := gjson.Parse(data)
+	if !gjson.Valid(data) || !val.IsObject() {
+		return nil, defErr.Errorf(common.CCErrCommParamsInvalid, "snapshot")
+	}
+
+	mappings := params.Mappings
+	if len(mappings) == 0 {
+		result, err := lgc.SearchHostSnapMapping(pheader, meta.QueryCondition{})
+		if err != nil {
+			return nil, err
+		}
+		mappings = result.Info
+	}
+
+	results := make([]meta.HostSnapMappingTestResult, 0)
+	for _, mapping := range mappings {
+		result := meta.HostSnapMappingTestResult{PropertyID: mapping.PropertyID, Path: mapping.Path}
+		if err := mapping.Validate(); err != nil {
+			result.ErrMsg = err.Error()
+		} else if result.Value, err = hostsnap.ApplyMapping(&val, mapping); err != nil {
+			result.ErrMsg = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// SearchHostHardwareHistory search the hardware timeline of the host, the latest change first by default
+func (lgc *Logics) SearchHostHardwareHistory(pheader http.Header, params meta.HostHardwareHistoryParams) (*meta.SearchHostHardwareHistory, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := map[string]interface{}{common.BKHostIDField: params.HostID}
+	if params.PropertyID != "" {
+		cond["changes."+common.BKPropertyIDField] = params.PropertyID
+	}
+	timeCond := map[string]interface{}{}
+	if params.StartTime != nil {
+		timeCond[common.BKDBGTE] = params.StartTime.Time
+	}
+	if params.EndTime != nil {
+		timeCond[common.BKDBLTE] = params.EndTime.Time
+	}
+	if len(timeCond) > 0 {
+		cond["change_time"] = timeCond
+	}
+	cond = util.SetQueryOwner(cond, util.GetOwnerID(pheader))
+
+	count, err := lgc.Instance.Table(common.BKTableNameHostHardwareHistory).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[HostSnap] search hardware history count failed, condition: %+v, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectHostHardwareHistorySearchFail)
+	}
+
+	sort := params.Page.Sort
+	if sort == "" {
+		sort = "-change_time"
+	}
+	histories := make([]meta.HostHardwareHistory, 0)
+	err = lgc.Instance.Table(common.BKTableNameHostHardwareHistory).Find(cond).Sort(sort).
+		Start(uint64(params.Page.Start)).Limit(uint64(params.Page.Limit)).All(lgc.ctx, &histories)
+	if err != nil {
+		blog.Errorf("[HostSnap] search hardware history failed, condition: %+v, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectHostHardwareHistorySearchFail)
+	}
+	return &meta.SearchHostHardwareHistory{Count: count, Info: histories}, nil
+}
+
+// SearchStaleHost search the hosts whose agent stops reporting snapshot, with the topology they belong to
+func (lgc *Logics) SearchStaleHost(pheader http.Header, params meta.StaleHostParams) (*meta.SearchStaleHost, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := map[string]interface{}{common.BKAgentStatusField: common.HostAgentStatusStale}
+	if params.AppID > 0 {
+		relations := make([]meta.ModuleHost, 0)
+		relationCond := util.SetQueryOwner(map[string]interface{}{common.BKAppIDField: params.AppID}, util.GetOwnerID(pheader))
+		if err := lgc.Instance.Table(common.BKTableNameModuleHostConfig).Find(relationCond).All(lgc.ctx, &relations); err != nil {
+			blog.Errorf("[HostSnap] search stale hosts get hosts of biz %d failed, err: %v", params.AppID, err)
+			return nil, defErr.Error(common.CCErrCollectStaleHostSearchFail)
+		}
+		hostIDs := make([]int64, 0, len(relations))
+		for _, relation := range relations {
+			hostIDs = append(hostIDs, relation.HostID)
+		}
+		cond[common.BKHostIDField] = map[string]interface{}{common.BKDBIN: hostIDs}
+	}
+	cond = util.SetQueryOwner(cond, util.GetOwnerID(pheader))
+
+	count, err := lgc.Instance.Table(common.BKTableNameHostSnapStatus).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[HostSnap] search stale hosts count failed, condition: %+v, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectStaleHostSearchFail)
+	}
+
+	sort := params.Page.Sort
+	if sort == "" {
+		sort = "-stale_time"
+	}
+	statuses := make([]meta.HostSnapStatus, 0)
+	err = lgc.Instance.Table(common.BKTableNameHostSnapStatus).Find(cond).Sort(sort).
+		Start(uint64(params.Page.Start)).Limit(uint64(params.Page.Limit)).All(lgc.ctx, &statuses)
+	if err != nil {
+		blog.Errorf("[HostSnap] search stale hosts failed, condition: %+v, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectStaleHostSearchFail)
+	}
+	if len(statuses) == 0 {
+		return &meta.SearchStaleHost{Count: count, Info: []meta.StaleHost{}}, nil
+	}
+
+	hostIDs := make([]int64, 0, len(statuses))
+	for _, status := range statuses {
+		hostIDs = append(hostIDs, status.HostID)
+	}
+	hosts, topos, err := lgc.getStaleHostTopo(hostIDs)
+	if err != nil {
+		blog.Errorf("[HostSnap] search stale hosts get topology of hosts %v failed, err: %v", hostIDs, err)
+		return nil, defErr.Error(common.CCErrCollectStaleHostSearchFail)
+	}
+
+	info := make([]meta.StaleHost, 0, len(statuses))
+	for _, status := range statuses {
+		host, ok := hosts[status.HostID]
+		if !ok {
+			// the host is deleted
+			host = map[string]interface{}{common.BKHostIDField: status.HostID}
+		}
+		info = append(info, meta.StaleHost{
+			Host:         host,
+			LastSnapTime: status.LastSnapTime,
+			StaleTime:    status.StaleTime,
+			Topo:         topos[status.HostID],
+		})
+	}
+	return &meta.SearchStaleHost{Count: count, Info: info}, nil
+}
+
+// getStaleHostTopo get the hosts and the business, set, module they belong to
+func (lgc *Logics) getStaleHostTopo(hostIDs []int64) (map[int64]map[string]interface{}, map[int64][]meta.StaleHostTopo, error) {
+	hostCond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+	hostList := make([]map[string]interface{}, 0)
+	if err := lgc.Instance.Table(common.BKTableNameBaseHost).Find(hostCond).All(lgc.ctx, &hostList); err != nil {
+		return nil, nil, err
+	}
+	hosts := make(map[int64]map[string]interface{}, len(hostList))
+	for _, host := range hostList {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			continue
+		}
+		hosts[hostID] = host
+	}
+
+	relations := make([]meta.ModuleHost, 0)
+	if err := lgc.Instance.Table(common.BKTableNameModuleHostConfig).Find(hostCond).All(lgc.ctx, &relations); err != nil {
+		return nil, nil, err
+	}
+	bizIDs, setIDs, moduleIDs := make([]int64, 0), make([]int64, 0), make([]int64, 0)
+	for _, relation := range relations {
+		bizIDs = append(bizIDs, relation.AppID)
+		setIDs = append(setIDs, relation.SetID)
+		moduleIDs = append(moduleIDs, relation.ModuleID)
+	}
+	bizNames, err := lgc.getInstNames(common.BKTableNameBaseApp, common.BKAppIDField, common.BKAppNameField, bizIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	setNames, err := lgc.getInstNames(common.BKTableNameBaseSet, common.BKSetIDField, common.BKSetNameField, setIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	moduleNames, err := lgc.getInstNames(common.BKTableNameBaseModule, common.BKModuleIDField, common.BKModuleNameField, moduleIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	topos := make(map[int64][]meta.StaleHostTopo, len(hostIDs))
+	for _, relation := range relations {
+		topos[relation.HostID] = append(topos[relation.HostID], meta.StaleHostTopo{
+			AppID:      relation.AppID,
+			AppName:    bizNames[relation.AppID],
+			SetID:      relation.SetID,
+			SetName:    setNames[relation.SetID],
+			ModuleID:   relation.ModuleID,
+			ModuleName: moduleNames[relation.ModuleID],
+		})
+	}
+	return hosts, topos, nil
+}
+
+// getInstNames get the names of the instances by id
+func (lgc *Logics) getInstNames(tableName, idField, nameField string, ids []int64) (map[int64]string, error) {
+	names := make(map[int64]string)
+	if len(ids) == 0 {
+		return names, nil
+	}
+	insts := make([]map[string]interface{}, 0)
+	cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: ids}}
+	if err := lgc.Instance.Table(tableName).Find(cond).Fields(idField, nameField).All(lgc.ctx, &insts); err != nil {
+		return nil, err
+	}
+	for _, inst := range insts {
+		id, err := util.GetInt64ByInterface(inst[idField])
+		if err != nil {
+			continue
+		}
+		names[id] = util.GetStrByInterface(inst[nameField])
+	}
+	return names, nil
+}
@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// SearchNetcollectLink search the links discovered from the neighbor tables, both the links from and to the instance
+func (lgc *Logics) SearchNetcollectLink(pheader http.Header, params metadata.ParamSearchNetcollectLink) (*metadata.RspNetcollectLink, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := map[string]interface{}{}
+	if params.ObjectID != "" {
+		from := map[string]interface{}{common.BKObjIDField: params.ObjectID}
+		to := map[string]interface{}{common.BKAsstObjIDField: params.ObjectID}
+		if params.InstID > 0 {
+			from[common.BKInstIDField] = params.InstID
+			to[common.BKAsstInstIDField] = params.InstID
+		}
+		cond[common.BKDBOR] = []map[string]interface{}{from, to}
+	}
+	cond = util.SetQueryOwner(cond, util.GetOwnerID(pheader))
+
+	count, err := lgc.Instance.Table(common.BKTableNameNetcollectLink).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[NetDevice][SearchNetcollectLink] count links by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectNetLinkSearchFail)
+	}
+
+	links := make([]metadata.NetcollectLink, 0)
+	err = lgc.Instance.Table(common.BKTableNameNetcollectLink).Find(cond).Sort(params.Page.Sort).
+		Start(uint64(params.Page.Start)).Limit(uint64(params.Page.Limit)).All(lgc.ctx, &links)
+	if err != nil {
+		blog.Errorf("[NetDevice][SearchNetcollectLink] search links by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectNetLinkSearchFail)
+	}
+	return &metadata.RspNetcollectLink{Count: count, Info: links}, nil
+}
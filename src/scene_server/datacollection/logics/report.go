@@ -14,6 +14,7 @@ package logics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -209,7 +210,9 @@ func (lgc *Logics) SearchReport(header http.Header, param metadata.ParamSearchNe
 
 		for asstIndex := range reports[index].Associations {
 			asst := &reports[index].Associations[asstIndex]
-			asst.Action = metadata.ReporctActionCreate
+			if asst.Action == "" {
+				asst.Action = metadata.ReporctActionCreate
+			}
 			if object, ok := objMap[asst.AsstObjectID]; ok {
 				asst.AsstObjectName = object.ObjectName
 			}
@@ -512,55 +515,209 @@ func (lgc *Logics) confirmAssociations(header http.Header, report *metadata.Netc
 	}
 
 	for _, asst := range report.Associations {
-		asstObjType := common.GetObjByType(asst.AsstObjectID)
-		asstCond := condition.CreateCondition()
-		if asstObjType == common.BKInnerObjIDObject {
-			asstCond.Field(common.GetInstNameField(asst.AsstObjectID)).Eq(asst.AsstInstName)
-			asstCond.Field(common.BKObjIDField).Eq(asst.AsstObjectID)
-		}
-		if asstObjType == common.BKInnerObjIDHost {
-			asstCond.Field(common.BKCloudIDField).Eq(report.CloudID)
-			asstCond.Field(common.BKHostInnerIPField).Eq(asst.AsstInstName)
+		if asst.Action == metadata.ReporctActionDelete {
+			if err := lgc.removeLink(header, report, instID, instassts, asst); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			successCount++
+			continue
 		}
-		asstInsts, err := lgc.findInst(header, asst.AsstObjectID, &metadata.QueryCondition{Condition: asstCond.ToMapStr()})
+
+		asstInstID, err := lgc.findAsstInstID(header, report, asst)
 		if err != nil {
-			blog.Errorf("[NetDevice][ConfirmReport] find inst by %+v failed %v", asstCond.ToMapStr(), err)
 			errs = append(errs, err)
 			continue
 		}
-		blog.V(4).Infof("[NetDevice][ConfirmReport] find inst result: %#v, condition: %#v", asstInsts, asstCond.ToMapStr())
-		if len(asstInsts) > 0 {
-			asstInstID, err := asstInsts[0].Int64(common.GetInstIDField(asst.AsstObjectID))
+		if asstInstID <= 0 {
+			continue
+		}
+
+		var instAsstID int64
+		if !isAssociationExists(instassts, report.ObjectID, instID, asst.AsstObjectID, asstInstID) {
+			req := metadata.CreateAssociationInstRequest{
+				ObjectAsstID: asst.ObjectAsstID,
+				InstID:       instID,
+				AsstInstID:   asstInstID,
+			}
+			resp, err := lgc.CoreAPI.TopoServer().Association().CreateInst(context.Background(), header, &req)
 			if err != nil {
-				blog.Errorf("[NetDevice][ConfirmReport] propertyID %s not exist in %#v ", common.GetInstIDField(asst.AsstObjectID), asstInsts[0])
+				blog.Errorf("[NetDevice][ConfirmReport] create inst association error: %v, %+v", err, req)
 				errs = append(errs, err)
 				continue
 			}
-
-			if !isAssociationExists(instassts, report.ObjectID, instID, asst.AsstObjectID, asstInstID) {
-				req := metadata.CreateAssociationInstRequest{
-					ObjectAsstID: asst.ObjectAsstID,
-					InstID:       instID,
-					AsstInstID:   asstInstID,
-				}
-				resp, err := lgc.CoreAPI.TopoServer().Association().CreateInst(context.Background(), header, &req)
-				if err != nil {
-					blog.Errorf("[NetDevice][ConfirmReport] create inst association error: %v, %+v", err, req)
-					errs = append(errs, err)
-					continue
-				}
-				if !resp.Result {
-					blog.Errorf("[NetDevice][ConfirmReport] create inst association error: %v, %+v", resp.ErrMsg, req)
-					errs = append(errs, fmt.Errorf(resp.ErrMsg))
-					continue
-				}
+			if !resp.Result {
+				blog.Errorf("[NetDevice][ConfirmReport] create inst association error: %v, %+v", resp.ErrMsg, req)
+				errs = append(errs, fmt.Errorf(resp.ErrMsg))
+				continue
+			}
+			instAsstID = resp.Data.ID
+		}
+		if asst.LocalPort != "" || asst.RemotePort != "" {
+			if err := lgc.saveLink(header, report, instID, asstInstID, instAsstID, asst); err != nil {
+				errs = append(errs, err)
+				continue
 			}
-			successCount++
 		}
+		successCount++
 	}
 	return successCount, errs
 }
 
+// findAsstInstID the id of the associated instance, 0 if it's not found
+func (lgc *Logics) findAsstInstID(header http.Header, report *metadata.NetcollectReport, asst metadata.NetcollectReportAssociation) (int64, error) {
+	if asst.AsstInstID > 0 {
+		return asst.AsstInstID, nil
+	}
+
+	asstObjType := common.GetObjByType(asst.AsstObjectID)
+	asstCond := condition.CreateCondition()
+	if asstObjType == common.BKInnerObjIDObject {
+		asstCond.Field(common.GetInstNameField(asst.AsstObjectID)).Eq(asst.AsstInstName)
+		asstCond.Field(common.BKObjIDField).Eq(asst.AsstObjectID)
+	}
+	if asstObjType == common.BKInnerObjIDHost {
+		asstCond.Field(common.BKCloudIDField).Eq(report.CloudID)
+		asstCond.Field(common.BKHostInnerIPField).Eq(asst.AsstInstName)
+	}
+	asstInsts, err := lgc.findInst(header, asst.AsstObjectID, &metadata.QueryCondition{Condition: asstCond.ToMapStr()})
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find inst by %+v failed %v", asstCond.ToMapStr(), err)
+		return 0, err
+	}
+	blog.V(4).Infof("[NetDevice][ConfirmReport] find inst result: %#v, condition: %#v", asstInsts, asstCond.ToMapStr())
+	if len(asstInsts) <= 0 {
+		return 0, nil
+	}
+	asstInstID, err := asstInsts[0].Int64(common.GetInstIDField(asst.AsstObjectID))
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] propertyID %s not exist in %#v ", common.GetInstIDField(asst.AsstObjectID), asstInsts[0])
+		return 0, err
+	}
+	return asstInstID, nil
+}
+
+// saveLink save the ports of the link discovered from the neighbor table. instAsstID is the
+// association the link creates, the link inherits it from the other links of the two instances
+// if the association already exists, so that only the association created by the links is removed.
+func (lgc *Logics) saveLink(header http.Header, report *metadata.NetcollectReport, instID, asstInstID, instAsstID int64, asst metadata.NetcollectReportAssociation) error {
+	pairCond := map[string]interface{}{
+		common.BKObjIDField:      report.ObjectID,
+		common.BKInstIDField:     instID,
+		common.BKAsstObjIDField:  asst.AsstObjectID,
+		common.BKAsstInstIDField: asstInstID,
+	}
+	pairLinks := make([]metadata.NetcollectLink, 0)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectLink).Find(pairCond).All(lgc.ctx, &pairLinks); err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find link by %+v failed: %v", pairCond, err)
+		return err
+	}
+	exists := false
+	for _, pairLink := range pairLinks {
+		if instAsstID == 0 {
+			instAsstID = pairLink.InstAsstID
+		}
+		if pairLink.LocalPort == asst.LocalPort && pairLink.RemotePort == asst.RemotePort {
+			exists = true
+		}
+	}
+
+	link := metadata.NetcollectLink{
+		ObjectID:     report.ObjectID,
+		InstID:       instID,
+		LocalPort:    asst.LocalPort,
+		AsstObjectID: asst.AsstObjectID,
+		AsstInstID:   asstInstID,
+		RemotePort:   asst.RemotePort,
+		Protocol:     asst.Protocol,
+		ObjectAsstID: asst.ObjectAsstID,
+		InstAsstID:   instAsstID,
+		OwnerID:      util.GetOwnerID(header),
+		LastTime:     metadata.Now(),
+	}
+	var err error
+	if exists {
+		cond := map[string]interface{}{
+			common.BKObjIDField:      link.ObjectID,
+			common.BKInstIDField:     link.InstID,
+			"local_port":             link.LocalPort,
+			common.BKAsstObjIDField:  link.AsstObjectID,
+			common.BKAsstInstIDField: link.AsstInstID,
+			"remote_port":            link.RemotePort,
+		}
+		err = lgc.Instance.Table(common.BKTableNameNetcollectLink).Update(lgc.ctx, cond, link)
+	} else {
+		err = lgc.Instance.Table(common.BKTableNameNetcollectLink).Insert(lgc.ctx, link)
+	}
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] save link %+v failed: %v", link, err)
+	}
+	return err
+}
+
+// removeLink remove the link no longer reported, the association created by the links is deleted
+// when no other link between the two instances remains, the association made by others is kept
+func (lgc *Logics) removeLink(header http.Header, report *metadata.NetcollectReport, instID int64, instassts []*metadata.InstAsst, asst metadata.NetcollectReportAssociation) error {
+	cond := map[string]interface{}{
+		common.BKObjIDField:      report.ObjectID,
+		common.BKInstIDField:     instID,
+		"local_port":             asst.LocalPort,
+		common.BKAsstObjIDField:  asst.AsstObjectID,
+		common.BKAsstInstIDField: asst.AsstInstID,
+		"remote_port":            asst.RemotePort,
+	}
+	links := make([]metadata.NetcollectLink, 0)
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectLink).Find(cond).All(lgc.ctx, &links); err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] find link by %+v failed: %v", cond, err)
+		return err
+	}
+	if err := lgc.Instance.Table(common.BKTableNameNetcollectLink).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] delete link by %+v failed: %v", cond, err)
+		return err
+	}
+	var instAsstID int64
+	for _, link := range links {
+		if link.InstAsstID > 0 {
+			instAsstID = link.InstAsstID
+		}
+	}
+	if instAsstID == 0 {
+		return nil
+	}
+
+	otherCond := map[string]interface{}{
+		common.BKObjIDField:      report.ObjectID,
+		common.BKInstIDField:     instID,
+		common.BKAsstObjIDField:  asst.AsstObjectID,
+		common.BKAsstInstIDField: asst.AsstInstID,
+	}
+	cnt, err := lgc.Instance.Table(common.BKTableNameNetcollectLink).Find(otherCond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[NetDevice][ConfirmReport] count link by %+v failed: %v", otherCond, err)
+		return err
+	}
+	if cnt > 0 {
+		return nil
+	}
+
+	for _, instasst := range instassts {
+		// the association may have been deleted by hand
+		if instasst.ID != instAsstID {
+			continue
+		}
+		resp, err := lgc.CoreAPI.TopoServer().Association().DeleteInst(context.Background(), header, instasst.ID)
+		if err != nil {
+			blog.Errorf("[NetDevice][ConfirmReport] delete inst association %d error: %v", instasst.ID, err)
+			return err
+		}
+		if !resp.Result {
+			blog.Errorf("[NetDevice][ConfirmReport] delete inst association %d error: %v", instasst.ID, resp.ErrMsg)
+			return errors.New(resp.ErrMsg)
+		}
+	}
+	return nil
+}
+
 func isAssociationExists(assts []*metadata.InstAsst, objectID string, instID int64, asstObjectID string, asstInstID int64) bool {
 	for _, asst := range assts {
 		if asst.ObjectID == objectID &&
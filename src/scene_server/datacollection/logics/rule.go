@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateNetcollectRule create the auto confirm rule of the object attribute, or all the attributes of the object
+// when the property id is empty
+func (lgc *Logics) CreateNetcollectRule(pheader http.Header, rule metadata.NetcollectConfirmRule) (*metadata.NetcollectConfirmRule, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	objects, err := lgc.findObjectIn(pheader, rule.ObjectID)
+	if err != nil {
+		blog.Errorf("[NetDevice][CreateNetcollectRule] find object %s failed, err: %v", rule.ObjectID, err)
+		return nil, defErr.Error(common.CCErrCollectNetRuleCreateFail)
+	}
+	if len(objects) == 0 {
+		return nil, defErr.Errorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+	if rule.PropertyID != "" {
+		attrsMap, err := lgc.findAttrsMap(pheader, rule.ObjectID)
+		if err != nil {
+			blog.Errorf("[NetDevice][CreateNetcollectRule] find attributes of %s failed, err: %v", rule.ObjectID, err)
+			return nil, defErr.Error(common.CCErrCollectNetRuleCreateFail)
+		}
+		if _, ok := attrsMap[attrMapKey(rule.ObjectID, rule.PropertyID)]; !ok {
+			return nil, defErr.Errorf(common.CCErrCommParamsInvalid, common.BKPropertyIDField)
+		}
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField:      rule.ObjectID,
+		common.BKPropertyIDField: rule.PropertyID,
+		common.BKOwnerIDField:    ownerID,
+	}
+	cnt, err := lgc.Instance.Table(common.BKTableNameNetcollectRule).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[NetDevice][CreateNetcollectRule] count rule by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectNetRuleCreateFail)
+	}
+	if cnt > 0 {
+		return nil, defErr.Errorf(common.CCErrCommDuplicateItem, rule.ObjectID+" "+rule.PropertyID)
+	}
+
+	id, err := lgc.Instance.NextSequence(lgc.ctx, common.BKTableNameNetcollectRule)
+	if err != nil {
+		blog.Errorf("[NetDevice][CreateNetcollectRule] get next sequence failed, err: %v", err)
+		return nil, defErr.Error(common.CCErrCollectNetRuleCreateFail)
+	}
+	now := metadata.Now()
+	rule.ID = int64(id)
+	rule.OwnerID = ownerID
+	rule.CreateTime = now
+	rule.LastTime = now
+	if err = lgc.Instance.Table(common.BKTableNameNetcollectRule).Insert(lgc.ctx, rule); err != nil {
+		blog.Errorf("[NetDevice][CreateNetcollectRule] insert rule %+v failed, err: %v", rule, err)
+		return nil, defErr.Error(common.CCErrCollectNetRuleCreateFail)
+	}
+	return &rule, nil
+}
+
+// UpdateNetcollectRule change whether the attribute is confirmed automatically
+func (lgc *Logics) UpdateNetcollectRule(pheader http.Header, id int64, autoConfirm bool) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := util.SetModOwner(map[string]interface{}{"id": id}, util.GetOwnerID(pheader))
+	cnt, err := lgc.Instance.Table(common.BKTableNameNetcollectRule).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[NetDevice][UpdateNetcollectRule] count rule %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectNetRuleUpdateFail)
+	}
+	if cnt == 0 {
+		return defErr.Error(common.CCErrCommNotFound)
+	}
+
+	data := map[string]interface{}{
+		"auto_confirm":       autoConfirm,
+		common.LastTimeField: metadata.Now(),
+	}
+	if err = lgc.Instance.Table(common.BKTableNameNetcollectRule).Update(lgc.ctx, cond, data); err != nil {
+		blog.Errorf("[NetDevice][UpdateNetcollectRule] update rule %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectNetRuleUpdateFail)
+	}
+	return nil
+}
+
+// DeleteNetcollectRule delete the auto confirm rule, the changes of the attribute need review again
+func (lgc *Logics) DeleteNetcollectRule(pheader http.Header, id int64) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := util.SetModOwner(map[string]interface{}{"id": id}, util.GetOwnerID(pheader))
+	cnt, err := lgc.Instance.Table(common.BKTableNameNetcollectRule).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[NetDevice][DeleteNetcollectRule] count rule %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectNetRuleDeleteFail)
+	}
+	if cnt == 0 {
+		return defErr.Error(common.CCErrCommNotFound)
+	}
+
+	if err = lgc.Instance.Table(common.BKTableNameNetcollectRule).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("[NetDevice][DeleteNetcollectRule] delete rule %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectNetRuleDeleteFail)
+	}
+	return nil
+}
+
+// SearchNetcollectRule search the auto confirm rules
+func (lgc *Logics) SearchNetcollectRule(pheader http.Header, params metadata.ParamSearchNetcollectRule) (*metadata.RspNetcollectRule, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := map[string]interface{}{}
+	if params.ObjectID != "" {
+		cond[common.BKObjIDField] = params.ObjectID
+	}
+	cond = util.SetQueryOwner(cond, util.GetOwnerID(pheader))
+
+	count, err := lgc.Instance.Table(common.BKTableNameNetcollectRule).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[NetDevice][SearchNetcollectRule] count rules by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectNetRuleSearchFail)
+	}
+
+	rules := make([]metadata.NetcollectConfirmRule, 0)
+	err = lgc.Instance.Table(common.BKTableNameNetcollectRule).Find(cond).Sort(params.Page.Sort).
+		Start(uint64(params.Page.Start)).Limit(uint64(params.Page.Limit)).All(lgc.ctx, &rules)
+	if err != nil {
+		blog.Errorf("[NetDevice][SearchNetcollectRule] search rules by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectNetRuleSearchFail)
+	}
+	return &metadata.RspNetcollectRule{Count: count, Info: rules}, nil
+}
@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateDiscoverSchemaPolicy create the schema policy of the discovered middleware model
+func (lgc *Logics) CreateDiscoverSchemaPolicy(pheader http.Header, policy metadata.DiscoverSchemaPolicy) (*metadata.DiscoverSchemaPolicy, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	objects, err := lgc.findObjectIn(pheader, policy.ObjectID)
+	if err != nil {
+		blog.Errorf("[Discover][CreateDiscoverSchemaPolicy] find object %s failed, err: %v", policy.ObjectID, err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverPolicyCreateFail)
+	}
+	if len(objects) == 0 {
+		return nil, defErr.Errorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField:   policy.ObjectID,
+		common.BKOwnerIDField: ownerID,
+	}
+	cnt, err := lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[Discover][CreateDiscoverSchemaPolicy] count policy by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverPolicyCreateFail)
+	}
+	if cnt > 0 {
+		return nil, defErr.Errorf(common.CCErrCommDuplicateItem, policy.ObjectID)
+	}
+
+	id, err := lgc.Instance.NextSequence(lgc.ctx, common.BKTableNameDiscoverSchemaPolicy)
+	if err != nil {
+		blog.Errorf("[Discover][CreateDiscoverSchemaPolicy] get next sequence failed, err: %v", err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverPolicyCreateFail)
+	}
+	now := metadata.Now()
+	policy.ID = int64(id)
+	policy.OwnerID = ownerID
+	policy.CreateTime = now
+	policy.LastTime = now
+	if err = lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Insert(lgc.ctx, policy); err != nil {
+		blog.Errorf("[Discover][CreateDiscoverSchemaPolicy] insert policy %+v failed, err: %v", policy, err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverPolicyCreateFail)
+	}
+	return &policy, nil
+}
+
+// UpdateDiscoverSchemaPolicy update how the model evolves, the model of the policy can't be changed
+func (lgc *Logics) UpdateDiscoverSchemaPolicy(pheader http.Header, id int64, policy metadata.DiscoverSchemaPolicy) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := util.SetModOwner(map[string]interface{}{"id": id}, util.GetOwnerID(pheader))
+	cnt, err := lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[Discover][UpdateDiscoverSchemaPolicy] count policy %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectDiscoverPolicyUpdateFail)
+	}
+	if cnt == 0 {
+		return defErr.Error(common.CCErrCommNotFound)
+	}
+
+	data := map[string]interface{}{
+		"allow_unknown_field": policy.AllowUnknownField,
+		"type_conflict":       policy.TypeConflict,
+		"deprecate_days":      policy.DeprecateDays,
+		common.LastTimeField:  metadata.Now(),
+	}
+	if err = lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Update(lgc.ctx, cond, data); err != nil {
+		blog.Errorf("[Discover][UpdateDiscoverSchemaPolicy] update policy %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectDiscoverPolicyUpdateFail)
+	}
+	return nil
+}
+
+// DeleteDiscoverSchemaPolicy delete the schema policy, the model evolves by the default policy again
+func (lgc *Logics) DeleteDiscoverSchemaPolicy(pheader http.Header, id int64) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := util.SetModOwner(map[string]interface{}{"id": id}, util.GetOwnerID(pheader))
+	cnt, err := lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[Discover][DeleteDiscoverSchemaPolicy] count policy %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectDiscoverPolicyDeleteFail)
+	}
+	if cnt == 0 {
+		return defErr.Error(common.CCErrCommNotFound)
+	}
+
+	if err = lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("[Discover][DeleteDiscoverSchemaPolicy] delete policy %d failed, err: %v", id, err)
+		return defErr.Error(common.CCErrCollectDiscoverPolicyDeleteFail)
+	}
+	return nil
+}
+
+// SearchDiscoverSchemaPolicy search the schema policies of the discovered models
+func (lgc *Logics) SearchDiscoverSchemaPolicy(pheader http.Header, params metadata.ParamSearchDiscoverSchemaPolicy) (*metadata.RspDiscoverSchemaPolicy, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := map[string]interface{}{}
+	if params.ObjectID != "" {
+		cond[common.BKObjIDField] = params.ObjectID
+	}
+	cond = util.SetQueryOwner(cond, util.GetOwnerID(pheader))
+
+	count, err := lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[Discover][SearchDiscoverSchemaPolicy] count policies by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverPolicySearchFail)
+	}
+
+	policies := make([]metadata.DiscoverSchemaPolicy, 0)
+	err = lgc.Instance.Table(common.BKTableNameDiscoverSchemaPolicy).Find(cond).Sort(params.Page.Sort).
+		Start(uint64(params.Page.Start)).Limit(uint64(params.Page.Limit)).All(lgc.ctx, &policies)
+	if err != nil {
+		blog.Errorf("[Discover][SearchDiscoverSchemaPolicy] search policies by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverPolicySearchFail)
+	}
+	return &metadata.RspDiscoverSchemaPolicy{Count: count, Info: policies}, nil
+}
+
+// SearchDiscoverSchemaDrift search the schema drifts between the discovered data and the models,
+// the latest found ones first by default
+func (lgc *Logics) SearchDiscoverSchemaDrift(pheader http.Header, params metadata.ParamSearchDiscoverSchemaDrift) (*metadata.RspDiscoverSchemaDrift, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := map[string]interface{}{}
+	if params.ObjectID != "" {
+		cond[common.BKObjIDField] = params.ObjectID
+	}
+	if params.Kind != "" {
+		cond["kind"] = params.Kind
+	}
+	cond = util.SetQueryOwner(cond, util.GetOwnerID(pheader))
+
+	count, err := lgc.Instance.Table(common.BKTableNameDiscoverSchemaDrift).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[Discover][SearchDiscoverSchemaDrift] count drifts by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverDriftSearchFail)
+	}
+
+	sort := params.Page.Sort
+	if sort == "" {
+		sort = "-" + common.LastTimeField
+	}
+	drifts := make([]metadata.DiscoverSchemaDrift, 0)
+	err = lgc.Instance.Table(common.BKTableNameDiscoverSchemaDrift).Find(cond).Sort(sort).
+		Start(uint64(params.Page.Start)).Limit(uint64(params.Page.Limit)).All(lgc.ctx, &drifts)
+	if err != nil {
+		blog.Errorf("[Discover][SearchDiscoverSchemaDrift] search drifts by %+v failed, err: %v", cond, err)
+		return nil, defErr.Error(common.CCErrCollectDiscoverDriftSearchFail)
+	}
+	return &metadata.RspDiscoverSchemaDrift{Count: count, Info: drifts}, nil
+}
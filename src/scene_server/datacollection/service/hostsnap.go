@@ -0,0 +1,204 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	restful "github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateHostSnapMapping create the mapping from host snapshot to host attribute
+func (s *Service) CreateHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	mapping := meta.HostSnapMapping{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&mapping); nil != err {
+		blog.Errorf("[HostSnap] create mapping failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := mapping.Validate(); nil != err {
+		blog.Errorf("[HostSnap] create mapping failed, invalid mapping: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	result, err := s.Logics.CreateHostSnapMapping(pheader, mapping)
+	if nil != err {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// UpdateHostSnapMapping update the host snapshot mapping
+func (s *Service) UpdateHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	id, err := checkHostSnapMappingIDPathParam(defErr, req.PathParameter("id"))
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	mapping := meta.HostSnapMapping{}
+	if err = json.NewDecoder(req.Request.Body).Decode(&mapping); nil != err {
+		blog.Errorf("[HostSnap] update mapping failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err = mapping.Validate(); nil != err {
+		blog.Errorf("[HostSnap] update mapping %d failed, invalid mapping: %v", id, err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	if err = s.Logics.UpdateHostSnapMapping(pheader, id, mapping); nil != err {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// DeleteHostSnapMapping delete the host snapshot mapping
+func (s *Service) DeleteHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	id, err := checkHostSnapMappingIDPathParam(defErr, req.PathParameter("id"))
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	if err = s.Logics.DeleteHostSnapMapping(pheader, id); nil != err {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchHostSnapMapping search the host snapshot mappings
+func (s *Service) SearchHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cond := meta.QueryCondition{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&cond); nil != err {
+		blog.Errorf("[HostSnap] search mapping failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchHostSnapMapping(pheader, cond)
+	if nil != err {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// TestHostSnapMapping get the values of the mappings from the sample snapshot
+func (s *Service) TestHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	params := meta.HostSnapMappingTestParams{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&params); nil != err {
+		blog.Errorf("[HostSnap] test mapping failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if nil == params.Snapshot {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "snapshot")})
+		return
+	}
+
+	result, err := s.Logics.TestHostSnapMapping(pheader, params)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+func checkHostSnapMappingIDPathParam(defErr errors.DefaultCCErrorIf, ID string) (int64, error) {
+	id, err := strconv.ParseInt(ID, 10, 64)
+	if nil != err || id <= 0 {
+		blog.Errorf("[HostSnap] invalid mapping id [%s]", ID)
+		return 0, defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKFieldID)
+	}
+	return id, nil
+}
+
+// SearchHostHardwareHistory search the hardware change timeline of the host
+func (s *Service) SearchHostHardwareHistory(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	params := meta.HostHardwareHistoryParams{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&params); nil != err {
+		blog.Errorf("[HostSnap] search hardware history failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if params.HostID <= 0 {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, common.BKHostIDField)})
+		return
+	}
+
+	result, err := s.Logics.SearchHostHardwareHistory(pheader, params)
+	if nil != err {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// SearchStaleHost search the hosts whose agent stops reporting snapshot
+func (s *Service) SearchStaleHost(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	params := meta.StaleHostParams{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&params); nil != err {
+		blog.Errorf("[HostSnap] search stale hosts failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchStaleHost(pheader, params)
+	if nil != err {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
@@ -0,0 +1,56 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/datacollection"
+
+	"github.com/emicklei/go-restful"
+)
+
+// ReplayMessages feeds the recorded messages of request body, one message per line, through the named analyzer,
+// with dry_run=true the writes each message would cause are reported instead of being applied
+func (s *Service) ReplayMessages(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	dryRun := false
+	if value := req.QueryParameter("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "dry_run")})
+			return
+		}
+	}
+
+	name := req.PathParameter("name")
+	result, err := s.replayer.Replay(name, req.Request.Body, dryRun)
+	if err == datacollection.ErrUnknownAnalyzer {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "name")})
+		return
+	}
+	if err != nil {
+		blog.Errorf("[Replay] replay messages through %s failed, err: %v", name, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCollectReplayFail)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -0,0 +1,140 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CreateNetcollectRule create the auto confirm rule of the netcollect reports
+func (s *Service) CreateNetcollectRule(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	rule := metadata.NetcollectConfirmRule{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&rule); err != nil {
+		blog.Errorf("[NetDevice][CreateNetcollectRule] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if rule.ObjectID == "" {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)})
+		return
+	}
+
+	result, err := s.Logics.CreateNetcollectRule(pheader, rule)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// UpdateNetcollectRule update whether the changes are confirmed automatically
+func (s *Service) UpdateNetcollectRule(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("[NetDevice][UpdateNetcollectRule] invalid rule id [%s]", req.PathParameter("id"))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKFieldID)})
+		return
+	}
+
+	rule := metadata.NetcollectConfirmRule{}
+	if err = json.NewDecoder(req.Request.Body).Decode(&rule); err != nil {
+		blog.Errorf("[NetDevice][UpdateNetcollectRule] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err = s.Logics.UpdateNetcollectRule(pheader, id, rule.AutoConfirm); err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// DeleteNetcollectRule delete the auto confirm rule of the netcollect reports
+func (s *Service) DeleteNetcollectRule(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("[NetDevice][DeleteNetcollectRule] invalid rule id [%s]", req.PathParameter("id"))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKFieldID)})
+		return
+	}
+
+	if err = s.Logics.DeleteNetcollectRule(pheader, id); err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// SearchNetcollectRule search the auto confirm rules of the netcollect reports
+func (s *Service) SearchNetcollectRule(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	params := metadata.ParamSearchNetcollectRule{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&params); err != nil {
+		blog.Errorf("[NetDevice][SearchNetcollectRule] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchNetcollectRule(pheader, params)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// SearchNetcollectLink search the links discovered from the neighbor tables
+func (s *Service) SearchNetcollectLink(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	params := metadata.ParamSearchNetcollectLink{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&params); err != nil {
+		blog.Errorf("[NetDevice][SearchNetcollectLink] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchNetcollectLink(pheader, params)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
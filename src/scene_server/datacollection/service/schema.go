@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CreateDiscoverSchemaPolicy create the schema policy of the discovered middleware model
+func (s *Service) CreateDiscoverSchemaPolicy(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	policy := metadata.DiscoverSchemaPolicy{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&policy); err != nil {
+		blog.Errorf("[Discover][CreateDiscoverSchemaPolicy] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if policy.ObjectID == "" {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)})
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		blog.Errorf("[Discover][CreateDiscoverSchemaPolicy] invalid policy: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	result, err := s.Logics.CreateDiscoverSchemaPolicy(pheader, policy)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// UpdateDiscoverSchemaPolicy update how the discovered middleware model evolves
+func (s *Service) UpdateDiscoverSchemaPolicy(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("[Discover][UpdateDiscoverSchemaPolicy] invalid policy id [%s]", req.PathParameter("id"))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKFieldID)})
+		return
+	}
+
+	policy := metadata.DiscoverSchemaPolicy{}
+	if err = json.NewDecoder(req.Request.Body).Decode(&policy); err != nil {
+		blog.Errorf("[Discover][UpdateDiscoverSchemaPolicy] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err = policy.Validate(); err != nil {
+		blog.Errorf("[Discover][UpdateDiscoverSchemaPolicy] invalid policy: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	if err = s.Logics.UpdateDiscoverSchemaPolicy(pheader, id, policy); err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// DeleteDiscoverSchemaPolicy delete the schema policy of the discovered middleware model
+func (s *Service) DeleteDiscoverSchemaPolicy(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("[Discover][DeleteDiscoverSchemaPolicy] invalid policy id [%s]", req.PathParameter("id"))
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKFieldID)})
+		return
+	}
+
+	if err = s.Logics.DeleteDiscoverSchemaPolicy(pheader, id); err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// SearchDiscoverSchemaPolicy search the schema policies of the discovered middleware models
+func (s *Service) SearchDiscoverSchemaPolicy(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	params := metadata.ParamSearchDiscoverSchemaPolicy{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&params); err != nil {
+		blog.Errorf("[Discover][SearchDiscoverSchemaPolicy] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchDiscoverSchemaPolicy(pheader, params)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// SearchDiscoverSchemaDrift search the schema drifts between the discovered data and the models
+func (s *Service) SearchDiscoverSchemaDrift(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	params := metadata.ParamSearchDiscoverSchemaDrift{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&params); err != nil {
+		blog.Errorf("[Discover][SearchDiscoverSchemaDrift] decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchDiscoverSchemaDrift(pheader, params)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
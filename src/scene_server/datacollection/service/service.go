@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/metric"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
+	"configcenter/src/scene_server/datacollection/datacollection"
 	"configcenter/src/scene_server/datacollection/logics"
 	"configcenter/src/storage/dal"
 
@@ -32,6 +33,7 @@ type Service struct {
 	db    dal.RDB
 	cache *redis.Client
 	*logics.Logics
+	replayer *datacollection.Replayer
 }
 
 func (s *Service) SetDB(db dal.RDB) {
@@ -42,6 +44,10 @@ func (s *Service) SetCache(db *redis.Client) {
 	s.cache = db
 }
 
+func (s *Service) SetReplayer(replayer *datacollection.Replayer) {
+	s.replayer = replayer
+}
+
 func (s *Service) WebService() *restful.Container {
 
 	container := restful.NewContainer()
@@ -70,10 +76,33 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/report/action/confirm").To(s.ConfirmReport))
 	api.Route(api.POST("/netcollect/history/action/search").To(s.SearchHistory))
 
+	api.Route(api.POST("/netcollect/rule/action/create").To(s.CreateNetcollectRule))
+	api.Route(api.POST("/netcollect/rule/{id}/action/update").To(s.UpdateNetcollectRule))
+	api.Route(api.DELETE("/netcollect/rule/{id}/action/delete").To(s.DeleteNetcollectRule))
+	api.Route(api.POST("/netcollect/rule/action/search").To(s.SearchNetcollectRule))
+	api.Route(api.POST("/netcollect/link/action/search").To(s.SearchNetcollectLink))
+
 	api.Route(api.POST("/netcollect/collector/action/search").To(s.SearchCollector))
 	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	api.Route(api.POST("/discover/schema/policy/action/create").To(s.CreateDiscoverSchemaPolicy))
+	api.Route(api.POST("/discover/schema/policy/{id}/action/update").To(s.UpdateDiscoverSchemaPolicy))
+	api.Route(api.DELETE("/discover/schema/policy/{id}/action/delete").To(s.DeleteDiscoverSchemaPolicy))
+	api.Route(api.POST("/discover/schema/policy/action/search").To(s.SearchDiscoverSchemaPolicy))
+	api.Route(api.POST("/discover/schema/drift/action/search").To(s.SearchDiscoverSchemaDrift))
+
+	api.Route(api.POST("/porter/stream/action/search").To(s.SearchStreamPorterMetrics))
+	api.Route(api.POST("/porter/{name}/action/replay").To(s.ReplayMessages))
+
+	api.Route(api.POST("/hostsnap/mapping/action/create").To(s.CreateHostSnapMapping))
+	api.Route(api.POST("/hostsnap/mapping/{id}/action/update").To(s.UpdateHostSnapMapping))
+	api.Route(api.DELETE("/hostsnap/mapping/{id}/action/delete").To(s.DeleteHostSnapMapping))
+	api.Route(api.POST("/hostsnap/mapping/action/search").To(s.SearchHostSnapMapping))
+	api.Route(api.POST("/hostsnap/mapping/action/test").To(s.TestHostSnapMapping))
+	api.Route(api.POST("/hostsnap/hardware/history/action/search").To(s.SearchHostHardwareHistory))
+	api.Route(api.POST("/hostsnap/stale/action/search").To(s.SearchStaleHost))
+
 	container.Add(api)
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
@@ -13,6 +13,9 @@
 package options
 
 import (
+	"time"
+
+	"configcenter/src/apimachinery/util"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/mongo"
@@ -49,4 +52,14 @@ type Config struct {
 	Redis   redis.Config
 	RPC     rpc.ClientConfig
 	Auth    authcenter.AuthConfig
+	// CallbackTLS the client certificate to send the callbacks of subscriptions with tls enabled
+	CallbackTLS util.TLSClientConfig
+	// FeedRetention how long the events of pull subscriptions are kept
+	FeedRetention time.Duration
+	// SinkFileDir the directory of the files the events are appended to, the file sink
+	// is disabled when it's empty.
+	SinkFileDir string
+	// PauseFailures the subscription is paused after so many consecutive failed deliveries,
+	// 0 means never
+	PauseFailures int64
 }
@@ -17,9 +17,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"configcenter/src/apimachinery/util"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
@@ -97,6 +99,13 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		process.Service.SetAuth(authcli)
 		blog.Infof("enable authcenter: %v", process.Config.Auth.Enable)
 
+		if err := distribution.SetCallbackTLS(&process.Config.CallbackTLS); err != nil {
+			return fmt.Errorf("load callback client certificate failed: %v", err)
+		}
+		distribution.SetFeedRetention(process.Config.FeedRetention)
+		distribution.SetDefaultSinks(cache, process.Config.SinkFileDir)
+		distribution.SetPauseThreshold(process.Config.PauseFailures)
+
 		go func() {
 			errCh <- distribution.SubscribeChannel(subcli)
 		}()
@@ -146,6 +155,35 @@ func (h *EventServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 
 		h.Config.RPC.Address = current.ConfigMap["rpc.address"]
 
+		h.Config.CallbackTLS = util.TLSClientConfig{
+			CAFile:   current.ConfigMap["callback.ca_file"],
+			CertFile: current.ConfigMap["callback.cert_file"],
+			KeyFile:  current.ConfigMap["callback.key_file"],
+			Password: current.ConfigMap["callback.password"],
+		}
+
+		h.Config.FeedRetention = 0
+		if retention, ok := current.ConfigMap["feed.retention_hours"]; ok && retention != "" {
+			hours, err := strconv.Atoi(retention)
+			if err != nil || hours <= 0 {
+				blog.Warnf("invalid feed.retention_hours %s, use the default", retention)
+			} else {
+				h.Config.FeedRetention = time.Duration(hours) * time.Hour
+			}
+		}
+
+		h.Config.SinkFileDir = current.ConfigMap["sink.file_dir"]
+
+		h.Config.PauseFailures = 0
+		if failures, ok := current.ConfigMap["subscription.pause_failures"]; ok && failures != "" {
+			n, err := strconv.ParseInt(failures, 10, 64)
+			if err != nil || n < 0 {
+				blog.Warnf("invalid subscription.pause_failures %s, never pause the subscriptions", failures)
+			} else {
+				h.Config.PauseFailures = n
+			}
+		}
+
 		h.Config.Auth, err = authcenter.ParseConfigFromKV("auth", current.ConfigMap)
 		if err != nil {
 			blog.Warnf("parse authcenter config failed: %v", err)
@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+// batchPollInterval the wait between the polls of queue while gathering a batch
+var batchPollInterval = 100 * time.Millisecond
+
+// collectDistBatch gather the events after the first one until the batch is full or the wait
+// is over. only the consecutive events are gathered, the others are put back to the queue, so
+// that the order keeps right when the queue is consumed by the other servers too.
+func (dh *DistHandler) collectDistBatch(sub *metadata.Subscription, first *metadata.DistInstCtx) []*metadata.DistInstCtx {
+	dists := []*metadata.DistInstCtx{first}
+	queue := types.EventCacheDistQueuePrefix + fmt.Sprint(sub.SubscriptionID)
+	deadline := time.Now().Add(sub.GetBatchWait())
+	for int64(len(dists)) < sub.GetBatchSize() && time.Now().Before(deadline) {
+		raw, err := dh.cache.LPop(queue).Result()
+		if err != nil {
+			// the queue is empty
+			time.Sleep(batchPollInterval)
+			continue
+		}
+		dist := parseDistInst(raw)
+		if dist == nil {
+			continue
+		}
+		if dist.DstbID != dists[len(dists)-1].DstbID+1 {
+			if err := dh.cache.LPush(queue, raw).Err(); err != nil {
+				blog.Errorf("put event %d back to queue of subscription %d failed, send it in batch, err: %v", dist.DstbID, sub.SubscriptionID, err)
+				dists = append(dists, dist)
+			}
+			break
+		}
+		dists = append(dists, dist)
+	}
+	return dists
+}
+
+// joinDistBatch join the events into a json array
+func joinDistBatch(dists []*metadata.DistInstCtx) string {
+	raws := make([]string, 0, len(dists))
+	for _, dist := range dists {
+		raws = append(raws, dist.Raw)
+	}
+	return "[" + strings.Join(raws, ",") + "]"
+}
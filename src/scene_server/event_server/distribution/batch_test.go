@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestJoinDistBatch(t *testing.T) {
+	dists := make([]*metadata.DistInstCtx, 0)
+	for id := int64(1); id <= 3; id++ {
+		dist := metadata.DistInst{DstbID: id, SubscriptionID: 1}
+		raw, _ := json.Marshal(dist)
+		dists = append(dists, &metadata.DistInstCtx{DistInst: dist, Raw: string(raw)})
+	}
+
+	batch := make([]metadata.DistInst, 0)
+	if err := json.Unmarshal([]byte(joinDistBatch(dists)), &batch); err != nil {
+		t.Fatalf("the batch is not a json array, err: %v", err)
+	}
+	if len(batch) != 3 {
+		t.Fatalf("expect 3 events in batch, got %d", len(batch))
+	}
+	for i, dist := range batch {
+		if dist.DstbID != int64(i+1) {
+			t.Errorf("expect event %d at %d, got %d", i+1, i, dist.DstbID)
+		}
+	}
+}
+
+func TestSubscriptionBatch(t *testing.T) {
+	sub := metadata.Subscription{}
+	if sub.IsBatch() {
+		t.Errorf("batch should be disabled by default")
+	}
+	if sub.GetBatchWait() != time.Second {
+		t.Errorf("expect default batch wait 1s, got %v", sub.GetBatchWait())
+	}
+
+	sub = metadata.Subscription{BatchSize: 2000, BatchWait: 120}
+	if !sub.IsBatch() || sub.GetBatchSize() != metadata.MaxEventBatchSize {
+		t.Errorf("expect batch size %d, got %d", metadata.MaxEventBatchSize, sub.GetBatchSize())
+	}
+	if sub.GetBatchWait() != metadata.MaxEventBatchWait*time.Second {
+		t.Errorf("expect max batch wait, got %v", sub.GetBatchWait())
+	}
+
+	sub.DeliveryMode = metadata.DeliveryModePull
+	if sub.IsBatch() {
+		t.Errorf("pull subscription should not be delivered in batch")
+	}
+}
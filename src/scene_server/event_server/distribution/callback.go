@@ -21,22 +21,29 @@ import (
 	"strconv"
 	"time"
 
-	redis "gopkg.in/redis.v5"
-
-	"configcenter/src/common/blog"
+	"configcenter/src/apimachinery/util"
+	"configcenter/src/common"
 	"configcenter/src/common/http/httpclient"
 	"configcenter/src/common/metadata"
-	"configcenter/src/scene_server/event_server/types"
 )
 
-func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event string) (err error) {
-	increaseTotal(dh.cache, receiver.SubscriptionID)
-
-	body := bytes.NewBufferString(event)
-	req, err := http.NewRequest("POST", receiver.CallbackURL, body)
+// sendCallback post the message to the callback url of subscription, it's signed when the
+// subscription has secret key.
+func sendCallback(receiver *metadata.Subscription, msg *SinkMessage) (err error) {
+	req, err := http.NewRequest("POST", receiver.CallbackURL, bytes.NewReader(msg.Body))
 	if err != nil {
-		increaseFailue(dh.cache, receiver.SubscriptionID)
-		return fmt.Errorf("event distribute fail, build request error: %v, date=[%s]", err, event)
+		return fmt.Errorf("event distribute fail, build request error: %v, date=[%s]", err, msg.Body)
+	}
+	for key, values := range msg.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	// every attempt is signed again, so the timestamp is always fresh
+	timestamp := time.Now().Unix()
+	req.Header.Set(common.BKHTTPEventTimestamp, strconv.FormatInt(timestamp, 10))
+	if receiver.SecretKey != "" {
+		req.Header.Set(common.BKHTTPEventSignature, metadata.SignEventCallback(receiver.SecretKey, timestamp, msg.Body))
 	}
 	var duration time.Duration
 	if receiver.TimeOut == 0 {
@@ -44,21 +51,25 @@ func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event strin
 	} else {
 		duration = receiver.GetTimeout()
 	}
-	resp, err := httpCli.DoWithTimeout(duration, req)
+	cli := httpCli
+	if receiver.TLSEnable {
+		if tlsHttpCli == nil {
+			return fmt.Errorf("event distribute fail, client certificate of callback is not configured, date=[%s]", msg.Body)
+		}
+		cli = tlsHttpCli
+	}
+	resp, err := cli.DoWithTimeout(duration, req)
 	if err != nil {
-		increaseFailue(dh.cache, receiver.SubscriptionID)
-		return fmt.Errorf("event distribute fail, send request error: %v, date=[%s]", err, event)
+		return fmt.Errorf("event distribute fail, send request error: %v, date=[%s]", err, msg.Body)
 	}
 	defer resp.Body.Close()
 	respdata, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		increaseFailue(dh.cache, receiver.SubscriptionID)
-		return fmt.Errorf("event distribute fail, read response error: %v, date=[%s]", err, event)
+		return fmt.Errorf("event distribute fail, read response error: %v, date=[%s]", err, msg.Body)
 	}
 	if receiver.ConfirmMode == metadata.ConfirmmodeHttpstatus {
 		if strconv.Itoa(resp.StatusCode) != receiver.ConfirmPattern {
-			increaseFailue(dh.cache, receiver.SubscriptionID)
-			return fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, event)
+			return fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, msg.Body)
 		}
 	} else if receiver.ConfirmMode == metadata.ConfirmmodeRegular {
 		pattern, err := regexp.Compile(receiver.ConfirmPattern)
@@ -66,8 +77,7 @@ func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event strin
 			return fmt.Errorf("event distribute fail, build regexp error: %v", err)
 		}
 		if !pattern.Match(respdata) {
-			increaseFailue(dh.cache, receiver.SubscriptionID)
-			return fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, event)
+			return fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, msg.Body)
 		}
 		return nil
 	}
@@ -77,18 +87,20 @@ func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event strin
 
 var httpCli = httpclient.NewHttpClient()
 
-func increaseTotal(cache *redis.Client, subscriptionID int64) error {
-	return increase(cache, subscriptionID, "total")
-}
-
-func increaseFailue(cache *redis.Client, subscriptionID int64) error {
-	return increase(cache, subscriptionID, "failue")
-}
+// tlsHttpCli sends the callbacks of subscriptions with tls enabled, it's nil when
+// the client certificate is not configured.
+var tlsHttpCli *httpclient.HttpClient
 
-func increase(cache *redis.Client, subscriptionID int64, key string) error {
-	err := cache.HIncrBy(types.EventCacheDistCallBackCountPrefix+strconv.FormatInt(subscriptionID, 10), key, 1).Err()
-	if err != nil {
-		blog.V(3).Infof("increaseFailue %s", err.Error())
+// SetCallbackTLS load the client certificate used to send the callbacks with mutual tls
+func SetCallbackTLS(conf *util.TLSClientConfig) error {
+	if conf == nil || conf.CertFile == "" || conf.KeyFile == "" || conf.CAFile == "" {
+		tlsHttpCli = nil
+		return nil
+	}
+	cli := httpclient.NewHttpClient()
+	if err := cli.SetTlsVerity(conf.CAFile, conf.CertFile, conf.KeyFile, conf.Password); err != nil {
+		return err
 	}
-	return err
+	tlsHttpCli = cli
+	return nil
 }
@@ -0,0 +1,89 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestSignEventCallback(t *testing.T) {
+	body := []byte(`{"event_type":"instdata"}`)
+	signature := metadata.SignEventCallback("secret", 1558000000, body)
+	if !strings.HasPrefix(signature, metadata.EventSignaturePrefix) {
+		t.Errorf("signature %s should have the algorithm prefix", signature)
+	}
+	if !metadata.VerifyEventCallback("secret", 1558000000, body, signature) {
+		t.Errorf("signature %s should be verified", signature)
+	}
+	if metadata.VerifyEventCallback("secret", 1558000001, body, signature) ||
+		metadata.VerifyEventCallback("other", 1558000000, body, signature) ||
+		metadata.VerifyEventCallback("secret", 1558000000, []byte(`{}`), signature) {
+		t.Errorf("signature %s should be bound to the secret, timestamp and body", signature)
+	}
+}
+
+func TestSendCallbackSigned(t *testing.T) {
+	var header http.Header
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		received, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	// the subscriber routines get the subscription from the json published or reconciled
+	sub := metadata.Subscription{
+		SubscriptionID: 1,
+		CallbackURL:    srv.URL,
+		ConfirmMode:    metadata.ConfirmmodeHttpstatus,
+		ConfirmPattern: "200",
+		SecretKey:      "secret",
+	}
+	raw, _ := json.Marshal(&sub)
+	subscriber := metadata.Subscription{}
+	if err := json.Unmarshal(raw, &subscriber); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(subscriber.GetCacheKey(), `"secret"`) {
+		t.Errorf("cache key %s should not contain the secret", subscriber.GetCacheKey())
+	}
+
+	msg := &SinkMessage{Header: http.Header{}, Body: []byte(`{"event_type":"instdata"}`)}
+	if err := sendCallback(&subscriber, msg); err != nil {
+		t.Fatal(err)
+	}
+	timestamp, err := strconv.ParseInt(header.Get(common.BKHTTPEventTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header %s", header.Get(common.BKHTTPEventTimestamp))
+	}
+	if !metadata.VerifyEventCallback("secret", timestamp, received, header.Get(common.BKHTTPEventSignature)) {
+		t.Errorf("callback should be signed with the secret key, signature: %s", header.Get(common.BKHTTPEventSignature))
+	}
+
+	subscriber.SecretKey = ""
+	if err := sendCallback(&subscriber, msg); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get(common.BKHTTPEventSignature) != "" {
+		t.Errorf("callback without secret key should not be signed")
+	}
+}
@@ -85,11 +85,12 @@ func (dh *DistHandler) StartDistribute() (err error) {
 			mesgBody := getChangeBody(mesg)
 
 			subscriber := metadata.Subscription{}
-			blog.Infof("mesg: action:%s ,body:%s", mesgAction, mesgBody)
 			if err := json.Unmarshal([]byte(mesgBody), &subscriber); err != nil {
 				chErr <- err
 				return
 			}
+			// the body carries the secret key, so it's not logged
+			blog.Infof("mesg: action:%s, subscription: %d", mesgAction, subscriber.SubscriptionID)
 			switch mesgAction {
 			case "create":
 				blog.Infof("starting subscribers process %d", subscriber.SubscriptionID)
@@ -148,9 +149,9 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 		case nsub := <-chNew:
 			if nsub.GetCacheKey() != sub.GetCacheKey() {
 				sub = nsub
-				blog.Infof("refreshed subcriber %v", sub.GetCacheKey())
+				blog.Infof("refreshed subcriber %d", sub.SubscriptionID)
 			} else {
-				blog.Infof("refresh ignore, subcriber cache key not change\nold:%s\nnew:%s ", sub.GetCacheKey(), nsub.GetCacheKey())
+				blog.V(3).Infof("refresh ignore, subcriber %d cache key not change", sub.SubscriptionID)
 			}
 		case <-ticker.C:
 			count, counterr := dh.db.Table(common.BKTableNameSubscription).Find(condition.CreateCondition().Field(common.BKSubscriptionIDField).Eq(sub.SubscriptionID).ToMapStr()).Count(context.Background())
@@ -165,30 +166,83 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 		case <-done:
 			return
 		default:
+			// the events of paused subscription are kept in queue until it's resumed
+			if sub.Paused {
+				time.Sleep(time.Second)
+				continue
+			}
 			dist := dh.popDistInst(sub.SubscriptionID)
 			if dist == nil {
 				continue
 			}
-			if err = dh.handleDist(&sub, dist); err != nil {
+			dists := []*metadata.DistInstCtx{dist}
+			if sub.IsBatch() {
+				dists = dh.collectDistBatch(&sub, dist)
+			}
+			if err = dh.handleDist(&sub, dists, chNew, done); err != nil {
 				blog.Errorf("error handle dist: %v, %v", err, dist)
 			}
 		}
 	}
 }
 
-func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.DistInstCtx) (err error) {
-	blog.Infof("handling dist %s", dist.Raw)
-	distID := fmt.Sprint(dist.DstbID - 1)
-	subscriberID := fmt.Sprint(dist.SubscriptionID)
-	runningkey := types.EventCacheDistRunningPrefix + subscriberID + "_" + distID
-	if err = saveRunning(dh.cache, runningkey, timeout+sub.GetTimeout()); err != nil {
-		if ErrProcessExists == err {
-			blog.Infof("process exist, continue")
-			return nil
+// handleDist send the events to the subscriber after the previous one is done, the delivery
+// is retried with backoff, so the subscription may be renewed or deleted while waiting.
+// the events are consecutive, they are sent in one callback in batch mode.
+func (dh *DistHandler) handleDist(sub *metadata.Subscription, dists []*metadata.DistInstCtx, chNew chan metadata.Subscription, stop chan struct{}) (err error) {
+	running := make([]*metadata.DistInstCtx, 0, len(dists))
+	for _, dist := range dists {
+		blog.Infof("handling dist %s", dist.Raw)
+		if err = saveRunning(dh.cache, distRunningKey(dist), timeout+sub.GetTimeout()); err != nil {
+			if ErrProcessExists == err {
+				blog.Infof("process exist, continue")
+				continue
+			}
+			return err
 		}
+		running = append(running, dist)
+	}
+	if len(running) == 0 {
+		return nil
+	}
+	dists = running
+
+	if err = dh.waitPreviousDist(sub, dists[0]); err != nil {
 		return err
 	}
 
+	defer func() {
+		for _, dist := range dists {
+			if err = dh.saveDistDone(dist); err != nil {
+				return
+			}
+			blog.Infof("done event dist : %v", dist.DstbID)
+		}
+	}()
+
+	err = dh.sendCallbackWithRetry(sub, dists, chNew, stop)
+	// the pushed events are kept in feed too, so that they can be replayed
+	if !sub.IsPull() && err != ErrSubscriptionStopped {
+		for _, dist := range dists {
+			if dist.Replay {
+				continue
+			}
+			if saveErr := dh.saveFeedEvent(sub, dist); saveErr != nil {
+				blog.Errorf("save event %d of subscription %d to feed failed, err: %v", dist.DstbID, sub.SubscriptionID, saveErr)
+			}
+		}
+	}
+	if err != nil {
+		blog.Errorf("send callback error: %v", err)
+		return
+	}
+
+	return
+}
+
+// waitPreviousDist wait until the event before the dist is done, or it's not running any more
+func (dh *DistHandler) waitPreviousDist(sub *metadata.Subscription, dist *metadata.DistInstCtx) error {
+	subscriberID := fmt.Sprint(dist.SubscriptionID)
 	priviousID := fmt.Sprint(dist.DstbID - 1)
 	priviousRunningkey := types.EventCacheDistRunningPrefix + subscriberID + "_" + priviousID
 	done, err := checkFromDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, priviousID)
@@ -212,7 +266,18 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 		if running {
 
 			blog.Infof("waitting previous id: " + priviousID)
-			if checkErr = waitPreviousDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, priviousID, sub.GetTimeout()); checkErr != nil && checkErr != ErrWaitTimeout {
+			// the previous one keeps running while it's retried, wait until it's done or stopped
+			for {
+				checkErr = waitPreviousDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, priviousID, sub.GetTimeout())
+				if checkErr != ErrWaitTimeout {
+					break
+				}
+				running, checkErr = checkFromRunning(dh.cache, priviousRunningkey)
+				if checkErr != nil || !running {
+					break
+				}
+			}
+			if checkErr != nil && checkErr != ErrWaitTimeout {
 				return checkErr
 			}
 			if checkErr == ErrWaitTimeout {
@@ -220,20 +285,11 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 			}
 		}
 	}
+	return nil
+}
 
-	defer func() {
-		if err = dh.saveDistDone(dist); err != nil {
-			return
-		}
-		blog.Infof("done event dist : %v", dist.DstbID)
-	}()
-
-	if err = dh.SendCallback(sub, dist.Raw); err != nil {
-		blog.Errorf("send callback error: %v", err)
-		return
-	}
-
-	return
+func distRunningKey(dist *metadata.DistInstCtx) string {
+	return types.EventCacheDistRunningPrefix + fmt.Sprintf("%d_%d", dist.SubscriptionID, dist.DstbID)
 }
 
 func (dh *DistHandler) popDistInst(subID int64) *metadata.DistInstCtx {
@@ -243,21 +299,24 @@ func (dh *DistHandler) popDistInst(subID int64) *metadata.DistInstCtx {
 		return nil
 	}
 
-	eventbytes := []byte(eventslice[1])
+	return parseDistInst(eventslice[1])
+}
+
+func parseDistInst(raw string) *metadata.DistInstCtx {
 	event := metadata.DistInst{}
-	if err := json.Unmarshal(eventbytes, &event); err != nil {
-		blog.Errorf("event distribute fail, unmarshal error: %v, date=[%s]", err, eventbytes)
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		blog.Errorf("event distribute fail, unmarshal error: %v, date=[%s]", err, raw)
 		return nil
 	}
 
-	return &metadata.DistInstCtx{DistInst: event, Raw: eventslice[1]}
+	return &metadata.DistInstCtx{DistInst: event, Raw: raw}
 }
 
 func (dh *DistHandler) saveDistDone(dist *metadata.DistInstCtx) (err error) {
 	if err = dh.cache.HSet(types.EventCacheDistDonePrefix+fmt.Sprint(dist.SubscriptionID), fmt.Sprint(dist.DstbID), dist.Raw).Err(); err != nil {
 		return
 	}
-	if err = dh.cache.Del(types.EventCacheDistRunningPrefix + fmt.Sprintf("%d_%d", dist.SubscriptionID, dist.DstbID)).Err(); err != nil {
+	if err = dh.cache.Del(distRunningKey(dist)).Err(); err != nil {
 		return
 	}
 	return
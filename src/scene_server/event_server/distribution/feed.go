@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// feedRetention the events in feed older than it are cleaned
+var feedRetention = metadata.DefaultEventFeedRetention
+
+// SetFeedRetention set how long the events of pull subscriptions are kept
+func SetFeedRetention(retention time.Duration) {
+	if retention <= 0 {
+		retention = metadata.DefaultEventFeedRetention
+	}
+	feedRetention = retention
+}
+
+// deliver send the events to the sink of subscription, or keep them in the feed for the pull
+// subscription. the events are sent as a json array in batch mode, a single event is sent as it is.
+func (dh *DistHandler) deliver(sub *metadata.Subscription, dists []*metadata.DistInstCtx) error {
+	if sub.IsPull() {
+		for _, dist := range dists {
+			if err := dh.saveFeedEvent(sub, dist); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := time.Now()
+	sink, ok := getSink(sub.GetSinkType())
+	if !ok {
+		err := fmt.Errorf("event distribute fail, sink %s is not supported", sub.GetSinkType())
+		dh.recordDelivery(sub, time.Since(start), err)
+		return err
+	}
+	msg, err := newSinkMessage(sub, dists)
+	if err == nil {
+		err = sink.Send(sub, msg)
+	}
+	dh.recordDelivery(sub, time.Since(start), err)
+	return err
+}
+
+func (dh *DistHandler) saveFeedEvent(sub *metadata.Subscription, dist *metadata.DistInstCtx) error {
+	feed := metadata.EventFeed{
+		SubscriptionID: sub.SubscriptionID,
+		DistributionID: dist.DstbID,
+		ActionTime:     dist.ActionTime,
+		Event:          dist.Raw,
+		OwnerID:        sub.OwnerID,
+		CreateTime:     metadata.Now(),
+	}
+	err := dh.db.Table(common.BKTableNameEventFeed).Insert(dh.ctx, feed)
+	// the event may be saved already by the previous attempt
+	if err != nil && !dh.db.IsDuplicatedError(err) {
+		return err
+	}
+	return nil
+}
+
+func cleanExpiredFeed(ctx context.Context, db dal.RDB) {
+	tick := util.NewTicker(time.Hour)
+	tick.Tick()
+	for range tick.C {
+		expired := time.Now().Add(-feedRetention)
+		condition := map[string]interface{}{
+			common.CreateTimeField: map[string]interface{}{common.BKDBLT: expired},
+		}
+		if err := db.Table(common.BKTableNameEventFeed).Delete(ctx, condition); err != nil {
+			blog.Errorf("clean the feed events before %v failed, err: %v", expired, err)
+			continue
+		}
+		blog.Infof("cleaned the feed events before %v", expired)
+	}
+}
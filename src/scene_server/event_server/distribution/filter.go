@@ -0,0 +1,196 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// ValidateSubscriptionFilter check the condition of subscription can be matched in memory
+func ValidateSubscriptionFilter(sub *metadata.Subscription) error {
+	for _, item := range sub.Condition {
+		if item.Field == "" {
+			return fmt.Errorf("condition field is empty")
+		}
+		switch item.Operator {
+		case common.BKDBEQ, common.BKDBNE, common.BKDBLT, common.BKDBLTE, common.BKDBGT, common.BKDBGTE:
+		case common.BKDBIN, common.BKDBNIN:
+			if item.Value == nil || reflect.TypeOf(item.Value).Kind() != reflect.Slice {
+				return fmt.Errorf("condition value of %s with %s should be array", item.Field, item.Operator)
+			}
+		case common.BKDBLIKE:
+			pattern, ok := item.Value.(string)
+			if !ok {
+				return fmt.Errorf("condition value of %s with %s should be string", item.Field, item.Operator)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("condition value of %s is invalid regular expression, %v", item.Field, err)
+			}
+		default:
+			return fmt.Errorf("condition operator %s of %s is not supported", item.Operator, item.Field)
+		}
+	}
+	return nil
+}
+
+// matchSubscriptionFilter check whether the event should be delivered to the subscription,
+// it's matched when any of the event data matches. the condition is matched with the data
+// after the change, or the data before deletion. update events are matched only when one of
+// the watched fields changed.
+func matchSubscriptionFilter(sub *metadata.Subscription, event *metadata.EventInst) bool {
+	if len(sub.Condition) == 0 && len(sub.WatchFields) == 0 {
+		return true
+	}
+	for _, data := range event.Data {
+		cur, _ := data.CurData.(map[string]interface{})
+		pre, _ := data.PreData.(map[string]interface{})
+		inst := cur
+		if event.Action == metadata.EventActionDelete {
+			inst = pre
+		}
+		if len(sub.Condition) > 0 && (inst == nil || !matchCondition(sub.Condition, inst)) {
+			continue
+		}
+		if event.Action == metadata.EventActionUpdate && len(sub.WatchFields) > 0 && !watchedFieldChanged(sub.WatchFields, pre, cur) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func watchedFieldChanged(fields []string, pre, cur map[string]interface{}) bool {
+	for _, field := range fields {
+		if !equalValue(pre[field], cur[field]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCondition all the condition items should be matched
+func matchCondition(cond []metadata.ConditionItem, inst map[string]interface{}) bool {
+	for _, item := range cond {
+		value, exists := inst[item.Field]
+		if !matchConditionItem(item, value, exists) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchConditionItem(item metadata.ConditionItem, value interface{}, exists bool) bool {
+	switch item.Operator {
+	case common.BKDBEQ:
+		return exists && equalValue(value, item.Value)
+	case common.BKDBNE:
+		return !exists || !equalValue(value, item.Value)
+	case common.BKDBIN:
+		return exists && inValues(value, item.Value)
+	case common.BKDBNIN:
+		return !exists || !inValues(value, item.Value)
+	case common.BKDBLT, common.BKDBLTE, common.BKDBGT, common.BKDBGTE:
+		if !exists {
+			return false
+		}
+		result, ok := compareValue(value, item.Value)
+		if !ok {
+			return false
+		}
+		switch item.Operator {
+		case common.BKDBLT:
+			return result < 0
+		case common.BKDBLTE:
+			return result <= 0
+		case common.BKDBGT:
+			return result > 0
+		default:
+			return result >= 0
+		}
+	case common.BKDBLIKE:
+		str, ok := value.(string)
+		pattern, isStr := item.Value.(string)
+		if !ok || !isStr {
+			return false
+		}
+		matched, err := regexp.MatchString(pattern, str)
+		return err == nil && matched
+	}
+	return false
+}
+
+func inValues(value, values interface{}) bool {
+	list := reflect.ValueOf(values)
+	if list.Kind() != reflect.Slice {
+		return false
+	}
+	for i := 0; i < list.Len(); i++ {
+		if equalValue(value, list.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValue the numbers are equal when they have the same value, no matter the type,
+// because the event data comes from json and the condition may come from db.
+func equalValue(a, b interface{}) bool {
+	if isNumber(a) && isNumber(b) {
+		fa, _ := util.GetFloat64ByInterface(a)
+		fb, _ := util.GetFloat64ByInterface(b)
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValue compare the numbers or the strings, ok is false when they can't be compared
+func compareValue(a, b interface{}) (result int, ok bool) {
+	if isNumber(a) && isNumber(b) {
+		fa, _ := util.GetFloat64ByInterface(a)
+		fb, _ := util.GetFloat64ByInterface(b)
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+	sa, aok := a.(string)
+	sb, bok := b.(string)
+	if !aok || !bok {
+		return 0, false
+	}
+	switch {
+	case sa < sb:
+		return -1, true
+	case sa > sb:
+		return 1, true
+	}
+	return 0, true
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func newTestEvent(t *testing.T, action, pre, cur string) *metadata.EventInst {
+	event := &metadata.EventInst{Action: action, ObjType: common.BKInnerObjIDHost, EventType: metadata.EventTypeInstData}
+	data := metadata.EventData{}
+	if pre != "" {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(pre), &m); err != nil {
+			t.Fatal(err)
+		}
+		data.PreData = m
+	}
+	if cur != "" {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(cur), &m); err != nil {
+			t.Fatal(err)
+		}
+		data.CurData = m
+	}
+	event.Data = []metadata.EventData{data}
+	return event
+}
+
+func TestMatchSubscriptionFilter(t *testing.T) {
+	sub := &metadata.Subscription{
+		Condition: []metadata.ConditionItem{
+			{Field: "env", Operator: common.BKDBEQ, Value: "prod"},
+			{Field: "bk_cloud_id", Operator: common.BKDBIN, Value: []interface{}{int64(0), int64(1)}},
+		},
+		WatchFields: []string{"bk_os_version"},
+	}
+	if err := ValidateSubscriptionFilter(sub); err != nil {
+		t.Fatalf("validate filter failed, %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		event *metadata.EventInst
+		match bool
+	}{
+		{"watched field changed", newTestEvent(t, metadata.EventActionUpdate,
+			`{"env":"prod","bk_cloud_id":0,"bk_os_version":"7.2"}`, `{"env":"prod","bk_cloud_id":0,"bk_os_version":"7.4"}`), true},
+		{"other field changed", newTestEvent(t, metadata.EventActionUpdate,
+			`{"env":"prod","bk_cloud_id":0,"bk_os_version":"7.2","bk_comment":""}`, `{"env":"prod","bk_cloud_id":0,"bk_os_version":"7.2","bk_comment":"x"}`), false},
+		{"condition not matched", newTestEvent(t, metadata.EventActionUpdate,
+			`{"env":"test","bk_cloud_id":0,"bk_os_version":"7.2"}`, `{"env":"test","bk_cloud_id":0,"bk_os_version":"7.4"}`), false},
+		{"created", newTestEvent(t, metadata.EventActionCreate, "", `{"env":"prod","bk_cloud_id":1}`), true},
+		{"deleted with pre data", newTestEvent(t, metadata.EventActionDelete, `{"env":"prod","bk_cloud_id":2}`, ""), false},
+	}
+	for _, c := range cases {
+		if match := matchSubscriptionFilter(sub, c.event); match != c.match {
+			t.Errorf("%s: expect %v, got %v", c.name, c.match, match)
+		}
+	}
+}
+
+func TestValidateSubscriptionFilter(t *testing.T) {
+	invalid := []metadata.ConditionItem{
+		{Field: "env", Operator: "$where", Value: "prod"},
+		{Field: "env", Operator: common.BKDBIN, Value: "prod"},
+		{Field: "env", Operator: common.BKDBLIKE, Value: "("},
+		{Field: "", Operator: common.BKDBEQ, Value: "prod"},
+	}
+	for _, item := range invalid {
+		sub := &metadata.Subscription{Condition: []metadata.ConditionItem{item}}
+		if err := ValidateSubscriptionFilter(sub); err == nil {
+			t.Errorf("condition %+v should be invalid", item)
+		}
+	}
+}
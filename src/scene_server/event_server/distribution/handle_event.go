@@ -30,6 +30,8 @@ import (
 var (
 	timeout    = time.Second * 10
 	waitperiod = time.Second
+	// filterRefreshPeriod the changed filter of subscription takes effect in this period
+	filterRefreshPeriod = time.Second * 10
 )
 
 // Err define
@@ -125,11 +127,15 @@ func (eh *EventHandler) handleInst(event *metadata.EventInstCtx) (err error) {
 		for _, subscriber := range subscribers {
 			var dstbID, subscribeID int64
 			distinst := origindist
-			dstbID, err = eh.nextDistID(subscriber)
+			subscribeID, err = strconv.ParseInt(subscriber, 10, 64)
 			if err != nil {
 				return err
 			}
-			subscribeID, err = strconv.ParseInt(subscriber, 10, 64)
+			if !eh.matchSubscriber(subscribeID, &distinst.EventInst) {
+				blog.V(3).Infof("event %d is filtered out by subscription %d", event.ID, subscribeID)
+				continue
+			}
+			dstbID, err = eh.nextDistID(subscriber)
 			if err != nil {
 				return err
 			}
@@ -143,6 +149,39 @@ func (eh *EventHandler) handleInst(event *metadata.EventInstCtx) (err error) {
 	return
 }
 
+// matchSubscriber check the event with the filter of subscription, the event is delivered
+// when the subscription can't be found, it's the same as before the filter is supported.
+func (eh *EventHandler) matchSubscriber(subscribeID int64, event *metadata.EventInst) bool {
+	sub, ok := eh.filters[subscribeID]
+	// the new subscription may not be loaded yet
+	if (!ok && time.Since(eh.filtersLoadTime) > waitperiod) || time.Since(eh.filtersLoadTime) > filterRefreshPeriod {
+		if err := eh.loadSubscriptionFilters(); err != nil {
+			blog.Errorf("load filters of subscriptions failed, use the loaded ones, err: %v", err)
+		}
+		sub, ok = eh.filters[subscribeID]
+	}
+	if !ok {
+		return true
+	}
+	return matchSubscriptionFilter(&sub, event)
+}
+
+func (eh *EventHandler) loadSubscriptionFilters() error {
+	// keep the load time even if failed, so that db is not queried for every event
+	eh.filtersLoadTime = time.Now()
+	subscriptions := make([]metadata.Subscription, 0)
+	fields := []string{common.BKSubscriptionIDField, "condition", "watch_fields"}
+	if err := eh.db.Table(common.BKTableNameSubscription).Find(nil).Fields(fields...).All(eh.ctx, &subscriptions); err != nil {
+		return err
+	}
+	filters := make(map[int64]metadata.Subscription, len(subscriptions))
+	for _, sub := range subscriptions {
+		filters[sub.SubscriptionID] = sub
+	}
+	eh.filters = filters
+	return nil
+}
+
 func (eh *EventHandler) GetDistInst(e *metadata.EventInst) []metadata.DistInst {
 	distinst := metadata.DistInst{
 		EventInst: *e,
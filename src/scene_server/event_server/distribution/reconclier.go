@@ -14,6 +14,7 @@ package distribution
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -82,7 +83,13 @@ func (r *reconciler) loadAllPersisted() {
 	blog.Infof("loaded %v subscriptions from persistent", len(subscriptions))
 	for _, sub := range subscriptions {
 		eventnames := strings.Split(sub.SubscriptionForm, ",")
-		r.persistedSubscribers = append(r.persistedSubscribers, sub.GetCacheKey())
+		// the subscriber routines are built from it, so the secret key is kept instead of the digest in cache key
+		subscriber, err := json.Marshal(&sub)
+		if err != nil {
+			blog.Errorf("reconcile subscription %d, marshal failed, err: %v", sub.SubscriptionID, err)
+			continue
+		}
+		r.persistedSubscribers = append(r.persistedSubscribers, string(subscriber))
 		for _, eventname := range eventnames {
 			eventname = sub.OwnerID + ":" + eventname
 			r.persisted[eventname] = append(r.persisted[eventname], fmt.Sprint(sub.SubscriptionID))
@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+	"configcenter/src/storage/dal"
+)
+
+// the replayed events are pushed to the queue of subscription in batches, the next batch
+// waits until the queue is short enough, so that the new events are not delayed too long.
+var (
+	replayBatchSize   = 100
+	replayQueueLimit  = int64(100)
+	replayCheckPeriod = time.Second * 5
+	replayLockTimeout = time.Minute
+)
+
+// ReplayHandler run the replay tasks of subscriptions
+type ReplayHandler struct {
+	cache *redis.Client
+	db    dal.RDB
+	ctx   context.Context
+}
+
+// StartReplay check the active replay tasks periodically and run them
+func (rh *ReplayHandler) StartReplay() error {
+	blog.Info("event replay process started")
+	ticker := time.NewTicker(replayCheckPeriod)
+	defer ticker.Stop()
+	for range ticker.C {
+		replays := make([]metadata.EventReplay, 0)
+		condition := map[string]interface{}{
+			"status": map[string]interface{}{
+				common.BKDBIN: []metadata.EventReplayStatus{metadata.EventReplayStatusPending, metadata.EventReplayStatusRunning},
+			},
+		}
+		if err := rh.db.Table(common.BKTableNameEventReplay).Find(condition).All(rh.ctx, &replays); err != nil {
+			blog.Errorf("find the active event replays failed, err: %v", err)
+			continue
+		}
+		for index := range replays {
+			if err := rh.runReplay(&replays[index]); err != nil {
+				blog.Errorf("run event replay %d failed, it's continued later, err: %v", replays[index].ReplayID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// runReplay push the events of the replay to the queue until the queue is long enough,
+// the progress is saved after every batch, so that any event server can continue it.
+func (rh *ReplayHandler) runReplay(replay *metadata.EventReplay) error {
+	hostname, _ := os.Hostname()
+	lockKey := types.EventCacheReplayLockPrefix + fmt.Sprint(replay.ReplayID)
+	locked, err := rh.cache.SetNX(lockKey, hostname, replayLockTimeout).Result()
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer rh.cache.Del(lockKey)
+
+	replayCond := map[string]interface{}{"replay_id": replay.ReplayID, common.BKOwnerIDField: replay.OwnerID}
+	queueKey := types.EventCacheDistQueuePrefix + fmt.Sprint(replay.SubscriptionID)
+	for {
+		// the replay may be canceled by now
+		current := metadata.EventReplay{}
+		if err := rh.db.Table(common.BKTableNameEventReplay).Find(replayCond).One(rh.ctx, &current); err != nil {
+			return err
+		}
+		if !current.Status.IsActive() {
+			return nil
+		}
+		length, err := rh.cache.LLen(queueKey).Result()
+		if err != nil {
+			return err
+		}
+		if length >= replayQueueLimit {
+			return nil
+		}
+
+		feeds, err := rh.nextReplayBatch(&current)
+		if err != nil {
+			return err
+		}
+		if len(feeds) == 0 {
+			blog.Infof("event replay %d of subscription %d finished, replayed: %d", current.ReplayID, current.SubscriptionID, current.Replayed)
+			return rh.db.Table(common.BKTableNameEventReplay).Update(rh.ctx, replayCond, map[string]interface{}{
+				"status":    metadata.EventReplayStatusFinished,
+				"last_time": metadata.Now(),
+			})
+		}
+
+		pushed := 0
+		for ; pushed < len(feeds); pushed++ {
+			if err = rh.pushReplayEvent(&current, &feeds[pushed]); err != nil {
+				break
+			}
+		}
+		replayed, position := replayBatchProgress(&current, feeds, pushed)
+		// save the progress even if failed, the pushed events are not replayed twice
+		updateErr := rh.db.Table(common.BKTableNameEventReplay).Update(rh.ctx, replayCond, map[string]interface{}{
+			"status":    metadata.EventReplayStatusRunning,
+			"replayed":  replayed,
+			"position":  position,
+			"last_time": metadata.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if updateErr != nil {
+			return updateErr
+		}
+		rh.cache.Expire(lockKey, replayLockTimeout)
+	}
+}
+
+func (rh *ReplayHandler) nextReplayBatch(replay *metadata.EventReplay) ([]metadata.EventFeed, error) {
+	feeds := make([]metadata.EventFeed, 0)
+	err := rh.db.Table(common.BKTableNameEventFeed).Find(replayBatchCondition(replay)).Sort("distribution_id").
+		Limit(uint64(replayBatchSize)).All(rh.ctx, &feeds)
+	return feeds, err
+}
+
+// replayBatchCondition the condition of the events in the range of replay after its position
+func replayBatchCondition(replay *metadata.EventReplay) map[string]interface{} {
+	condition := replay.EventReplayRange.Condition()
+	if idCond, ok := condition["distribution_id"].(map[string]interface{}); ok {
+		idCond[common.BKDBGT] = replay.Position
+	} else {
+		condition["distribution_id"] = map[string]interface{}{common.BKDBGT: replay.Position}
+	}
+	condition[common.BKSubscriptionIDField] = replay.SubscriptionID
+	condition[common.BKOwnerIDField] = replay.OwnerID
+	return condition
+}
+
+// replayBatchProgress the progress of replay after the first pushed events of batch,
+// the position stays at the last pushed event so the rest are pushed next time.
+func replayBatchProgress(replay *metadata.EventReplay, feeds []metadata.EventFeed, pushed int) (int64, int64) {
+	if pushed == 0 {
+		return replay.Replayed, replay.Position
+	}
+	return replay.Replayed + int64(pushed), feeds[pushed-1].DistributionID
+}
+
+// pushReplayEvent push the event to the end of queue with a new distribution id, it's marked
+// as replay so that the subscriber can tell it.
+func (rh *ReplayHandler) pushReplayEvent(replay *metadata.EventReplay, feed *metadata.EventFeed) error {
+	dist := metadata.DistInst{}
+	if err := json.Unmarshal([]byte(feed.Event), &dist); err != nil {
+		return fmt.Errorf("unmarshal event %d failed, err: %v", feed.DistributionID, err)
+	}
+	subID := fmt.Sprint(replay.SubscriptionID)
+	dstbID, err := rh.cache.Incr(types.EventCacheDistIDPrefix + subID).Result()
+	if err != nil {
+		return err
+	}
+	return rh.cache.RPush(types.EventCacheDistQueuePrefix+subID, newReplayEvent(replay, dist, dstbID)).Err()
+}
+
+// newReplayEvent the event to push again with the new distribution id
+func newReplayEvent(replay *metadata.EventReplay, dist metadata.DistInst, dstbID int64) string {
+	dist.DstbID = dstbID
+	dist.Replay = true
+	dist.ReplayID = replay.ReplayID
+	distByte, _ := json.Marshal(dist)
+	return string(distByte)
+}
@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestReplayBatchCondition(t *testing.T) {
+	start := metadata.Time{Time: time.Now().Add(-time.Hour)}
+	replay := &metadata.EventReplay{ReplayID: 1, SubscriptionID: 2, Position: 15, OwnerID: "0"}
+	replay.StartTime = &start
+	condition := replayBatchCondition(replay)
+	if condition[common.BKSubscriptionIDField] != int64(2) || condition[common.BKOwnerIDField] != "0" {
+		t.Errorf("batch condition should be scoped by the subscription and owner, got %v", condition)
+	}
+	if _, ok := condition["action_time"]; !ok {
+		t.Errorf("batch condition should keep the time range, got %v", condition)
+	}
+	idCond := condition["distribution_id"].(map[string]interface{})
+	if len(idCond) != 1 || idCond[common.BKDBGT] != int64(15) {
+		t.Errorf("batch condition should get the events after the position, got %v", idCond)
+	}
+
+	replay = &metadata.EventReplay{ReplayID: 1, SubscriptionID: 2, Position: 15, OwnerID: "0"}
+	replay.StartDistributionID = 10
+	replay.EndDistributionID = 20
+	idCond = replayBatchCondition(replay)["distribution_id"].(map[string]interface{})
+	if idCond[common.BKDBGTE] != int64(10) || idCond[common.BKDBLTE] != int64(20) || idCond[common.BKDBGT] != int64(15) {
+		t.Errorf("batch condition should keep the id range and the position, got %v", idCond)
+	}
+}
+
+func TestReplayBatchProgress(t *testing.T) {
+	replay := &metadata.EventReplay{Replayed: 5, Position: 15}
+	feeds := []metadata.EventFeed{{DistributionID: 16}, {DistributionID: 18}, {DistributionID: 21}}
+
+	replayed, position := replayBatchProgress(replay, feeds, len(feeds))
+	if replayed != 8 || position != 21 {
+		t.Errorf("expect the whole batch replayed to 21, got %d %d", replayed, position)
+	}
+	replayed, position = replayBatchProgress(replay, feeds, 2)
+	if replayed != 7 || position != 18 {
+		t.Errorf("expect the position at the last pushed event 18, got %d %d", replayed, position)
+	}
+	replayed, position = replayBatchProgress(replay, feeds, 0)
+	if replayed != 5 || position != 15 {
+		t.Errorf("expect the progress not changed when nothing is pushed, got %d %d", replayed, position)
+	}
+}
+
+func TestNewReplayEvent(t *testing.T) {
+	replay := &metadata.EventReplay{ReplayID: 3, SubscriptionID: 2}
+	dist := metadata.DistInst{DstbID: 16, SubscriptionID: 2}
+	dist.ObjType = "host"
+
+	event := metadata.DistInst{}
+	if err := json.Unmarshal([]byte(newReplayEvent(replay, dist, 100)), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.DstbID != 100 || !event.Replay || event.ReplayID != 3 {
+		t.Errorf("replayed event should have the new distribution id and the replay mark, got %+v", event)
+	}
+	if event.SubscriptionID != 2 || event.ObjType != "host" {
+		t.Errorf("replayed event should keep the event, got %+v", event)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+// ErrSubscriptionStopped the subscription is deleted while the event is retried
+var ErrSubscriptionStopped = fmt.Errorf("subscription stopped")
+
+// sendCallbackWithRetry deliver the events until it succeeds or the retry times run out, the running
+// keys are kept alive while waiting, so that the next event of the subscription keeps waiting too.
+// the events of a batch are retried together, and each of them becomes a dead letter when it fails.
+func (dh *DistHandler) sendCallbackWithRetry(sub *metadata.Subscription, dists []*metadata.DistInstCtx,
+	chNew chan metadata.Subscription, done chan struct{}) error {
+
+	first, last := dists[0].DstbID, dists[len(dists)-1].DstbID
+	var attempts int64
+	for {
+		attempts++
+		err := dh.deliver(sub, dists)
+		if err == nil {
+			return nil
+		}
+		// the subscription may be paused for failing too many times, the events are not retried then
+		if attempts > sub.GetRetryTimes() || sub.Paused {
+			blog.Errorf("send event %d-%d to subscription %d failed after %d attempts, move to dead letters, err: %v",
+				first, last, sub.SubscriptionID, attempts, err)
+			for _, dist := range dists {
+				if saveErr := dh.saveDeadLetter(sub, dist, attempts, err); saveErr != nil {
+					blog.Errorf("save dead letter of event %d failed, err: %v, event: %s", dist.DstbID, saveErr, dist.Raw)
+				}
+			}
+			return err
+		}
+
+		backoff := sub.GetRetryBackoff(attempts)
+		blog.Warnf("send event %d-%d to subscription %d failed, retry %d after %v, err: %v", first, last, sub.SubscriptionID, attempts, backoff, err)
+		for _, dist := range dists {
+			runningkey := distRunningKey(dist)
+			if err := dh.cache.Expire(runningkey, backoff+timeout+sub.GetTimeout()).Err(); err != nil {
+				blog.Errorf("refresh running key %s failed, err: %v", runningkey, err)
+			}
+		}
+		if err := waitRetryBackoff(sub, backoff, chNew, done); err != nil {
+			blog.Infof("stop retrying event %d-%d, subscription %d is deleted", first, last, sub.SubscriptionID)
+			return err
+		}
+	}
+}
+
+// waitRetryBackoff wait for the backoff, the subscription is renewed if it's changed in the meantime
+func waitRetryBackoff(sub *metadata.Subscription, backoff time.Duration, chNew chan metadata.Subscription, done chan struct{}) error {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return nil
+		case nsub := <-chNew:
+			if nsub.GetCacheKey() != sub.GetCacheKey() {
+				*sub = nsub
+				blog.Infof("refreshed subcriber %d while retrying", sub.SubscriptionID)
+			}
+		case <-done:
+			return ErrSubscriptionStopped
+		}
+	}
+}
+
+func (dh *DistHandler) saveDeadLetter(sub *metadata.Subscription, dist *metadata.DistInstCtx, attempts int64, sendErr error) error {
+	id, err := dh.db.NextSequence(dh.ctx, common.BKTableNameEventDeadLetter)
+	if err != nil {
+		return err
+	}
+	letter := newEventDeadLetter(sub, dist, attempts, sendErr)
+	letter.DeadLetterID = int64(id)
+	return dh.db.Table(common.BKTableNameEventDeadLetter).Insert(dh.ctx, letter)
+}
+
+// newEventDeadLetter keep the event failed to deliver with the last error
+func newEventDeadLetter(sub *metadata.Subscription, dist *metadata.DistInstCtx, attempts int64, sendErr error) metadata.EventDeadLetter {
+	return metadata.EventDeadLetter{
+		SubscriptionID: sub.SubscriptionID,
+		DistributionID: dist.DstbID,
+		EventType:      dist.EventType,
+		ObjType:        dist.ObjType,
+		Action:         dist.Action,
+		Event:          dist.Raw,
+		Attempts:       attempts,
+		LastError:      sendErr.Error(),
+		OwnerID:        sub.OwnerID,
+		CreateTime:     metadata.Now(),
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestSubscriptionRetryPolicy(t *testing.T) {
+	sub := metadata.Subscription{}
+	if sub.GetRetryTimes() != metadata.DefaultEventRetryTimes {
+		t.Errorf("expect default retry times %d, got %d", metadata.DefaultEventRetryTimes, sub.GetRetryTimes())
+	}
+	backoffs := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for i, want := range backoffs {
+		if got := sub.GetRetryBackoff(int64(i + 1)); got != want {
+			t.Errorf("expect backoff %v of retry %d, got %v", want, i+1, got)
+		}
+	}
+
+	sub = metadata.Subscription{RetryTimes: 3, RetryInterval: 60}
+	if sub.GetRetryTimes() != 3 {
+		t.Errorf("expect retry times 3, got %d", sub.GetRetryTimes())
+	}
+	if got := sub.GetRetryBackoff(10); got != metadata.MaxEventRetryInterval {
+		t.Errorf("expect backoff capped at %v, got %v", metadata.MaxEventRetryInterval, got)
+	}
+}
+
+func TestWaitRetryBackoff(t *testing.T) {
+	sub := &metadata.Subscription{SubscriptionID: 1, CallbackURL: "http://old"}
+	chNew := make(chan metadata.Subscription)
+	done := make(chan struct{})
+
+	go func() {
+		chNew <- metadata.Subscription{SubscriptionID: 1, CallbackURL: "http://new"}
+	}()
+	if err := waitRetryBackoff(sub, 100*time.Millisecond, chNew, done); err != nil {
+		t.Fatalf("wait backoff failed, err: %v", err)
+	}
+	if sub.CallbackURL != "http://new" {
+		t.Errorf("subscription should be renewed while waiting, got %s", sub.CallbackURL)
+	}
+
+	close(done)
+	if err := waitRetryBackoff(sub, time.Minute, chNew, done); err != ErrSubscriptionStopped {
+		t.Errorf("expect stopped when subscription is deleted, got %v", err)
+	}
+}
+
+func TestNewEventDeadLetter(t *testing.T) {
+	sub := &metadata.Subscription{SubscriptionID: 1, OwnerID: "0"}
+	dist := metadata.DistInst{DstbID: 10, SubscriptionID: 1}
+	dist.EventType = metadata.EventTypeInstData
+	dist.ObjType = "host"
+	dist.Action = metadata.EventActionUpdate
+	distCtx := &metadata.DistInstCtx{DistInst: dist, Raw: `{"distribution_id":10}`}
+
+	letter := newEventDeadLetter(sub, distCtx, 6, errors.New("connection refused"))
+	if letter.SubscriptionID != 1 || letter.DistributionID != 10 || letter.Event != distCtx.Raw {
+		t.Errorf("dead letter should keep the event, got %+v", letter)
+	}
+	if letter.Attempts != 6 || letter.LastError != "connection refused" || letter.OwnerID != "0" {
+		t.Errorf("dead letter should keep the attempts and last error, got %+v", letter)
+	}
+	if letter.EventType != metadata.EventTypeInstData || letter.ObjType != "host" || letter.Action != metadata.EventActionUpdate {
+		t.Errorf("dead letter should keep the event type, got %+v", letter)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+// EventSink deliver the messages of subscriptions, the message is confirmed when Send returns nil,
+// otherwise it's retried by the retry policy of subscription.
+type EventSink interface {
+	Send(sub *metadata.Subscription, msg *SinkMessage) error
+}
+
+// SinkMessage the message of one event, or a batch of events in batch mode
+type SinkMessage struct {
+	// Header the headers of http request, the other sinks keep only the content type
+	Header http.Header
+	Body   []byte
+}
+
+// ContentType the content type of body
+func (m *SinkMessage) ContentType() string {
+	return m.Header.Get("Content-Type")
+}
+
+var (
+	sinksLock sync.RWMutex
+	sinks     = map[string]EventSink{metadata.SinkTypeHTTP: &httpSink{}}
+)
+
+// RegisterSink add or replace the sink of the type
+func RegisterSink(sinkType string, sink EventSink) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	sinks[sinkType] = sink
+}
+
+func getSink(sinkType string) (EventSink, bool) {
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+	sink, ok := sinks[sinkType]
+	return sink, ok
+}
+
+// SetDefaultSinks register the redis stream sink, and the file sink when the directory is set
+func SetDefaultSinks(cache *redis.Client, fileDir string) {
+	RegisterSink(metadata.SinkTypeRedis, &redisSink{cache: cache})
+	if fileDir != "" {
+		RegisterSink(metadata.SinkTypeFile, &fileSink{dir: fileDir, files: map[string]*sync.Mutex{}})
+	}
+}
+
+var sinkFileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-][A-Za-z0-9_.\-]*$`)
+
+// ValidateSubscriptionSink check the sink and the event format of subscription can be delivered
+func ValidateSubscriptionSink(sub *metadata.Subscription) error {
+	switch sub.EventFormat {
+	case "", metadata.EventFormatCMDB, metadata.EventFormatCloudEvents:
+	default:
+		return fmt.Errorf("event format %s is not supported", sub.EventFormat)
+	}
+	switch sub.CloudEventsMode {
+	case "", metadata.CloudEventsModeStructured, metadata.CloudEventsModeBinary:
+	default:
+		return fmt.Errorf("cloudevents mode %s is not supported", sub.CloudEventsMode)
+	}
+	if sub.IsPull() {
+		return nil
+	}
+
+	sinkType := sub.GetSinkType()
+	if _, ok := getSink(sinkType); !ok {
+		return fmt.Errorf("sink type %s is not supported", sinkType)
+	}
+	if sub.IsCloudEventsBinary() && (sinkType != metadata.SinkTypeHTTP || sub.IsBatch()) {
+		return fmt.Errorf("cloudevents binary mode is only supported by http sink without batch")
+	}
+	switch sinkType {
+	case metadata.SinkTypeRedis:
+		if sub.SinkTarget == "" {
+			return fmt.Errorf("the stream name of redis sink is empty")
+		}
+	case metadata.SinkTypeFile:
+		if !sinkFileNameRegexp.MatchString(sub.SinkTarget) {
+			return fmt.Errorf("the file name %s of file sink is invalid", sub.SinkTarget)
+		}
+	}
+	return nil
+}
+
+// newSinkMessage build the message of the events in the format of subscription
+func newSinkMessage(sub *metadata.Subscription, dists []*metadata.DistInstCtx) (*SinkMessage, error) {
+	msg := &SinkMessage{Header: http.Header{}}
+	if !sub.IsCloudEvents() {
+		msg.Header.Set("Content-Type", "application/json")
+		if sub.IsBatch() {
+			msg.Body = []byte(joinDistBatch(dists))
+		} else {
+			msg.Body = []byte(dists[0].Raw)
+		}
+		return msg, nil
+	}
+
+	if sub.IsCloudEventsBinary() {
+		if len(dists) != 1 {
+			return nil, fmt.Errorf("cloudevents binary mode can't send %d events in one message", len(dists))
+		}
+		event := metadata.NewCloudEvent(&dists[0].DistInst, dists[0].Raw)
+		for attr, value := range event.Attributes() {
+			msg.Header.Set(metadata.CloudEventsHeaderPrefix+attr, value)
+		}
+		msg.Header.Set("Content-Type", event.DataContentType)
+		msg.Body = event.Data
+		return msg, nil
+	}
+
+	var err error
+	if sub.IsBatch() {
+		events := make([]*metadata.CloudEvent, 0, len(dists))
+		for _, dist := range dists {
+			events = append(events, metadata.NewCloudEvent(&dist.DistInst, dist.Raw))
+		}
+		msg.Header.Set("Content-Type", metadata.CloudEventsBatchContentType)
+		msg.Body, err = json.Marshal(events)
+	} else {
+		msg.Header.Set("Content-Type", metadata.CloudEventsContentType)
+		msg.Body, err = json.Marshal(metadata.NewCloudEvent(&dists[0].DistInst, dists[0].Raw))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("build cloudevents envelope failed, err: %v", err)
+	}
+	return msg, nil
+}
+
+// httpSink post the message to the callback url, it's confirmed by the confirm mode of subscription
+type httpSink struct{}
+
+func (s *httpSink) Send(sub *metadata.Subscription, msg *SinkMessage) error {
+	return sendCallback(sub, msg)
+}
+
+// redisSinkMaxLen the streams are trimmed to about so many messages
+const redisSinkMaxLen = 100000
+
+// redisSink add the message to a redis stream, it's confirmed when the message gets its id.
+// the streams need redis 5.0 or later.
+type redisSink struct {
+	cache *redis.Client
+}
+
+func (s *redisSink) Send(sub *metadata.Subscription, msg *SinkMessage) error {
+	stream := types.EventCacheSinkStreamPrefix + sub.SinkTarget
+	cmd := redis.NewStringCmd("XADD", stream, "MAXLEN", "~", redisSinkMaxLen, "*",
+		"subscription_id", sub.SubscriptionID, "content_type", msg.ContentType(), "data", string(msg.Body))
+	if err := s.cache.Process(cmd); err != nil {
+		return fmt.Errorf("event distribute fail, add to redis stream %s error: %v", stream, err)
+	}
+	return cmd.Err()
+}
+
+// fileSink append the message to a local file as one line, it's confirmed when the line is
+// synced to disk. the files are appended by different subscriptions, so each file is locked.
+type fileSink struct {
+	dir   string
+	lock  sync.Mutex
+	files map[string]*sync.Mutex
+}
+
+func (s *fileSink) fileLock(name string) *sync.Mutex {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	lock, ok := s.files[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.files[name] = lock
+	}
+	return lock
+}
+
+func (s *fileSink) Send(sub *metadata.Subscription, msg *SinkMessage) error {
+	if !sinkFileNameRegexp.MatchString(sub.SinkTarget) {
+		return fmt.Errorf("event distribute fail, invalid file name %s", sub.SinkTarget)
+	}
+	lock := s.fileLock(sub.SinkTarget)
+	lock.Lock()
+	defer lock.Unlock()
+
+	path := filepath.Join(s.dir, sub.SinkTarget)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("event distribute fail, open file %s error: %v", path, err)
+	}
+	defer f.Close()
+	line := append(append(make([]byte, 0, len(msg.Body)+1), msg.Body...), '\n')
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("event distribute fail, write file %s error: %v", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("event distribute fail, sync file %s error: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func newTestDists(count int) []*metadata.DistInstCtx {
+	dists := make([]*metadata.DistInstCtx, 0)
+	for id := int64(1); id <= int64(count); id++ {
+		dist := metadata.DistInst{DstbID: id, SubscriptionID: 1}
+		dist.EventType = metadata.EventTypeInstData
+		dist.ObjType = "host"
+		dist.Action = metadata.EventActionCreate
+		raw, _ := json.Marshal(dist)
+		dists = append(dists, &metadata.DistInstCtx{DistInst: dist, Raw: string(raw)})
+	}
+	return dists
+}
+
+func TestNewSinkMessage(t *testing.T) {
+	sub := &metadata.Subscription{SubscriptionID: 1}
+	msg, err := newSinkMessage(sub, newTestDists(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.ContentType() != "application/json" || msg.Header.Get("ce-id") != "" {
+		t.Errorf("unexpected cmdb format message header %v", msg.Header)
+	}
+
+	sub.EventFormat = metadata.EventFormatCloudEvents
+	msg, err = newSinkMessage(sub, newTestDists(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := metadata.CloudEvent{}
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		t.Fatalf("structured message is not a cloud event, err: %v", err)
+	}
+	if msg.ContentType() != metadata.CloudEventsContentType || event.ID != "1-1" || event.SpecVersion != "1.0" ||
+		event.Type != "com.tencent.bkcmdb.instdata.create" || event.Subject != "host" {
+		t.Errorf("unexpected structured cloud event %+v, content type %s", event, msg.ContentType())
+	}
+
+	sub.CloudEventsMode = metadata.CloudEventsModeBinary
+	msg, err = newSinkMessage(sub, newTestDists(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.Get("ce-id") != "1-1" || msg.Header.Get("ce-specversion") != "1.0" || msg.ContentType() != "application/json" {
+		t.Errorf("unexpected binary cloud event header %v", msg.Header)
+	}
+	dist := metadata.DistInst{}
+	if err := json.Unmarshal(msg.Body, &dist); err != nil || dist.DstbID != 1 {
+		t.Errorf("binary cloud event body should be the event, err: %v", err)
+	}
+
+	sub.CloudEventsMode = metadata.CloudEventsModeStructured
+	sub.BatchSize = 10
+	msg, err = newSinkMessage(sub, newTestDists(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make([]metadata.CloudEvent, 0)
+	if err := json.Unmarshal(msg.Body, &events); err != nil || len(events) != 3 {
+		t.Errorf("expect 3 cloud events in batch, got %d, err: %v", len(events), err)
+	}
+	if msg.ContentType() != metadata.CloudEventsBatchContentType {
+		t.Errorf("unexpected batch content type %s", msg.ContentType())
+	}
+}
+
+func TestValidateSubscriptionSink(t *testing.T) {
+	cases := []struct {
+		sub   metadata.Subscription
+		valid bool
+	}{
+		{metadata.Subscription{}, true},
+		{metadata.Subscription{EventFormat: "xml"}, false},
+		{metadata.Subscription{EventFormat: metadata.EventFormatCloudEvents, CloudEventsMode: metadata.CloudEventsModeBinary}, true},
+		{metadata.Subscription{EventFormat: metadata.EventFormatCloudEvents, CloudEventsMode: metadata.CloudEventsModeBinary, BatchSize: 10}, false},
+		{metadata.Subscription{SinkType: "kafka"}, false},
+		{metadata.Subscription{SinkType: metadata.SinkTypeFile, SinkTarget: "events.log"}, true},
+		{metadata.Subscription{SinkType: metadata.SinkTypeFile, SinkTarget: "../events.log"}, false},
+		{metadata.Subscription{SinkType: metadata.SinkTypeFile, SinkTarget: ".."}, false},
+	}
+
+	RegisterSink(metadata.SinkTypeFile, &fileSink{dir: os.TempDir(), files: map[string]*sync.Mutex{}})
+	for i, c := range cases {
+		err := ValidateSubscriptionSink(&c.sub)
+		if c.valid != (err == nil) {
+			t.Errorf("case %d: expect valid %v, got err %v", i, c.valid, err)
+		}
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event_sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := &fileSink{dir: dir, files: map[string]*sync.Mutex{}}
+	sub := &metadata.Subscription{SubscriptionID: 1, SinkType: metadata.SinkTypeFile, SinkTarget: "events.log"}
+	for _, dist := range newTestDists(2) {
+		msg, err := newSinkMessage(sub, []*metadata.DistInstCtx{dist})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Send(sub, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "events.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expect 2 lines in file, got %d", len(lines))
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/event_server/identifier"
 	"configcenter/src/storage/dal"
@@ -37,7 +38,7 @@ func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client)
 		return fmt.Errorf("migrateIDToMongo failed: %v", err)
 	}
 
-	eh := &EventHandler{cache: cache}
+	eh := &EventHandler{cache: cache, db: db, ctx: ctx}
 	go func() {
 		chErr <- eh.StartHandleInsts()
 	}()
@@ -47,12 +48,18 @@ func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client)
 		chErr <- dh.StartDistribute()
 	}()
 
+	rh := &ReplayHandler{cache: cache, db: db, ctx: ctx}
+	go func() {
+		chErr <- rh.StartReplay()
+	}()
+
 	ih := identifier.NewIdentifierHandler(ctx, cache, db)
 	go func() {
 		chErr <- ih.StartHandleInsts()
 	}()
 
 	go cleanOutdateEvents(cache)
+	go cleanExpiredFeed(ctx, db)
 
 	if rc != nil {
 		th := &TxnHandler{cache: cache, db: db, ctx: ctx, rc: rc, committed: make(chan string, 100), shouldClose: util.NewBool(false)}
@@ -99,7 +106,14 @@ func migrateIDToMongo(ctx context.Context, cache *redis.Client, db dal.RDB) erro
 	return cache.Del(common.EventCacheEventIDKey).Err()
 }
 
-type EventHandler struct{ cache *redis.Client }
+type EventHandler struct {
+	cache *redis.Client
+	db    dal.RDB
+	ctx   context.Context
+	// filters the subscriptions with filter, reloaded from db in a interval
+	filters         map[int64]metadata.Subscription
+	filtersLoadTime time.Time
+}
 type DistHandler struct {
 	cache *redis.Client
 	db    dal.RDB
@@ -0,0 +1,235 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+	"configcenter/src/storage/dal"
+)
+
+// the fields of the statistics hash of subscription
+const (
+	statTotal              = "total"
+	statFailure            = "failue"
+	statConsecutiveFailure = "consecutive_failure"
+	statLastSuccessTime    = "last_success_time"
+	statLastErrorTime      = "last_error_time"
+	statLastError          = "last_error"
+	statLatencyCount       = "latency_count"
+	statLatencySum         = "latency_sum_ms"
+	statLatencyBucket      = "latency_le_"
+	statLatencyInf         = "+Inf"
+)
+
+// maxLastErrorLength the last error is cut to it, the error may carry the whole event
+const maxLastErrorLength = 512
+
+// pauseFailures the subscription is paused after so many consecutive failed attempts, 0 means never
+var pauseFailures int64
+
+// SetPauseThreshold set the consecutive failed attempts to pause the subscription
+func SetPauseThreshold(failures int64) {
+	if failures < 0 {
+		failures = 0
+	}
+	pauseFailures = failures
+}
+
+func statisticsKey(subID int64) string {
+	return types.EventCacheDistCallBackCountPrefix + strconv.FormatInt(subID, 10)
+}
+
+func latencyBucket(cost time.Duration) string {
+	ms := int64(cost / time.Millisecond)
+	for _, bound := range metadata.EventLatencyBounds {
+		if ms <= bound {
+			return statLatencyBucket + strconv.FormatInt(bound, 10)
+		}
+	}
+	return statLatencyBucket + statLatencyInf
+}
+
+// recordDelivery update the statistics of subscription after a delivery attempt, and pause the
+// subscription when it keeps failing.
+func (dh *DistHandler) recordDelivery(sub *metadata.Subscription, cost time.Duration, sendErr error) {
+	key := statisticsKey(sub.SubscriptionID)
+	incr, set := deliveryStatistics(cost, sendErr, time.Now())
+
+	pipe := dh.cache.Pipeline()
+	defer pipe.Close()
+	var consecutive *redis.IntCmd
+	for field, n := range incr {
+		cmd := pipe.HIncrBy(key, field, n)
+		if field == statConsecutiveFailure {
+			consecutive = cmd
+		}
+	}
+	pipe.HMSet(key, set)
+	if _, err := pipe.Exec(); err != nil {
+		blog.Errorf("record the delivery statistics of subscription %d failed, err: %v", sub.SubscriptionID, err)
+		return
+	}
+
+	if consecutive == nil || !needPauseSubscription(sub, consecutive.Val()) {
+		return
+	}
+	reason := pauseReason(consecutive.Val(), sendErr)
+	if err := dh.pauseSubscription(sub, reason); err != nil {
+		blog.Errorf("pause subscription %d failed, err: %v", sub.SubscriptionID, err)
+		return
+	}
+	blog.Warnf("subscription %d is paused, %s", sub.SubscriptionID, reason)
+}
+
+// deliveryStatistics the counters to increase and the fields to set after a delivery attempt,
+// the consecutive failures are cleared by a success.
+func deliveryStatistics(cost time.Duration, sendErr error, now time.Time) (map[string]int64, map[string]string) {
+	incr := map[string]int64{
+		statTotal:           1,
+		statLatencyCount:    1,
+		statLatencySum:      int64(cost / time.Millisecond),
+		latencyBucket(cost): 1,
+	}
+	unix := strconv.FormatInt(now.Unix(), 10)
+	if sendErr == nil {
+		return incr, map[string]string{statLastSuccessTime: unix, statConsecutiveFailure: "0"}
+	}
+	lastErr := sendErr.Error()
+	if len(lastErr) > maxLastErrorLength {
+		lastErr = lastErr[:maxLastErrorLength]
+	}
+	incr[statFailure] = 1
+	incr[statConsecutiveFailure] = 1
+	return incr, map[string]string{statLastErrorTime: unix, statLastError: lastErr}
+}
+
+// needPauseSubscription whether the subscription should be paused after so many consecutive failures
+func needPauseSubscription(sub *metadata.Subscription, consecutive int64) bool {
+	return pauseFailures > 0 && !sub.Paused && consecutive >= pauseFailures
+}
+
+func pauseReason(consecutive int64, sendErr error) string {
+	reason := fmt.Sprintf("%d consecutive delivery failures, last error: %s", consecutive, sendErr.Error())
+	if len(reason) > maxLastErrorLength {
+		reason = reason[:maxLastErrorLength]
+	}
+	return reason
+}
+
+// pauseSubscription pause the subscription and notify all the event servers
+func (dh *DistHandler) pauseSubscription(sub *metadata.Subscription, reason string) error {
+	sub.Paused = true
+	sub.PausedReason = reason
+	return SetSubscriptionPaused(dh.ctx, dh.cache, dh.db, sub.SubscriptionID, sub.OwnerID, true, reason)
+}
+
+// SetSubscriptionPaused pause or resume the subscription, the consecutive failures are cleared
+// when it's resumed, so that it's not paused again right away.
+func SetSubscriptionPaused(ctx context.Context, cache *redis.Client, db dal.RDB, subID int64, ownerID string, paused bool, reason string) error {
+	condition := util.NewMapBuilder(common.BKSubscriptionIDField, subID, common.BKOwnerIDField, ownerID).Build()
+	data := map[string]interface{}{
+		"paused":        paused,
+		"paused_reason": reason,
+		"last_time":     metadata.Now(),
+	}
+	if err := db.Table(common.BKTableNameSubscription).Update(ctx, condition, data); err != nil {
+		return err
+	}
+	if !paused {
+		if err := cache.HSet(statisticsKey(subID), statConsecutiveFailure, "0").Err(); err != nil {
+			return err
+		}
+	}
+
+	sub := metadata.Subscription{}
+	if err := db.Table(common.BKTableNameSubscription).Find(condition).One(ctx, &sub); err != nil {
+		return err
+	}
+	mesg, err := json.Marshal(&sub)
+	if err != nil {
+		return err
+	}
+	return cache.Publish(types.EventCacheProcessChannel, "update"+string(mesg)).Err()
+}
+
+// GetSubscriptionStatistics get the delivery statistics of subscription
+func GetSubscriptionStatistics(cache *redis.Client, sub *metadata.Subscription) *metadata.Statistics {
+	val := cache.HGetAll(statisticsKey(sub.SubscriptionID)).Val()
+	parse := func(field string) int64 {
+		if val[field] == "" {
+			return 0
+		}
+		n, err := strconv.ParseInt(val[field], 10, 64)
+		if err != nil {
+			blog.Warnf("get %s of subscription %d statistics failed, err: %v", field, sub.SubscriptionID, err)
+		}
+		return n
+	}
+	parseTime := func(field string) *metadata.Time {
+		unix := parse(field)
+		if unix <= 0 {
+			return nil
+		}
+		return &metadata.Time{Time: time.Unix(unix, 0)}
+	}
+
+	stat := &metadata.Statistics{
+		Total:              parse(statTotal),
+		Failure:            parse(statFailure),
+		ConsecutiveFailure: parse(statConsecutiveFailure),
+		LastSuccessTime:    parseTime(statLastSuccessTime),
+		LastErrorTime:      parseTime(statLastErrorTime),
+		LastError:          val[statLastError],
+		Latency: metadata.EventLatency{
+			Count:   parse(statLatencyCount),
+			SumMs:   parse(statLatencySum),
+			Buckets: make([]metadata.EventLatencyBucket, 0, len(metadata.EventLatencyBounds)+1),
+		},
+	}
+	var cumulative int64
+	for _, bound := range metadata.EventLatencyBounds {
+		le := strconv.FormatInt(bound, 10)
+		cumulative += parse(statLatencyBucket + le)
+		stat.Latency.Buckets = append(stat.Latency.Buckets, metadata.EventLatencyBucket{LE: le, Count: cumulative})
+	}
+	cumulative += parse(statLatencyBucket + statLatencyInf)
+	stat.Latency.Buckets = append(stat.Latency.Buckets, metadata.EventLatencyBucket{LE: statLatencyInf, Count: cumulative})
+
+	backlog, err := cache.LLen(types.EventCacheDistQueuePrefix + strconv.FormatInt(sub.SubscriptionID, 10)).Result()
+	if err != nil {
+		blog.Warnf("get the backlog of subscription %d failed, err: %v", sub.SubscriptionID, err)
+	}
+	stat.Backlog = backlog
+
+	switch {
+	case sub.Paused:
+		stat.Status = metadata.SubscriptionStatusPaused
+	case stat.ConsecutiveFailure > 0:
+		stat.Status = metadata.SubscriptionStatusFailing
+	default:
+		stat.Status = metadata.SubscriptionStatusHealthy
+	}
+	return stat
+}
@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	cases := map[time.Duration]string{
+		0:                     "latency_le_50",
+		50 * time.Millisecond: "latency_le_50",
+		51 * time.Millisecond: "latency_le_100",
+		3 * time.Second:       "latency_le_5000",
+		10 * time.Second:      "latency_le_10000",
+		time.Minute:           "latency_le_+Inf",
+	}
+	for cost, bucket := range cases {
+		if got := latencyBucket(cost); got != bucket {
+			t.Errorf("expect bucket %s for %v, got %s", bucket, cost, got)
+		}
+	}
+}
+
+func TestDeliveryStatistics(t *testing.T) {
+	now := time.Unix(1558000000, 0)
+	incr, set := deliveryStatistics(80*time.Millisecond, nil, now)
+	if incr[statTotal] != 1 || incr[statLatencyCount] != 1 || incr[statLatencySum] != 80 || incr["latency_le_100"] != 1 {
+		t.Errorf("expect the total and latency counted, got %v", incr)
+	}
+	if _, ok := incr[statFailure]; ok {
+		t.Errorf("success should not count a failure, got %v", incr)
+	}
+	if set[statConsecutiveFailure] != "0" || set[statLastSuccessTime] != "1558000000" {
+		t.Errorf("success should reset the consecutive failures, got %v", set)
+	}
+
+	incr, set = deliveryStatistics(time.Second, errors.New(strings.Repeat("x", 1000)), now)
+	if incr[statTotal] != 1 || incr[statFailure] != 1 || incr[statConsecutiveFailure] != 1 {
+		t.Errorf("failure should count the failures, got %v", incr)
+	}
+	if _, ok := set[statConsecutiveFailure]; ok {
+		t.Errorf("failure should not reset the consecutive failures, got %v", set)
+	}
+	if len(set[statLastError]) != maxLastErrorLength || set[statLastErrorTime] != "1558000000" {
+		t.Errorf("failure should save the cut last error, got %v", set)
+	}
+}
+
+func TestNeedPauseSubscription(t *testing.T) {
+	defer SetPauseThreshold(0)
+
+	sub := &metadata.Subscription{SubscriptionID: 1}
+	SetPauseThreshold(0)
+	if needPauseSubscription(sub, 100) {
+		t.Errorf("subscription should never be paused without threshold")
+	}
+
+	SetPauseThreshold(5)
+	if needPauseSubscription(sub, 4) {
+		t.Errorf("subscription should not be paused below the threshold")
+	}
+	if !needPauseSubscription(sub, 5) || !needPauseSubscription(sub, 6) {
+		t.Errorf("subscription should be paused at the threshold")
+	}
+	sub.Paused = true
+	if needPauseSubscription(sub, 6) {
+		t.Errorf("paused subscription should not be paused again")
+	}
+
+	SetPauseThreshold(-1)
+	if pauseFailures != 0 {
+		t.Errorf("negative threshold should disable the pause, got %d", pauseFailures)
+	}
+
+	reason := pauseReason(5, errors.New(strings.Repeat("x", 1000)))
+	if !strings.HasPrefix(reason, "5 consecutive delivery failures") || len(reason) != maxLastErrorLength {
+		t.Errorf("unexpected pause reason %s", reason)
+	}
+}
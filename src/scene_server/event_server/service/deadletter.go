@@ -0,0 +1,220 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+
+	"github.com/emicklei/go-restful"
+)
+
+// SearchDeadLetter search the events failed to deliver, the latest first by default
+func (s *Service) SearchDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	var dat metadata.ParamSubscriptionSearch
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("search dead letter, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := util.SetModOwner(dat.Condition, ownerID)
+	limit := dat.Page.Limit
+	if limit <= 0 {
+		limit = common.BKNoLimit
+	}
+	sort := dat.Page.Sort
+	if sort == "" {
+		sort = "-dead_letter_id"
+	}
+
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("search dead letter count failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	results := make([]metadata.EventDeadLetter, 0)
+	err = s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Fields(dat.Fields...).Sort(sort).
+		Start(uint64(dat.Page.Start)).Limit(uint64(limit)).All(s.ctx, &results)
+	if err != nil {
+		blog.Errorf("search dead letter failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspEventDeadLetterSearch{Count: count, Info: results}))
+}
+
+// GetDeadLetter get the dead letter with the raw event
+func (s *Service) GetDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	id, err := strconv.ParseInt(req.PathParameter("deadLetterID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "dead_letter_id")})
+		return
+	}
+
+	letter := metadata.EventDeadLetter{}
+	condition := util.NewMapBuilder("dead_letter_id", id, common.BKOwnerIDField, ownerID).Build()
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).One(s.ctx, &letter); err != nil {
+		if s.db.IsNotFoundError(err) {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return
+		}
+		blog.Errorf("get dead letter %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(letter))
+}
+
+// ReplayDeadLetter push the dead letters to the subscription again in the order they failed,
+// they are delivered after the events in queue, and removed from the dead letters.
+func (s *Service) ReplayDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subID, letters, ok := s.getSubscriptionDeadLetters(req, resp)
+	if !ok {
+		return
+	}
+
+	replayed := make([]int64, 0)
+	for _, letter := range letters {
+		dist := metadata.DistInst{}
+		if err := json.Unmarshal([]byte(letter.Event), &dist); err != nil {
+			blog.Errorf("replay dead letter %d, but unmarshal event failed, err: %v, event: %s", letter.DeadLetterID, err, letter.Event)
+			continue
+		}
+
+		// the distribution id decides the order, a new one puts the event to the end of queue
+		dstbID, err := s.cache.Incr(types.EventCacheDistIDPrefix + fmt.Sprint(subID)).Result()
+		if err != nil {
+			blog.Errorf("replay dead letter %d, but get distribution id failed, err: %v", letter.DeadLetterID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+		dist.DstbID = dstbID
+		distByte, _ := json.Marshal(dist)
+		if err := s.cache.RPush(types.EventCacheDistQueuePrefix+fmt.Sprint(subID), string(distByte)).Err(); err != nil {
+			blog.Errorf("replay dead letter %d, but push to queue failed, err: %v", letter.DeadLetterID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+
+		condition := util.NewMapBuilder("dead_letter_id", letter.DeadLetterID, common.BKOwnerIDField, ownerID).Build()
+		if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, condition); err != nil {
+			blog.Errorf("replay dead letter %d, but delete it failed, err: %v", letter.DeadLetterID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+		replayed = append(replayed, letter.DeadLetterID)
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(map[string]interface{}{"dead_letter_id": replayed}))
+}
+
+// PurgeDeadLetter delete the dead letters of the subscription
+func (s *Service) PurgeDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	_, letters, ok := s.getSubscriptionDeadLetters(req, resp)
+	if !ok {
+		return
+	}
+
+	purged := make([]int64, 0)
+	for _, letter := range letters {
+		purged = append(purged, letter.DeadLetterID)
+	}
+	if len(purged) > 0 {
+		condition := map[string]interface{}{
+			"dead_letter_id":      map[string]interface{}{common.BKDBIN: purged},
+			common.BKOwnerIDField: ownerID,
+		}
+		if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, condition); err != nil {
+			blog.Errorf("purge dead letters %v failed, err: %v", purged, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterPurgeFailed)})
+			return
+		}
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(map[string]interface{}{"dead_letter_id": purged}))
+}
+
+// getSubscriptionDeadLetters get the dead letters of the subscription in path chosen by the body,
+// the oldest first.
+func (s *Service) getSubscriptionDeadLetters(req *restful.Request, resp *restful.Response) (int64, []metadata.EventDeadLetter, bool) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKSubscriptionIDField)})
+		return 0, nil, false
+	}
+	dat := metadata.ParamEventDeadLetter{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("get dead letters of subscription %d, but decode body failed, err: %v", subID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return 0, nil, false
+	}
+
+	subCond := util.NewMapBuilder(common.BKSubscriptionIDField, subID, common.BKOwnerIDField, ownerID).Build()
+	count, err := s.db.Table(common.BKTableNameSubscription).Find(subCond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("get subscription %d failed, err: %v", subID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSelectFailed)})
+		return 0, nil, false
+	}
+	if count == 0 {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+		return 0, nil, false
+	}
+
+	condition := map[string]interface{}{
+		common.BKSubscriptionIDField: subID,
+		common.BKOwnerIDField:        ownerID,
+	}
+	if len(dat.DeadLetterIDs) > 0 {
+		condition["dead_letter_id"] = map[string]interface{}{common.BKDBIN: dat.DeadLetterIDs}
+	}
+	letters := make([]metadata.EventDeadLetter, 0)
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Sort("dead_letter_id").All(s.ctx, &letters); err != nil {
+		blog.Errorf("get dead letters of subscription %d failed, err: %v", subID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return 0, nil, false
+	}
+	return subID, letters, true
+}
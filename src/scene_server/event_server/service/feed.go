@@ -0,0 +1,322 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+
+	"github.com/emicklei/go-restful"
+	redis "gopkg.in/redis.v5"
+)
+
+// feedPollInterval how often the new events are checked while long polling
+var feedPollInterval = time.Second
+
+// CreateEventCursor register a cursor of consumer on the pull subscription
+func (s *Service) CreateEventCursor(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subID, ok := s.getPullSubscriptionID(req, resp)
+	if !ok {
+		return
+	}
+	dat := metadata.ParamEventCursorCreate{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil && err != io.EOF {
+		blog.Errorf("create event cursor, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	var position int64
+	switch dat.From {
+	case "", metadata.EventCursorFromLatest:
+		latest, err := s.latestDistributionID(subID)
+		if err != nil {
+			blog.Errorf("create event cursor, but get the latest distribution id of subscription %d failed, err: %v", subID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+			return
+		}
+		position = latest
+	case metadata.EventCursorFromEarliest:
+		position = 0
+	default:
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "from")})
+		return
+	}
+
+	id, err := s.db.NextSequence(s.ctx, common.BKTableNameEventCursor)
+	if err != nil {
+		blog.Errorf("create event cursor, but get id failed, err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+	now := metadata.Now()
+	cursor := metadata.EventCursor{
+		CursorID:       int64(id),
+		SubscriptionID: subID,
+		Position:       position,
+		Operator:       util.GetUser(pheader),
+		OwnerID:        ownerID,
+		CreateTime:     now,
+		LastTime:       now,
+	}
+	if err := s.db.Table(common.BKTableNameEventCursor).Insert(s.ctx, cursor); err != nil {
+		blog.Errorf("create event cursor failed, err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(cursor))
+}
+
+// GetEventCursor get the acknowledged position of cursor, the consumer resumes from it after restarts
+func (s *Service) GetEventCursor(req *restful.Request, resp *restful.Response) {
+	cursor, ok := s.getEventCursor(req, resp)
+	if !ok {
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(cursor))
+}
+
+// DeleteEventCursor delete the cursor of consumer, the feed is not changed
+func (s *Service) DeleteEventCursor(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cursor, ok := s.getEventCursor(req, resp)
+	if !ok {
+		return
+	}
+	condition := util.NewMapBuilder("cursor_id", cursor.CursorID, common.BKOwnerIDField, cursor.OwnerID).Build()
+	if err := s.db.Table(common.BKTableNameEventCursor).Delete(s.ctx, condition); err != nil {
+		blog.Errorf("delete event cursor %d failed, err: %v", cursor.CursorID, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// FetchEventFeed get a batch of events after the position, it waits until there are new events
+// or the timeout, the returned position is used to fetch the next batch.
+func (s *Service) FetchEventFeed(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cursor, ok := s.getEventCursor(req, resp)
+	if !ok {
+		return
+	}
+	dat := metadata.ParamEventFeedFetch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil && err != io.EOF {
+		blog.Errorf("fetch event feed, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	position := cursor.Position
+	if dat.Position != nil {
+		position = *dat.Position
+	}
+
+	result := newFeedFetchResult(cursor, position, nil)
+	deadline := time.Now().Add(dat.GetTimeout())
+	for {
+		// the distribution id in cache tells whether there are new events, so that db
+		// is not queried on every poll.
+		latest, err := s.latestDistributionID(cursor.SubscriptionID)
+		if err != nil {
+			blog.Errorf("fetch event feed, but get the latest distribution id of subscription %d failed, err: %v", cursor.SubscriptionID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventFeedFetchFailed)})
+			return
+		}
+		if latest > position {
+			feeds := make([]metadata.EventFeed, 0)
+			condition := feedFetchCondition(cursor, position)
+			err := s.db.Table(common.BKTableNameEventFeed).Find(condition).Sort("distribution_id").
+				Limit(uint64(dat.GetLimit())).All(s.ctx, &feeds)
+			if err != nil {
+				blog.Errorf("fetch event feed of cursor %d failed, err: %v", cursor.CursorID, err)
+				resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventFeedFetchFailed)})
+				return
+			}
+			if len(feeds) > 0 {
+				result = newFeedFetchResult(cursor, position, feeds)
+				break
+			}
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		select {
+		case <-req.Request.Context().Done():
+			blog.V(3).Infof("fetch event feed of cursor %d, the consumer is gone", cursor.CursorID)
+			return
+		case <-time.After(feedPollInterval):
+		}
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// AckEventFeed acknowledge the events until the position, the position never goes back
+func (s *Service) AckEventFeed(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	cursor, ok := s.getEventCursor(req, resp)
+	if !ok {
+		return
+	}
+	dat := metadata.ParamEventFeedAck{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("ack event feed, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if condition, data, ok := eventCursorAck(cursor, dat.Position); ok {
+		if err := s.db.Table(common.BKTableNameEventCursor).Update(s.ctx, condition, data); err != nil {
+			blog.Errorf("ack event feed of cursor %d to %d failed, err: %v", cursor.CursorID, dat.Position, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+			return
+		}
+		cursor.Position = dat.Position
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(cursor))
+}
+
+// feedFetchCondition the condition of the feed events after the position
+func feedFetchCondition(cursor *metadata.EventCursor, position int64) map[string]interface{} {
+	return map[string]interface{}{
+		common.BKSubscriptionIDField: cursor.SubscriptionID,
+		common.BKOwnerIDField:        cursor.OwnerID,
+		"distribution_id":            map[string]interface{}{common.BKDBGT: position},
+	}
+}
+
+// newFeedFetchResult the batch of the fetched feed events, the position moves to the last
+// event of batch, it stays when there is no new event.
+func newFeedFetchResult(cursor *metadata.EventCursor, position int64, feeds []metadata.EventFeed) metadata.RspEventFeedFetch {
+	result := metadata.RspEventFeedFetch{
+		CursorID: cursor.CursorID,
+		Position: position,
+		Events:   make([]json.RawMessage, 0, len(feeds)),
+	}
+	for _, feed := range feeds {
+		result.Events = append(result.Events, json.RawMessage(feed.Event))
+	}
+	if len(feeds) > 0 {
+		result.Position = feeds[len(feeds)-1].DistributionID
+	}
+	return result
+}
+
+// eventCursorAck the update of cursor to acknowledge the position, it's false when the position
+// is not after the cursor. the condition keeps the position from going back on the concurrent acks.
+func eventCursorAck(cursor *metadata.EventCursor, position int64) (map[string]interface{}, map[string]interface{}, bool) {
+	if position <= cursor.Position {
+		return nil, nil, false
+	}
+	condition := map[string]interface{}{
+		"cursor_id":           cursor.CursorID,
+		common.BKOwnerIDField: cursor.OwnerID,
+		"position":            map[string]interface{}{common.BKDBLT: position},
+	}
+	data := map[string]interface{}{
+		"position":  position,
+		"last_time": metadata.Now(),
+	}
+	return condition, data, true
+}
+
+// getPullSubscriptionID get the subscription in path, it should be in pull mode
+func (s *Service) getPullSubscriptionID(req *restful.Request, resp *restful.Response) (int64, bool) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKSubscriptionIDField)})
+		return 0, false
+	}
+	sub := metadata.Subscription{}
+	condition := util.NewMapBuilder(common.BKSubscriptionIDField, subID, common.BKOwnerIDField, ownerID).Build()
+	if err := s.db.Table(common.BKTableNameSubscription).Find(condition).One(s.ctx, &sub); err != nil {
+		if s.db.IsNotFoundError(err) {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return 0, false
+		}
+		blog.Errorf("get subscription %d failed, err: %v", subID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSelectFailed)})
+		return 0, false
+	}
+	if !sub.IsPull() {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscriptionNotPull)})
+		return 0, false
+	}
+	return subID, true
+}
+
+// getEventCursor get the cursor in path, it should belong to the subscription in path
+func (s *Service) getEventCursor(req *restful.Request, resp *restful.Response) (*metadata.EventCursor, bool) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKSubscriptionIDField)})
+		return nil, false
+	}
+	cursorID, err := strconv.ParseInt(req.PathParameter("cursorID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "cursor_id")})
+		return nil, false
+	}
+
+	cursor := new(metadata.EventCursor)
+	condition := util.NewMapBuilder("cursor_id", cursorID, common.BKSubscriptionIDField, subID, common.BKOwnerIDField, ownerID).Build()
+	if err := s.db.Table(common.BKTableNameEventCursor).Find(condition).One(s.ctx, cursor); err != nil {
+		if s.db.IsNotFoundError(err) {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return nil, false
+		}
+		blog.Errorf("get event cursor %d failed, err: %v", cursorID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return nil, false
+	}
+	return cursor, true
+}
+
+// latestDistributionID the id of the latest event distributed to the subscription
+func (s *Service) latestDistributionID(subID int64) (int64, error) {
+	id, err := s.cache.Get(types.EventCacheDistIDPrefix + fmt.Sprint(subID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return id, err
+}
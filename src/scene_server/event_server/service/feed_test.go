@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestFeedFetchParams(t *testing.T) {
+	dat := metadata.ParamEventFeedFetch{}
+	if dat.GetLimit() != metadata.DefaultEventFeedLimit || dat.GetTimeout() != metadata.DefaultEventFeedTimeout {
+		t.Errorf("expect the default limit and timeout, got %d %v", dat.GetLimit(), dat.GetTimeout())
+	}
+	dat = metadata.ParamEventFeedFetch{Limit: 5000, Timeout: 3600}
+	if dat.GetLimit() != metadata.MaxEventFeedLimit || dat.GetTimeout() != metadata.MaxEventFeedTimeout {
+		t.Errorf("expect the max limit and timeout, got %d %v", dat.GetLimit(), dat.GetTimeout())
+	}
+	dat = metadata.ParamEventFeedFetch{Limit: 10, Timeout: -1}
+	if dat.GetLimit() != 10 || dat.GetTimeout() != 0 {
+		t.Errorf("expect limit 10 without waiting, got %d %v", dat.GetLimit(), dat.GetTimeout())
+	}
+	if (metadata.ParamEventFeedFetch{Timeout: 5}).GetTimeout() != 5*time.Second {
+		t.Errorf("expect timeout 5s")
+	}
+}
+
+func TestFeedFetch(t *testing.T) {
+	cursor := &metadata.EventCursor{CursorID: 1, SubscriptionID: 2, Position: 10, OwnerID: "0"}
+
+	condition := feedFetchCondition(cursor, 15)
+	if condition[common.BKSubscriptionIDField] != int64(2) || condition[common.BKOwnerIDField] != "0" {
+		t.Errorf("fetch condition should be scoped by the subscription and owner, got %v", condition)
+	}
+	after, ok := condition["distribution_id"].(map[string]interface{})
+	if !ok || after[common.BKDBGT] != int64(15) {
+		t.Errorf("fetch condition should get the events after the position, got %v", condition)
+	}
+
+	result := newFeedFetchResult(cursor, 15, nil)
+	if result.CursorID != 1 || result.Position != 15 || result.Events == nil || len(result.Events) != 0 {
+		t.Errorf("the position should stay without new events, got %+v", result)
+	}
+
+	feeds := []metadata.EventFeed{
+		{SubscriptionID: 2, DistributionID: 16, Event: `{"distribution_id":16}`},
+		{SubscriptionID: 2, DistributionID: 18, Event: `{"distribution_id":18}`},
+	}
+	result = newFeedFetchResult(cursor, 15, feeds)
+	if result.Position != 18 || len(result.Events) != 2 {
+		t.Fatalf("the position should move to the last event, got %+v", result)
+	}
+	if string(result.Events[0]) != feeds[0].Event || string(result.Events[1]) != feeds[1].Event {
+		t.Errorf("the events should be returned in order, got %s %s", result.Events[0], result.Events[1])
+	}
+}
+
+func TestEventCursorAck(t *testing.T) {
+	cursor := &metadata.EventCursor{CursorID: 1, SubscriptionID: 2, Position: 10, OwnerID: "0"}
+
+	for _, position := range []int64{5, 10} {
+		if _, _, ok := eventCursorAck(cursor, position); ok {
+			t.Errorf("ack position %d should not move the cursor back from %d", position, cursor.Position)
+		}
+	}
+
+	condition, data, ok := eventCursorAck(cursor, 20)
+	if !ok {
+		t.Fatalf("ack position 20 should move the cursor")
+	}
+	if condition["cursor_id"] != int64(1) || condition[common.BKOwnerIDField] != "0" {
+		t.Errorf("ack condition should be scoped by the cursor and owner, got %v", condition)
+	}
+	before, ok := condition["position"].(map[string]interface{})
+	if !ok || before[common.BKDBLT] != int64(20) {
+		t.Errorf("ack condition should keep the position from going back, got %v", condition)
+	}
+	if data["position"] != int64(20) {
+		t.Errorf("ack should save the position, got %v", data)
+	}
+	if _, ok := data["last_time"]; !ok {
+		t.Errorf("ack should save the last time, got %v", data)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/metric"
+	"configcenter/src/common/metric/plugin"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/distribution"
+
+	"github.com/emicklei/go-restful"
+)
+
+// PauseSubscription stop delivering the events of subscription, they are kept in queue
+func (s *Service) PauseSubscription(req *restful.Request, resp *restful.Response) {
+	s.setSubscriptionPaused(req, resp, true)
+}
+
+// ResumeSubscription deliver the events of the paused subscription again
+func (s *Service) ResumeSubscription(req *restful.Request, resp *restful.Response) {
+	s.setSubscriptionPaused(req, resp, false)
+}
+
+func (s *Service) setSubscriptionPaused(req *restful.Request, resp *restful.Response, paused bool) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+	condition := util.NewMapBuilder(common.BKSubscriptionIDField, subID, common.BKOwnerIDField, ownerID).Build()
+	count, err := s.db.Table(common.BKTableNameSubscription).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("get subscription %d failed, err: %v", subID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSelectFailed)})
+		return
+	}
+	if count == 0 {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+		return
+	}
+
+	reason := ""
+	if paused {
+		reason = fmt.Sprintf("paused by %s", util.GetUser(pheader))
+	}
+	if err := distribution.SetSubscriptionPaused(s.ctx, s.cache, s.db, subID, ownerID, paused, reason); err != nil {
+		blog.Errorf("set subscription %d paused %v failed, err: %v", subID, paused, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeUpdateFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// subscriptionCollector export the delivery statistics of subscriptions as metrics
+type subscriptionCollector struct {
+	s *Service
+}
+
+func (c *subscriptionCollector) Collect() []metric.MetricInterf {
+	subs := make([]metadata.Subscription, 0)
+	fields := []string{common.BKSubscriptionIDField, "paused"}
+	if err := c.s.db.Table(common.BKTableNameSubscription).Find(nil).Fields(fields...).All(c.s.ctx, &subs); err != nil {
+		blog.Errorf("collect subscription metrics, but get subscriptions failed, err: %v", err)
+		return nil
+	}
+
+	metrics := make([]metric.MetricInterf, 0)
+	gauge := func(subID int64, name, help string, value float64) {
+		m := plugin.NewCounterMetric(fmt.Sprintf("event_subscription_%d_%s", subID, name), help)
+		m.Set(value)
+		metrics = append(metrics, m)
+	}
+	for index := range subs {
+		sub := &subs[index]
+		stat := distribution.GetSubscriptionStatistics(c.s.cache, sub)
+		paused := 0.0
+		if sub.Paused {
+			paused = 1
+		}
+		gauge(sub.SubscriptionID, "total", "The delivery attempts of subscription.", float64(stat.Total))
+		gauge(sub.SubscriptionID, "failure", "The failed delivery attempts of subscription.", float64(stat.Failure))
+		gauge(sub.SubscriptionID, "consecutive_failure", "The failed attempts since the last success.", float64(stat.ConsecutiveFailure))
+		gauge(sub.SubscriptionID, "backlog", "The events waiting in queue.", float64(stat.Backlog))
+		gauge(sub.SubscriptionID, "paused", "Whether the subscription is paused.", paused)
+		gauge(sub.SubscriptionID, "latency_count", "The delivery attempts with latency recorded.", float64(stat.Latency.Count))
+		gauge(sub.SubscriptionID, "latency_sum_ms", "The sum of delivery latency in millisecond.", float64(stat.Latency.SumMs))
+		for _, bucket := range stat.Latency.Buckets {
+			le := bucket.LE
+			if le == "+Inf" {
+				le = "inf"
+			}
+			gauge(sub.SubscriptionID, "latency_le_"+le, "The delivery attempts not longer than the bound in millisecond.", float64(bucket.Count))
+		}
+	}
+	return metrics
+}
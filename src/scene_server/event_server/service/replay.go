@@ -0,0 +1,218 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CreateEventReplay create a task to deliver the events in the range to the subscription again,
+// the task is run by the event server in background.
+func (s *Service) CreateEventReplay(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+	dat := metadata.EventReplayRange{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("create event replay, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := dat.Validate(); err != nil {
+		blog.Errorf("create event replay, but the range is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	sub := metadata.Subscription{}
+	subCond := util.NewMapBuilder(common.BKSubscriptionIDField, subID, common.BKOwnerIDField, ownerID).Build()
+	if err := s.db.Table(common.BKTableNameSubscription).Find(subCond).One(s.ctx, &sub); err != nil {
+		if s.db.IsNotFoundError(err) {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return
+		}
+		blog.Errorf("create event replay, but get subscription %d failed, err: %v", subID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSelectFailed)})
+		return
+	}
+	if sub.IsPull() {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscriptionNotPush)})
+		return
+	}
+
+	feedCond := dat.Condition()
+	feedCond[common.BKSubscriptionIDField] = subID
+	feedCond[common.BKOwnerIDField] = ownerID
+	total, err := s.db.Table(common.BKTableNameEventFeed).Find(feedCond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("create event replay, but count the events of subscription %d failed, err: %v", subID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	id, err := s.db.NextSequence(s.ctx, common.BKTableNameEventReplay)
+	if err != nil {
+		blog.Errorf("create event replay, but get id failed, err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+	now := metadata.Now()
+	replay := metadata.EventReplay{
+		ReplayID:         int64(id),
+		SubscriptionID:   subID,
+		EventReplayRange: dat,
+		Status:           metadata.EventReplayStatusPending,
+		Total:            int64(total),
+		Operator:         util.GetUser(pheader),
+		OwnerID:          ownerID,
+		CreateTime:       now,
+		LastTime:         now,
+	}
+	if err := s.db.Table(common.BKTableNameEventReplay).Insert(s.ctx, replay); err != nil {
+		blog.Errorf("create event replay failed, err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(replay))
+}
+
+// SearchEventReplay search the replay tasks, the latest first by default
+func (s *Service) SearchEventReplay(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	var dat metadata.ParamSubscriptionSearch
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("search event replay, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := util.SetModOwner(dat.Condition, ownerID)
+	limit := dat.Page.Limit
+	if limit <= 0 {
+		limit = common.BKNoLimit
+	}
+	sort := dat.Page.Sort
+	if sort == "" {
+		sort = "-replay_id"
+	}
+
+	count, err := s.db.Table(common.BKTableNameEventReplay).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("search event replay count failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	results := make([]metadata.EventReplay, 0)
+	err = s.db.Table(common.BKTableNameEventReplay).Find(condition).Fields(dat.Fields...).Sort(sort).
+		Start(uint64(dat.Page.Start)).Limit(uint64(limit)).All(s.ctx, &results)
+	if err != nil {
+		blog.Errorf("search event replay failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspEventReplaySearch{Count: count, Info: results}))
+}
+
+// GetEventReplay get the replay task with its progress
+func (s *Service) GetEventReplay(req *restful.Request, resp *restful.Response) {
+	replay, ok := s.getEventReplay(req, resp, false)
+	if !ok {
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(replay))
+}
+
+// CancelEventReplay stop the replay task, the events already in queue are still delivered
+func (s *Service) CancelEventReplay(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	replay, ok := s.getEventReplay(req, resp, true)
+	if !ok {
+		return
+	}
+	if !replay.Status.IsActive() {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventReplayEnded)})
+		return
+	}
+
+	condition := map[string]interface{}{
+		"replay_id":           replay.ReplayID,
+		common.BKOwnerIDField: replay.OwnerID,
+	}
+	data := map[string]interface{}{
+		"status":    metadata.EventReplayStatusCanceled,
+		"last_time": metadata.Now(),
+	}
+	if err := s.db.Table(common.BKTableNameEventReplay).Update(s.ctx, condition, data); err != nil {
+		blog.Errorf("cancel event replay %d failed, err: %v", replay.ReplayID, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+	replay.Status = metadata.EventReplayStatusCanceled
+	resp.WriteEntity(metadata.NewSuccessResp(replay))
+}
+
+// getEventReplay get the replay task in path, the subscription in path is checked when withSubscription is true
+func (s *Service) getEventReplay(req *restful.Request, resp *restful.Response, withSubscription bool) (*metadata.EventReplay, bool) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	replayID, err := strconv.ParseInt(req.PathParameter("replayID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "replay_id")})
+		return nil, false
+	}
+	condition := util.NewMapBuilder("replay_id", replayID, common.BKOwnerIDField, ownerID).Build()
+	if withSubscription {
+		subID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+		if nil != err {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKSubscriptionIDField)})
+			return nil, false
+		}
+		condition[common.BKSubscriptionIDField] = subID
+	}
+
+	replay := new(metadata.EventReplay)
+	if err := s.db.Table(common.BKTableNameEventReplay).Find(condition).One(s.ctx, replay); err != nil {
+		if s.db.IsNotFoundError(err) {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return nil, false
+		}
+		blog.Errorf("get event replay %d failed, err: %v", replayID, err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return nil, false
+	}
+	return replay, true
+}
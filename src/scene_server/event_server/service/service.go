@@ -72,16 +72,67 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.DELETE("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UnSubscribe))
 	api.Route(api.PUT("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.Rebook))
 
+	api.Route(api.POST("/deadletter/search").To(s.SearchDeadLetter))
+	api.Route(api.GET("/deadletter/{deadLetterID}").To(s.GetDeadLetter))
+	api.Route(api.POST("/deadletter/replay/{subscribeID}").To(s.ReplayDeadLetter))
+	api.Route(api.POST("/deadletter/purge/{subscribeID}").To(s.PurgeDeadLetter))
+
+	api.Route(api.POST("/feed/cursor/{subscribeID}").To(s.CreateEventCursor))
+	api.Route(api.GET("/feed/cursor/{subscribeID}/{cursorID}").To(s.GetEventCursor))
+	api.Route(api.DELETE("/feed/cursor/{subscribeID}/{cursorID}").To(s.DeleteEventCursor))
+	api.Route(api.POST("/feed/fetch/{subscribeID}/{cursorID}").To(s.FetchEventFeed))
+	api.Route(api.POST("/feed/ack/{subscribeID}/{cursorID}").To(s.AckEventFeed))
+
+	api.Route(api.POST("/replay/search").To(s.SearchEventReplay))
+	api.Route(api.GET("/replay/{replayID}").To(s.GetEventReplay))
+	api.Route(api.POST("/replay/cancel/{subscribeID}/{replayID}").To(s.CancelEventReplay))
+	api.Route(api.POST("/replay/{subscribeID}").To(s.CreateEventReplay))
+
+	api.Route(api.POST("/subscription/pause/{subscribeID}").To(s.PauseSubscription))
+	api.Route(api.POST("/subscription/resume/{subscribeID}").To(s.ResumeSubscription))
+
 	container.Add(api)
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
+	// the delivery statistics of subscriptions are exported as metrics
+	metricConf := metric.Config{ModuleName: types.CC_MODULE_EVENTSERVER}
+	collector := metric.NewCollector("event_subscription", &subscriptionCollector{s: s})
+	for _, action := range metric.NewMetricController(metricConf, s.healthMeta, collector) {
+		if action.Path == "/healthz" {
+			continue
+		}
+		handler := action.HandlerFunc
+		healthzAPI.Route(healthzAPI.Method(action.Method).Path(action.Path).To(func(req *restful.Request, resp *restful.Response) {
+			handler(resp.ResponseWriter, req.Request)
+		}))
+	}
 	container.Add(healthzAPI)
 
 	return container
 }
 
 func (s *Service) Healthz(req *restful.Request, resp *restful.Response) {
+	meta := s.healthMeta()
+
+	info := metric.HealthInfo{
+		Module:     types.CC_MODULE_EVENTSERVER,
+		HealthMeta: meta,
+		AtTime:     metadata.Now(),
+	}
+
+	answer := metric.HealthResponse{
+		Code:    common.CCSuccess,
+		Data:    info,
+		OK:      meta.IsHealthy,
+		Result:  meta.IsHealthy,
+		Message: meta.Message,
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteEntity(answer)
+}
+
+func (s *Service) healthMeta() metric.HealthMeta {
 	meta := metric.HealthMeta{IsHealthy: true}
 
 	// zk health status
@@ -105,20 +156,5 @@ func (s *Service) Healthz(req *restful.Request, resp *restful.Response) {
 			break
 		}
 	}
-
-	info := metric.HealthInfo{
-		Module:     types.CC_MODULE_EVENTSERVER,
-		HealthMeta: meta,
-		AtTime:     metadata.Now(),
-	}
-
-	answer := metric.HealthResponse{
-		Code:    common.CCSuccess,
-		Data:    info,
-		OK:      meta.IsHealthy,
-		Result:  meta.IsHealthy,
-		Message: meta.Message,
-	}
-	resp.Header().Set("Content-Type", "application/json")
-	resp.WriteEntity(answer)
+	return meta
 }
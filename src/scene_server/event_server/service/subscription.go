@@ -22,12 +22,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"configcenter/src/auth/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/distribution"
 	"configcenter/src/scene_server/event_server/types"
 
 	"github.com/emicklei/go-restful"
@@ -56,6 +58,37 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 	}
 	sub.LastTime = now
 	sub.OwnerID = ownerID
+	// the subscription is paused and resumed by the pause and resume api only
+	sub.Paused = false
+	sub.PausedReason = ""
+
+	if sub.DeliveryMode != "" && sub.DeliveryMode != metadata.DeliveryModePush && !sub.IsPull() {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "delivery_mode")})
+		return
+	}
+	if sub.BatchSize < 0 || sub.BatchSize > metadata.MaxEventBatchSize {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "batch_size")})
+		return
+	}
+	if sub.BatchWait < 0 || sub.BatchWait > metadata.MaxEventBatchWait {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "batch_wait")})
+		return
+	}
+	if sub.TLSEnable && !strings.HasPrefix(strings.ToLower(sub.CallbackURL), "https://") {
+		blog.Errorf("add subscription, but tls is enabled with the callback url %s which is not https", sub.CallbackURL)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "tls_enable")})
+		return
+	}
+	if err := distribution.ValidateSubscriptionFilter(sub); err != nil {
+		blog.Errorf("add subscription, but the filter is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "condition")})
+		return
+	}
+	if err := distribution.ValidateSubscriptionSink(sub); err != nil {
+		blog.Errorf("add subscription, but the sink is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "sink_type")})
+		return
+	}
 
 	sub.SubscriptionForm = strings.Replace(sub.SubscriptionForm, " ", "", -1)
 
@@ -165,6 +198,26 @@ func (s *Service) UnSubscribe(req *restful.Request, resp *restful.Response) {
 		types.EventCacheDistQueuePrefix+subID,
 		types.EventCacheDistDonePrefix+subID)
 
+	// the dead letters can't be replayed without the subscription, nor the feed can be pulled
+	for _, table := range []string{common.BKTableNameEventDeadLetter, common.BKTableNameEventFeed, common.BKTableNameEventCursor} {
+		if err := s.db.Table(table).Delete(s.ctx, condiction); err != nil {
+			blog.Errorf("delete %s of subscription %d failed, err: %v", table, id, err)
+		}
+	}
+
+	// the active replays stop with the queue
+	replayCond := map[string]interface{}{
+		common.BKSubscriptionIDField: id,
+		common.BKOwnerIDField:        ownerID,
+		"status": map[string]interface{}{
+			common.BKDBIN: []metadata.EventReplayStatus{metadata.EventReplayStatusPending, metadata.EventReplayStatusRunning},
+		},
+	}
+	replayData := map[string]interface{}{"status": metadata.EventReplayStatusCanceled, "last_time": metadata.Now()}
+	if err := s.db.Table(common.BKTableNameEventReplay).Update(s.ctx, replayCond, replayData); err != nil {
+		blog.Errorf("cancel the replays of subscription %d failed, err: %v", id, err)
+	}
+
 	mesg, _ := json.Marshal(&sub)
 	s.cache.Publish(types.EventCacheProcessChannel, "delete"+string(mesg))
 
@@ -217,6 +270,33 @@ func (s *Service) rebook(id int64, ownerID string, sub *metadata.Subscription) e
 		}
 	}
 
+	if sub.DeliveryMode != "" && sub.DeliveryMode != metadata.DeliveryModePush && !sub.IsPull() {
+		blog.Errorf("update subscription %d, but the delivery mode %s is invalid", id, sub.DeliveryMode)
+		return fmt.Errorf("invalid delivery mode %s", sub.DeliveryMode)
+	}
+	if sub.BatchSize < 0 || sub.BatchSize > metadata.MaxEventBatchSize || sub.BatchWait < 0 || sub.BatchWait > metadata.MaxEventBatchWait {
+		blog.Errorf("update subscription %d, but the batch size %d or batch wait %d is invalid", id, sub.BatchSize, sub.BatchWait)
+		return fmt.Errorf("invalid batch size %d or batch wait %d", sub.BatchSize, sub.BatchWait)
+	}
+	if sub.TLSEnable && !strings.HasPrefix(strings.ToLower(sub.CallbackURL), "https://") {
+		blog.Errorf("update subscription %d, but tls is enabled with the callback url %s which is not https", id, sub.CallbackURL)
+		return fmt.Errorf("tls is enabled with the callback url which is not https")
+	}
+	if err := distribution.ValidateSubscriptionFilter(sub); err != nil {
+		blog.Errorf("update subscription %d, but the filter is invalid, err: %v", id, err)
+		return err
+	}
+	if err := distribution.ValidateSubscriptionSink(sub); err != nil {
+		blog.Errorf("update subscription %d, but the sink is invalid, err: %v", id, err)
+		return err
+	}
+	// the searched subscription is updated with the masked secret key, which keeps the old one
+	if sub.SecretKey == metadata.EventSecretKeyMask {
+		sub.SecretKey = oldsub.SecretKey
+	}
+	sub.Paused = oldsub.Paused
+	sub.PausedReason = oldsub.PausedReason
+
 	sub.SubscriptionID = oldsub.SubscriptionID
 	if sub.TimeOut <= 0 {
 		sub.TimeOut = 10
@@ -303,18 +383,9 @@ func (s *Service) Query(req *restful.Request, resp *restful.Response) {
 	}
 
 	for index := range results {
-		val := s.cache.HGetAll(types.EventCacheDistCallBackCountPrefix + fmt.Sprint(results[index].SubscriptionID)).Val()
-		failue, err := strconv.ParseInt(val["failue"], 10, 64)
-		if nil != err {
-			blog.Warnf("get failue value error %s", err.Error())
-		}
-		total, err := strconv.ParseInt(val["total"], 10, 64)
-		if nil != err {
-			blog.Warnf("get total value error %s", err.Error())
-		}
-		results[index].Statistics = &metadata.Statistics{
-			Total:   total,
-			Failure: failue,
+		results[index].Statistics = distribution.GetSubscriptionStatistics(s.cache, &results[index])
+		if results[index].SecretKey != "" {
+			results[index].SecretKey = metadata.EventSecretKeyMask
 		}
 	}
 
@@ -345,6 +416,11 @@ func (s *Service) Ping(req *restful.Request, resp *restful.Response) {
 
 	blog.Infof("requesting callback: %v,%s", callbackurl, callbackBody)
 	callbackreq, _ := http.NewRequest("POST", callbackurl, bytes.NewBufferString(callbackBody))
+	if dat.SecretKey != "" {
+		timestamp := time.Now().Unix()
+		callbackreq.Header.Set(common.BKHTTPEventTimestamp, strconv.FormatInt(timestamp, 10))
+		callbackreq.Header.Set(common.BKHTTPEventSignature, metadata.SignEventCallback(dat.SecretKey, timestamp, []byte(callbackBody)))
+	}
 	callbackResp, err := http.DefaultClient.Do(callbackreq)
 	if err != nil {
 		blog.Errorf("test distribute error:%v", err)
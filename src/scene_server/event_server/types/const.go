@@ -37,6 +37,12 @@ const (
 
 	EventCacheDistCallBackCountPrefix = common.BKCacheKeyV3Prefix + "event:dist_callback_"
 
+	// EventCacheReplayLockPrefix only one event server runs the replay task at the same time
+	EventCacheReplayLockPrefix = common.BKCacheKeyV3Prefix + "event:replay_lock_"
+
+	// EventCacheSinkStreamPrefix the redis streams of the subscriptions with redis sink
+	EventCacheSinkStreamPrefix = common.BKCacheKeyV3Prefix + "event:sink:"
+
 	// EventCacheSubscribeformKey the key prefix in cache
 	EventCacheSubscribeformKey = common.BKCacheKeyV3Prefix + "event:subscribeform:"
 	EventCacheSubscribesKey    = common.BKCacheKeyV3Prefix + "event:subscribers"
@@ -13,10 +13,18 @@
 package options
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/spf13/pflag"
 
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/common/metadata"
 	"configcenter/src/storage/dal/redis"
 )
 
@@ -42,6 +50,77 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 }
 
 type Config struct {
-	Redis redis.Config
-	Auth  authcenter.AuthConfig
+	Redis     redis.Config
+	Auth      authcenter.AuthConfig
+	Duplicate HostDuplicateConfig
+}
+
+// HostDuplicateConfig the config of duplicate host detection, it's replaced
+// when the config is updated, and read by the detection at the same time.
+type HostDuplicateConfig struct {
+	lock sync.RWMutex
+	conf metadata.HostDuplicateConfig
+}
+
+// Get returns the current config
+func (c *HostDuplicateConfig) Get() metadata.HostDuplicateConfig {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.conf
+}
+
+// Set replace the config
+func (c *HostDuplicateConfig) Set(conf metadata.HostDuplicateConfig) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.conf = conf
+}
+
+// defaultHostDuplicateInterval the duplicate hosts are detected once a day by default
+const defaultHostDuplicateInterval = 24 * time.Hour
+
+// ParseHostDuplicateConfig parse the config of duplicate host detection, the keys are in
+// format "field:weight,field:weight", and the interval "0" disables the detection.
+// the default config is returned when any item is invalid.
+func ParseHostDuplicateConfig(prefix string, configmap map[string]string) (metadata.HostDuplicateConfig, error) {
+	defaultCfg := metadata.HostDuplicateConfig{
+		Keys:      metadata.DefaultHostDuplicateKeys,
+		Threshold: metadata.DefaultHostDuplicateThreshold,
+		Interval:  defaultHostDuplicateInterval,
+	}
+	cfg := defaultCfg
+
+	if keys := strings.TrimSpace(configmap[prefix+".keys"]); len(keys) > 0 {
+		cfg.Keys = make([]metadata.HostDuplicateKey, 0)
+		for _, item := range strings.Split(keys, ",") {
+			parts := strings.Split(strings.TrimSpace(item), ":")
+			if len(parts) != 2 || len(parts[0]) == 0 {
+				return defaultCfg, fmt.Errorf(`invalid duplicate key "%s"`, item)
+			}
+			weight, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil || weight <= 0 {
+				return defaultCfg, fmt.Errorf(`invalid weight of duplicate key "%s"`, item)
+			}
+			cfg.Keys = append(cfg.Keys, metadata.HostDuplicateKey{Field: parts[0], Weight: weight})
+		}
+	}
+
+	if threshold := strings.TrimSpace(configmap[prefix+".threshold"]); len(threshold) > 0 {
+		var err error
+		cfg.Threshold, err = strconv.ParseInt(threshold, 10, 64)
+		if err != nil || cfg.Threshold <= 0 {
+			return defaultCfg, errors.New(`invalid duplicate "threshold" value`)
+		}
+	}
+
+	if interval := strings.TrimSpace(configmap[prefix+".interval"]); len(interval) > 0 {
+		var err error
+		if interval == "0" {
+			cfg.Interval = 0
+		} else if cfg.Interval, err = time.ParseDuration(interval); err != nil || cfg.Interval < time.Minute {
+			return defaultCfg, errors.New(`invalid duplicate "interval" value`)
+		}
+	}
+
+	return cfg, nil
 }
@@ -14,6 +14,7 @@ package options
 
 import "testing"
 import "github.com/spf13/pflag"
+import "configcenter/src/common/metadata"
 
 var svrOpt *ServerOption
 
@@ -31,3 +32,43 @@ func TestNewServerOption(t *testing.T) {
 func TestServerOption_AddFlags(t *testing.T) {
 	svrOpt.AddFlags(pflag.CommandLine)
 }
+
+func TestParseHostDuplicateConfig(t *testing.T) {
+	cfg, err := ParseHostDuplicateConfig("host.duplicate", map[string]string{})
+	if err != nil {
+		t.Fatalf("parse empty config failed, err: %v", err)
+	}
+	if len(cfg.Keys) != len(metadata.DefaultHostDuplicateKeys) || cfg.Interval != defaultHostDuplicateInterval {
+		t.Errorf("empty config should be the default, got %+v", cfg)
+	}
+
+	cfg, err = ParseHostDuplicateConfig("host.duplicate", map[string]string{
+		"host.duplicate.keys":      "bk_asset_id:10, bk_mac:5",
+		"host.duplicate.threshold": "5",
+		"host.duplicate.interval":  "0",
+	})
+	if err != nil {
+		t.Fatalf("parse config failed, err: %v", err)
+	}
+	if len(cfg.Keys) != 2 || cfg.Keys[1].Field != "bk_mac" || cfg.Keys[1].Weight != 5 {
+		t.Errorf("unexpected keys %+v", cfg.Keys)
+	}
+	if cfg.Threshold != 5 || cfg.Interval != 0 {
+		t.Errorf("unexpected threshold %d or interval %v", cfg.Threshold, cfg.Interval)
+	}
+
+	for _, invalid := range []map[string]string{
+		{"host.duplicate.keys": "bk_asset_id"},
+		{"host.duplicate.keys": "bk_asset_id:-1"},
+		{"host.duplicate.threshold": "abc"},
+		{"host.duplicate.interval": "1s"},
+	} {
+		cfg, err = ParseHostDuplicateConfig("host.duplicate", invalid)
+		if err == nil {
+			t.Errorf("config %v should be invalid", invalid)
+		}
+		if cfg.Threshold != metadata.DefaultHostDuplicateThreshold {
+			t.Errorf("invalid config %v should fall back to default, got %+v", invalid, cfg)
+		}
+	}
+}
@@ -122,6 +122,12 @@ func (h *HostServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 	if err != nil {
 		blog.Warnf("parse auth center config failed: %v", err)
 	}
+
+	duplicate, err := options.ParseHostDuplicateConfig("host.duplicate", current.ConfigMap)
+	if err != nil {
+		blog.Warnf("parse host duplicate config failed: %v, use the default config", err)
+	}
+	h.Config.Duplicate.Set(duplicate)
 }
 
 func newServerInfo(op *options.ServerOption) (*types.ServerInfo, error) {
@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+// FakeCloud the account type of the file backed fake provider, it's only
+// registered in test, as it reads the local file given by the endpoint.
+const FakeCloud = "fake_cloud"
+
+func init() {
+	Register(FakeCloud, &fileProvider{})
+}
+
+// FakeCloudData the content of the fake provider data file, for example:
+// {
+//     "secret_id": "id",
+//     "secret_key": "key",
+//     "regions": [{"id": "region-1", "name": "region one"}],
+//     "instances": [{"instance_id": "ins-1", "region": "region-1", "private_ip": ["127.0.0.1"], "os_name": "linux"}]
+// }
+// the credential is not checked when secret_id is empty.
+type FakeCloudData struct {
+	SecretID  string     `json:"secret_id"`
+	SecretKey string     `json:"secret_key"`
+	Regions   []Region   `json:"regions"`
+	Instances []Instance `json:"instances"`
+}
+
+// fileProvider a fake provider which read the regions and instances from
+// the file specified by the endpoint of credential, used to test cloud sync.
+type fileProvider struct{}
+
+func (f *fileProvider) load(cred Credential) (*FakeCloudData, error) {
+	if "" == cred.Endpoint {
+		return nil, errors.New("fake cloud data file is not specified")
+	}
+	content, err := ioutil.ReadFile(cred.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	data := new(FakeCloudData)
+	if err := json.Unmarshal(content, data); err != nil {
+		return nil, err
+	}
+	if "" != data.SecretID && (data.SecretID != cred.SecretID || data.SecretKey != cred.SecretKey) {
+		return nil, errors.New("AuthFailure: secret id or secret key is invalid")
+	}
+	return data, nil
+}
+
+func (f *fileProvider) ValidateEndpoint(endpoint string) error {
+	return nil
+}
+
+func (f *fileProvider) ValidateCredential(ctx context.Context, cred Credential) error {
+	_, err := f.load(cred)
+	return err
+}
+
+func (f *fileProvider) ListRegions(ctx context.Context, cred Credential) ([]Region, error) {
+	data, err := f.load(cred)
+	if err != nil {
+		return nil, err
+	}
+	return data.Regions, nil
+}
+
+func (f *fileProvider) ListInstances(ctx context.Context, cred Credential, region string) ([]Instance, error) {
+	data, err := f.load(cred)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Instance, 0)
+	for _, inst := range data.Instances {
+		if inst.Region == region {
+			result = append(result, inst)
+		}
+	}
+	return result, nil
+}
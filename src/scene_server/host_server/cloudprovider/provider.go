@@ -0,0 +1,187 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+)
+
+// the attributes of a cloud instance, used as the key of attribute mapping
+const (
+	AttrInstanceID   = "instance_id"
+	AttrInstanceName = "instance_name"
+	AttrRegion       = "region"
+	AttrZone         = "zone"
+	AttrPrivateIP    = "private_ip"
+	AttrPublicIP     = "public_ip"
+	AttrOsName       = "os_name"
+	AttrCPU          = "cpu"
+	AttrMemory       = "mem"
+)
+
+// Credential the account used to access the cloud provider
+type Credential struct {
+	SecretID  string
+	SecretKey string
+	// Endpoint the api address given by user, it must pass the ValidateEndpoint of the provider
+	Endpoint string
+}
+
+// Region a region of the cloud provider
+type Region struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Instance a cloud host instance
+type Instance struct {
+	InstanceID   string   `json:"instance_id"`
+	InstanceName string   `json:"instance_name"`
+	Region       string   `json:"region"`
+	Zone         string   `json:"zone"`
+	PrivateIPs   []string `json:"private_ip"`
+	PublicIPs    []string `json:"public_ip"`
+	OsName       string   `json:"os_name"`
+	CPU          int64    `json:"cpu"`
+	// Memory the memory size in MB
+	Memory int64 `json:"mem"`
+}
+
+// Attributes return the attributes of the instance, keyed by the Attr constants.
+// only the first ip is used, as host only has one inner ip and one outer ip.
+func (i *Instance) Attributes() map[string]interface{} {
+	attrs := map[string]interface{}{
+		AttrInstanceID:   i.InstanceID,
+		AttrInstanceName: i.InstanceName,
+		AttrRegion:       i.Region,
+		AttrZone:         i.Zone,
+		AttrPrivateIP:    "",
+		AttrPublicIP:     "",
+		AttrOsName:       i.OsName,
+		AttrCPU:          i.CPU,
+		AttrMemory:       i.Memory,
+	}
+	if len(i.PrivateIPs) > 0 {
+		attrs[AttrPrivateIP] = i.PrivateIPs[0]
+	}
+	if len(i.PublicIPs) > 0 {
+		attrs[AttrPublicIP] = i.PublicIPs[0]
+	}
+	return attrs
+}
+
+// Provider the interface a cloud provider plugin should implement
+type Provider interface {
+	// ValidateEndpoint check whether the endpoint given by user is allowed,
+	// empty endpoint means the default address of the provider.
+	ValidateEndpoint(endpoint string) error
+	// ValidateCredential check whether the credential can access the cloud provider
+	ValidateCredential(ctx context.Context, cred Credential) error
+	// ListRegions list all the regions can be accessed by the credential
+	ListRegions(ctx context.Context, cred Credential) ([]Region, error)
+	// ListInstances list all the host instances in the region
+	ListInstances(ctx context.Context, cred Credential, region string) ([]Instance, error)
+}
+
+var (
+	providerLock sync.RWMutex
+	providers    = make(map[string]Provider)
+)
+
+// Register register a cloud provider, the name is the account type of cloud sync task.
+// register the same name twice will replace the former one.
+func Register(name string, provider Provider) {
+	providerLock.Lock()
+	defer providerLock.Unlock()
+	providers[name] = provider
+	blog.Infof("registed cloud provider: %s", name)
+}
+
+// GetProvider get the cloud provider by name
+func GetProvider(name string) (Provider, error) {
+	providerLock.RLock()
+	defer providerLock.RUnlock()
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("cloud provider %s is not supported", name)
+	}
+	return provider, nil
+}
+
+// ListProviderNames list the name of all registered cloud providers
+func ListProviderNames() []string {
+	providerLock.RLock()
+	defer providerLock.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultAttrMapping the default mapping from instance attribute to host field
+var DefaultAttrMapping = map[string]string{
+	AttrPrivateIP: common.BKHostInnerIPField,
+	AttrPublicIP:  common.BKHostOuterIPField,
+	AttrOsName:    common.BKOSNameField,
+	AttrRegion:    common.BKHostCloudRegionField,
+}
+
+// MapInstance convert the instance into host data with the attribute mapping,
+// the default mapping is used for the attribute not configured in mapping.
+// the inner ip is always mapped, it's the identity of the host.
+func MapInstance(inst Instance, mapping map[string]string) mapstr.MapStr {
+	attrs := inst.Attributes()
+	host := mapstr.New()
+	for attr, field := range DefaultAttrMapping {
+		if _, ok := mapping[attr]; ok {
+			continue
+		}
+		host[field] = attrs[attr]
+	}
+	for attr, field := range mapping {
+		value, ok := attrs[attr]
+		if !ok || "" == field {
+			continue
+		}
+		host[field] = value
+	}
+	host[common.BKHostInnerIPField] = attrs[AttrPrivateIP]
+	return host
+}
+
+// ListAllInstances list the instances of all regions
+func ListAllInstances(ctx context.Context, provider Provider, cred Credential) ([]Instance, error) {
+	regions, err := provider.ListRegions(ctx, cred)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0)
+	for _, region := range regions {
+		regionInstances, err := provider.ListInstances(ctx, cred, region.ID)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, regionInstances...)
+	}
+	return instances, nil
+}
@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"configcenter/src/common"
+)
+
+func writeFakeData(t *testing.T, data *FakeCloudData) string {
+	content, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := ioutil.TempFile("", "fake_cloud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	return file.Name()
+}
+
+func TestFakeProvider(t *testing.T) {
+	path := writeFakeData(t, &FakeCloudData{
+		SecretID:  "id",
+		SecretKey: "key",
+		Regions:   []Region{{ID: "r1"}, {ID: "r2"}},
+		Instances: []Instance{
+			{InstanceID: "ins-1", Region: "r1", PrivateIPs: []string{"10.0.0.1"}, OsName: "linux"},
+			{InstanceID: "ins-2", Region: "r2", PrivateIPs: []string{"10.0.0.2"}, PublicIPs: []string{"1.1.1.1"}},
+		},
+	})
+	defer os.Remove(path)
+
+	provider, err := GetProvider(FakeCloud)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.ValidateCredential(context.Background(), Credential{SecretID: "id", SecretKey: "bad", Endpoint: path}); err == nil {
+		t.Errorf("ValidateCredential() with invalid key should fail")
+	}
+
+	cred := Credential{SecretID: "id", SecretKey: "key", Endpoint: path}
+	if err := provider.ValidateCredential(context.Background(), cred); err != nil {
+		t.Errorf("ValidateCredential() error = %v", err)
+	}
+
+	instances, err := ListAllInstances(context.Background(), provider, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 {
+		t.Errorf("ListAllInstances() got %d instances, want 2", len(instances))
+	}
+}
+
+func TestGetProviderNotSupported(t *testing.T) {
+	if _, err := GetProvider("not_exist_cloud"); err == nil {
+		t.Errorf("GetProvider() should fail for unknown provider")
+	}
+}
+
+func TestMapInstance(t *testing.T) {
+	inst := Instance{
+		InstanceID: "ins-1",
+		Region:     "r1",
+		PrivateIPs: []string{"10.0.0.1", "10.0.0.2"},
+		PublicIPs:  []string{"1.1.1.1"},
+		OsName:     "linux",
+		CPU:        4,
+	}
+
+	host := MapInstance(inst, nil)
+	if host[common.BKHostInnerIPField] != "10.0.0.1" || host[common.BKHostOuterIPField] != "1.1.1.1" ||
+		host[common.BKOSNameField] != "linux" || host[common.BKHostCloudRegionField] != "r1" {
+		t.Errorf("MapInstance() with default mapping got %v", host)
+	}
+
+	host = MapInstance(inst, map[string]string{AttrCPU: "bk_cpu", AttrOsName: ""})
+	if host["bk_cpu"] != int64(4) {
+		t.Errorf("MapInstance() bk_cpu = %v, want 4", host["bk_cpu"])
+	}
+	if _, ok := host[common.BKOSNameField]; ok {
+		t.Errorf("MapInstance() os name should not be mapped, got %v", host)
+	}
+	if host[common.BKHostInnerIPField] != "10.0.0.1" {
+		t.Errorf("MapInstance() inner ip = %v, want 10.0.0.1", host[common.BKHostInnerIPField])
+	}
+}
+
+func TestTencentCloudValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{"", false},
+		{"cvm.tencentcloudapi.com", false},
+		{"cvm.ap-guangzhou.tencentcloudapi.com", false},
+		{"/etc/passwd", true},
+		{"http://cvm.tencentcloudapi.com", true},
+		{"cvm.tencentcloudapi.com:8080", true},
+		{"cvm.tencentcloudapi.com/path", true},
+		{"tencentcloudapi.com.evil.com", true},
+		{"eviltencentcloudapi.com", true},
+	}
+	provider, err := GetProvider(TencentCloud)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if err := provider.ValidateEndpoint(tt.endpoint); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateEndpoint(%q) error = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+		}
+	}
+}
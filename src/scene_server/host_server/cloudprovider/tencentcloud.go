@@ -0,0 +1,165 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	com "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+)
+
+// TencentCloud the account type of tencent cloud
+const TencentCloud = "tencent_cloud"
+
+// the max instance number of one DescribeInstances request
+const tencentCloudPageSize = 100
+
+// tencentCloudEndpointRegexp the endpoint can only be a domain of tencent cloud api,
+// such as cvm.ap-guangzhou.tencentcloudapi.com, scheme, port and path are not allowed.
+var tencentCloudEndpointRegexp = regexp.MustCompile(`^([a-z0-9-]+\.)+tencentcloudapi\.com$`)
+
+func init() {
+	Register(TencentCloud, &tencentCloud{})
+}
+
+type tencentCloud struct{}
+
+func (t *tencentCloud) ValidateEndpoint(endpoint string) error {
+	if "" == endpoint || tencentCloudEndpointRegexp.MatchString(endpoint) {
+		return nil
+	}
+	return fmt.Errorf("endpoint %s is not a tencent cloud api domain", endpoint)
+}
+
+func (t *tencentCloud) newClient(cred Credential, region string) (*cvm.Client, error) {
+	if err := t.ValidateEndpoint(cred.Endpoint); err != nil {
+		return nil, err
+	}
+	credential := com.NewCredential(cred.SecretID, cred.SecretKey)
+
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.ReqMethod = common.BKHttpGet
+	cpf.HttpProfile.ReqTimeout = common.BKTencentCloudTimeOut
+	cpf.HttpProfile.Endpoint = common.TencentCloudUrl
+	if "" != cred.Endpoint {
+		cpf.HttpProfile.Endpoint = cred.Endpoint
+	}
+	cpf.SignMethod = common.TencentCloudSignMethod
+
+	return cvm.NewClient(credential, region, cpf)
+}
+
+func (t *tencentCloud) ValidateCredential(ctx context.Context, cred Credential) error {
+	_, err := t.ListRegions(ctx, cred)
+	return err
+}
+
+func (t *tencentCloud) ListRegions(ctx context.Context, cred Credential) ([]Region, error) {
+	client, err := t.newClient(cred, regions.Guangzhou)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.DescribeRegions(cvm.NewDescribeRegionsRequest())
+	if err != nil {
+		blog.Errorf("describe tencent cloud regions failed, err: %v", err)
+		return nil, err
+	}
+
+	result := make([]Region, 0)
+	if nil == response.Response {
+		return result, nil
+	}
+	for _, region := range response.Response.RegionSet {
+		if nil == region || nil == region.Region {
+			continue
+		}
+		item := Region{ID: *region.Region}
+		if nil != region.RegionName {
+			item.Name = *region.RegionName
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (t *tencentCloud) ListInstances(ctx context.Context, cred Credential, region string) ([]Instance, error) {
+	client, err := t.newClient(cred, region)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Instance, 0)
+	for offset := int64(0); ; offset += tencentCloudPageSize {
+		request := cvm.NewDescribeInstancesRequest()
+		request.Offset = com.Int64Ptr(offset)
+		request.Limit = com.Int64Ptr(tencentCloudPageSize)
+		response, err := client.DescribeInstances(request)
+		if err != nil {
+			blog.Errorf("describe tencent cloud instances failed, region: %s, err: %v", region, err)
+			return nil, err
+		}
+		if nil == response.Response {
+			break
+		}
+
+		for _, inst := range response.Response.InstanceSet {
+			if nil == inst {
+				continue
+			}
+			result = append(result, convertTencentInstance(region, inst))
+		}
+
+		if len(response.Response.InstanceSet) < tencentCloudPageSize ||
+			nil == response.Response.TotalCount || offset+tencentCloudPageSize >= *response.Response.TotalCount {
+			break
+		}
+	}
+	return result, nil
+}
+
+func convertTencentInstance(region string, inst *cvm.Instance) Instance {
+	result := Instance{
+		Region:     region,
+		PrivateIPs: com.StringValues(inst.PrivateIpAddresses),
+		PublicIPs:  com.StringValues(inst.PublicIpAddresses),
+	}
+	if nil != inst.InstanceId {
+		result.InstanceID = *inst.InstanceId
+	}
+	if nil != inst.InstanceName {
+		result.InstanceName = *inst.InstanceName
+	}
+	if nil != inst.Placement && nil != inst.Placement.Zone {
+		result.Zone = *inst.Placement.Zone
+	}
+	if nil != inst.OsName {
+		result.OsName = *inst.OsName
+	}
+	if nil != inst.CPU {
+		result.CPU = *inst.CPU
+	}
+	if nil != inst.Memory {
+		// tencent cloud return memory in GB
+		result.Memory = *inst.Memory * 1024
+	}
+	return result
+}
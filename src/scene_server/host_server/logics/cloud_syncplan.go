@@ -0,0 +1,514 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	hutil "configcenter/src/scene_server/host_server/util"
+)
+
+// cloudSyncPlan the changes a cloud sync run will make, all the host data
+// of updated hosts carry the host id.
+type cloudSyncPlan struct {
+	preview meta.CloudSyncPreview
+	// newHosts the cloud hosts not exist in cmdb
+	newHosts []mapstr.MapStr
+	// changedHosts the fields should be changed, include the fields need review
+	changedHosts []mapstr.MapStr
+	// updateHosts the fields can be updated directly
+	updateHosts []mapstr.MapStr
+	// reviewHosts the fields need review before update
+	reviewHosts []mapstr.MapStr
+	// vanishedHosts the hosts synced by the last run, but not exist in cloud now
+	vanishedHosts []mapstr.MapStr
+	// cloudIPs the inner ip of all the cloud hosts
+	cloudIPs []string
+	// existHosts the exist hosts of the updated hosts, key is host id
+	existHosts map[int64]mapstr.MapStr
+}
+
+// ValidateCloudSyncPolicy check the conflict policy and vanished policy of the cloud task
+func ValidateCloudSyncPolicy(conflictPolicy map[string]string, vanishedPolicy string) error {
+	for field, policy := range conflictPolicy {
+		switch policy {
+		case meta.CloudConflictPolicyProvider, meta.CloudConflictPolicyCMDB, meta.CloudConflictPolicyReview:
+		default:
+			return fmt.Errorf("unknown conflict policy %s of field %s", policy, field)
+		}
+		if field == common.BKHostInnerIPField {
+			return fmt.Errorf("conflict policy of %s is not allowed", field)
+		}
+	}
+
+	switch vanishedPolicy {
+	case meta.CloudVanishedPolicyIgnore, meta.CloudVanishedPolicyMark, meta.CloudVanishedPolicyRecycle, meta.CloudVanishedPolicyDelete:
+	default:
+		return fmt.Errorf("unknown vanished policy %s", vanishedPolicy)
+	}
+	return nil
+}
+
+// GetCloudTask get the cloud task by id
+func (lgc *Logics) GetCloudTask(ctx context.Context, taskID int64) (*meta.CloudTaskInfo, error) {
+	opt := map[string]interface{}{common.BKCloudTaskID: taskID}
+	response, err := lgc.CoreAPI.HostController().Cloud().SearchCloudTask(ctx, lgc.header, opt)
+	if err != nil {
+		blog.Errorf("get cloud task %d failed, err: %v, rid: %s", taskID, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCloudGetTaskFail)
+	}
+	if len(response.Info) == 0 {
+		blog.Errorf("get cloud task %d failed, not found, rid: %s", taskID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCloudGetTaskFail)
+	}
+	return &response.Info[0], nil
+}
+
+// PreviewCloudSync return the hosts will be added, updated and vanished by the cloud task, nothing is changed.
+func (lgc *Logics) PreviewCloudSync(ctx context.Context, taskID int64) (*meta.CloudSyncPreview, error) {
+	taskInfo, err := lgc.GetCloudTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := lgc.buildCloudSyncPlan(ctx, *taskInfo)
+	if err != nil {
+		blog.Errorf("preview cloud task %d failed, err: %v, rid: %s", taskID, err, lgc.rid)
+		return nil, err
+	}
+	return &plan.preview, nil
+}
+
+func (lgc *Logics) buildCloudSyncPlan(ctx context.Context, taskInfo meta.CloudTaskInfo) (*cloudSyncPlan, error) {
+	// obtain the hosts from cc_HostBase
+	body := new(meta.HostCommonSearch)
+	host, err := lgc.SearchHost(ctx, body, false)
+	if err != nil {
+		blog.Errorf("search host failed, err: %v, rid: %s", err, lgc.rid)
+		return nil, err
+	}
+
+	existHostMap := make(map[string]mapstr.MapStr, 0)
+	for i := 0; i < host.Count; i++ {
+		hostInfo, err := mapstr.NewFromInterface(host.Info[i]["host"])
+		if err != nil {
+			blog.Errorf("get hostInfo failed with err: %v, rid: %s", err, lgc.rid)
+			return nil, err
+		}
+
+		ip, err := hostInfo.String(common.BKHostInnerIPField)
+		if err != nil {
+			blog.Errorf("get hostIp failed with err: %v, rid: %s", err, lgc.rid)
+			return nil, err
+		}
+
+		existHostMap[ip] = hostInfo
+	}
+
+	// ObtainCloudHosts obtain cloud hosts
+	cloudHostInfo, err := lgc.ObtainCloudHosts(ctx, taskInfo)
+	if err != nil {
+		blog.Errorf("obtain cloud hosts failed with err: %v, rid: %s", err, lgc.rid)
+		return nil, err
+	}
+
+	plan := &cloudSyncPlan{
+		preview: meta.CloudSyncPreview{
+			Added:    make([]mapstr.MapStr, 0),
+			Updated:  make([]meta.CloudHostDiff, 0),
+			Vanished: make([]mapstr.MapStr, 0),
+		},
+		existHosts: make(map[int64]mapstr.MapStr, 0),
+	}
+	cloudIPMap := make(map[string]bool, 0)
+	for _, hostInfo := range cloudHostInfo {
+		innerIP, err := hostInfo.String(common.BKHostInnerIPField)
+		if err != nil || "" == innerIP {
+			blog.Errorf("cloud host has no inner ip, host: %v, rid: %s", hostInfo, lgc.rid)
+			continue
+		}
+		if cloudIPMap[innerIP] {
+			continue
+		}
+		cloudIPMap[innerIP] = true
+		plan.cloudIPs = append(plan.cloudIPs, innerIP)
+
+		existHost, ok := existHostMap[innerIP]
+		if !ok {
+			plan.newHosts = append(plan.newHosts, hostInfo)
+			plan.preview.Added = append(plan.preview.Added, hostInfo)
+			continue
+		}
+
+		existHostID, err := existHost.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("get hostID failed, host: %v, err: %v, rid: %s", existHost, err, lgc.rid)
+			return nil, err
+		}
+		diffs := diffCloudHost(existHost, hostInfo, taskInfo.ConflictPolicy)
+		if len(diffs) == 0 {
+			continue
+		}
+		plan.preview.Updated = append(plan.preview.Updated, meta.CloudHostDiff{HostID: existHostID, InnerIP: innerIP, Fields: diffs})
+
+		changed := mapstr.MapStr{common.BKHostIDField: existHostID, common.BKHostInnerIPField: innerIP}
+		update := changed.Clone()
+		review := changed.Clone()
+		for _, diff := range diffs {
+			switch diff.Policy {
+			case meta.CloudConflictPolicyCMDB:
+				continue
+			case meta.CloudConflictPolicyReview:
+				review[diff.Field] = diff.After
+			default:
+				update[diff.Field] = diff.After
+			}
+			changed[diff.Field] = diff.After
+		}
+		// only host id and inner ip means nothing to change
+		if len(changed) > 2 {
+			plan.changedHosts = append(plan.changedHosts, changed)
+		}
+		if len(update) > 2 {
+			plan.updateHosts = append(plan.updateHosts, update)
+			plan.existHosts[existHostID] = existHost
+		}
+		if len(review) > 2 {
+			plan.reviewHosts = append(plan.reviewHosts, review)
+		}
+	}
+
+	for _, ip := range taskInfo.SyncedIPs {
+		if cloudIPMap[ip] {
+			continue
+		}
+		existHost, ok := existHostMap[ip]
+		if !ok {
+			continue
+		}
+		plan.vanishedHosts = append(plan.vanishedHosts, existHost)
+		plan.preview.Vanished = append(plan.preview.Vanished, existHost)
+	}
+
+	return plan, nil
+}
+
+// diffCloudHost compare the host data mapped from cloud with the exist host
+func diffCloudHost(existHost, cloudHost mapstr.MapStr, conflictPolicy map[string]string) []meta.CloudFieldDiff {
+	fields := make([]string, 0)
+	for field := range cloudHost {
+		if field == common.BKHostInnerIPField || field == common.BKHostIDField {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	diffs := make([]meta.CloudFieldDiff, 0)
+	for _, field := range fields {
+		existValue, _ := existHost.String(field)
+		cloudValue, _ := cloudHost.String(field)
+		if existValue == cloudValue {
+			continue
+		}
+		policy, ok := conflictPolicy[field]
+		if !ok {
+			policy = meta.CloudConflictPolicyProvider
+		}
+		diffs = append(diffs, meta.CloudFieldDiff{
+			Field:  field,
+			Before: existHost[field],
+			After:  cloudHost[field],
+			Policy: policy,
+		})
+	}
+	return diffs
+}
+
+// skipLockedCloudHosts remove the locked hosts from the hosts to update, the locked hosts
+// are not changed whatever the conflict policy is, their inner ip are returned to be reported.
+func (lgc *Logics) skipLockedCloudHosts(ctx context.Context, plan *cloudSyncPlan) ([]string, error) {
+	if len(plan.updateHosts) == 0 {
+		return nil, nil
+	}
+	existHosts := make([]mapstr.MapStr, 0)
+	for _, host := range plan.updateHosts {
+		hostID, _ := host.Int64(common.BKHostIDField)
+		existHosts = append(existHosts, plan.existHosts[hostID])
+	}
+	_, locked, err := lgc.splitLockedHosts(ctx, existHosts)
+	if err != nil {
+		return nil, err
+	}
+	if len(locked) == 0 {
+		return nil, nil
+	}
+
+	lockedIPs := make([]string, 0)
+	lockedIDs := make(map[int64]bool, 0)
+	for _, host := range locked {
+		hostID, _ := host.Int64(common.BKHostIDField)
+		innerIP, _ := host.String(common.BKHostInnerIPField)
+		lockedIDs[hostID] = true
+		lockedIPs = append(lockedIPs, innerIP)
+	}
+	updateHosts := make([]mapstr.MapStr, 0)
+	for _, host := range plan.updateHosts {
+		hostID, _ := host.Int64(common.BKHostIDField)
+		if !lockedIDs[hostID] {
+			updateHosts = append(updateHosts, host)
+		}
+	}
+	plan.updateHosts = updateHosts
+	blog.Warnf("update cloud hosts, skip the locked hosts %v, rid: %s", lockedIPs, lgc.rid)
+	return lockedIPs, nil
+}
+
+// saveCloudSyncedIPs record the hosts synced by the task, the next run use it to find the vanished hosts
+func (lgc *Logics) saveCloudSyncedIPs(ctx context.Context, taskID int64, ips []string) error {
+	if nil == ips {
+		ips = make([]string, 0)
+	}
+	updateData := mapstr.MapStr{
+		common.BKCloudTaskID:        taskID,
+		common.BKCloudSyncedIPField: ips,
+	}
+	if _, err := lgc.CoreAPI.HostController().Cloud().UpdateCloudTask(ctx, lgc.header, updateData); err != nil {
+		blog.Errorf("save cloud task %d synced hosts failed, err: %v, rid: %s", taskID, err, lgc.rid)
+		return err
+	}
+	return nil
+}
+
+// handleVanishedCloudHosts handle the hosts vanished from cloud with the vanished policy of the task,
+// the locked hosts are not recycled or deleted, their inner ip are returned to be reported.
+func (lgc *Logics) handleVanishedCloudHosts(ctx context.Context, taskInfo meta.CloudTaskInfo, hosts []mapstr.MapStr) ([]string, error) {
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	switch taskInfo.VanishedPolicy {
+	case meta.CloudVanishedPolicyMark:
+		return nil, lgc.markVanishedCloudHosts(ctx, taskInfo, hosts)
+	case meta.CloudVanishedPolicyRecycle, meta.CloudVanishedPolicyDelete:
+	default:
+		return nil, nil
+	}
+
+	unlocked, locked, err := lgc.splitLockedHosts(ctx, hosts)
+	if err != nil {
+		return nil, err
+	}
+	lockedIPs := make([]string, 0)
+	for _, host := range locked {
+		innerIP, _ := host.String(common.BKHostInnerIPField)
+		lockedIPs = append(lockedIPs, innerIP)
+	}
+	if len(lockedIPs) > 0 {
+		blog.Warnf("handle vanished cloud hosts, skip the locked hosts %v, task: %d, rid: %s", lockedIPs, taskInfo.TaskID, lgc.rid)
+	}
+
+	if taskInfo.VanishedPolicy == meta.CloudVanishedPolicyRecycle {
+		return lockedIPs, lgc.recycleVanishedCloudHosts(ctx, taskInfo, unlocked)
+	}
+	return lockedIPs, lgc.deleteVanishedCloudHosts(ctx, taskInfo, unlocked)
+}
+
+// markVanishedCloudHosts add the vanished hosts to resource confirm list, skip the host already marked
+func (lgc *Logics) markVanishedCloudHosts(ctx context.Context, taskInfo meta.CloudTaskInfo, hosts []mapstr.MapStr) error {
+	opt := map[string]interface{}{
+		common.BKCloudTaskID:  taskInfo.TaskID,
+		common.BKResourceType: common.BKVanishedHost,
+	}
+	confirmHosts, err := lgc.CoreAPI.HostController().Cloud().SearchConfirm(ctx, lgc.header, opt)
+	if err != nil {
+		blog.Errorf("get confirm info failed with err: %v, rid: %s", err, lgc.rid)
+		return err
+	}
+	markedIPs := make(map[string]bool, 0)
+	for _, confirmInfo := range confirmHosts.Info {
+		if ip, ok := confirmInfo[common.BKHostInnerIPField].(string); ok {
+			markedIPs[ip] = true
+		}
+	}
+
+	for _, host := range hosts {
+		innerIP, _ := host.String(common.BKHostInnerIPField)
+		if markedIPs[innerIP] {
+			continue
+		}
+		hostID, _ := host.Int64(common.BKHostIDField)
+		hostData := mapstr.MapStr{common.BKHostIDField: hostID, common.BKHostInnerIPField: innerIP}
+		resourceConfirm := newCloudResourceConfirm(taskInfo, hostData, common.BKVanishedHost)
+		resourceConfirm[common.BKHostOuterIPField] = host[common.BKHostOuterIPField]
+		resourceConfirm[common.BKOSNameField] = host[common.BKOSNameField]
+		if _, err := lgc.CoreAPI.HostController().Cloud().ResourceConfirm(ctx, lgc.header, resourceConfirm); err != nil {
+			blog.Errorf("add vanished host confirm failed, confirmInfo: %#v, err: %v, rid: %s", resourceConfirm, err, lgc.rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// recycleVanishedCloudHosts move the vanished hosts to the fault module of their business,
+// the host of the business has no fault module is marked.
+func (lgc *Logics) recycleVanishedCloudHosts(ctx context.Context, taskInfo meta.CloudTaskInfo, hosts []mapstr.MapStr) error {
+	hostIDArr, hostMap := vanishedHostIDs(hosts)
+	if len(hostIDArr) == 0 {
+		return nil
+	}
+	relations, err := lgc.GetConfigByCond(ctx, meta.HostModuleRelationRequest{HostIDArr: hostIDArr})
+	if err != nil {
+		return err
+	}
+	appHostMap := make(map[int64][]int64, 0)
+	for _, relation := range relations {
+		if !util.ContainsInt64(appHostMap[relation.AppID], relation.HostID) {
+			appHostMap[relation.AppID] = append(appHostMap[relation.AppID], relation.HostID)
+		}
+	}
+
+	unrecycled := make([]mapstr.MapStr, 0)
+	for appID, appHostIDArr := range appHostMap {
+		cond := hutil.NewOperation().WithModuleName(common.DefaultFaultModuleName).WithAppID(appID).Data()
+		cond[common.BKDefaultField] = common.DefaultFaultModuleFlag
+		moduleID, err := lgc.GetResoulePoolModuleID(ctx, cond)
+		if err != nil {
+			blog.Warnf("recycle vanished cloud hosts, business %d has no fault module, err: %v, rid: %s", appID, err, lgc.rid)
+			for _, hostID := range appHostIDArr {
+				unrecycled = append(unrecycled, hostMap[hostID])
+			}
+			continue
+		}
+
+		audit := lgc.NewHostModuleLog(appHostIDArr)
+		if err := audit.WithPrevious(ctx); err != nil {
+			return err
+		}
+		input := &meta.TransferHostToInnerModule{ApplicationID: appID, ModuleID: moduleID, HostID: appHostIDArr}
+		result, err := lgc.CoreAPI.CoreService().Host().TransferHostToInnerModule(ctx, lgc.header, input)
+		if err != nil {
+			blog.Errorf("recycle vanished cloud hosts http do error, input: %+v, err: %v, rid: %s", input, err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("recycle vanished cloud hosts failed, input: %+v, err: %s, rid: %s", input, result.ErrMsg, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+		if err := audit.SaveAudit(ctx, appID, lgc.user, "recycle vanished cloud host"); err != nil {
+			blog.Errorf("recycle vanished cloud hosts, save audit failed, err: %v, rid: %s", err, lgc.rid)
+		}
+	}
+
+	return lgc.markVanishedCloudHosts(ctx, taskInfo, unrecycled)
+}
+
+// deleteVanishedCloudHosts delete the vanished hosts in resource pool, the host
+// assigned to business is marked, it's used by somebody and should be handled by hand.
+func (lgc *Logics) deleteVanishedCloudHosts(ctx context.Context, taskInfo meta.CloudTaskInfo, hosts []mapstr.MapStr) error {
+	hostIDArr, hostMap := vanishedHostIDs(hosts)
+	if len(hostIDArr) == 0 {
+		return nil
+	}
+	defaultAppID, err := lgc.GetDefaultAppIDWithSupplier(ctx)
+	if err != nil {
+		return err
+	}
+	relations, err := lgc.GetConfigByCond(ctx, meta.HostModuleRelationRequest{HostIDArr: hostIDArr})
+	if err != nil {
+		return err
+	}
+	inBusiness := make(map[int64]bool, 0)
+	for _, relation := range relations {
+		if relation.AppID != defaultAppID {
+			inBusiness[relation.HostID] = true
+		}
+	}
+
+	deleteIDArr := make([]int64, 0)
+	unDeleted := make([]mapstr.MapStr, 0)
+	for _, hostID := range hostIDArr {
+		if inBusiness[hostID] {
+			unDeleted = append(unDeleted, hostMap[hostID])
+			continue
+		}
+		deleteIDArr = append(deleteIDArr, hostID)
+	}
+
+	if len(deleteIDArr) > 0 {
+		hostFields, err := lgc.GetHostAttributes(ctx, util.GetOwnerID(lgc.header), nil)
+		if err != nil {
+			return err
+		}
+		logs := make(map[int64]meta.SaveAuditLogParams, 0)
+		for _, hostID := range deleteIDArr {
+			logger := lgc.NewHostLog(ctx, util.GetOwnerID(lgc.header))
+			if err := logger.WithPrevious(ctx, strconv.FormatInt(hostID, 10), hostFields); err != nil {
+				return err
+			}
+			logs[hostID] = logger.AuditLog(ctx, hostID)
+		}
+
+		if err := lgc.AuthManager.DeregisterHostsByID(ctx, lgc.header, deleteIDArr...); err != nil {
+			blog.Errorf("deregister vanished cloud hosts from iam failed, hosts: %+v, err: %v, rid: %s", deleteIDArr, err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+		}
+		input := &meta.DeleteHostRequest{ApplicationID: defaultAppID, HostIDArr: deleteIDArr}
+		result, err := lgc.CoreAPI.CoreService().Host().DeleteHost(ctx, lgc.header, input)
+		if err != nil {
+			blog.Errorf("delete vanished cloud hosts http do error, input: %+v, err: %v, rid: %s", input, err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("delete vanished cloud hosts failed, input: %+v, err: %s, rid: %s", input, result.ErrMsg, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+
+		auditLogs := make([]meta.SaveAuditLogParams, 0)
+		for _, item := range logs {
+			item.Model = common.BKInnerObjIDHost
+			item.OpDesc = "delete vanished cloud host"
+			item.OpType = auditoplog.AuditOpTypeDel
+			auditLogs = append(auditLogs, item)
+		}
+		auditResult, err := lgc.CoreAPI.CoreService().Audit().SaveAuditLog(ctx, lgc.header, auditLogs...)
+		if err != nil || !auditResult.Result {
+			blog.Errorf("delete vanished cloud hosts, save audit log failed, err: %v, result: %+v, rid: %s", err, auditResult, lgc.rid)
+		}
+	}
+
+	return lgc.markVanishedCloudHosts(ctx, taskInfo, unDeleted)
+}
+
+func vanishedHostIDs(hosts []mapstr.MapStr) ([]int64, map[int64]mapstr.MapStr) {
+	hostIDArr := make([]int64, 0)
+	hostMap := make(map[int64]mapstr.MapStr, 0)
+	for _, host := range hosts {
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			continue
+		}
+		hostIDArr = append(hostIDArr, hostID)
+		hostMap[hostID] = host
+	}
+	return hostIDArr, hostMap
+}
@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestValidateCloudSyncPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		conflictPolicy map[string]string
+		vanishedPolicy string
+		wantErr        bool
+	}{
+		{"default policy", nil, metadata.CloudVanishedPolicyIgnore, false},
+		{"all conflict policies", map[string]string{
+			common.BKHostOuterIPField:     metadata.CloudConflictPolicyProvider,
+			common.BKOSNameField:          metadata.CloudConflictPolicyCMDB,
+			common.BKHostCloudRegionField: metadata.CloudConflictPolicyReview,
+		}, metadata.CloudVanishedPolicyMark, false},
+		{"recycle", nil, metadata.CloudVanishedPolicyRecycle, false},
+		{"delete", nil, metadata.CloudVanishedPolicyDelete, false},
+		{"unknown conflict policy", map[string]string{common.BKOSNameField: "keep"}, metadata.CloudVanishedPolicyIgnore, true},
+		{"inner ip policy", map[string]string{common.BKHostInnerIPField: metadata.CloudConflictPolicyCMDB}, metadata.CloudVanishedPolicyIgnore, true},
+		{"unknown vanished policy", nil, "remove", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCloudSyncPolicy(tt.conflictPolicy, tt.vanishedPolicy); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCloudSyncPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiffCloudHost(t *testing.T) {
+	existHost := mapstr.MapStr{
+		common.BKHostIDField:      int64(1),
+		common.BKHostInnerIPField: "10.0.0.1",
+		common.BKHostOuterIPField: "1.1.1.1",
+		common.BKOSNameField:      "linux",
+	}
+	tests := []struct {
+		name           string
+		cloudHost      mapstr.MapStr
+		conflictPolicy map[string]string
+		want           []metadata.CloudFieldDiff
+	}{
+		{
+			name:      "nothing changed",
+			cloudHost: mapstr.MapStr{common.BKHostInnerIPField: "10.0.0.1", common.BKHostOuterIPField: "1.1.1.1", common.BKOSNameField: "linux"},
+			want:      []metadata.CloudFieldDiff{},
+		},
+		{
+			name:      "inner ip and host id are not compared",
+			cloudHost: mapstr.MapStr{common.BKHostInnerIPField: "10.0.0.2", common.BKHostIDField: int64(2)},
+			want:      []metadata.CloudFieldDiff{},
+		},
+		{
+			name:      "default provider policy",
+			cloudHost: mapstr.MapStr{common.BKHostOuterIPField: "2.2.2.2", common.BKOSNameField: "linux"},
+			want: []metadata.CloudFieldDiff{
+				{Field: common.BKHostOuterIPField, Before: "1.1.1.1", After: "2.2.2.2", Policy: metadata.CloudConflictPolicyProvider},
+			},
+		},
+		{
+			name:           "configured policy and sorted fields",
+			cloudHost:      mapstr.MapStr{common.BKOSNameField: "windows", common.BKHostOuterIPField: "2.2.2.2"},
+			conflictPolicy: map[string]string{common.BKOSNameField: metadata.CloudConflictPolicyReview},
+			want: []metadata.CloudFieldDiff{
+				{Field: common.BKHostOuterIPField, Before: "1.1.1.1", After: "2.2.2.2", Policy: metadata.CloudConflictPolicyProvider},
+				{Field: common.BKOSNameField, Before: "linux", After: "windows", Policy: metadata.CloudConflictPolicyReview},
+			},
+		},
+		{
+			name:      "field not in cmdb",
+			cloudHost: mapstr.MapStr{common.BKHostCloudRegionField: "r1"},
+			want: []metadata.CloudFieldDiff{
+				{Field: common.BKHostCloudRegionField, Before: nil, After: "r1", Policy: metadata.CloudConflictPolicyProvider},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffCloudHost(existHost, tt.cloudHost, tt.conflictPolicy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffCloudHost() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,17 +23,12 @@ import (
 	"sync"
 	"time"
 
-	com "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
-	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
-
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/cloudprovider"
 	hutil "configcenter/src/scene_server/host_server/util"
 )
 
@@ -462,208 +457,81 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 		lgc.CloudSyncHistory(ctx, taskInfo.TaskID, startTime, cloudHistory)
 	}()
 
-	// obtain the hosts from cc_HostBase
-	body := new(meta.HostCommonSearch)
-	host, err := lgc.SearchHost(ctx, body, false)
+	// the task in memory is loaded when the sync started, reload it to get the latest
+	// policy and the hosts synced by the last run.
+	latestTask, err := lgc.GetCloudTask(ctx, taskInfo.TaskID)
 	if err != nil {
-		blog.Errorf("search host failed, err: %v, rid: %s", err, lgc.rid)
 		errOrigin = err
 		return err
 	}
+	taskInfo = *latestTask
 
-	existHostList := make([]string, 0)
-	for i := 0; i < host.Count; i++ {
-		hostInfo, err := mapstr.NewFromInterface(host.Info[i]["host"])
-		if err != nil {
-			blog.Errorf("get hostInfo failed with err: %v, rid: %s", err, lgc.rid)
-			errOrigin = err
-			return err
-		}
-
-		ip, err := hostInfo.String(common.BKHostInnerIPField)
-		if err != nil {
-			blog.Errorf("get hostIp failed with err: %v, rid: %s", err, lgc.rid)
-			errOrigin = err
-			return err
-		}
-
-		existHostList = append(existHostList, ip)
-	}
-
-	// obtain hosts from TencentCloud needs secretID and secretKey
-	decodeBytes, errDecode := base64.StdEncoding.DecodeString(taskInfo.SecretKey)
-	if errDecode != nil {
-		blog.Errorf("Base64 decode secretKey failed, rid: %s", lgc.rid)
-		errOrigin = errDecode
-		return errDecode
-	}
-	secretKey := string(decodeBytes)
-
-	secretID := taskInfo.SecretID
-
-	// ObtainCloudHosts obtain cloud hosts
-	cloudHostInfo, err := lgc.ObtainCloudHosts(ctx, secretID, secretKey)
+	plan, err := lgc.buildCloudSyncPlan(ctx, taskInfo)
 	if err != nil {
-		blog.Errorf("obtain cloud hosts failed with err: %v, rid: %s", err, lgc.rid)
+		blog.Errorf("build cloud sync plan failed, err: %v, rid: %s", err, lgc.rid)
 		errOrigin = err
 		return err
 	}
 
-	// pick out the new add cloud hosts
-	newAddHost := make([]string, 0)
-	newCloudHost := make([]mapstr.MapStr, 0)
-	for _, hostInfo := range cloudHostInfo {
-		newHostInnerip, ok := hostInfo[common.BKHostInnerIPField].(string)
-		if !ok {
-			blog.Errorf("interface convert to string failed, rid: %s", lgc.rid)
-		}
-		if !util.InStrArr(existHostList, newHostInnerip) {
-			newAddHost = append(newAddHost, newHostInnerip)
-			newCloudHost = append(newCloudHost, hostInfo)
-		}
-	}
+	cloudHistory.NewAdd = len(plan.newHosts)
+	cloudHistory.AttrChanged = len(plan.changedHosts)
+	cloudHistory.Vanished = len(plan.vanishedHosts)
 
-	// pick out the hosts that has changed attributes
-	cloudHostAttr := make([]mapstr.MapStr, 0)
-	for _, hostInfo := range cloudHostInfo {
-		newHostInnerip, ok := hostInfo[common.BKHostInnerIPField].(string)
-		if !ok {
-			blog.Errorf("interface convert to string failed, err: %v, rid: %s", err, lgc.rid)
-			continue
-		}
-		newHostOuterip, ok := hostInfo[common.BKHostOuterIPField].(string)
-		if !ok {
-			blog.Errorf("interface convert to string failed, err: %v, rid: %s", err, lgc.rid)
-			continue
-		}
-		newHostOsname, ok := hostInfo[common.BKOSNameField].(string)
-		if !ok {
-			blog.Errorf("interface convert to string failed, err: %v, rid: %s", err, lgc.rid)
-			continue
+	if taskInfo.ResourceConfirm {
+		newAddNum, err := lgc.NewAddConfirm(ctx, taskInfo, plan.newHosts)
+		cloudHistory.NewAdd = newAddNum
+		if err != nil {
+			blog.Errorf("newly add cloud resource confirm failed, err: %v, rid: %s", err, lgc.rid)
+			errOrigin = err
+			return err
 		}
-
-		for i := 0; i < host.Count; i++ {
-			existHostInfo, err := mapstr.NewFromInterface(host.Info[i]["host"])
-			if err != nil {
-				blog.Errorf("get hostInfo failed with err: %v, rid: %s", err, lgc.rid)
-				errOrigin = err
-				return err
-			}
-
-			existHostIp, ok := existHostInfo.String(common.BKHostInnerIPField)
-			if ok != nil {
-				blog.Errorf("get hostIp failed with err: %v, rid: %s", ok, lgc.rid)
-				errOrigin = ok
-				break
-			}
-			existHostOsname, osOk := existHostInfo.String(common.BKOSNameField)
-			if osOk != nil {
-				blog.Errorf("get os name failed with err: %v, rid: %s", ok, lgc.rid)
-				errOrigin = osOk
-				break
-			}
-
-			existHostOuterip, ipOk := existHostInfo.String(common.BKHostOuterIPField)
-			if ipOk != nil {
-				blog.Errorf("get outerip failed with, rid: %s", lgc.rid)
-				errOrigin = ipOk
-				break
-			}
-
-			existHostID, ok := existHostInfo.String(common.BKHostIDField)
-			if ok != nil {
-				blog.Errorf("get hostID failed, rid: %s", lgc.rid)
-				errOrigin = ok
-				break
-			}
-
-			if existHostIp == newHostInnerip {
-				if existHostOsname != newHostOsname || existHostOuterip != newHostOuterip {
-					hostInfo[common.BKHostIDField] = existHostID
-					cloudHostAttr = append(cloudHostAttr, hostInfo)
-				}
-			}
+	} else if len(plan.newHosts) > 0 {
+		if err := lgc.AddCloudHosts(ctx, plan.newHosts); err != nil {
+			blog.Errorf("add cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
+			errOrigin = err
+			return err
 		}
 	}
 
-	cloudHistory.NewAdd = len(newAddHost)
-	cloudHistory.AttrChanged = len(cloudHostAttr)
-
-	attrConfirm := taskInfo.AttrConfirm
-	resourceConfirm := taskInfo.ResourceConfirm
-
-	if !resourceConfirm && !attrConfirm {
-		if len(newCloudHost) > 0 {
-			err := lgc.AddCloudHosts(ctx, newCloudHost)
-			if err != nil {
-				blog.Errorf("add cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
-				errOrigin = err
-				return err
-			}
+	// all the changes need confirm when attr confirm is on,
+	// otherwise only the fields with review policy need confirm.
+	confirmHosts := plan.reviewHosts
+	if taskInfo.AttrConfirm {
+		confirmHosts = plan.changedHosts
+	} else if len(plan.updateHosts) > 0 {
+		lockedIPs, err := lgc.skipLockedCloudHosts(ctx, plan)
+		if err != nil {
+			blog.Errorf("skip locked cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
+			errOrigin = err
+			return err
 		}
-		if len(cloudHostAttr) > 0 {
-			err := lgc.UpdateCloudHosts(ctx, cloudHostAttr)
-			if err != nil {
-				blog.Errorf("update cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
-				errOrigin = err
-				return err
-			}
+		cloudHistory.LockedHosts = append(cloudHistory.LockedHosts, lockedIPs...)
+		if err := lgc.UpdateCloudHosts(ctx, plan.updateHosts); err != nil {
+			blog.Errorf("update cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
+			errOrigin = err
+			return err
 		}
 	}
-
-	if resourceConfirm {
-		newAddNum, err := lgc.NewAddConfirm(ctx, taskInfo, newCloudHost)
-		cloudHistory.NewAdd = newAddNum
-		if err != nil {
-			blog.Errorf("newly add cloud resource confirm failed, err: %v, rid: %s", err, lgc.rid)
+	for _, host := range confirmHosts {
+		resourceConfirm := newCloudResourceConfirm(taskInfo, host, "change")
+		if _, err := lgc.CoreAPI.HostController().Cloud().ResourceConfirm(ctx, lgc.header, resourceConfirm); err != nil {
+			blog.Errorf("add resource confirm failed with confirmInfo: %#v, err: %v, rid: %s", resourceConfirm, err, lgc.rid)
 			errOrigin = err
 			return err
 		}
 	}
 
-	if attrConfirm && len(cloudHostAttr) > 0 {
-		blog.V(5).Info("attr chang")
-
-		for _, host := range cloudHostAttr {
-			resourceConfirm := mapstr.MapStr{}
-			resourceConfirm["bk_obj_id"] = taskInfo.ObjID
-			innerIp, err := host.String(common.BKHostInnerIPField)
-			if err != nil {
-				blog.Debug("mapstr.Map convert to string failed, rid: %s", lgc.rid)
-				errOrigin = err
-				return err
-			}
-			outerIp, errOut := host.String(common.BKHostOuterIPField)
-			if errOut != nil {
-				blog.Error("mapstr.Map convert to string failed, rid: %s", lgc.rid)
-				errOrigin = errOut
-				return errOut
-			}
-			osName, err := host.String(common.BKOSNameField)
-			if err != nil {
-				blog.Error("mapstr.Map convert to string failed, rid: %s", lgc.rid)
-				errOrigin = err
-				return err
-			}
-
-			resourceConfirm[common.BKHostInnerIPField] = innerIp
-			resourceConfirm[common.BKHostOuterIPField] = outerIp
-			resourceConfirm[common.BKOSNameField] = osName
-			resourceConfirm[common.BKCloudTaskID] = taskInfo.TaskID
-			resourceConfirm[common.BKAttrConfirm] = attrConfirm
-			resourceConfirm[common.BKCloudConfirm] = false
-			resourceConfirm[common.BKCloudSyncTaskName] = taskInfo.TaskName
-			resourceConfirm[common.BKCloudAccountType] = taskInfo.AccountType
-			resourceConfirm[common.BKCloudSyncAccountAdmin] = taskInfo.AccountAdmin
-			resourceConfirm[common.BKResourceType] = "change"
+	lockedIPs, err := lgc.handleVanishedCloudHosts(ctx, taskInfo, plan.vanishedHosts)
+	cloudHistory.LockedHosts = append(cloudHistory.LockedHosts, lockedIPs...)
+	if err != nil {
+		blog.Errorf("handle vanished cloud hosts failed, policy: %s, err: %v, rid: %s", taskInfo.VanishedPolicy, err, lgc.rid)
+		errOrigin = err
+		return err
+	}
 
-			if _, err := lgc.CoreAPI.HostController().Cloud().ResourceConfirm(ctx, lgc.header, resourceConfirm); err != nil {
-				blog.Errorf("add resource confirm failed with confirmInfo: %#v, err: %v, rid: %s", resourceConfirm, err, lgc.rid)
-				errOrigin = err
-				return err
-			}
-		}
-		return nil
+	if err := lgc.saveCloudSyncedIPs(ctx, taskInfo.TaskID, plan.cloudIPs); err != nil {
+		errOrigin = err
+		return err
 	}
 
 	cloudHistory.Status = "success"
@@ -671,6 +539,41 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 	return nil
 }
 
+// newCloudResourceConfirm build the resource confirm record of the cloud host,
+// the mapped host data is kept in BKCloudHostAttrField, so that it can be applied after confirmed.
+func newCloudResourceConfirm(taskInfo meta.CloudTaskInfo, host mapstr.MapStr, resourceType string) mapstr.MapStr {
+	resourceConfirm := mapstr.MapStr{}
+	resourceConfirm["bk_obj_id"] = taskInfo.ObjID
+	for _, field := range []string{common.BKHostInnerIPField, common.BKHostOuterIPField, common.BKOSNameField} {
+		value, _ := host.String(field)
+		resourceConfirm[field] = value
+	}
+	resourceConfirm[common.BKCloudHostAttrField] = host
+	resourceConfirm[common.BKCloudTaskID] = taskInfo.TaskID
+	resourceConfirm[common.BKCloudConfirm] = resourceType == common.BKNewAddHost
+	resourceConfirm[common.BKAttrConfirm] = resourceType == "change"
+	resourceConfirm[common.BKCloudSyncTaskName] = taskInfo.TaskName
+	resourceConfirm[common.BKCloudAccountType] = taskInfo.AccountType
+	resourceConfirm[common.BKCloudSyncAccountAdmin] = taskInfo.AccountAdmin
+	resourceConfirm[common.BKResourceType] = resourceType
+	return resourceConfirm
+}
+
+// CloudHostFromConfirm get the host data from the resource confirm record.
+// the record created before attribute mapping only has inner ip, outer ip and os name.
+func CloudHostFromConfirm(confirm mapstr.MapStr) mapstr.MapStr {
+	if host, err := confirm.MapStr(common.BKCloudHostAttrField); err == nil && len(host) != 0 {
+		return host
+	}
+	host := mapstr.New()
+	for _, field := range []string{common.BKHostIDField, common.BKHostInnerIPField, common.BKHostOuterIPField, common.BKOSNameField} {
+		if value, ok := confirm[field]; ok {
+			host[field] = value
+		}
+	}
+	return host
+}
+
 func (lgc *Logics) AddCloudHosts(ctx context.Context, newCloudHost []mapstr.MapStr) error {
 	hostList := new(meta.HostList)
 	hostInfoMap := make(map[int64]map[string]interface{}, 0)
@@ -700,9 +603,12 @@ func (lgc *Logics) AddCloudHosts(ctx context.Context, newCloudHost []mapstr.MapS
 			hostInfoMap[int64(index)] = make(map[string]interface{}, 0)
 		}
 
-		hostInfoMap[int64(index)][common.BKHostInnerIPField] = hostInfo[common.BKHostInnerIPField]
-		hostInfoMap[int64(index)][common.BKHostOuterIPField] = hostInfo[common.BKHostOuterIPField]
-		hostInfoMap[int64(index)][common.BKOSNameField] = hostInfo[common.BKOSNameField]
+		for field, value := range hostInfo {
+			if field == common.BKHostIDField {
+				continue
+			}
+			hostInfoMap[int64(index)][field] = value
+		}
 		hostInfoMap[int64(index)][common.BKImportFrom] = "3"
 		hostInfoMap[int64(index)][common.BKCloudIDField] = 1
 	}
@@ -717,17 +623,27 @@ func (lgc *Logics) AddCloudHosts(ctx context.Context, newCloudHost []mapstr.MapS
 }
 
 func (lgc *Logics) UpdateCloudHosts(ctx context.Context, cloudHostAttr []mapstr.MapStr) error {
+	hostIDs := make([]int64, 0)
 	for _, hostInfo := range cloudHostAttr {
 		hostID, err := hostInfo.Int64(common.BKHostIDField)
 		if err != nil {
 			blog.Errorf("hostID convert to string failed, hostInfo: %#v, err: %v, rid: %s", hostInfo, err, lgc.rid)
 			return err
 		}
+		hostIDs = append(hostIDs, hostID)
+	}
+	overrideLocks, lockErr := lgc.CheckHostLock(ctx, hostIDs)
+	if lockErr != nil {
+		blog.Errorf("update cloud hosts, check host lock failed, hosts: %v, err: %v, rid: %s", hostIDs, lockErr, lgc.rid)
+		return lockErr
+	}
 
-		delete(hostInfo, common.BKHostIDField)
-		delete(hostInfo, common.BKCloudConfirm)
-		delete(hostInfo, common.BKAttrConfirm)
-		opt := mapstr.MapStr{"condition": mapstr.MapStr{common.BKHostIDField: hostID}, "data": hostInfo}
+	for _, hostInfo := range cloudHostAttr {
+		hostID, _ := hostInfo.Int64(common.BKHostIDField)
+
+		data := hostInfo.Clone()
+		delete(data, common.BKHostIDField)
+		opt := mapstr.MapStr{"condition": mapstr.MapStr{common.BKHostIDField: hostID}, "data": data}
 
 		blog.V(5).Infof("opt: %+v", opt)
 		result, err := lgc.CoreAPI.ObjectController().Instance().UpdateObject(ctx, common.BKInnerObjIDHost, lgc.header, opt)
@@ -736,6 +652,7 @@ func (lgc *Logics) UpdateCloudHosts(ctx context.Context, cloudHostAttr []mapstr.
 			return err
 		}
 	}
+	lgc.SaveHostLockOverrideAudit(ctx, overrideLocks)
 	return nil
 }
 
@@ -774,34 +691,7 @@ func (lgc *Logics) NewAddConfirm(ctx context.Context, taskInfo meta.CloudTaskInf
 	// newly added cloud hosts confirm
 	if len(newHostIp) > 0 {
 		for _, host := range newCloudHost {
-			innerIp, err := host.String(common.BKHostInnerIPField)
-			if err != nil {
-				blog.Errorf("mapstr.Map convert to string failed, err: %v, rid: %s", err, lgc.rid)
-				return 0, err
-			}
-			outerIp, err := host.String(common.BKHostOuterIPField)
-			if err != nil {
-				blog.Error("mapstr.Map convert to string failed, err: %v, rid: %s", err, lgc.rid)
-				return 0, err
-			}
-			osName, err := host.String(common.BKOSNameField)
-			if err != nil {
-				blog.Error("mapstr.Map convert to string failed, err: %v, rid: %s", err, lgc.rid)
-				return 0, err
-			}
-			resourceConfirm := mapstr.MapStr{}
-			resourceConfirm["bk_obj_id"] = taskInfo.ObjID
-			resourceConfirm[common.BKHostInnerIPField] = innerIp
-			resourceConfirm[common.BKCloudTaskID] = taskInfo.TaskID
-			resourceConfirm[common.BKOSNameField] = osName
-			resourceConfirm[common.BKHostOuterIPField] = outerIp
-			resourceConfirm[common.BKCloudConfirm] = true
-			resourceConfirm[common.BKAttrConfirm] = false
-			resourceConfirm[common.BKCloudSyncTaskName] = taskInfo.TaskName
-			resourceConfirm[common.BKCloudAccountType] = taskInfo.AccountType
-			resourceConfirm[common.BKCloudSyncAccountAdmin] = taskInfo.AccountAdmin
-			resourceConfirm[common.BKResourceType] = common.BKNewAddHost
-
+			resourceConfirm := newCloudResourceConfirm(taskInfo, host, common.BKNewAddHost)
 			if _, err := lgc.CoreAPI.HostController().Cloud().ResourceConfirm(ctx, lgc.header, resourceConfirm); err != nil {
 				blog.Errorf("add resource confirm failed with err: confirmInfo: %#v, %v, rid: %s", resourceConfirm, err, lgc.rid)
 				return 0, err
@@ -896,6 +786,7 @@ func (lgc *Logics) CloudSyncHistory(ctx context.Context, taskID int64, startTime
 	updateData[common.BKSyncStatus] = cloudHistory.Status
 	updateData[common.BKNewAddHost] = cloudHistory.NewAdd
 	updateData[common.BKAttrChangedHost] = cloudHistory.AttrChanged
+	updateData[common.BKVanishedHost] = cloudHistory.Vanished
 
 	if _, err := lgc.CoreAPI.HostController().Cloud().UpdateCloudTask(ctx, lgc.header, updateData); err != nil {
 		blog.Errorf("update task failed, taskInfo: %#v, err: %v, rid: %s", updateData, err, lgc.rid)
@@ -910,84 +801,86 @@ func (lgc *Logics) CloudSyncHistory(ctx context.Context, taskID int64, startTime
 	return nil
 }
 
-func (lgc *Logics) ObtainCloudHosts(ctx context.Context, secretID string, secretKey string) ([]map[string]interface{}, error) {
-	credential := com.NewCredential(
-		secretID,
-		secretKey,
-	)
-
-	cpf := profile.NewClientProfile()
-	cpf.HttpProfile.ReqMethod = common.BKHttpGet
-	cpf.HttpProfile.ReqTimeout = common.BKTencentCloudTimeOut
-	cpf.HttpProfile.Endpoint = common.TencentCloudUrl
-	cpf.SignMethod = common.TencentCloudSignMethod
+// ValidateCloudAccount check whether the cloud account of the task is usable
+func (lgc *Logics) ValidateCloudAccount(ctx context.Context, accountType string, cred cloudprovider.Credential) error {
+	provider, err := cloudprovider.GetProvider(cloudAccountType(accountType))
+	if err != nil {
+		blog.Errorf("validate cloud account failed, err: %v, rid: %s", err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCloudProviderNotSupported, accountType)
+	}
+	if err := provider.ValidateEndpoint(cred.Endpoint); err != nil {
+		blog.Errorf("validate cloud account of %s failed, err: %v, rid: %s", accountType, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCloudEndpointInvalid, err.Error())
+	}
+	if err := provider.ValidateCredential(ctx, cred); err != nil {
+		blog.Errorf("validate cloud account of %s failed, err: %v, rid: %s", accountType, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCloudCredentialInvalid, err.Error())
+	}
+	return nil
+}
 
-	ClientRegion, _ := cvm.NewClient(credential, regions.Guangzhou, cpf)
-	regionRequest := cvm.NewDescribeRegionsRequest()
-	Response, err := ClientRegion.DescribeRegions(regionRequest)
+// ValidateCloudEndpoint check whether the endpoint is allowed by the provider of the account type
+func (lgc *Logics) ValidateCloudEndpoint(accountType string, endpoint string) error {
+	provider, err := cloudprovider.GetProvider(cloudAccountType(accountType))
+	if err != nil {
+		blog.Errorf("validate cloud endpoint failed, err: %v, rid: %s", err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCloudProviderNotSupported, accountType)
+	}
+	if err := provider.ValidateEndpoint(endpoint); err != nil {
+		blog.Errorf("validate cloud endpoint of %s failed, err: %v, rid: %s", accountType, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCloudEndpointInvalid, err.Error())
+	}
+	return nil
+}
 
+// ObtainCloudHosts obtain the hosts of all regions from the cloud provider of the task,
+// the instances are converted into host data with the attribute mapping of the task.
+func (lgc *Logics) ObtainCloudHosts(ctx context.Context, taskInfo meta.CloudTaskInfo) ([]mapstr.MapStr, error) {
+	// the secret key is saved with base64 encoded
+	decodeBytes, err := base64.StdEncoding.DecodeString(taskInfo.SecretKey)
 	if err != nil {
+		blog.Errorf("Base64 decode secretKey failed, rid: %s", lgc.rid)
 		return nil, err
 	}
+	cred := cloudprovider.Credential{
+		SecretID:  taskInfo.SecretID,
+		SecretKey: string(decodeBytes),
+		Endpoint:  taskInfo.Endpoint,
+	}
 
-	data := Response.ToJsonString()
-	regionResponse := new(meta.RegionResponse)
-	if err := json.Unmarshal([]byte(data), regionResponse); err != nil {
-		blog.Errorf("json unmarsha1 error :%v\n", err)
+	provider, err := cloudprovider.GetProvider(cloudAccountType(taskInfo.AccountType))
+	if err != nil {
+		blog.Errorf("obtain cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
+		return nil, err
+	}
+	// the task saved before the endpoint is validated may have an endpoint not allowed
+	if err := provider.ValidateEndpoint(cred.Endpoint); err != nil {
+		blog.Errorf("obtain cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
 		return nil, err
 	}
 
-	cloudHostInfo := make([]map[string]interface{}, 0)
-	for _, region := range regionResponse.Response.Data {
-		var inneripList string
-		var outeripList string
-		var osName string
-		regionHosts := make(map[string]interface{})
-
-		client, _ := cvm.NewClient(credential, region.Region, cpf)
-		instRequest := cvm.NewDescribeInstancesRequest()
-		response, err := client.DescribeInstances(instRequest)
-
-		if _, ok := err.(*errors.TencentCloudSDKError); ok {
-			fmt.Printf("An API error has returned: %s", err)
-			return nil, err
-		}
-		if err != nil {
-			blog.Error("obtain cloud hosts failed")
-			return nil, err
-		}
-
-		data := response.ToJsonString()
-		Hosts := meta.HostResponse{}
-		if err := json.Unmarshal([]byte(data), &Hosts); err != nil {
-			fmt.Printf("json unmarsha1 error :%v\n", err)
-		}
-
-		instSet := Hosts.HostResponse.InstanceSet
-		for _, obj := range instSet {
-			osName = obj.OsName
-			if len(obj.PrivateIpAddresses) > 0 {
-				inneripList = obj.PrivateIpAddresses[0]
-			}
-		}
-
-		for _, obj := range instSet {
-			if len(obj.PublicIpAddresses) > 0 {
-				outeripList = obj.PublicIpAddresses[0]
-			}
-		}
+	instances, err := cloudprovider.ListAllInstances(ctx, provider, cred)
+	if err != nil {
+		blog.Errorf("obtain cloud hosts from %s failed, err: %v, rid: %s", taskInfo.AccountType, err, lgc.rid)
+		return nil, err
+	}
 
-		if len(instSet) > 0 {
-			regionHosts[common.BKHostCloudRegionField] = region.Region
-			regionHosts[common.BKHostInnerIPField] = inneripList
-			regionHosts[common.BKHostOuterIPField] = outeripList
-			regionHosts[common.BKOSNameField] = osName
-			cloudHostInfo = append(cloudHostInfo, regionHosts)
-		}
+	cloudHostInfo := make([]mapstr.MapStr, 0)
+	for _, inst := range instances {
+		cloudHostInfo = append(cloudHostInfo, cloudprovider.MapInstance(inst, taskInfo.AttrMapping))
 	}
 	return cloudHostInfo, nil
 }
 
+// cloudAccountType the task created before cloud provider plugin has no account type,
+// they are all tencent cloud task.
+func cloudAccountType(accountType string) string {
+	if "" == accountType {
+		return cloudprovider.TencentCloud
+	}
+	return accountType
+}
+
 func copyHeader(ctx context.Context, header http.Header) http.Header {
 	newHeader := make(http.Header, 0)
 	for key, values := range header {
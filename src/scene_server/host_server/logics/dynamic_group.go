@@ -0,0 +1,230 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	parse "configcenter/src/common/paraparse"
+)
+
+// dynamicGroupPageSize the page size used to get all the members of a dynamic group
+const dynamicGroupPageSize = 500
+
+// ValidateDynamicGroupObject check the model targeted by a dynamic group,
+// business and the inner object are not supported
+func (lgc *Logics) ValidateDynamicGroupObject(ctx context.Context, objID string) errors.CCError {
+	switch objID {
+	case "", common.BKInnerObjIDHost, common.BKInnerObjIDSet, common.BKInnerObjIDModule:
+		return nil
+	case common.BKInnerObjIDApp, common.BKInnerObjIDObject:
+		return lgc.ccErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+	}
+
+	query := &meta.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: objID}}
+	result, err := lgc.CoreAPI.CoreService().Model().ReadModel(ctx, lgc.header, query)
+	if err != nil {
+		blog.Errorf("validate dynamic group object %s, http do error, err: %v, rid: %s", objID, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("validate dynamic group object %s, http response error, err code: %d, err msg: %s, rid: %s", objID, result.Code, result.ErrMsg, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	if 0 == result.Data.Count {
+		blog.Errorf("validate dynamic group object %s, model not exists, rid: %s", objID, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+	}
+	return nil
+}
+
+// SearchDynamicGroup returns a page of the instances matched by the dynamic group,
+// the hosts are returned with topology like host search.
+func (lgc *Logics) SearchDynamicGroup(ctx context.Context, group *meta.UserConfigMeta, page meta.BasePage) (*meta.SearchHost, errors.CCError) {
+	input := new(meta.HostCommonSearch)
+	if "" != group.Info {
+		if err := json.Unmarshal([]byte(group.Info), input); err != nil {
+			blog.Errorf("search dynamic group %s, unmarshal info failed, err: %v, info: %s, rid: %s", group.ID, err, group.Info, lgc.rid)
+			return nil, lgc.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)
+		}
+	}
+
+	objID := group.GetObjectID()
+	if common.BKInnerObjIDHost == objID {
+		input.AppID = group.AppID
+		input.Page = page
+		result, err := lgc.SearchHost(ctx, input, false)
+		if err != nil {
+			blog.Errorf("search dynamic group %s, search host failed, err: %v, rid: %s", group.ID, err, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrGetUserCustomQueryDetailFaild, err.Error())
+		}
+		return result, nil
+	}
+
+	condition := make(map[string]interface{})
+	for _, cond := range input.Condition {
+		if objID != cond.ObjectID {
+			continue
+		}
+		if err := parse.ParseHostParams(cond.Condition, condition); err != nil {
+			blog.Errorf("search dynamic group %s, parse condition failed, err: %v, rid: %s", group.ID, err, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+		}
+	}
+	switch objID {
+	case common.BKInnerObjIDSet, common.BKInnerObjIDModule, common.BKInnerObjIDProc:
+		condition[common.BKAppIDField] = group.AppID
+	default:
+		// the instance of custom object belongs to business by the business label of metadata
+		condition[meta.BKMetadata+"."+meta.BKLabel+"."+meta.LabelBusinessID] = strconv.FormatInt(group.AppID, 10)
+	}
+
+	query := &meta.QueryCondition{
+		Condition: mapstr.NewFromMap(condition),
+		Limit:     meta.SearchLimit{Offset: int64(page.Start), Limit: int64(page.Limit)},
+		SortArr:   meta.NewSearchSortParse().String(common.GetInstIDField(objID)).ToSearchSortArr(),
+	}
+	result, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, objID, query)
+	if err != nil {
+		blog.Errorf("search dynamic group %s, http do error, err: %v, objID: %s, input: %+v, rid: %s", group.ID, err, objID, query, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search dynamic group %s, http response error, err code: %d, err msg: %s, objID: %s, input: %+v, rid: %s", group.ID, result.Code, result.ErrMsg, objID, query, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return &meta.SearchHost{Count: result.Data.Count, Info: result.Data.Info}, nil
+}
+
+// searchDynamicGroupMembers returns all the instances matched by the dynamic group
+func (lgc *Logics) searchDynamicGroupMembers(ctx context.Context, group *meta.UserConfigMeta) ([]mapstr.MapStr, errors.CCError) {
+	return lgc.pageDynamicGroupMembers(group, func(page meta.BasePage) (*meta.SearchHost, errors.CCError) {
+		return lgc.SearchDynamicGroup(ctx, group, page)
+	})
+}
+
+// pageDynamicGroupMembers get all the members page by page with the search function
+func (lgc *Logics) pageDynamicGroupMembers(group *meta.UserConfigMeta, search func(page meta.BasePage) (*meta.SearchHost, errors.CCError)) ([]mapstr.MapStr, errors.CCError) {
+	members := make([]mapstr.MapStr, 0)
+	page := meta.BasePage{Limit: dynamicGroupPageSize}
+	for {
+		result, err := search(page)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Info {
+			if common.BKInnerObjIDHost != group.GetObjectID() {
+				members = append(members, item)
+				continue
+			}
+			host, convErr := item.MapStr(common.BKInnerObjIDHost)
+			if convErr != nil {
+				blog.Errorf("search dynamic group %s, get host failed, err: %v, item: %v, rid: %s", group.ID, convErr, item, lgc.rid)
+				return nil, lgc.ccErr.Errorf(common.CCErrCommInstFieldConvFail, common.BKInnerObjIDHost, common.BKInnerObjIDHost, "map", convErr.Error())
+			}
+			members = append(members, host)
+		}
+		page.Start += dynamicGroupPageSize
+		if 0 == len(result.Info) || page.Start >= result.Count {
+			return members, nil
+		}
+	}
+}
+
+// EvaluateDynamicGroup evaluate the members of the dynamic group and save them as a snapshot,
+// the join and leave events are sent by host controller when the members changed.
+func (lgc *Logics) EvaluateDynamicGroup(ctx context.Context, group *meta.UserConfigMeta) (*meta.DynamicGroupSnapshot, errors.CCError) {
+	members, err := lgc.searchDynamicGroupMembers(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	bizID := strconv.FormatInt(group.AppID, 10)
+	input := &meta.SaveDynamicGroupSnapshot{Members: members}
+	result, httpErr := lgc.CoreAPI.HostController().User().SaveUserConfigSnapshot(ctx, bizID, group.ID, lgc.header, input)
+	if httpErr != nil {
+		blog.Errorf("evaluate dynamic group %s, save snapshot http do error, err: %v, rid: %s", group.ID, httpErr, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("evaluate dynamic group %s, save snapshot http response error, err code: %d, err msg: %s, rid: %s", group.ID, result.Code, result.ErrMsg, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return &result.Data, nil
+}
+
+// TimerEvaluateDynamicGroup evaluate the dynamic groups which have eval interval when they are due
+func (lgc *Logics) TimerEvaluateDynamicGroup(ctx context.Context) {
+	go func() {
+		timer := time.NewTicker(1 * time.Minute)
+		for range timer.C {
+			lgc.evaluateDueDynamicGroups(ctx)
+		}
+	}()
+}
+
+func (lgc *Logics) evaluateDueDynamicGroups(ctx context.Context) {
+	result, err := lgc.CoreAPI.HostController().User().SearchScheduledUserConfig(ctx, lgc.header)
+	if err != nil {
+		blog.Errorf("evaluate dynamic groups, search scheduled user api http do error, err: %v, rid: %s", err, lgc.rid)
+		return
+	}
+	if !result.Result {
+		blog.Errorf("evaluate dynamic groups, search scheduled user api http response error, err code: %d, err msg: %s, rid: %s", result.Code, result.ErrMsg, lgc.rid)
+		return
+	}
+
+	now := time.Now()
+	for index := range result.Data {
+		group := result.Data[index]
+		interval := group.GetEvalInterval()
+		if 0 == interval {
+			continue
+		}
+		if nil != group.LastEvalTime && now.Sub(*group.LastEvalTime) < interval {
+			continue
+		}
+
+		// the lock is kept for the whole interval, so that the group is
+		// evaluated by only one host server even if the evaluation is slow
+		key := common.RedisDynamicGroupEvalLockPrefix + group.ID
+		locked, err := lgc.cache.SetNX(key, "", interval).Result()
+		if err != nil {
+			blog.Errorf("evaluate dynamic group %s, lock failed, err: %v, rid: %s", group.ID, err, lgc.rid)
+			continue
+		}
+		if !locked {
+			continue
+		}
+
+		header := make(http.Header)
+		header.Set(common.BKHTTPOwnerID, group.OwnerID)
+		header.Set(common.BKHTTPHeaderUser, group.CreateUser)
+		snapshot, err := lgc.NewFromHeader(header).EvaluateDynamicGroup(ctx, &group)
+		if err != nil {
+			blog.Errorf("evaluate dynamic group %s failed, err: %v, rid: %s", group.ID, err, lgc.rid)
+			continue
+		}
+		blog.V(4).Infof("evaluate dynamic group %s, %d members, %d joined, %d left, rid: %s",
+			group.ID, len(snapshot.Members), len(snapshot.Joined), len(snapshot.Left), lgc.rid)
+	}
+}
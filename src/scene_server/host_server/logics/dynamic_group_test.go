@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+)
+
+// pagedSearch returns a search function which pages the instances with id from 1 to count
+func pagedSearch(objID string, count int, pages *[]meta.BasePage) func(page meta.BasePage) (*meta.SearchHost, errors.CCError) {
+	return func(page meta.BasePage) (*meta.SearchHost, errors.CCError) {
+		*pages = append(*pages, page)
+		result := &meta.SearchHost{Count: count, Info: make([]mapstr.MapStr, 0)}
+		for id := page.Start + 1; id <= count && id <= page.Start+page.Limit; id++ {
+			inst := mapstr.MapStr{common.GetInstIDField(objID): int64(id)}
+			if common.BKInnerObjIDHost == objID {
+				inst = mapstr.MapStr{common.BKInnerObjIDHost: inst}
+			}
+			result.Info = append(result.Info, inst)
+		}
+		return result, nil
+	}
+}
+
+func TestPageDynamicGroupMembers(t *testing.T) {
+	tests := []struct {
+		name      string
+		objID     string
+		count     int
+		wantPages int
+	}{
+		{"empty", common.BKInnerObjIDHost, 0, 1},
+		{"one page", common.BKInnerObjIDHost, 10, 1},
+		{"exactly one page", common.BKInnerObjIDSet, dynamicGroupPageSize, 1},
+		{"several pages", common.BKInnerObjIDHost, dynamicGroupPageSize*2 + 1, 3},
+		{"several pages of module", common.BKInnerObjIDModule, dynamicGroupPageSize + 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lgc := &Logics{}
+			pages := make([]meta.BasePage, 0)
+			group := &meta.UserConfigMeta{ID: "group", ObjectID: tt.objID}
+			members, err := lgc.pageDynamicGroupMembers(group, pagedSearch(tt.objID, tt.count, &pages))
+			if err != nil {
+				t.Fatalf("pageDynamicGroupMembers() error = %v", err)
+			}
+			if len(pages) != tt.wantPages {
+				t.Errorf("pageDynamicGroupMembers() searched %d pages, want %d", len(pages), tt.wantPages)
+			}
+			if len(members) != tt.count {
+				t.Fatalf("pageDynamicGroupMembers() got %d members, want %d", len(members), tt.count)
+			}
+			instIDField := common.GetInstIDField(tt.objID)
+			for index, member := range members {
+				if id, _ := member.Int64(instIDField); id != int64(index+1) {
+					t.Errorf("pageDynamicGroupMembers() member %d = %v, want %s %d", index, member, instIDField, index+1)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,822 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// hostDuplicateMaxBucket the hosts sharing a value are not compared when there are too many of them,
+// such a value is a placeholder like "0.0.0.0" rather than an identity of the machine.
+const hostDuplicateMaxBucket = 50
+
+// hostDuplicatePageSize the page size used to scan the hosts on detection
+const hostDuplicatePageSize = 1000
+
+// hostMergeSkipFields the fields which are not merged from the duplicates
+var hostMergeSkipFields = []string{
+	common.BKHostIDField,
+	common.BKOwnerIDField,
+	common.CreateTimeField,
+	common.LastTimeField,
+	common.BKResourceDirIDField,
+}
+
+// normalizeHostDuplicateValue split the field value into the comparable values,
+// the ip and mac fields may have several values separated by comma.
+func normalizeHostDuplicateValue(field string, value interface{}) []string {
+	if nil == value {
+		return nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		str = util.GetStrByInterface(value)
+	}
+
+	values := make([]string, 0)
+	for _, item := range strings.Split(str, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if "bk_mac" == field {
+			item = strings.Replace(item, "-", ":", -1)
+		}
+		if "" == item {
+			continue
+		}
+		values = append(values, item)
+	}
+	return values
+}
+
+// detectHostDuplicates group the hosts whose score reaches the threshold, the score of two hosts
+// is the sum of weight of the keys they share. the hosts are grouped transitively.
+func detectHostDuplicates(hosts []mapstr.MapStr, keys []metadata.HostDuplicateKey, threshold int64) []metadata.HostDuplicate {
+	type hostPair struct {
+		a, b int64
+	}
+	pairKeys := make(map[hostPair]map[string]int64)
+	for _, key := range keys {
+		buckets := make(map[string][]int64)
+		for _, host := range hosts {
+			hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+			if err != nil {
+				continue
+			}
+			for _, value := range normalizeHostDuplicateValue(key.Field, host[key.Field]) {
+				buckets[value] = append(buckets[value], hostID)
+			}
+		}
+
+		for _, hostIDs := range buckets {
+			hostIDs = util.IntArrayUnique(hostIDs)
+			if len(hostIDs) < 2 || len(hostIDs) > hostDuplicateMaxBucket {
+				continue
+			}
+			for i := 0; i < len(hostIDs); i++ {
+				for j := i + 1; j < len(hostIDs); j++ {
+					pair := hostPair{a: hostIDs[i], b: hostIDs[j]}
+					if pair.a > pair.b {
+						pair.a, pair.b = pair.b, pair.a
+					}
+					if nil == pairKeys[pair] {
+						pairKeys[pair] = make(map[string]int64)
+					}
+					// a key counts once for a pair no matter how many values they share
+					pairKeys[pair][key.Field] = key.Weight
+				}
+			}
+		}
+	}
+
+	parent := make(map[int64]int64)
+	var find func(id int64) int64
+	find = func(id int64) int64 {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		parent[id] = id
+		return id
+	}
+
+	pairScores := make(map[hostPair]int64)
+	for pair, matched := range pairKeys {
+		var score int64
+		for _, weight := range matched {
+			score += weight
+		}
+		if score < threshold {
+			continue
+		}
+		pairScores[pair] = score
+		rootA, rootB := find(pair.a), find(pair.b)
+		if rootA != rootB {
+			parent[rootB] = rootA
+		}
+	}
+
+	groups := make(map[int64]*metadata.HostDuplicate)
+	for pair, score := range pairScores {
+		root := find(pair.a)
+		group, ok := groups[root]
+		if !ok {
+			group = &metadata.HostDuplicate{HostIDs: make([]int64, 0), MatchedKeys: make([]string, 0)}
+			groups[root] = group
+		}
+		group.HostIDs = append(group.HostIDs, pair.a, pair.b)
+		if score > group.Score {
+			group.Score = score
+		}
+		for field := range pairKeys[pair] {
+			group.MatchedKeys = append(group.MatchedKeys, field)
+		}
+	}
+
+	duplicates := make([]metadata.HostDuplicate, 0)
+	for _, group := range groups {
+		group.HostIDs = util.IntArrayUnique(group.HostIDs)
+		sort.Slice(group.HostIDs, func(i, j int) bool { return group.HostIDs[i] < group.HostIDs[j] })
+		group.MatchedKeys = util.StrArrayUnique(group.MatchedKeys)
+		sort.Strings(group.MatchedKeys)
+		group.Fingerprint = metadata.HostDuplicateFingerprint(group.HostIDs)
+		duplicates = append(duplicates, *group)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Score != duplicates[j].Score {
+			return duplicates[i].Score > duplicates[j].Score
+		}
+		return duplicates[i].Fingerprint < duplicates[j].Fingerprint
+	})
+	return duplicates
+}
+
+// DetectHostDuplicates detect the duplicate hosts of every supplier account, the pending candidates
+// are replaced with the detected ones.
+func (lgc *Logics) DetectHostDuplicates(ctx context.Context, conf *metadata.HostDuplicateConfig) (map[string][]metadata.HostDuplicate, errors.CCError) {
+	fields := []string{common.BKHostIDField, common.BKOwnerIDField}
+	for _, key := range conf.Keys {
+		fields = append(fields, key.Field)
+	}
+
+	ownerHosts := make(map[string][]mapstr.MapStr)
+	for start := 0; ; start += hostDuplicatePageSize {
+		query := &metadata.QueryInput{
+			Condition: map[string]interface{}{},
+			Fields:    strings.Join(fields, ","),
+			Start:     start,
+			Limit:     hostDuplicatePageSize,
+			Sort:      common.BKHostIDField,
+		}
+		result, err := lgc.CoreAPI.HostController().Host().GetHosts(ctx, lgc.header, query)
+		if err != nil {
+			blog.Errorf("detect host duplicates, get hosts http do error, err: %v, input: %+v, rid: %s", err, query, lgc.rid)
+			return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("detect host duplicates, get hosts http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, query, lgc.rid)
+			return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+
+		for _, host := range result.Data.Info {
+			ownerID := util.GetStrByInterface(host[common.BKOwnerIDField])
+			ownerHosts[ownerID] = append(ownerHosts[ownerID], host)
+		}
+		if len(result.Data.Info) < hostDuplicatePageSize {
+			break
+		}
+	}
+
+	detected := make(map[string][]metadata.HostDuplicate)
+	for ownerID, hosts := range ownerHosts {
+		duplicates := detectHostDuplicates(hosts, conf.Keys, conf.Threshold)
+
+		// save even if nothing is detected, so that the stale candidates are cleared
+		if err := lgc.NewFromHeader(hostDuplicateHeader(ownerID)).SaveHostDuplicates(ctx, duplicates); err != nil {
+			return nil, err
+		}
+		detected[ownerID] = duplicates
+	}
+	return detected, nil
+}
+
+// SaveHostDuplicates replace the pending candidates of the supplier account in header
+func (lgc *Logics) SaveHostDuplicates(ctx context.Context, duplicates []metadata.HostDuplicate) errors.CCError {
+	input := &metadata.SaveHostDuplicates{Duplicates: duplicates}
+	result, err := lgc.CoreAPI.HostController().Host().SaveHostDuplicates(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("save host duplicates http do error, err: %v, owner: %s, rid: %s", err, lgc.ownerID, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("save host duplicates http response error, err code: %d, err msg: %s, owner: %s, rid: %s", result.Code, result.ErrMsg, lgc.ownerID, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return nil
+}
+
+// SearchHostDuplicates search the duplicate candidates
+func (lgc *Logics) SearchHostDuplicates(ctx context.Context, input *metadata.QueryInput) (*metadata.SearchHostDuplicate, errors.CCError) {
+	result, err := lgc.CoreAPI.HostController().Host().SearchHostDuplicates(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("search host duplicates http do error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search host duplicates http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return &result.Data, nil
+}
+
+// GetHostDuplicate get the duplicate candidate by id
+func (lgc *Logics) GetHostDuplicate(ctx context.Context, duplicateID int64) (*metadata.HostDuplicate, errors.CCError) {
+	input := &metadata.QueryInput{Condition: map[string]interface{}{"bk_duplicate_id": duplicateID}, Limit: 1}
+	result, err := lgc.SearchHostDuplicates(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if 0 == len(result.Info) {
+		blog.Errorf("get host duplicate %d, but not found, rid: %s", duplicateID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommNotFound)
+	}
+	return &result.Info[0], nil
+}
+
+// UpdateHostDuplicateStatus mark the duplicate candidate as ignored or merged
+func (lgc *Logics) UpdateHostDuplicateStatus(ctx context.Context, duplicateID int64, status string) errors.CCError {
+	result, err := lgc.CoreAPI.HostController().Host().UpdateHostDuplicateStatus(ctx, duplicateID, lgc.header, status)
+	if err != nil {
+		blog.Errorf("update host duplicate %d http do error, err: %v, status: %s, rid: %s", duplicateID, err, status, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("update host duplicate %d http response error, err code: %d, err msg: %s, status: %s, rid: %s", duplicateID, result.Code, result.ErrMsg, status, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return nil
+}
+
+// TimerDetectHostDuplicates detect the duplicate hosts periodically, the config may be changed
+// at runtime, so it's got from getConf every time.
+func (lgc *Logics) TimerDetectHostDuplicates(ctx context.Context, getConf func() metadata.HostDuplicateConfig) {
+	go func() {
+		timer := time.NewTicker(1 * time.Minute)
+		for range timer.C {
+			conf := getConf()
+			interval := conf.Interval
+			if 0 == interval {
+				continue
+			}
+
+			// the lock expires after an interval, which is the schedule of the detection
+			locked, err := lgc.cache.SetNX(common.RedisHostDuplicateDetectLockKey, "", interval).Result()
+			if err != nil {
+				blog.Errorf("detect host duplicates, lock failed, err: %v, rid: %s", err, lgc.rid)
+				continue
+			}
+			if !locked {
+				continue
+			}
+
+			detected, err := lgc.DetectHostDuplicates(ctx, &conf)
+			if err != nil {
+				blog.Errorf("detect host duplicates failed, err: %v, rid: %s", err, lgc.rid)
+				continue
+			}
+			for ownerID, duplicates := range detected {
+				blog.V(4).Infof("detect host duplicates of owner %s, %d groups found, rid: %s", ownerID, len(duplicates), lgc.rid)
+			}
+		}
+	}()
+}
+
+// MergeHostDuplicate merge the duplicate hosts into the survivor, the relations of the duplicates
+// are moved to the survivor before they are deleted, then the attributes are merged.
+func (lgc *Logics) MergeHostDuplicate(ctx context.Context, input *metadata.MergeHostDuplicate) (*metadata.MergeHostDuplicateResult, errors.CCError) {
+	hostIDs := util.IntArrayUnique(input.HostIDs)
+	if len(hostIDs) < 2 {
+		blog.Errorf("merge host duplicate, at least 2 hosts are needed, input: %+v, rid: %s", input, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "bk_host_ids")
+	}
+	sort.Slice(hostIDs, func(i, j int) bool { return hostIDs[i] < hostIDs[j] })
+
+	survivorID := input.SurvivorID
+	if 0 == survivorID {
+		// the host id is increasing, the smallest one is created the earliest
+		survivorID = hostIDs[0]
+	}
+	if !util.InArray(survivorID, hostIDs) {
+		blog.Errorf("merge host duplicate, survivor %d is not in hosts %v, rid: %s", survivorID, hostIDs, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrHostMergeSurvivorInvalid, survivorID)
+	}
+	mergedIDs := make([]int64, 0)
+	for _, hostID := range hostIDs {
+		if hostID != survivorID {
+			mergedIDs = append(mergedIDs, hostID)
+		}
+	}
+	for field, hostID := range input.Fields {
+		if !util.InArray(hostID, hostIDs) {
+			blog.Errorf("merge host duplicate, the source host %d of field %s is not in hosts %v, rid: %s", hostID, field, hostIDs, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "fields")
+		}
+	}
+
+	hosts, err := lgc.GetHostInfoByConds(ctx, map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}})
+	if err != nil {
+		return nil, err
+	}
+	hostMap := make(map[int64]mapstr.MapStr)
+	for _, host := range hosts {
+		hostID, convErr := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if convErr != nil {
+			continue
+		}
+		hostMap[hostID] = host
+	}
+	for _, hostID := range hostIDs {
+		if _, ok := hostMap[hostID]; !ok {
+			blog.Errorf("merge host duplicate, host %d not found, rid: %s", hostID, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrHostNotFound)
+		}
+	}
+	overrideLocks, err := lgc.CheckHostLock(ctx, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultAppID, err := lgc.GetDefaultAppID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	relations, err := lgc.GetHostModuleRelation(ctx, metadata.HostModuleRelationRequest{HostIDArr: hostIDs})
+	if err != nil {
+		return nil, err
+	}
+	hostAppID := make(map[int64]int64)
+	for _, relation := range relations {
+		hostAppID[relation.HostID] = relation.AppID
+	}
+
+	if err := lgc.mergeHostModules(ctx, defaultAppID, survivorID, hostAppID, relations); err != nil {
+		return nil, err
+	}
+	if err := lgc.mergeHostAssociations(ctx, survivorID, mergedIDs); err != nil {
+		return nil, err
+	}
+	if err := lgc.mergeHostProcesses(ctx, survivorID, mergedIDs); err != nil {
+		return nil, err
+	}
+
+	hostFields, err := lgc.GetHostAttributes(ctx, lgc.ownerID, nil)
+	if err != nil {
+		return nil, lgc.ccErr.Error(common.CCErrHostDetailFail)
+	}
+	if err := lgc.deleteMergedHosts(ctx, hostAppID, mergedIDs, hostFields); err != nil {
+		return nil, err
+	}
+
+	// the attributes are merged after the duplicates are deleted, so that the unique fields don't conflict
+	changed := mergeHostAttributes(survivorID, mergedIDs, hostMap, hostFields, input.Fields)
+	if err := lgc.updateMergedHost(ctx, survivorID, hostAppID[survivorID], changed, hostFields); err != nil {
+		return nil, err
+	}
+
+	if 0 != input.DuplicateID {
+		if err := lgc.UpdateHostDuplicateStatus(ctx, input.DuplicateID, metadata.HostDuplicateStatusMerged); err != nil {
+			return nil, err
+		}
+	}
+	lgc.SaveHostLockOverrideAudit(ctx, overrideLocks)
+
+	return &metadata.MergeHostDuplicateResult{SurvivorID: survivorID, MergedIDs: mergedIDs, Changed: changed}, nil
+}
+
+// mergeHostModules move the survivor to all the modules the hosts are in, the hosts must be
+// in the same business or in the resource pool.
+func (lgc *Logics) mergeHostModules(ctx context.Context, defaultAppID, survivorID int64, hostAppID map[int64]int64, relations []metadata.ModuleHost) errors.CCError {
+	bizIDs := make([]int64, 0)
+	for _, appID := range hostAppID {
+		if appID != defaultAppID {
+			bizIDs = append(bizIDs, appID)
+		}
+	}
+	bizIDs = util.IntArrayUnique(bizIDs)
+	if len(bizIDs) > 1 {
+		blog.Errorf("merge host duplicate, the hosts are in different businesses %v, rid: %s", bizIDs, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrHostMergeCrossBusiness, util.Int64Join(bizIDs, ","))
+	}
+	if 0 == len(bizIDs) {
+		return nil
+	}
+	bizID := bizIDs[0]
+
+	moduleIDs := make([]int64, 0)
+	survivorModuleIDs := make([]int64, 0)
+	for _, relation := range relations {
+		if relation.AppID != bizID {
+			continue
+		}
+		moduleIDs = append(moduleIDs, relation.ModuleID)
+		if relation.HostID == survivorID {
+			survivorModuleIDs = append(survivorModuleIDs, relation.ModuleID)
+		}
+	}
+	moduleIDs = util.IntArrayUnique(moduleIDs)
+
+	cond := mapstr.MapStr{common.BKModuleIDField: mapstr.MapStr{common.BKDBIN: moduleIDs}}
+	modules, err := lgc.GetModuleMapByCond(ctx, []string{common.BKModuleIDField, common.BKDefaultField}, cond)
+	if err != nil {
+		return err
+	}
+	// a host can't be in an inner module and a normal module at the same time
+	normalIDs := make([]int64, 0)
+	innerIDs := make([]int64, 0)
+	for _, moduleID := range moduleIDs {
+		flag, _ := util.GetInt64ByInterface(modules[moduleID][common.BKDefaultField])
+		if 0 == flag {
+			normalIDs = append(normalIDs, moduleID)
+		} else {
+			innerIDs = append(innerIDs, moduleID)
+		}
+	}
+	targetIDs := normalIDs
+	if 0 == len(targetIDs) {
+		sort.Slice(innerIDs, func(i, j int) bool { return innerIDs[i] < innerIDs[j] })
+		targetIDs = innerIDs[:1]
+	}
+	if hostAppID[survivorID] == bizID && len(targetIDs) == len(util.IntArrayUnique(survivorModuleIDs)) {
+		unchanged := true
+		for _, moduleID := range targetIDs {
+			if !util.InArray(moduleID, survivorModuleIDs) {
+				unchanged = false
+				break
+			}
+		}
+		if unchanged {
+			return nil
+		}
+	}
+
+	audit := lgc.NewHostModuleLog([]int64{survivorID})
+	if err := audit.WithPrevious(ctx); err != nil {
+		blog.Errorf("merge host duplicate, get prev module host config of %d failed, err: %v, rid: %s", survivorID, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+
+	if hostAppID[survivorID] != bizID {
+		conf := &metadata.TransferHostsCrossBusinessRequest{SrcApplicationID: hostAppID[survivorID], HostIDArr: []int64{survivorID}, DstApplicationID: bizID, DstModuleIDArr: targetIDs}
+		result, err := lgc.CoreAPI.CoreService().Host().TransferHostCrossBusiness(ctx, lgc.header, conf)
+		if err != nil {
+			blog.Errorf("merge host duplicate, transfer host across business http do error, err: %v, input: %+v, rid: %s", err, conf, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("merge host duplicate, transfer host across business http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, conf, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+	} else {
+		conf := &metadata.HostsModuleRelation{ApplicationID: bizID, HostID: []int64{survivorID}, ModuleID: targetIDs, IsIncrement: false}
+		result, err := lgc.CoreAPI.CoreService().Host().TransferHostModule(ctx, lgc.header, conf)
+		if err != nil {
+			blog.Errorf("merge host duplicate, transfer host module http do error, err: %v, input: %+v, rid: %s", err, conf, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("merge host duplicate, transfer host module http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, conf, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+	}
+
+	if err := audit.SaveAudit(ctx, bizID, lgc.user, "merge duplicate hosts"); err != nil {
+		blog.Errorf("merge host duplicate, save module audit of %d failed, err: %v, rid: %s", survivorID, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+	hostAppID[survivorID] = bizID
+	return nil
+}
+
+// mergeHostAssociations move the instance associations of the merged hosts to the survivor
+func (lgc *Logics) mergeHostAssociations(ctx context.Context, survivorID int64, mergedIDs []int64) errors.CCError {
+	allIDs := append([]int64{survivorID}, mergedIDs...)
+	conds := []mapstr.MapStr{
+		{common.BKObjIDField: common.BKInnerObjIDHost, common.BKInstIDField: mapstr.MapStr{common.BKDBIN: allIDs}},
+		{common.BKAsstObjIDField: common.BKInnerObjIDHost, common.BKAsstInstIDField: mapstr.MapStr{common.BKDBIN: allIDs}},
+	}
+
+	assts := make([]metadata.InstAsst, 0)
+	for _, cond := range conds {
+		query := &metadata.QueryCondition{Condition: cond}
+		result, err := lgc.CoreAPI.CoreService().Association().ReadInstAssociation(ctx, lgc.header, query)
+		if err != nil {
+			blog.Errorf("merge host duplicate, read associations http do error, err: %v, input: %+v, rid: %s", err, query, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("merge host duplicate, read associations http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, query, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+		assts = append(assts, result.Data.Info...)
+	}
+
+	isHost := func(objID string, instID int64) bool {
+		return common.BKInnerObjIDHost == objID && util.InArray(instID, allIDs)
+	}
+	asstKey := func(asst metadata.InstAsst) string {
+		return strings.Join([]string{asst.ObjectAsstID, asst.ObjectID, strconv.FormatInt(asst.InstID, 10),
+			asst.AsstObjectID, strconv.FormatInt(asst.AsstInstID, 10)}, "|")
+	}
+
+	existing := make(map[string]bool)
+	for _, asst := range assts {
+		if asst.InstID == survivorID && common.BKInnerObjIDHost == asst.ObjectID ||
+			asst.AsstInstID == survivorID && common.BKInnerObjIDHost == asst.AsstObjectID {
+			existing[asstKey(asst)] = true
+		}
+	}
+
+	moved := make(map[int64]bool)
+	for _, asst := range assts {
+		if moved[asst.ID] {
+			continue
+		}
+		newAsst := asst
+		changed := false
+		if isHost(asst.ObjectID, asst.InstID) && asst.InstID != survivorID {
+			newAsst.InstID = survivorID
+			changed = true
+		}
+		if isHost(asst.AsstObjectID, asst.AsstInstID) && asst.AsstInstID != survivorID {
+			newAsst.AsstInstID = survivorID
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		moved[asst.ID] = true
+
+		// the association between the duplicates themselves becomes a self link, which is dropped
+		selfLink := newAsst.ObjectID == newAsst.AsstObjectID && newAsst.InstID == newAsst.AsstInstID
+		if !selfLink && !existing[asstKey(newAsst)] {
+			newAsst.ID = 0
+			input := &metadata.CreateOneInstanceAssociation{Data: newAsst}
+			result, err := lgc.CoreAPI.CoreService().Association().CreateInstAssociation(ctx, lgc.header, input)
+			if err != nil {
+				blog.Errorf("merge host duplicate, create association http do error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+				return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+			}
+			if !result.Result {
+				blog.Errorf("merge host duplicate, create association http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+				return lgc.ccErr.New(result.Code, result.ErrMsg)
+			}
+			existing[asstKey(newAsst)] = true
+		}
+
+		input := &metadata.DeleteOption{Condition: mapstr.MapStr{common.BKFieldID: asst.ID}}
+		result, err := lgc.CoreAPI.CoreService().Association().DeleteInstAssociation(ctx, lgc.header, input)
+		if err != nil {
+			blog.Errorf("merge host duplicate, delete association http do error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("merge host duplicate, delete association http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+	}
+	return nil
+}
+
+// mergeHostProcesses bind the processes of the merged hosts to the survivor
+func (lgc *Logics) mergeHostProcesses(ctx context.Context, survivorID int64, mergedIDs []int64) errors.CCError {
+	allIDs := append([]int64{survivorID}, mergedIDs...)
+	query := &metadata.QueryInput{
+		Condition: map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: allIDs}},
+		Limit:     common.BKNoLimit,
+	}
+	result, err := lgc.CoreAPI.ProcController().GetProcInstanceModel(ctx, lgc.header, query)
+	if err != nil {
+		blog.Errorf("merge host duplicate, get process instances http do error, err: %v, input: %+v, rid: %s", err, query, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("merge host duplicate, get process instances http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, query, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+
+	procKey := func(inst metadata.ProcInstanceModel) string {
+		return strconv.FormatInt(inst.ProcID, 10) + "|" + strconv.FormatInt(inst.ModuleID, 10)
+	}
+	existing := make(map[string]bool)
+	for _, inst := range result.Data.Info {
+		if inst.HostID == survivorID {
+			existing[procKey(inst)] = true
+		}
+	}
+	moved := make([]*metadata.ProcInstanceModel, 0)
+	for index := range result.Data.Info {
+		inst := result.Data.Info[index]
+		if inst.HostID == survivorID || existing[procKey(inst)] {
+			continue
+		}
+		inst.HostID = survivorID
+		existing[procKey(inst)] = true
+		moved = append(moved, &inst)
+	}
+
+	if 0 != len(moved) {
+		createResult, err := lgc.CoreAPI.ProcController().CreateProcInstanceModel(ctx, lgc.header, moved)
+		if err != nil {
+			blog.Errorf("merge host duplicate, create process instances http do error, err: %v, rid: %s", err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !createResult.Result {
+			blog.Errorf("merge host duplicate, create process instances http response error, err code: %d, err msg: %s, rid: %s", createResult.Code, createResult.ErrMsg, lgc.rid)
+			return lgc.ccErr.New(createResult.Code, createResult.ErrMsg)
+		}
+	}
+
+	cond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: mergedIDs}}
+	deleteResult, err := lgc.CoreAPI.ProcController().DeleteProcInstanceModel(ctx, lgc.header, cond)
+	if err != nil {
+		blog.Errorf("merge host duplicate, delete process instances http do error, err: %v, input: %+v, rid: %s", err, cond, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !deleteResult.Result {
+		blog.Errorf("merge host duplicate, delete process instances http response error, err code: %d, err msg: %s, input: %+v, rid: %s", deleteResult.Code, deleteResult.ErrMsg, cond, lgc.rid)
+		return lgc.ccErr.New(deleteResult.Code, deleteResult.ErrMsg)
+	}
+	return nil
+}
+
+// deleteMergedHosts delete the merged hosts from their businesses and save the audit logs
+func (lgc *Logics) deleteMergedHosts(ctx context.Context, hostAppID map[int64]int64, mergedIDs []int64, hostFields []metadata.Header) errors.CCError {
+	appHosts := make(map[int64][]int64)
+	logs := make([]metadata.SaveAuditLogParams, 0)
+	for _, hostID := range mergedIDs {
+		appHosts[hostAppID[hostID]] = append(appHosts[hostAppID[hostID]], hostID)
+
+		logger := lgc.NewHostLog(ctx, lgc.ownerID)
+		if err := logger.WithPrevious(ctx, strconv.FormatInt(hostID, 10), hostFields); err != nil {
+			blog.Errorf("merge host duplicate, get pre data of host %d failed, err: %v, rid: %s", hostID, err, lgc.rid)
+			return err
+		}
+		item := logger.AuditLog(ctx, hostID)
+		item.Model = common.BKInnerObjIDHost
+		item.OpDesc = "merge duplicate hosts"
+		item.OpType = auditoplog.AuditOpTypeDel
+		item.BizID = hostAppID[hostID]
+		logs = append(logs, item)
+	}
+
+	for appID, hostIDs := range appHosts {
+		input := &metadata.DeleteHostRequest{ApplicationID: appID, HostIDArr: hostIDs}
+		result, err := lgc.CoreAPI.CoreService().Host().DeleteHost(ctx, lgc.header, input)
+		if err != nil {
+			blog.Errorf("merge host duplicate, delete host http do error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("merge host duplicate, delete host http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrHostDeleteFail)
+		}
+	}
+
+	auditResult, err := lgc.CoreAPI.CoreService().Audit().SaveAuditLog(ctx, lgc.header, logs...)
+	if err != nil || !auditResult.Result {
+		blog.Errorf("merge host duplicate, save delete audit log failed, err: %v, result: %+v, rid: %s", err, auditResult, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrAuditSaveLogFaile)
+	}
+	return nil
+}
+
+// mergeHostAttributes get the attributes of the survivor to change, the field given explicitly
+// takes the value of the given host, otherwise the empty field is filled with the latest updated host.
+func mergeHostAttributes(survivorID int64, mergedIDs []int64, hostMap map[int64]mapstr.MapStr, hostFields []metadata.Header, sources map[string]int64) mapstr.MapStr {
+	candidates := make([]int64, len(mergedIDs))
+	copy(candidates, mergedIDs)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return hostUpdateTime(hostMap[candidates[i]]).After(hostUpdateTime(hostMap[candidates[j]]))
+	})
+
+	survivor := hostMap[survivorID]
+	changed := mapstr.New()
+	for _, header := range hostFields {
+		field := header.PropertyID
+		if util.InStrArr(hostMergeSkipFields, field) {
+			continue
+		}
+
+		if sourceID, ok := sources[field]; ok {
+			if sourceID != survivorID && !isEmptyHostValue(hostMap[sourceID][field]) {
+				changed[field] = hostMap[sourceID][field]
+			}
+			continue
+		}
+		if !isEmptyHostValue(survivor[field]) {
+			continue
+		}
+		for _, hostID := range candidates {
+			if value := hostMap[hostID][field]; !isEmptyHostValue(value) {
+				changed[field] = value
+				break
+			}
+		}
+	}
+	return changed
+}
+
+func isEmptyHostValue(value interface{}) bool {
+	if nil == value {
+		return true
+	}
+	if str, ok := value.(string); ok {
+		return "" == strings.TrimSpace(str)
+	}
+	return false
+}
+
+func hostUpdateTime(host mapstr.MapStr) time.Time {
+	switch value := host[common.LastTimeField].(type) {
+	case time.Time:
+		return value
+	case string:
+		if updateTime, err := time.Parse(time.RFC3339, value); err == nil {
+			return updateTime
+		}
+	}
+	return time.Time{}
+}
+
+func (lgc *Logics) updateMergedHost(ctx context.Context, survivorID, appID int64, data mapstr.MapStr, hostFields []metadata.Header) errors.CCError {
+	if 0 == len(data) {
+		return nil
+	}
+
+	hostIDStr := strconv.FormatInt(survivorID, 10)
+	logger := lgc.NewHostLog(ctx, lgc.ownerID)
+	if err := logger.WithPrevious(ctx, hostIDStr, hostFields); err != nil {
+		blog.Errorf("merge host duplicate, get pre data of host %d failed, err: %v, rid: %s", survivorID, err, lgc.rid)
+		return err
+	}
+
+	opt := &metadata.UpdateOption{
+		Condition: mapstr.MapStr{common.BKHostIDField: survivorID},
+		Data:      data,
+	}
+	result, err := lgc.CoreAPI.CoreService().Instance().UpdateInstance(ctx, lgc.header, common.BKInnerObjIDHost, opt)
+	if err != nil {
+		blog.Errorf("merge host duplicate, update host http do error, err: %v, input: %+v, rid: %s", err, opt, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("merge host duplicate, update host http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, opt, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+
+	if err := logger.WithCurrent(ctx, hostIDStr); err != nil {
+		blog.Errorf("merge host duplicate, get current data of host %d failed, err: %v, rid: %s", survivorID, err, lgc.rid)
+		return err
+	}
+	item := logger.AuditLog(ctx, survivorID)
+	item.Model = common.BKInnerObjIDHost
+	item.OpDesc = "merge duplicate hosts"
+	item.OpType = auditoplog.AuditOpTypeModify
+	item.BizID = appID
+	auditResult, err := lgc.CoreAPI.CoreService().Audit().SaveAuditLog(ctx, lgc.header, item)
+	if err != nil || !auditResult.Result {
+		blog.Errorf("merge host duplicate, save update audit log failed, err: %v, result: %+v, rid: %s", err, auditResult, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrAuditSaveLogFaile)
+	}
+	return nil
+}
+
+// hostDuplicateHeader the header used to detect the duplicates of the supplier account in background
+func hostDuplicateHeader(ownerID string) http.Header {
+	header := make(http.Header)
+	header.Set(common.BKHTTPOwnerID, ownerID)
+	header.Set(common.BKHTTPHeaderUser, common.BKProcInstanceOpUser)
+	return header
+}
@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func duplicateHost(hostID int64, ip, mac, sn string) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKHostIDField:      hostID,
+		common.BKHostInnerIPField: ip,
+		"bk_mac":                  mac,
+		"bk_sn":                   sn,
+	}
+}
+
+func TestDetectHostDuplicates(t *testing.T) {
+	keys := []metadata.HostDuplicateKey{
+		{Field: common.BKHostInnerIPField, Weight: 1},
+		{Field: "bk_mac", Weight: 2},
+		{Field: "bk_sn", Weight: 3},
+	}
+
+	// the hosts 100+ share the same placeholder mac, which is more than the bucket cap
+	bigBucket := make([]mapstr.MapStr, 0)
+	for i := 0; i <= hostDuplicateMaxBucket; i++ {
+		bigBucket = append(bigBucket, duplicateHost(int64(100+i), fmt.Sprintf("10.1.0.%d", i), "00:00:00:00:00:00", ""))
+	}
+
+	tests := []struct {
+		name      string
+		hosts     []mapstr.MapStr
+		threshold int64
+		want      []metadata.HostDuplicate
+	}{
+		{
+			name: "score below threshold",
+			hosts: []mapstr.MapStr{
+				duplicateHost(1, "10.0.0.1", "aa:aa", ""),
+				duplicateHost(2, "10.0.0.1", "bb:bb", ""),
+			},
+			threshold: 2,
+			want:      []metadata.HostDuplicate{},
+		},
+		{
+			name: "score reaches threshold",
+			hosts: []mapstr.MapStr{
+				duplicateHost(1, "10.0.0.1", "AA-AA", ""),
+				duplicateHost(2, "10.0.0.1, 10.0.0.2", "aa:aa", ""),
+				duplicateHost(3, "10.0.0.3", "cc:cc", ""),
+			},
+			threshold: 3,
+			want: []metadata.HostDuplicate{
+				{HostIDs: []int64{1, 2}, Score: 3, MatchedKeys: []string{common.BKHostInnerIPField, "bk_mac"}, Fingerprint: "1,2"},
+			},
+		},
+		{
+			name: "transitive grouping",
+			hosts: []mapstr.MapStr{
+				duplicateHost(1, "10.0.0.1", "", "sn1"),
+				duplicateHost(2, "10.0.0.2", "", "sn1"),
+				duplicateHost(3, "10.0.0.3", "bb:bb", "sn2"),
+				duplicateHost(4, "10.0.0.4", "bb:bb", "sn2"),
+				duplicateHost(5, "10.0.0.5", "cc:cc", "sn2"),
+			},
+			threshold: 3,
+			want: []metadata.HostDuplicate{
+				{HostIDs: []int64{3, 4, 5}, Score: 5, MatchedKeys: []string{"bk_mac", "bk_sn"}, Fingerprint: "3,4,5"},
+				{HostIDs: []int64{1, 2}, Score: 3, MatchedKeys: []string{"bk_sn"}, Fingerprint: "1,2"},
+			},
+		},
+		{
+			name: "chain joins groups",
+			hosts: []mapstr.MapStr{
+				duplicateHost(1, "10.0.0.1", "aa:aa", ""),
+				duplicateHost(2, "10.0.0.2", "aa:aa", "sn1"),
+				duplicateHost(3, "10.0.0.3", "", "sn1"),
+			},
+			threshold: 2,
+			want: []metadata.HostDuplicate{
+				{HostIDs: []int64{1, 2, 3}, Score: 3, MatchedKeys: []string{"bk_mac", "bk_sn"}, Fingerprint: "1,2,3"},
+			},
+		},
+		{
+			name:      "bucket cap",
+			hosts:     bigBucket,
+			threshold: 1,
+			want:      []metadata.HostDuplicate{},
+		},
+		{
+			name:      "bucket cap with other keys",
+			hosts:     append(bigBucket, duplicateHost(200, "10.1.0.0", "00:00:00:00:00:00", "")),
+			threshold: 1,
+			want: []metadata.HostDuplicate{
+				{HostIDs: []int64{100, 200}, Score: 1, MatchedKeys: []string{common.BKHostInnerIPField}, Fingerprint: "100,200"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectHostDuplicates(tt.hosts, keys, tt.threshold); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectHostDuplicates() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
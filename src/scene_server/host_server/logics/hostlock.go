@@ -14,10 +14,15 @@ package logics
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"configcenter/src/auth/meta"
 	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 )
 
@@ -37,6 +42,20 @@ func (lgc *Logics) LockHost(ctx context.Context, input *metadata.HostLockRequest
 
 func (lgc *Logics) UnlockHost(ctx context.Context, input *metadata.HostLockRequest) errors.CCError {
 
+	// the locks owned by other user released by force need audit
+	var overrideLocks []metadata.HostLockData
+	if input.Force {
+		lockMap, err := lgc.QueryHostLockDetail(ctx, &metadata.QueryHostLockRequest{IPS: input.IPS, CloudID: input.CloudID})
+		if nil != err {
+			return err
+		}
+		for _, lock := range lockMap {
+			if nil != lock && lock.User != lgc.user {
+				overrideLocks = append(overrideLocks, *lock)
+			}
+		}
+	}
+
 	hostUnlockResult, err := lgc.CoreAPI.HostController().Host().UnlockHost(ctx, lgc.header, input)
 	if nil != err {
 		blog.Errorf("unlock host, http request error, error:%s,input:%+v,logID:%s", err.Error(), input, lgc.rid)
@@ -46,6 +65,9 @@ func (lgc *Logics) UnlockHost(ctx context.Context, input *metadata.HostLockReque
 		blog.Errorf("unlock host, release host lock  error, error code:%d error message:%s,input:%+v,logID:%s", hostUnlockResult.Code, hostUnlockResult.ErrMsg, input, lgc.rid)
 		return lgc.ccErr.New(hostUnlockResult.Code, hostUnlockResult.ErrMsg)
 	}
+	if 0 != len(overrideLocks) {
+		return lgc.saveHostLockOverrideAudit(ctx, "force release host lock", overrideLocks)
+	}
 	return nil
 }
 
@@ -70,3 +92,206 @@ func (lgc *Logics) QueryHostLock(ctx context.Context, input *metadata.QueryHostL
 
 	return hostLockMap, nil
 }
+
+// QueryHostLockDetail query host lock detail, the key of result is ip,
+// the value is nil when the host is not locked
+func (lgc *Logics) QueryHostLockDetail(ctx context.Context, input *metadata.QueryHostLockRequest) (map[string]*metadata.HostLockData, errors.CCError) {
+
+	hostLockResult, err := lgc.CoreAPI.HostController().Host().QueryHostLock(ctx, lgc.header, input)
+	if nil != err {
+		blog.Errorf("query lock host detail, http request error, error:%s,input:%+v,logID:%s", err.Error(), input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !hostLockResult.Result {
+		blog.Errorf("query host lock detail error, error code:%d error message:%s,input:%+v,logID:%s", hostLockResult.Code, hostLockResult.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(hostLockResult.Code, hostLockResult.ErrMsg)
+	}
+	hostLockMap := make(map[string]*metadata.HostLockData, 0)
+	for _, ip := range input.IPS {
+		hostLockMap[ip] = nil
+	}
+	now := time.Now()
+	for idx := range hostLockResult.Data.Info {
+		hostLock := hostLockResult.Data.Info[idx]
+		if hostLock.IsExpired(now) {
+			continue
+		}
+		hostLockMap[hostLock.IP] = &hostLock
+	}
+
+	return hostLockMap, nil
+}
+
+// CheckHostLock check whether the hosts can be changed by current user.
+// the host locked by other user refuse changes, unless the caller set override
+// header and has the permission to manage the locks of the hosts. the overridden
+// locks are returned, the caller audits them by SaveHostLockOverrideAudit after
+// the change succeeds.
+func (lgc *Logics) CheckHostLock(ctx context.Context, hostIDArr []int64) ([]metadata.HostLockData, errors.CCError) {
+	if 0 == len(hostIDArr) {
+		return nil, nil
+	}
+
+	cond := map[string]interface{}{
+		common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDArr},
+	}
+	hostInfoArr, err := lgc.GetHostInfoByConds(ctx, cond)
+	if nil != err {
+		return nil, err
+	}
+
+	cloudIPMap := make(map[int64][]string, 0)
+	ipHostIDMap := make(map[string]int64, 0)
+	for _, hostInfo := range hostInfoArr {
+		cloudID, err := hostInfo.Int64(common.BKCloudIDField)
+		if nil != err {
+			blog.Errorf("check host lock, get cloud id failed, host:%+v, err:%s, rid:%s", hostInfo, err.Error(), lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrCommInstFieldConvFail, common.BKInnerObjIDHost, common.BKCloudIDField, "int", err.Error())
+		}
+		innerIP, err := hostInfo.String(common.BKHostInnerIPField)
+		if nil != err || "" == innerIP {
+			continue
+		}
+		cloudIPMap[cloudID] = append(cloudIPMap[cloudID], innerIP)
+		hostID, _ := hostInfo.Int64(common.BKHostIDField)
+		ipHostIDMap[fmt.Sprintf("%d:%s", cloudID, innerIP)] = hostID
+	}
+
+	override := "true" == lgc.header.Get(common.BKHTTPHostLockOverride)
+	var overrideLocks []metadata.HostLockData
+	overrideHostIDs := make([]int64, 0)
+	for cloudID, ipArr := range cloudIPMap {
+		lockMap, err := lgc.QueryHostLockDetail(ctx, &metadata.QueryHostLockRequest{IPS: ipArr, CloudID: cloudID})
+		if nil != err {
+			return nil, err
+		}
+		for _, lock := range lockMap {
+			if nil == lock || lock.User == lgc.user {
+				continue
+			}
+			if !override {
+				blog.Errorf("check host lock, host %s is locked by %s, user:%s, rid:%s", lock.IP, lock.User, lgc.user, lgc.rid)
+				return nil, lgc.ccErr.Errorf(common.CCErrHostLockedByOtherUser, lock.IP, lock.User, lock.Reason)
+			}
+			overrideLocks = append(overrideLocks, *lock)
+			overrideHostIDs = append(overrideHostIDs, ipHostIDMap[fmt.Sprintf("%d:%s", cloudID, lock.IP)])
+		}
+	}
+
+	// anyone can set the override header, it's honored only when the user can manage
+	// the locks of the hosts, which is the same permission as lock and unlock them
+	if 0 != len(overrideHostIDs) {
+		if err := lgc.AuthManager.AuthorizeByHostsIDs(ctx, lgc.header, meta.Update, overrideHostIDs...); nil != err {
+			blog.Errorf("check host lock, not allowed to override the locks of hosts %v, err: %v, user:%s, rid:%s", overrideHostIDs, err, lgc.user, lgc.rid)
+			return nil, lgc.ccErr.Error(common.CCErrCommAuthorizeFailed)
+		}
+	}
+
+	return overrideLocks, nil
+}
+
+// splitLockedHosts split the hosts into the unlocked ones and the ones locked by any user,
+// it's used by the background jobs, which change hosts without a user to override the lock.
+func (lgc *Logics) splitLockedHosts(ctx context.Context, hosts []mapstr.MapStr) ([]mapstr.MapStr, []mapstr.MapStr, errors.CCError) {
+	cloudHostMap := make(map[int64][]mapstr.MapStr, 0)
+	for _, host := range hosts {
+		cloudID, err := host.Int64(common.BKCloudIDField)
+		if nil != err {
+			blog.Errorf("split locked hosts, get cloud id failed, host:%+v, err:%s, rid:%s", host, err.Error(), lgc.rid)
+			return nil, nil, lgc.ccErr.Errorf(common.CCErrCommInstFieldConvFail, common.BKInnerObjIDHost, common.BKCloudIDField, "int", err.Error())
+		}
+		cloudHostMap[cloudID] = append(cloudHostMap[cloudID], host)
+	}
+
+	unlocked := make([]mapstr.MapStr, 0)
+	locked := make([]mapstr.MapStr, 0)
+	for cloudID, cloudHosts := range cloudHostMap {
+		ipArr := make([]string, 0)
+		for _, host := range cloudHosts {
+			innerIP, _ := host.String(common.BKHostInnerIPField)
+			ipArr = append(ipArr, innerIP)
+		}
+		lockMap, err := lgc.QueryHostLockDetail(ctx, &metadata.QueryHostLockRequest{IPS: ipArr, CloudID: cloudID})
+		if nil != err {
+			return nil, nil, err
+		}
+		for _, host := range cloudHosts {
+			innerIP, _ := host.String(common.BKHostInnerIPField)
+			if nil != lockMap[innerIP] {
+				locked = append(locked, host)
+				continue
+			}
+			unlocked = append(unlocked, host)
+		}
+	}
+	return unlocked, locked, nil
+}
+
+// SaveHostLockOverrideAudit audit the locks overridden by the change, the failure is
+// only logged, as the change is already done.
+func (lgc *Logics) SaveHostLockOverrideAudit(ctx context.Context, locks []metadata.HostLockData) {
+	if 0 == len(locks) {
+		return
+	}
+	if err := lgc.saveHostLockOverrideAudit(ctx, "override host lock", locks); nil != err {
+		blog.Errorf("save host lock override audit failed, locks: %+v, err: %v, rid: %s", locks, err, lgc.rid)
+	}
+}
+
+func (lgc *Logics) saveHostLockOverrideAudit(ctx context.Context, desc string, locks []metadata.HostLockData) errors.CCError {
+	cloudIPMap := make(map[int64][]string, 0)
+	for _, lock := range locks {
+		cloudIPMap[lock.CloudID] = append(cloudIPMap[lock.CloudID], lock.IP)
+	}
+	hostIDMap := make(map[string]int64, 0)
+	for cloudID, ipArr := range cloudIPMap {
+		cond := map[string]interface{}{
+			common.BKCloudIDField:     cloudID,
+			common.BKHostInnerIPField: map[string]interface{}{common.BKDBIN: ipArr},
+		}
+		hostInfoArr, err := lgc.GetHostInfoByConds(ctx, cond)
+		if nil != err {
+			return err
+		}
+		for _, hostInfo := range hostInfoArr {
+			hostID, err := hostInfo.Int64(common.BKHostIDField)
+			if nil != err {
+				continue
+			}
+			innerIP, _ := hostInfo.String(common.BKHostInnerIPField)
+			hostIDMap[fmt.Sprintf("%d:%s", cloudID, innerIP)] = hostID
+		}
+	}
+
+	headers := []metadata.Header{
+		{PropertyID: "bk_user", PropertyName: "lock user"},
+		{PropertyID: "reason", PropertyName: "lock reason"},
+		{PropertyID: "expire_time", PropertyName: "lock expire time"},
+	}
+	logs := make([]metadata.SaveAuditLogParams, 0)
+	for _, lock := range locks {
+		logs = append(logs, metadata.SaveAuditLogParams{
+			ID:    hostIDMap[fmt.Sprintf("%d:%s", lock.CloudID, lock.IP)],
+			Model: common.BKInnerObjIDHost,
+			Content: metadata.Content{
+				PreData: common.KvMap{"bk_user": lock.User, "reason": lock.Reason, "expire_time": lock.ExpireTime},
+				CurData: common.KvMap{"bk_user": lgc.user},
+				Headers: headers,
+			},
+			OpDesc: desc,
+			OpType: auditoplog.AuditOpTypeHostLockOverride,
+			ExtKey: lock.IP,
+		})
+	}
+
+	result, err := lgc.CoreAPI.CoreService().Audit().SaveAuditLog(ctx, lgc.header, logs...)
+	if nil != err {
+		blog.Errorf("save host lock override audit log http do error, err:%s,input:%+v,rid:%s", err.Error(), logs, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrAuditSaveLogFaile)
+	}
+	if !result.Result {
+		blog.Errorf("save host lock override audit log http reponse error, err code:%d, err msg:%s,input:%+v,rid:%s", result.Code, result.ErrMsg, logs, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return nil
+}
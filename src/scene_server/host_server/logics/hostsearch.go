@@ -678,8 +678,46 @@ func (sh *searchHost) searchByHostConds() errors.CCError {
 	}
 
 	condition := make(map[string]interface{})
-	hostParse.ParseHostParams(sh.conds.hostCond.Condition, condition)
-	hostParse.ParseHostIPParams(sh.hostSearchParam.Ip, condition)
+	if err := hostParse.ParseHostParams(sh.conds.hostCond.Condition, condition); err != nil {
+		blog.Errorf("parse host condition failed, err: %v, rid: %s", err, sh.ccRid)
+		return sh.ccErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+	if err := hostParse.ParseHostIPParams(sh.hostSearchParam.Ip, condition); err != nil {
+		blog.Errorf("parse host ip condition failed, err: %v, rid: %s", err, sh.ccRid)
+		return sh.ccErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	if sh.hostSearchParam.Expression != nil {
+		exprCond, matchNone, err := sh.lgc.CompileHostSearchExpression(sh.ctx, sh.hostSearchParam.Expression)
+		if err != nil {
+			blog.Errorf("compile host search expression failed, err: %v, rid: %s", err, sh.ccRid)
+			return err
+		}
+		if matchNone {
+			sh.noData = true
+			return nil
+		}
+		if exprCond != nil {
+			condition = map[string]interface{}{
+				common.BKDBAND: []interface{}{condition, exprCond},
+			}
+		}
+	}
+
+	// the hosts in the sub directories are also in the directory
+	if 0 != sh.hostSearchParam.DirID {
+		dirIDs, err := sh.lgc.GetResourceDirectorySubIDs(sh.ctx, sh.hostSearchParam.DirID)
+		if err != nil {
+			blog.Errorf("get sub resource directories failed, err: %v, rid: %s", err, sh.ccRid)
+			return err
+		}
+		condition = map[string]interface{}{
+			common.BKDBAND: []interface{}{
+				condition,
+				map[string]interface{}{common.BKResourceDirIDField: map[string]interface{}{common.BKDBIN: dirIDs}},
+			},
+		}
+	}
 
 	query := &metadata.QueryInput{
 		Condition: condition,
@@ -0,0 +1,320 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	hostParse "configcenter/src/common/paraparse"
+)
+
+// CompileHostSearchExpression compile the host search expression into a condition of host table.
+// host field leaves are pushed down to db directly, topology and associated object leaves are
+// resolved into host id list. the returned condition is nil when the expression matches all hosts,
+// matchNone is true when the expression can not match any host, so that the caller can skip the query.
+func (lgc *Logics) CompileHostSearchExpression(ctx context.Context, expr *metadata.HostSearchExpression) (cond mapstr.MapStr, matchNone bool, err errors.CCError) {
+	if expr == nil {
+		return nil, false, nil
+	}
+	if err := expr.Validate(); err != nil {
+		blog.Errorf("CompileHostSearchExpression invalid expression, err: %v, rid: %s", err, lgc.rid)
+		return nil, false, lgc.ccErr.Errorf(common.CCErrHostSearchExpressionInvalid, err.Error())
+	}
+
+	compiler := &hostExpressionCompiler{
+		lgc:     lgc,
+		ctx:     ctx,
+		idCache: make(map[string][]int64),
+	}
+	result, err := compiler.compile(expr)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.matchNone {
+		return nil, true, nil
+	}
+	return result.toCondition(), false, nil
+}
+
+// compiledExpression the compiled result of a expression node
+type compiledExpression struct {
+	// cond db condition of host table, nil means match all hosts
+	cond mapstr.MapStr
+	// hostIDs when isIDSet is true, the node matches exactly these hosts.
+	// kept as id set so that sibling nodes can be merged in memory.
+	hostIDs   map[int64]bool
+	isIDSet   bool
+	matchNone bool
+}
+
+func (c *compiledExpression) matchAll() bool {
+	return !c.matchNone && !c.isIDSet && c.cond == nil
+}
+
+func (c *compiledExpression) toCondition() mapstr.MapStr {
+	if c.isIDSet {
+		return mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: idSetToArray(c.hostIDs)}}
+	}
+	return c.cond
+}
+
+type hostExpressionCompiler struct {
+	lgc *Logics
+	ctx context.Context
+	// idCache cache the host id of the same leaf, avoid query the same topology twice
+	idCache map[string][]int64
+}
+
+func (c *hostExpressionCompiler) compile(expr *metadata.HostSearchExpression) (*compiledExpression, errors.CCError) {
+	switch expr.Logic {
+	case metadata.ExpressionLogicAnd:
+		return c.compileAnd(expr.Children)
+	case metadata.ExpressionLogicOr:
+		return c.compileOr(expr.Children)
+	case metadata.ExpressionLogicNot:
+		return c.compileNot(expr.Children[0])
+	default:
+		return c.compileLeaf(expr)
+	}
+}
+
+func (c *hostExpressionCompiler) compileAnd(children []*metadata.HostSearchExpression) (*compiledExpression, errors.CCError) {
+	var idSet map[int64]bool
+	conds := make([]interface{}, 0)
+	for _, child := range children {
+		result, err := c.compile(child)
+		if err != nil {
+			return nil, err
+		}
+		if result.matchNone {
+			return &compiledExpression{matchNone: true}, nil
+		}
+		if result.isIDSet {
+			idSet = intersectIDSet(idSet, result.hostIDs)
+			if len(idSet) == 0 {
+				return &compiledExpression{matchNone: true}, nil
+			}
+			continue
+		}
+		if result.matchAll() {
+			continue
+		}
+		conds = append(conds, result.cond)
+	}
+
+	if len(conds) == 0 {
+		if idSet != nil {
+			return &compiledExpression{isIDSet: true, hostIDs: idSet}, nil
+		}
+		return &compiledExpression{}, nil
+	}
+	if idSet != nil {
+		conds = append(conds, (&compiledExpression{isIDSet: true, hostIDs: idSet}).toCondition())
+	}
+	if len(conds) == 1 {
+		return &compiledExpression{cond: conds[0].(mapstr.MapStr)}, nil
+	}
+	return &compiledExpression{cond: mapstr.MapStr{common.BKDBAND: conds}}, nil
+}
+
+func (c *hostExpressionCompiler) compileOr(children []*metadata.HostSearchExpression) (*compiledExpression, errors.CCError) {
+	var idSet map[int64]bool
+	conds := make([]interface{}, 0)
+	for _, child := range children {
+		result, err := c.compile(child)
+		if err != nil {
+			return nil, err
+		}
+		if result.matchNone {
+			continue
+		}
+		if result.matchAll() {
+			return &compiledExpression{}, nil
+		}
+		if result.isIDSet {
+			if idSet == nil {
+				idSet = make(map[int64]bool)
+			}
+			for id := range result.hostIDs {
+				idSet[id] = true
+			}
+			continue
+		}
+		conds = append(conds, result.cond)
+	}
+
+	if len(conds) == 0 {
+		if len(idSet) != 0 {
+			return &compiledExpression{isIDSet: true, hostIDs: idSet}, nil
+		}
+		return &compiledExpression{matchNone: true}, nil
+	}
+	if len(idSet) != 0 {
+		conds = append(conds, (&compiledExpression{isIDSet: true, hostIDs: idSet}).toCondition())
+	}
+	if len(conds) == 1 {
+		return &compiledExpression{cond: conds[0].(mapstr.MapStr)}, nil
+	}
+	return &compiledExpression{cond: mapstr.MapStr{common.BKDBOR: conds}}, nil
+}
+
+func (c *hostExpressionCompiler) compileNot(child *metadata.HostSearchExpression) (*compiledExpression, errors.CCError) {
+	result, err := c.compile(child)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case result.matchNone:
+		return &compiledExpression{}, nil
+	case result.matchAll():
+		return &compiledExpression{matchNone: true}, nil
+	case result.isIDSet:
+		cond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBNIN: idSetToArray(result.hostIDs)}}
+		return &compiledExpression{cond: cond}, nil
+	default:
+		return &compiledExpression{cond: mapstr.MapStr{common.BKDBNOR: []interface{}{result.cond}}}, nil
+	}
+}
+
+func (c *hostExpressionCompiler) compileLeaf(leaf *metadata.HostSearchExpression) (*compiledExpression, errors.CCError) {
+	condItems := []metadata.ConditionItem{*leaf.Condition}
+
+	switch leaf.ObjectID {
+	case common.BKInnerObjIDHost:
+		cond := make(map[string]interface{})
+		if err := hostParse.ParseHostParams(condItems, cond); err != nil {
+			blog.Errorf("compile host search expression, parse condition %+v failed, err: %v, rid: %s", leaf.Condition, err, c.lgc.rid)
+			return nil, c.lgc.ccErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+		}
+		return &compiledExpression{cond: mapstr.NewFromMap(cond)}, nil
+
+	case common.BKInnerObjIDPlat:
+		cloudIDs, err := c.lgc.GetObjectInstByCond(c.ctx, common.BKInnerObjIDPlat, condItems)
+		if err != nil {
+			return nil, err
+		}
+		if len(cloudIDs) == 0 {
+			return &compiledExpression{matchNone: true}, nil
+		}
+		cond := mapstr.MapStr{common.BKCloudIDField: mapstr.MapStr{common.BKDBIN: cloudIDs}}
+		return &compiledExpression{cond: cond}, nil
+	}
+
+	hostIDs, err := c.getLeafHostIDs(leaf)
+	if err != nil {
+		return nil, err
+	}
+	if len(hostIDs) == 0 {
+		return &compiledExpression{matchNone: true}, nil
+	}
+	idSet := make(map[int64]bool, len(hostIDs))
+	for _, id := range hostIDs {
+		idSet[id] = true
+	}
+	return &compiledExpression{isIDSet: true, hostIDs: idSet}, nil
+}
+
+// getLeafHostIDs get the id of hosts which belong to the topology node or associate to
+// the object instance described by the leaf.
+func (c *hostExpressionCompiler) getLeafHostIDs(leaf *metadata.HostSearchExpression) ([]int64, errors.CCError) {
+	key, jsErr := json.Marshal(leaf)
+	if jsErr == nil {
+		if hostIDs, ok := c.idCache[string(key)]; ok {
+			return hostIDs, nil
+		}
+	}
+
+	hostIDs, err := c.searchLeafHostIDs(leaf)
+	if err != nil {
+		return nil, err
+	}
+	if jsErr == nil {
+		c.idCache[string(key)] = hostIDs
+	}
+	return hostIDs, nil
+}
+
+func (c *hostExpressionCompiler) searchLeafHostIDs(leaf *metadata.HostSearchExpression) ([]int64, errors.CCError) {
+	condItems := []metadata.ConditionItem{*leaf.Condition}
+
+	switch leaf.ObjectID {
+	case common.BKInnerObjIDApp:
+		appIDArr, err := c.lgc.GetAppIDByCond(c.ctx, condItems)
+		if err != nil {
+			return nil, err
+		}
+		if len(appIDArr) == 0 {
+			return nil, nil
+		}
+		return c.lgc.GetHostIDByCond(c.ctx, metadata.HostModuleRelationRequest{AppIDArr: appIDArr})
+
+	case common.BKInnerObjIDSet:
+		setIDArr, err := c.lgc.GetSetIDByCond(c.ctx, condItems)
+		if err != nil {
+			return nil, err
+		}
+		if len(setIDArr) == 0 {
+			return nil, nil
+		}
+		return c.lgc.GetHostIDByCond(c.ctx, metadata.HostModuleRelationRequest{SetIDArr: setIDArr})
+
+	case common.BKInnerObjIDModule:
+		moduleIDArr, err := c.lgc.GetModuleIDByCond(c.ctx, condItems)
+		if err != nil {
+			return nil, err
+		}
+		if len(moduleIDArr) == 0 {
+			return nil, nil
+		}
+		return c.lgc.GetHostIDByCond(c.ctx, metadata.HostModuleRelationRequest{ModuleIDArr: moduleIDArr})
+
+	default:
+		// the other objects are matched by the instance association with host
+		instIDArr, err := c.lgc.GetObjectInstByCond(c.ctx, leaf.ObjectID, condItems)
+		if err != nil {
+			return nil, err
+		}
+		if len(instIDArr) == 0 {
+			return nil, nil
+		}
+		return c.lgc.GetHostIDByInstID(c.ctx, leaf.ObjectID, instIDArr)
+	}
+}
+
+// intersectIDSet intersect two id set, a nil base means no limit yet.
+func intersectIDSet(base, other map[int64]bool) map[int64]bool {
+	if base == nil {
+		return other
+	}
+	result := make(map[int64]bool)
+	for id := range base {
+		if other[id] {
+			result[id] = true
+		}
+	}
+	return result
+}
+
+func idSetToArray(idSet map[int64]bool) []int64 {
+	ids := make([]int64, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	return ids
+}
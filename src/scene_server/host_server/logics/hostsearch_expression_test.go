@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func hostLeaf(name string) *metadata.HostSearchExpression {
+	return &metadata.HostSearchExpression{
+		ObjectID:  common.BKInnerObjIDHost,
+		Condition: &metadata.ConditionItem{Field: common.BKHostNameField, Operator: common.BKDBEQ, Value: name},
+	}
+}
+
+func moduleLeaf(name string) *metadata.HostSearchExpression {
+	return &metadata.HostSearchExpression{
+		ObjectID:  common.BKInnerObjIDModule,
+		Condition: &metadata.ConditionItem{Field: common.BKModuleNameField, Operator: common.BKDBEQ, Value: name},
+	}
+}
+
+func logicNode(logic string, children ...*metadata.HostSearchExpression) *metadata.HostSearchExpression {
+	return &metadata.HostSearchExpression{Logic: logic, Children: children}
+}
+
+// newTestCompiler returns a compiler whose module leaves are resolved from cache,
+// module "none" matches no host
+func newTestCompiler(t *testing.T, modules map[string][]int64) *hostExpressionCompiler {
+	c := &hostExpressionCompiler{lgc: &Logics{}, ctx: context.Background(), idCache: make(map[string][]int64)}
+	for name, ids := range modules {
+		key, err := json.Marshal(moduleLeaf(name))
+		if err != nil {
+			t.Fatalf("marshal leaf failed: %v", err)
+		}
+		c.idCache[string(key)] = ids
+	}
+	return c
+}
+
+func TestCompileHostSearchExpression(t *testing.T) {
+	modules := map[string][]int64{
+		"m1":   {1, 2, 3},
+		"m2":   {2, 3, 4},
+		"m3":   {5},
+		"none": {},
+	}
+	hostCond := func(name string) mapstr.MapStr {
+		return mapstr.MapStr{common.BKHostNameField: name}
+	}
+
+	testCases := []struct {
+		name      string
+		expr      *metadata.HostSearchExpression
+		matchNone bool
+		matchAll  bool
+		hostIDs   []int64
+		cond      mapstr.MapStr
+	}{
+		{
+			name: "host leaf",
+			expr: hostLeaf("a"),
+			cond: hostCond("a"),
+		},
+		{
+			name:    "topology leaf",
+			expr:    moduleLeaf("m1"),
+			hostIDs: []int64{1, 2, 3},
+		},
+		{
+			name:      "empty topology leaf",
+			expr:      moduleLeaf("none"),
+			matchNone: true,
+		},
+		{
+			name:    "and intersects id sets",
+			expr:    logicNode(metadata.ExpressionLogicAnd, moduleLeaf("m1"), moduleLeaf("m2")),
+			hostIDs: []int64{2, 3},
+		},
+		{
+			name:      "and of disjoint id sets",
+			expr:      logicNode(metadata.ExpressionLogicAnd, moduleLeaf("m1"), moduleLeaf("m3")),
+			matchNone: true,
+		},
+		{
+			name:      "and with match none child",
+			expr:      logicNode(metadata.ExpressionLogicAnd, hostLeaf("a"), moduleLeaf("none")),
+			matchNone: true,
+		},
+		{
+			name: "and of host leaves",
+			expr: logicNode(metadata.ExpressionLogicAnd, hostLeaf("a"), hostLeaf("b")),
+			cond: mapstr.MapStr{common.BKDBAND: []interface{}{hostCond("a"), hostCond("b")}},
+		},
+		{
+			name: "and skips match all child",
+			expr: logicNode(metadata.ExpressionLogicAnd, hostLeaf("a"), logicNode(metadata.ExpressionLogicNot, moduleLeaf("none"))),
+			cond: hostCond("a"),
+		},
+		{
+			name: "and of host leaf and id set",
+			expr: logicNode(metadata.ExpressionLogicAnd, hostLeaf("a"), moduleLeaf("m3")),
+			cond: mapstr.MapStr{common.BKDBAND: []interface{}{
+				hostCond("a"),
+				mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: []int64{5}}},
+			}},
+		},
+		{
+			name:    "or unions id sets",
+			expr:    logicNode(metadata.ExpressionLogicOr, moduleLeaf("m1"), moduleLeaf("m3"), moduleLeaf("none")),
+			hostIDs: []int64{1, 2, 3, 5},
+		},
+		{
+			name:      "or of match none children",
+			expr:      logicNode(metadata.ExpressionLogicOr, moduleLeaf("none"), moduleLeaf("none")),
+			matchNone: true,
+		},
+		{
+			name:     "or with match all child",
+			expr:     logicNode(metadata.ExpressionLogicOr, hostLeaf("a"), logicNode(metadata.ExpressionLogicNot, moduleLeaf("none"))),
+			matchAll: true,
+		},
+		{
+			name: "or of host leaves",
+			expr: logicNode(metadata.ExpressionLogicOr, hostLeaf("a"), hostLeaf("b")),
+			cond: mapstr.MapStr{common.BKDBOR: []interface{}{hostCond("a"), hostCond("b")}},
+		},
+		{
+			name:     "not of match none",
+			expr:     logicNode(metadata.ExpressionLogicNot, moduleLeaf("none")),
+			matchAll: true,
+		},
+		{
+			name:      "not of match all",
+			expr:      logicNode(metadata.ExpressionLogicNot, logicNode(metadata.ExpressionLogicNot, moduleLeaf("none"))),
+			matchNone: true,
+		},
+		{
+			name: "not of id set",
+			expr: logicNode(metadata.ExpressionLogicNot, moduleLeaf("m3")),
+			cond: mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBNIN: []int64{5}}},
+		},
+		{
+			name: "not of host leaf",
+			expr: logicNode(metadata.ExpressionLogicNot, hostLeaf("a")),
+			cond: mapstr.MapStr{common.BKDBNOR: []interface{}{hostCond("a")}},
+		},
+	}
+
+	for _, tc := range testCases {
+		c := newTestCompiler(t, modules)
+		result, err := c.compile(tc.expr)
+		if err != nil {
+			t.Errorf("%s: compile failed: %v", tc.name, err)
+			continue
+		}
+		if result.matchNone != tc.matchNone {
+			t.Errorf("%s: expected match none %v, got %v", tc.name, tc.matchNone, result.matchNone)
+			continue
+		}
+		if result.matchAll() != tc.matchAll {
+			t.Errorf("%s: expected match all %v, got %v", tc.name, tc.matchAll, result.matchAll())
+			continue
+		}
+		if tc.hostIDs != nil {
+			ids := idSetToArray(result.hostIDs)
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			if !result.isIDSet || !reflect.DeepEqual(ids, tc.hostIDs) {
+				t.Errorf("%s: expected host ids %v, got %v", tc.name, tc.hostIDs, result)
+			}
+			continue
+		}
+		if tc.cond != nil && !reflect.DeepEqual(result.toCondition(), tc.cond) {
+			t.Errorf("%s: expected condition %v, got %v", tc.name, tc.cond, result.toCondition())
+		}
+	}
+}
@@ -88,9 +88,12 @@ func (lgc *Logics) AddHost(ctx context.Context, appID int64, moduleID []int64, o
 			}
 			existInDB = true
 		} else {
-			// try to get hostID from db
-			key := generateHostCloudKey(innerIP, iSubArea)
-			intHostID, existInDB = hostIDMap[key]
+			// try to get hostID from db, any address of the host can match
+			for _, ip := range util.GetHostAddresses(host) {
+				if intHostID, existInDB = hostIDMap[generateHostCloudKey(ip, iSubArea)]; existInDB {
+					break
+				}
+			}
 		}
 		var preData mapstr.MapStr
 		// remove unchangeable fields
@@ -99,6 +102,13 @@ func (lgc *Logics) AddHost(ctx context.Context, appID int64, moduleID []int64, o
 			// remove unchangeable fields
 			delete(host, common.BKHostInnerIPField)
 
+			// locked host can not be updated by import
+			overrideLocks, lockErr := lgc.CheckHostLock(ctx, []int64{intHostID})
+			if lockErr != nil {
+				updateErrMsg = append(updateErrMsg, lockErr.Error())
+				continue
+			}
+
 			// get host info before really change it
 			preData, _, _ = lgc.GetHostInstanceDetails(ctx, ownerID, strconv.FormatInt(intHostID, 10))
 
@@ -107,6 +117,7 @@ func (lgc *Logics) AddHost(ctx context.Context, appID int64, moduleID []int64, o
 				updateErrMsg = append(updateErrMsg, err.Error())
 				continue
 			}
+			lgc.SaveHostLockOverrideAudit(ctx, overrideLocks)
 		} else {
 			intHostID, err = instance.addHostInstance(int64(common.BKDefaultDirSubArea), index, appID, moduleID, host)
 			if err != nil {
@@ -114,7 +125,9 @@ func (lgc *Logics) AddHost(ctx context.Context, appID int64, moduleID []int64, o
 				continue
 			}
 			host[common.BKHostIDField] = intHostID
-			hostIDMap[generateHostCloudKey(innerIP, iSubArea)] = intHostID
+			for _, ip := range util.GetHostAddresses(host) {
+				hostIDMap[generateHostCloudKey(ip, iSubArea)] = intHostID
+			}
 		}
 		// add current host operate result to  batch add result
 		succMsg = append(succMsg, strconv.FormatInt(index, 10))
@@ -285,20 +298,22 @@ func (h *importInstance) addHostInstance(cloudID, index, appID int64, moduleID [
 // GetHostIDByHostInfoArr get host id map at it best, ip not exist in db will be ignored.
 func (h *importInstance) GetHostIDByHostInfoArr(ctx context.Context, hostInfos map[int64]map[string]interface{}) (map[string]int64, error) {
 	// TODO why don't it just return a data structure of cloudKey: hostID map ?
-	// step1. extract all innerIP from hostInfos
+	// step1. extract all addresses from hostInfos
 	var ipArr []string
 	for _, host := range hostInfos {
-		innerIP, isOk := host[common.BKHostInnerIPField].(string)
-		if isOk && "" != innerIP {
-			ipArr = append(ipArr, innerIP)
-		}
+		ipArr = append(ipArr, util.GetHostAddresses(host)...)
 	}
 
-	// step2. query host info by innerIPs
+	// step2. query host info by addresses, the host without address lists
+	// is matched by inner ip
 	var conds map[string]interface{}
 	if 0 < len(ipArr) {
-		conds = map[string]interface{}{common.BKHostInnerIPField: common.KvMap{common.BKDBIN: ipArr}}
-
+		conds = map[string]interface{}{
+			common.BKDBOR: []map[string]interface{}{
+				{common.BKHostInnerIPField: common.KvMap{common.BKDBIN: ipArr}},
+				{common.BKHostIPKeyField: common.KvMap{common.BKDBIN: util.IPKeys(ipArr)}},
+			},
+		}
 	}
 	query := &metadata.QueryCondition{
 		Condition: conds,
@@ -320,14 +335,15 @@ func (h *importInstance) GetHostIDByHostInfoArr(ctx context.Context, hostInfos m
 	// step3. arrange data as a map, cloudKey: hostID
 	hostMap := make(map[string]int64, 0)
 	for _, host := range hResult.Data.Info {
-		key := generateHostCloudKey(host[common.BKHostInnerIPField], host[common.BKCloudIDField])
 		hostID, err := host.Int64(common.BKHostIDField)
 		if err != nil {
 			blog.Errorf("GetHostIDByHostInfoArr get hostID error. err:%s, hostInfo:%#v, rid:%s", err.Error(), host, h.rid)
 			// convert %s  field %s to %s error %s
 			return hostMap, h.ccErr.Errorf(common.CCErrCommInstFieldConvFail, common.BKInnerObjIDHost, common.BKHostIDField, "int", err.Error())
 		}
-		hostMap[key] = hostID
+		for _, ip := range util.GetHostAddresses(host) {
+			hostMap[generateHostCloudKey(ip, host[common.BKCloudIDField])] = hostID
+		}
 	}
 
 	return hostMap, nil
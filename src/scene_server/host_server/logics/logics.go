@@ -56,6 +56,7 @@ func (lgc *Logics) NewFromHeader(header http.Header) *Logics {
 		cache:   lgc.cache,
 		user:    util.GetUser(header),
 		ownerID: util.GetOwnerID(header),
+		AuthManager: lgc.AuthManager,
 	}
 	// if language not exist, use old language
 	if lang == "" {
@@ -0,0 +1,161 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// GetResourceDirectory get the resource directory by id
+func (lgc *Logics) GetResourceDirectory(ctx context.Context, dirID int64) (*metadata.ResourceDirectory, errors.CCError) {
+	input := &metadata.QueryInput{Condition: map[string]interface{}{common.BKResourceDirIDField: dirID}, Limit: 1}
+	result, err := lgc.SearchResourceDirectory(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if 0 == len(result.Info) {
+		blog.Errorf("get resource directory %d, but not found, rid: %s", dirID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommNotFound)
+	}
+	return &result.Info[0], nil
+}
+
+// SearchResourceDirectory search the resource directories
+func (lgc *Logics) SearchResourceDirectory(ctx context.Context, input *metadata.QueryInput) (*metadata.SearchResourceDirectory, errors.CCError) {
+	result, err := lgc.CoreAPI.HostController().Host().SearchResourceDirectory(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("search resource directory http do error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search resource directory http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return &result.Data, nil
+}
+
+// GetResourceDirectorySubIDs get the id of the directory and all its sub directories
+func (lgc *Logics) GetResourceDirectorySubIDs(ctx context.Context, dirID int64) ([]int64, errors.CCError) {
+	result, err := lgc.SearchResourceDirectory(ctx, &metadata.QueryInput{})
+	if err != nil {
+		return nil, err
+	}
+	return metadata.SubDirectoryIDs(result.Info, dirID), nil
+}
+
+// GetResourceDirectoryHostIDs get the hosts directly in the directory, the hosts in sub directories are excluded
+func (lgc *Logics) GetResourceDirectoryHostIDs(ctx context.Context, dirID int64) ([]int64, errors.CCError) {
+	hosts, err := lgc.GetHostInfoByConds(ctx, map[string]interface{}{common.BKResourceDirIDField: dirID})
+	if err != nil {
+		return nil, err
+	}
+
+	hostIDs := make([]int64, 0)
+	for _, host := range hosts {
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("get resource directory hosts, but got invalid host id, host: %v, rid: %s", host, lgc.rid)
+			return nil, lgc.ccErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKHostIDField)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+	return hostIDs, nil
+}
+
+// GetHostResourceDirectoryIDs get the directories the hosts are in, 0 means not in any directory
+func (lgc *Logics) GetHostResourceDirectoryIDs(ctx context.Context, hostIDs []int64) ([]int64, errors.CCError) {
+	cond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+	hosts, err := lgc.GetHostInfoByConds(ctx, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	dirIDs := make([]int64, 0)
+	for _, host := range hosts {
+		dirID, _ := util.GetInt64ByInterface(host[common.BKResourceDirIDField])
+		dirIDs = append(dirIDs, dirID)
+	}
+	return util.IntArrayUnique(dirIDs), nil
+}
+
+// MoveHostToResourceDirectory move the hosts to the directory, all the hosts must be in the resource pool
+func (lgc *Logics) MoveHostToResourceDirectory(ctx context.Context, input *metadata.MoveHostToResourceDirectory) errors.CCError {
+	if 0 == len(input.HostIDs) {
+		return nil
+	}
+
+	defaultAppID, err := lgc.GetDefaultAppID(ctx)
+	if err != nil {
+		blog.Errorf("move host to resource directory, get default app failed, err: %v, rid: %s", err, lgc.rid)
+		return err
+	}
+	poolHostIDs, err := lgc.GetHostIDByCond(ctx, metadata.HostModuleRelationRequest{ApplicationID: defaultAppID, HostIDArr: input.HostIDs})
+	if err != nil {
+		return err
+	}
+	notInPool := make([]int64, 0)
+	for _, hostID := range input.HostIDs {
+		if !util.InArray(hostID, poolHostIDs) {
+			notInPool = append(notInPool, hostID)
+		}
+	}
+	if 0 != len(notInPool) {
+		blog.Errorf("move host to resource directory, hosts %v are not in resource pool, rid: %s", notInPool, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrHostNotInResourceDir, util.Int64Join(notInPool, ","))
+	}
+
+	return lgc.setHostResourceDirectory(ctx, input.DirID, input.HostIDs)
+}
+
+// MoveResourcePoolHostToDirectory move the hosts which are in the resource pool to the directory,
+// the others are ignored. it's used on import, the existing hosts may belong to a business.
+func (lgc *Logics) MoveResourcePoolHostToDirectory(ctx context.Context, dirID int64, hostIDs []int64) errors.CCError {
+	if 0 == len(hostIDs) {
+		return nil
+	}
+
+	defaultAppID, err := lgc.GetDefaultAppID(ctx)
+	if err != nil {
+		blog.Errorf("move resource pool host to directory, get default app failed, err: %v, rid: %s", err, lgc.rid)
+		return err
+	}
+	poolHostIDs, err := lgc.GetHostIDByCond(ctx, metadata.HostModuleRelationRequest{ApplicationID: defaultAppID, HostIDArr: hostIDs})
+	if err != nil {
+		return err
+	}
+	if 0 == len(poolHostIDs) {
+		return nil
+	}
+
+	return lgc.setHostResourceDirectory(ctx, dirID, util.IntArrayUnique(poolHostIDs))
+}
+
+func (lgc *Logics) setHostResourceDirectory(ctx context.Context, dirID int64, hostIDs []int64) errors.CCError {
+	input := &metadata.MoveHostToResourceDirectory{DirID: dirID, HostIDs: hostIDs}
+	result, err := lgc.CoreAPI.HostController().Host().MoveHostToResourceDirectory(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("move host to resource directory http do error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("move host to resource directory http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// hostTransferPlanLockExpire the expire time of the execute lock of a plan, the lock is
+// renewed while the plan is executing, the plan still executing after the lock expired
+// is failed, the host server executing it may be stopped.
+const hostTransferPlanLockExpire = 2 * time.Minute
+
+// hostTransferPlanLockRenew the interval to renew the execute lock of the executing plan
+const hostTransferPlanLockRenew = 30 * time.Second
+
+// hostTransferPlanFinishRetry the times to retry saving the result of the plan
+const hostTransferPlanFinishRetry = 3
+
+// GetHostTransferPlan get the host transfer plan by id
+func (lgc *Logics) GetHostTransferPlan(ctx context.Context, planID int64) (*metadata.HostTransferPlan, errors.CCError) {
+	input := &metadata.QueryInput{Condition: map[string]interface{}{"bk_plan_id": planID}, Limit: 1}
+	result, err := lgc.SearchHostTransferPlan(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if 0 == len(result.Info) {
+		blog.Errorf("get host transfer plan %d, but not found, rid: %s", planID, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommNotFound)
+	}
+	return &result.Info[0], nil
+}
+
+// SearchHostTransferPlan search the host transfer plans
+func (lgc *Logics) SearchHostTransferPlan(ctx context.Context, input *metadata.QueryInput) (*metadata.SearchHostTransferPlan, errors.CCError) {
+	result, err := lgc.CoreAPI.HostController().Host().SearchHostTransferPlan(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("search host transfer plan http do error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search host transfer plan http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return &result.Data, nil
+}
+
+// ValidateHostTransferPlan check the hosts belong to the source business
+// and the target modules belong to the target business
+func (lgc *Logics) ValidateHostTransferPlan(ctx context.Context, plan *metadata.HostTransferPlan) errors.CCError {
+	if 0 >= plan.SrcAppID || 0 >= plan.DstAppID {
+		return lgc.ccErr.Errorf(common.CCErrCommParamsNeedSet, common.BKAppIDField)
+	}
+	if 0 == len(plan.HostIDs) {
+		return lgc.ccErr.Errorf(common.CCErrCommParamsNeedSet, common.BKHostIDField)
+	}
+	if 0 == len(plan.ModuleIDs) {
+		return lgc.ccErr.Errorf(common.CCErrCommParamsNeedSet, common.BKModuleIDField)
+	}
+
+	for _, moduleID := range plan.ModuleIDs {
+		module, err := lgc.GetNormalModuleByModuleID(ctx, plan.DstAppID, moduleID)
+		if err != nil {
+			blog.Errorf("validate host transfer plan, get module %d failed, err: %v, rid: %s", moduleID, err, lgc.rid)
+			return err
+		}
+		if 0 == len(module) {
+			blog.Errorf("validate host transfer plan, module %d not in business %d, rid: %s", moduleID, plan.DstAppID, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrTopoMulueIDNotfoundFailed)
+		}
+	}
+
+	for _, hostID := range plan.HostIDs {
+		exist, err := lgc.IsHostExistInApp(ctx, plan.SrcAppID, hostID)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			blog.Errorf("validate host transfer plan, host %d not in business %d, rid: %s", hostID, plan.SrcAppID, lgc.rid)
+			return lgc.ccErr.Errorf(common.CCErrHostNotINAPPFail, hostID)
+		}
+	}
+	return nil
+}
+
+// UpdateHostTransferPlanStatus move the plan to the status with the data,
+// the transition must be allowed by the state machine of the plan.
+func (lgc *Logics) UpdateHostTransferPlanStatus(ctx context.Context, plan *metadata.HostTransferPlan, status string, data mapstr.MapStr) (*metadata.HostTransferPlan, errors.CCError) {
+	if !plan.CanTransferTo(status) {
+		blog.Errorf("update host transfer plan %d, can not change status from %s to %s, rid: %s", plan.PlanID, plan.Status, status, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrHostTransferPlanStatusInvalid, plan.Status, status)
+	}
+
+	if nil == data {
+		data = mapstr.New()
+	}
+	data["status"] = status
+	return lgc.UpdateHostTransferPlan(ctx, plan.PlanID, []string{plan.Status}, data)
+}
+
+// UpdateHostTransferPlan update the plan which is in one of the status
+func (lgc *Logics) UpdateHostTransferPlan(ctx context.Context, planID int64, status []string, data mapstr.MapStr) (*metadata.HostTransferPlan, errors.CCError) {
+	input := &metadata.UpdateHostTransferPlan{Status: status, Data: data}
+	result, err := lgc.CoreAPI.HostController().Host().UpdateHostTransferPlan(ctx, planID, lgc.header, input)
+	if err != nil {
+		blog.Errorf("update host transfer plan %d http do error, err: %v, input: %+v, rid: %s", planID, err, input, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("update host transfer plan %d http response error, err code: %d, err msg: %s, input: %+v, rid: %s", planID, result.Code, result.ErrMsg, input, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return &result.Data, nil
+}
+
+// ExecuteHostTransferPlan transfer the hosts of the approved plan one by one,
+// the result of each host is recorded, the plan is done when all the hosts
+// are transferred, otherwise it's failed.
+func (lgc *Logics) ExecuteHostTransferPlan(ctx context.Context, plan *metadata.HostTransferPlan) (*metadata.HostTransferPlan, errors.CCError) {
+	plan, err := lgc.UpdateHostTransferPlanStatus(ctx, plan, metadata.HostTransferPlanStatusExecuting,
+		mapstr.MapStr{"start_time": time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+
+	status := metadata.HostTransferPlanStatusDone
+	results := make([]metadata.HostTransferResult, 0, len(plan.HostIDs))
+	for _, hostID := range plan.HostIDs {
+		var err errors.CCError
+		if plan.IsCrossBusiness() {
+			var overrideLocks []metadata.HostLockData
+			if overrideLocks, err = lgc.CheckHostLock(ctx, []int64{hostID}); nil == err {
+				if err = lgc.TransferHostAcrossBusiness(ctx, plan.SrcAppID, plan.DstAppID, hostID, plan.ModuleIDs); nil == err {
+					lgc.SaveHostLockOverrideAudit(ctx, overrideLocks)
+				}
+			}
+		} else {
+			err = lgc.transferHostInBusiness(ctx, plan.SrcAppID, hostID, plan.ModuleIDs, plan.IsIncrement)
+		}
+
+		result := metadata.HostTransferResult{HostID: hostID, Success: nil == err}
+		if nil != err {
+			blog.Errorf("execute host transfer plan %d, transfer host %d failed, err: %v, rid: %s", plan.PlanID, hostID, err, lgc.rid)
+			result.Message = err.Error()
+			status = metadata.HostTransferPlanStatusFailed
+		}
+		results = append(results, result)
+	}
+
+	data := mapstr.MapStr{"results": results, "finish_time": time.Now().UTC()}
+	return lgc.finishHostTransferPlan(ctx, plan, status, data)
+}
+
+// finishHostTransferPlan save the result of the executing plan, it's retried as
+// the plan stays executing until it's recovered when the result is not saved.
+func (lgc *Logics) finishHostTransferPlan(ctx context.Context, plan *metadata.HostTransferPlan, status string, data mapstr.MapStr) (*metadata.HostTransferPlan, errors.CCError) {
+	var err errors.CCError
+	for retry := 0; retry < hostTransferPlanFinishRetry; retry++ {
+		var finished *metadata.HostTransferPlan
+		finished, err = lgc.UpdateHostTransferPlanStatus(ctx, plan, status, data)
+		if nil == err {
+			return finished, nil
+		}
+		blog.Errorf("finish host transfer plan %d failed, retry: %d, err: %v, rid: %s", plan.PlanID, retry, err, lgc.rid)
+		time.Sleep(time.Duration(retry+1) * time.Second)
+	}
+	return nil, err
+}
+
+// transferHostInBusiness transfer the host to the modules of the same business
+func (lgc *Logics) transferHostInBusiness(ctx context.Context, bizID, hostID int64, moduleIDs []int64, isIncrement bool) errors.CCError {
+	audit := lgc.NewHostModuleLog([]int64{hostID})
+	if err := audit.WithPrevious(ctx); err != nil {
+		blog.Errorf("transfer host %d in business %d, get prev module host config failed, err: %v, rid: %s", hostID, bizID, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+	// auth: check host authorization
+	if err := lgc.AuthManager.AuthorizeByHostsIDs(ctx, lgc.header, meta.MoveHostsToBusinessOrModule, hostID); err != nil {
+		blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", hostID, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommAuthorizeFailed)
+	}
+	overrideLocks, err := lgc.CheckHostLock(ctx, []int64{hostID})
+	if err != nil {
+		return err
+	}
+	// auth: deregister
+	if err := lgc.AuthManager.DeregisterHostsByID(ctx, lgc.header, hostID); err != nil {
+		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v, rid: %s", hostID, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+	}
+
+	conf := &metadata.HostsModuleRelation{ApplicationID: bizID, HostID: []int64{hostID}, ModuleID: moduleIDs, IsIncrement: isIncrement}
+	result, err := lgc.CoreAPI.CoreService().Host().TransferHostModule(ctx, lgc.header, conf)
+	if err != nil {
+		blog.Errorf("transfer host in business http do error, err: %v, input: %+v, rid: %s", err, conf, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("transfer host in business http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, conf, lgc.rid)
+		return lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+
+	if err := audit.SaveAudit(ctx, bizID, lgc.user, "host transfer plan"); err != nil {
+		blog.Errorf("transfer host %d in business %d, save audit log failed, err: %v, rid: %s", hostID, bizID, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommResourceInitFailed, "audit server")
+	}
+	// auth: register host
+	if err := lgc.AuthManager.RegisterHostsByID(ctx, lgc.header, hostID); err != nil {
+		blog.Errorf("register host to iam failed, hosts: %+v, err: %v, rid: %s", hostID, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)
+	}
+	lgc.SaveHostLockOverrideAudit(ctx, overrideLocks)
+	return nil
+}
+
+// TimerExecuteHostTransferPlan execute the approved host transfer plans when they are due
+func (lgc *Logics) TimerExecuteHostTransferPlan(ctx context.Context) {
+	go func() {
+		timer := time.NewTicker(1 * time.Minute)
+		for range timer.C {
+			lgc.executeDueHostTransferPlans(ctx)
+		}
+	}()
+}
+
+func (lgc *Logics) executeDueHostTransferPlans(ctx context.Context) {
+	lgc.recoverStaleHostTransferPlans(ctx)
+
+	input := &metadata.QueryInput{
+		Condition: map[string]interface{}{"status": metadata.HostTransferPlanStatusApproved},
+		Sort:      "execute_time",
+	}
+	result, err := lgc.SearchHostTransferPlan(ctx, input)
+	if err != nil {
+		blog.Errorf("execute host transfer plans, search approved plans failed, err: %v, rid: %s", err, lgc.rid)
+		return
+	}
+
+	now := time.Now()
+	for index := range result.Info {
+		plan := result.Info[index]
+		if !plan.IsDue(now) {
+			continue
+		}
+
+		key := common.RedisHostTransferPlanLockPrefix + strconv.FormatInt(plan.PlanID, 10)
+		locked, err := lgc.cache.SetNX(key, "", hostTransferPlanLockExpire).Result()
+		if err != nil {
+			blog.Errorf("execute host transfer plan %d, lock failed, err: %v, rid: %s", plan.PlanID, err, lgc.rid)
+			continue
+		}
+		if !locked {
+			continue
+		}
+
+		// the hosts are transferred as the creator of the plan, so the
+		// audit logs and authorization are the same as transfer by hand
+		header := make(http.Header)
+		header.Set(common.BKHTTPOwnerID, plan.OwnerID)
+		header.Set(common.BKHTTPHeaderUser, plan.Creator)
+		stopRenew := lgc.renewHostTransferPlanLock(key)
+		executed, execErr := lgc.NewFromHeader(header).ExecuteHostTransferPlan(ctx, &plan)
+		close(stopRenew)
+		if execErr != nil {
+			blog.Errorf("execute host transfer plan %d failed, err: %v, rid: %s", plan.PlanID, execErr, lgc.rid)
+			continue
+		}
+		blog.Infof("execute host transfer plan %d finished, status: %s, rid: %s", plan.PlanID, executed.Status, lgc.rid)
+	}
+}
+
+// renewHostTransferPlanLock keep the execute lock of the plan until the returned channel is closed
+func (lgc *Logics) renewHostTransferPlanLock(key string) chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(hostTransferPlanLockRenew)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := lgc.cache.Expire(key, hostTransferPlanLockExpire).Err(); err != nil {
+					blog.Errorf("renew host transfer plan lock %s failed, err: %v, rid: %s", key, err, lgc.rid)
+				}
+			}
+		}
+	}()
+	return stop
+}
+
+// recoverStaleHostTransferPlans fail the plans which are still executing after the
+// execute lock expired, the host server executing them is stopped or the result is
+// failed to save, the hosts should be checked by hand.
+func (lgc *Logics) recoverStaleHostTransferPlans(ctx context.Context) {
+	input := &metadata.QueryInput{Condition: map[string]interface{}{"status": metadata.HostTransferPlanStatusExecuting}}
+	result, err := lgc.SearchHostTransferPlan(ctx, input)
+	if err != nil {
+		blog.Errorf("recover host transfer plans, search executing plans failed, err: %v, rid: %s", err, lgc.rid)
+		return
+	}
+
+	now := time.Now()
+	for index := range result.Info {
+		plan := result.Info[index]
+		key := common.RedisHostTransferPlanLockPrefix + strconv.FormatInt(plan.PlanID, 10)
+		exists, err := lgc.cache.Exists(key).Result()
+		if err != nil {
+			blog.Errorf("recover host transfer plan %d, check execute lock failed, err: %v, rid: %s", plan.PlanID, err, lgc.rid)
+			continue
+		}
+		if !isStaleHostTransferPlan(&plan, exists) {
+			continue
+		}
+
+		results := plan.Results
+		if 0 == len(results) {
+			results = make([]metadata.HostTransferResult, 0, len(plan.HostIDs))
+			for _, hostID := range plan.HostIDs {
+				results = append(results, metadata.HostTransferResult{HostID: hostID, Message: "the execution is interrupted"})
+			}
+		}
+		data := mapstr.MapStr{"results": results, "finish_time": now.UTC()}
+		if _, err := lgc.UpdateHostTransferPlanStatus(ctx, &plan, metadata.HostTransferPlanStatusFailed, data); err != nil {
+			blog.Errorf("recover host transfer plan %d failed, err: %v, rid: %s", plan.PlanID, err, lgc.rid)
+			continue
+		}
+		blog.Warnf("host transfer plan %d is executing since %v, set it failed, rid: %s", plan.PlanID, plan.StartTime, lgc.rid)
+	}
+}
+
+// isStaleHostTransferPlan check whether the plan is executing but the execute lock is expired
+func isStaleHostTransferPlan(plan *metadata.HostTransferPlan, locked bool) bool {
+	return metadata.HostTransferPlanStatusExecuting == plan.Status && !locked
+}
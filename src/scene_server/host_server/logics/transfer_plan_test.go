@@ -0,0 +1,41 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestIsStaleHostTransferPlan(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		locked bool
+		want   bool
+	}{
+		{"executing with lock", metadata.HostTransferPlanStatusExecuting, true, false},
+		{"executing lock expired", metadata.HostTransferPlanStatusExecuting, false, true},
+		{"done", metadata.HostTransferPlanStatusDone, false, false},
+		{"approved", metadata.HostTransferPlanStatusApproved, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &metadata.HostTransferPlan{Status: tt.status}
+			if got := isStaleHostTransferPlan(plan, tt.locked); got != tt.want {
+				t.Errorf("isStaleHostTransferPlan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
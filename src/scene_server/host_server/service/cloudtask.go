@@ -17,6 +17,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/emicklei/go-restful"
 
@@ -24,6 +25,8 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
+	"configcenter/src/scene_server/host_server/cloudprovider"
+	"configcenter/src/scene_server/host_server/logics"
 )
 
 // CloudAddTask create cloud sync task
@@ -39,6 +42,19 @@ func (s *Service) AddCloudTask(req *restful.Request, resp *restful.Response) {
 
 	taskList.User = srvData.user
 
+	if err := logics.ValidateCloudSyncPolicy(taskList.ConflictPolicy, taskList.VanishedPolicy); err != nil {
+		blog.Errorf("add task failed, invalid sync policy, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCloudSyncPolicyInvalid, err.Error())})
+		return
+	}
+
+	cred := cloudprovider.Credential{SecretID: taskList.SecretID, SecretKey: taskList.SecretKey, Endpoint: taskList.Endpoint}
+	if err := srvData.lgc.ValidateCloudAccount(srvData.ctx, taskList.AccountType, cred); err != nil {
+		blog.Errorf("add task failed, validate cloud account failed, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
 	if err := srvData.lgc.AddCloudTask(srvData.ctx, taskList); err != nil {
 		blog.Errorf("add task failed with err: %v, rid: %s", err.Error(), srvData.rid)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudSyncCreateFail)})
@@ -91,6 +107,20 @@ func (s *Service) UpdateCloudTask(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	if err := validateCloudTaskPolicy(data); err != nil {
+		blog.Errorf("update task failed, invalid sync policy, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCloudSyncPolicyInvalid, err.Error())})
+		return
+	}
+
+	if data.Exists(common.BKCloudEndpointField) {
+		if err := validateCloudTaskEndpoint(srvData, data); err != nil {
+			blog.Errorf("update task failed, invalid endpoint, err: %v, rid: %s", err, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
+		}
+	}
+
 	// TaskName Uniqueness check
 	response, err := s.CoreAPI.HostController().Cloud().TaskNameCheck(srvData.ctx, srvData.header, data)
 	if err != nil {
@@ -224,7 +254,7 @@ func (s *Service) ResourceConfirm(req *restful.Request, resp *restful.Response)
 			continue
 		}
 		if addConfirm {
-			AddHostList = append(AddHostList, hostInfo)
+			AddHostList = append(AddHostList, logics.CloudHostFromConfirm(hostInfo))
 		}
 
 		attrConfirm, ok := hostInfo["bk_attr_confirm"].(bool)
@@ -233,7 +263,18 @@ func (s *Service) ResourceConfirm(req *restful.Request, resp *restful.Response)
 			continue
 		}
 		if attrConfirm {
-			updateHostList = append(updateHostList, hostInfo)
+			updateHostList = append(updateHostList, logics.CloudHostFromConfirm(hostInfo))
+		}
+	}
+
+	// the update is refused when the hosts are locked, then nothing is confirmed,
+	// so that the confirmations are kept until the hosts are unlocked
+	if len(updateHostList) > 0 {
+		err := srvData.lgc.UpdateCloudHosts(srvData.ctx, updateHostList)
+		if err != nil {
+			blog.Errorf("update cloud hosts failed, err: %v, rid: %s", err, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
 		}
 	}
 
@@ -246,14 +287,6 @@ func (s *Service) ResourceConfirm(req *restful.Request, resp *restful.Response)
 		}
 	}
 
-	if len(updateHostList) > 0 {
-		err := srvData.lgc.UpdateCloudHosts(srvData.ctx, updateHostList)
-		if err != nil {
-			blog.Errorf("update cloud hosts failed, err: %v, rid: %s", err, srvData.rid)
-			srvData.ccErr.Error(1110003)
-		}
-	}
-
 	// After resource confirmation, delete the items from table cc_CloudResourceSync
 	for _, id := range resourceIDs {
 		_, errD := srvData.lgc.CoreAPI.HostController().Cloud().DeleteConfirm(srvData.ctx, srvData.header, id)
@@ -391,3 +424,62 @@ func (s *Service) SearchConfirmHistory(req *restful.Request, resp *restful.Respo
 
 	resp.WriteEntity(meta.NewSuccessResp(response))
 }
+
+// PreviewCloudSync return the changes the cloud task will make, without changing anything
+func (s *Service) PreviewCloudSync(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	taskID, err := strconv.ParseInt(req.PathParameter("taskID"), 10, 64)
+	if err != nil {
+		blog.Errorf("preview cloud sync failed, invalid task id %s, rid: %s", req.PathParameter("taskID"), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedInt, common.BKCloudTaskID)})
+		return
+	}
+
+	preview, err := srvData.lgc.PreviewCloudSync(srvData.ctx, taskID)
+	if err != nil {
+		blog.Errorf("preview cloud sync task %d failed, err: %v, rid: %s", taskID, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.CloudSyncPreviewResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     *preview,
+	})
+}
+
+// validateCloudTaskPolicy check the sync policy in the update data of cloud task
+func validateCloudTaskPolicy(data mapstr.MapStr) error {
+	conflictPolicy := make(map[string]string)
+	if data.Exists(common.BKCloudConflictPolicyField) {
+		policy, err := data.MapStr(common.BKCloudConflictPolicyField)
+		if err != nil {
+			return err
+		}
+		for field, value := range policy {
+			conflictPolicy[field], _ = value.(string)
+		}
+	}
+	vanishedPolicy, _ := data.String(common.BKCloudVanishedPolicyField)
+	return logics.ValidateCloudSyncPolicy(conflictPolicy, vanishedPolicy)
+}
+
+// validateCloudTaskEndpoint check the endpoint in the update data of cloud task, the account
+// type of the saved task is used when the update data doesn't change it.
+func validateCloudTaskEndpoint(srvData *srvComm, data mapstr.MapStr) error {
+	endpoint, _ := data.String(common.BKCloudEndpointField)
+	accountType, _ := data.String(common.BKCloudAccountType)
+	if !data.Exists(common.BKCloudAccountType) {
+		taskID, err := data.Int64(common.BKCloudTaskID)
+		if err != nil {
+			return srvData.ccErr.Errorf(common.CCErrCommParamsNeedInt, common.BKCloudTaskID)
+		}
+		taskInfo, err := srvData.lgc.GetCloudTask(srvData.ctx, taskID)
+		if err != nil {
+			return err
+		}
+		accountType = taskInfo.AccountType
+	}
+	return srvData.lgc.ValidateCloudEndpoint(accountType, endpoint)
+}
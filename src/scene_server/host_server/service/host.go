@@ -79,6 +79,13 @@ func (s *Service) DeleteHostBatch(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, iHostIDArr)
+	if lockErr != nil {
+		blog.Errorf("delete host batch, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, opt, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
+
 	condition := hutil.NewOperation().WithDefaultField(int64(common.DefaultAppFlag)).WithOwnerID(srvData.ownerID).MapStr()
 	query := meta.QueryCondition{Condition: condition}
 	query.Limit.Limit = 1
@@ -171,6 +178,7 @@ func (s *Service) DeleteHostBatch(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 }
 
@@ -305,6 +313,21 @@ func (s *Service) AddHost(req *restful.Request, resp *restful.Response) {
 		}
 	}
 
+	// auth: the hosts are put into the resource directory on import
+	if 0 != hostList.DirID {
+		if err := s.AuthManager.AuthorizeByResourceDirectoryIDs(srvData.ctx, srvData.header, authmeta.Update, hostList.DirID); err != nil {
+			blog.Errorf("add host, but authorize on resource directory %d failed, err: %v, rid: %s", hostList.DirID, err, srvData.rid)
+			resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+			return
+		}
+		// the directory is checked before import, the hosts can't be rolled back after imported
+		if _, err := srvData.lgc.GetResourceDirectory(srvData.ctx, hostList.DirID); err != nil {
+			blog.Errorf("add host, but get resource directory %d failed, err: %v, rid: %s", hostList.DirID, err, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
+		}
+	}
+
 	cond := hutil.NewOperation().WithModuleName(common.DefaultResModuleName).WithAppID(appID).MapStr()
 	cond.Set(common.BKDefaultField, common.DefaultResModuleFlag)
 	moduleID, err := srvData.lgc.GetResoulePoolModuleID(srvData.ctx, cond)
@@ -335,6 +358,16 @@ func (s *Service) AddHost(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	if 0 != hostList.DirID {
+		// the hosts are imported, they are kept in the resource pool when failed to move
+		if err := srvData.lgc.MoveResourcePoolHostToDirectory(srvData.ctx, hostList.DirID, hostIDs); err != nil {
+			blog.Errorf("add host, but move hosts %v to resource directory %d failed, err: %v, rid: %s", hostIDs, hostList.DirID, err, srvData.rid)
+			retData["dir_error"] = err.Error()
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err, Data: retData})
+			return
+		}
+	}
+
 	resp.WriteEntity(meta.NewSuccessResp(retData))
 }
 
@@ -424,6 +457,14 @@ func (s *Service) SearchHost(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	if body.Expression != nil {
+		if err := body.Expression.Validate(); err != nil {
+			blog.Errorf("search host failed, invalid expression, err: %v,input:%+v,rid:%s", err, body, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrHostSearchExpressionInvalid, err.Error())})
+			return
+		}
+	}
+
 	host, err := srvData.lgc.SearchHost(srvData.ctx, body, false)
 	if err != nil {
 		blog.Errorf("search host failed, err: %v,input:%+v,rid:%s", err, body, srvData.rid)
@@ -524,6 +565,13 @@ func (s *Service) UpdateHostBatch(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, hostIDArr)
+	if lockErr != nil {
+		blog.Errorf("update host batch, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, data, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
+
 	logPreConents := make(map[int64]meta.SaveAuditLogParams, 0)
 	hostIDs := make([]int64, 0)
 	for _, id := range strings.Split(hostIDStr, ",") {
@@ -632,6 +680,7 @@ func (s *Service) UpdateHostBatch(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 }
 
@@ -831,6 +880,12 @@ func (s *Service) MoveSetHost2IdleModule(req *restful.Request, resp *restful.Res
 	// 	resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 	// 	return
 	// }
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, hostIDArr)
+	if lockErr != nil {
+		blog.Errorf("MoveSetHost2IdleModule check host lock failed, err: %v, input:%+v, rid:%s", lockErr, data, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
 	// step3. deregister host from iam
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, hostIDArr...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v", hostIDArr, err)
@@ -930,6 +985,7 @@ func (s *Service) MoveSetHost2IdleModule(req *restful.Request, resp *restful.Res
 		return
 	}
 
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 	return
 }
@@ -1009,6 +1065,12 @@ func (s *Service) CloneHostProperty(req *restful.Request, resp *restful.Response
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, []int64{dstHostID})
+	if lockErr != nil {
+		blog.Errorf("CloneHostProperty check host lock failed, err: %v, input:%#v, rid:%s", lockErr, input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
 
 	res, err := srvData.lgc.CloneHostProperty(srvData.ctx, input, input.AppID, input.CloudID)
 	if nil != err {
@@ -1016,6 +1078,7 @@ func (s *Service) CloneHostProperty(req *restful.Request, resp *restful.Response
 		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
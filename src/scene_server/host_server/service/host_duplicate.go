@@ -0,0 +1,193 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	authmeta "configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// DetectHostDuplicates detect the duplicate hosts of the supplier account right now
+func (s *Service) DetectHostDuplicates(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	conf := s.Config.Duplicate.Get()
+	detected, err := srvData.lgc.DetectHostDuplicates(srvData.ctx, &conf)
+	if err != nil {
+		blog.Errorf("detect host duplicates failed, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	duplicates := detected[srvData.ownerID]
+	if nil == duplicates {
+		duplicates = make([]metadata.HostDuplicate, 0)
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(duplicates))
+}
+
+// SearchHostDuplicates search the duplicate candidates, the highest score first by default
+func (s *Service) SearchHostDuplicates(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(metadata.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("search host duplicates failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := srvData.lgc.SearchHostDuplicates(srvData.ctx, input)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// IgnoreHostDuplicate ignore the duplicate candidate, the same hosts are not reported again
+func (s *Service) IgnoreHostDuplicate(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	duplicateID, err := strconv.ParseInt(req.PathParameter("bk_duplicate_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("ignore host duplicate, invalid id %s, err: %v, rid: %s", req.PathParameter("bk_duplicate_id"), err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "bk_duplicate_id")})
+		return
+	}
+
+	duplicate, ccErr := srvData.lgc.GetHostDuplicate(srvData.ctx, duplicateID)
+	if ccErr != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: ccErr})
+		return
+	}
+	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Update, duplicate.HostIDs...); err != nil {
+		blog.Errorf("ignore host duplicate, authorize failed, hosts: %v, err: %v, rid: %s", duplicate.HostIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	if err := srvData.lgc.UpdateHostDuplicateStatus(srvData.ctx, duplicateID, metadata.HostDuplicateStatusIgnored); err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// MergeHostDuplicate merge the duplicate hosts into one, it needs to edit the survivor
+// and delete the others.
+func (s *Service) MergeHostDuplicate(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(metadata.MergeHostDuplicate)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("merge host duplicate failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 != input.DuplicateID && 0 == len(input.HostIDs) {
+		duplicate, err := srvData.lgc.GetHostDuplicate(srvData.ctx, input.DuplicateID)
+		if err != nil {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+			return
+		}
+		input.HostIDs = duplicate.HostIDs
+	}
+	hostIDs := util.IntArrayUnique(input.HostIDs)
+	if len(hostIDs) < 2 {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "bk_host_ids")})
+		return
+	}
+
+	// the survivor is picked by the logics when it's not given, so all the hosts
+	// are checked for both permissions.
+	deleteIDs := hostIDs
+	updateIDs := hostIDs
+	if 0 != input.SurvivorID {
+		updateIDs = []int64{input.SurvivorID}
+		deleteIDs = make([]int64, 0)
+		for _, hostID := range hostIDs {
+			if hostID != input.SurvivorID {
+				deleteIDs = append(deleteIDs, hostID)
+			}
+		}
+	}
+	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Update, updateIDs...); err != nil {
+		blog.Errorf("merge host duplicate, authorize update failed, hosts: %v, err: %v, rid: %s", updateIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Delete, deleteIDs...); err != nil {
+		blog.Errorf("merge host duplicate, authorize delete failed, hosts: %v, err: %v, rid: %s", deleteIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	// auth: deregister the hosts, the survivor is registered again after its business may change
+	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, hostIDs...); err != nil {
+		blog.Errorf("merge host duplicate, deregister hosts from iam failed, hosts: %v, err: %v, rid: %s", hostIDs, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommUnRegistResourceToIAMFailed)})
+		return
+	}
+
+	result, err := srvData.lgc.MergeHostDuplicate(srvData.ctx, input)
+	if err != nil {
+		blog.Errorf("merge host duplicate failed, err: %v, input: %+v, rid: %s", err, input, srvData.rid)
+		// the hosts still exist are registered back, the merged ones may be deleted already
+		s.registerExistHosts(srvData, hostIDs)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	if err := s.AuthManager.RegisterHostsByID(srvData.ctx, srvData.header, result.SurvivorID); err != nil {
+		blog.Errorf("merge host duplicate, register host to iam failed, host: %d, err: %v, rid: %s", result.SurvivorID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// registerExistHosts register the hosts which still exist to iam, the error is only logged
+func (s *Service) registerExistHosts(srvData *srvComm, hostIDs []int64) {
+	cond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+	hosts, err := srvData.lgc.GetHostInfoByConds(srvData.ctx, cond)
+	if err != nil {
+		blog.Errorf("register exist hosts to iam, get hosts failed, hosts: %v, err: %v, rid: %s", hostIDs, err, srvData.rid)
+		return
+	}
+	existIDs := make([]int64, 0)
+	for _, host := range hosts {
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("register exist hosts to iam, get host id failed, host: %v, err: %v, rid: %s", host, err, srvData.rid)
+			continue
+		}
+		existIDs = append(existIDs, hostID)
+	}
+	if 0 == len(existIDs) {
+		return
+	}
+	if err := s.AuthManager.RegisterHostsByID(srvData.ctx, srvData.header, existIDs...); err != nil {
+		blog.Errorf("register exist hosts to iam failed, hosts: %v, err: %v, rid: %s", existIDs, err, srvData.rid)
+	}
+}
@@ -15,6 +15,7 @@ package service
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/emicklei/go-restful"
 
@@ -39,6 +40,11 @@ func (s *Service) LockHost(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "ip_list")})
 		return
 	}
+	if nil != input.ExpireTime && !input.ExpireTime.After(time.Now()) {
+		blog.Errorf("lock host, expire_time is not a future time, input:%+v, rid:%s", input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrHostLockExpireTimeInvalid)})
+		return
+	}
 
 	// check authorization
 	hostIDArr := make([]int64, 0)
@@ -156,3 +162,50 @@ func (s *Service) QueryHostLock(req *restful.Request, resp *restful.Response) {
 		Data:     hostLockInfos,
 	})
 }
+
+func (s *Service) QueryHostLockDetail(req *restful.Request, resp *restful.Response) {
+
+	srvData := s.newSrvComm(req.Request.Header)
+	input := &metadata.QueryHostLockRequest{}
+
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("query lock host detail, but decode body failed, err: %s, rid:%s", err.Error(), srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == len(input.IPS) {
+		blog.Errorf("query lock host detail, ip_list is empty, input:%+v,rid:%s", input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "ip_list")})
+		return
+	}
+
+	// check authorization
+	hostIDArr := make([]int64, 0)
+	for _, ip := range input.IPS {
+		hostID, err := s.ip2hostID(srvData, ip, input.CloudID)
+		if err != nil {
+			blog.Errorf("invalid ip %s:%s, err: %s, rid:%s", ip, input.CloudID, err.Error(), srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommParamsIsInvalid)})
+			return
+		}
+		hostIDArr = append(hostIDArr, hostID)
+	}
+	// auth: check authorization
+	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Find, hostIDArr...); err != nil {
+		blog.Errorf("check host authorization failed, hosts: %+v, err: %v", hostIDArr, err)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	locks, err := srvData.lgc.QueryHostLockDetail(srvData.ctx, input)
+	if nil != err {
+		blog.Errorf("query lock host detail, handle query host lock error, error:%s, input:%+v,rid:%s", err.Error(), input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.HostLockDetailResponse{
+		BaseResp: metadata.SuccessBaseResp,
+		Data:     locks,
+	})
+}
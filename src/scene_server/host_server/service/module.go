@@ -67,6 +67,12 @@ func (s *Service) HostModuleRelation(req *restful.Request, resp *restful.Respons
 		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, config.HostID)
+	if lockErr != nil {
+		blog.Errorf("host module relation, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, config, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
 	// auth: deregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, config.HostID...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v", config.HostID, err)
@@ -98,6 +104,7 @@ func (s *Service) HostModuleRelation(req *restful.Request, resp *restful.Respons
 		return
 	}
 
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 }
 
@@ -130,6 +137,12 @@ func (s *Service) MoveHostToResourcePool(req *restful.Request, resp *restful.Res
 	// 	resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 	// 	return
 	// }
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, conf.HostID)
+	if lockErr != nil {
+		blog.Errorf("move host to resource pool, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, conf, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
 	// auth: deregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, conf.HostID...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v", conf.HostID, err)
@@ -150,6 +163,7 @@ func (s *Service) MoveHostToResourcePool(req *restful.Request, resp *restful.Res
 		return
 	}
 
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 }
 
@@ -178,6 +192,12 @@ func (s *Service) AssignHostToApp(req *restful.Request, resp *restful.Response)
 	// 	return
 	// }
 
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, conf.HostID)
+	if lockErr != nil {
+		blog.Errorf("assign host to app, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, conf, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
 	// auth: deregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, conf.HostID...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v, rid:%s", conf.HostID, err, srvData.rid)
@@ -189,6 +209,7 @@ func (s *Service) AssignHostToApp(req *restful.Request, resp *restful.Response)
 	if err != nil {
 		blog.Errorf("assign host to app, but assign to app http do error. err: %v, input:%+v,rid:%s", err, conf, srvData.rid)
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err, Data: exceptionArr})
+		return
 	}
 
 	// register host to new business
@@ -198,6 +219,7 @@ func (s *Service) AssignHostToApp(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 }
 
@@ -289,6 +311,12 @@ func (s *Service) AssignHostToAppModule(req *restful.Request, resp *restful.Resp
 		// 	return
 		// }
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, hostIDArr)
+	if lockErr != nil {
+		blog.Errorf("assign host to app module, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, data, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
 
 	// auth: deregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, hostIDArr...); err != nil {
@@ -322,6 +350,7 @@ func (s *Service) AssignHostToAppModule(req *restful.Request, resp *restful.Resp
 			resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)})
 			return
 		}
+		srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 		resp.WriteEntity(metadata.NewSuccessResp(nil))
 		return
 	}
@@ -370,12 +399,19 @@ func (s *Service) TransferHostAcrossBusiness(req *restful.Request, resp *restful
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, []int64{data.HostID})
+	if lockErr != nil {
+		blog.Errorf("transfer host across business, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, data, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
 	err := srvData.lgc.TransferHostAcrossBusiness(srvData.ctx, data.SrcAppID, data.DstAppID, data.HostID, data.DstModuleIDArr)
 	if err != nil {
 		blog.Errorf("TransferHostAcrossBusiness logcis err:%s,input:%#v,rid:%s", err.Error(), data, srvData.rid)
 		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 	return
 }
@@ -391,12 +427,19 @@ func (s *Service) DeleteHostFromBusiness(req *restful.Request, resp *restful.Res
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, data.HostIDArr)
+	if lockErr != nil {
+		blog.Errorf("delete host from business, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, data, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
 	exceptionArr, err := srvData.lgc.DeleteHostFromBusiness(srvData.ctx, data.AppID, data.HostIDArr)
 	if err != nil {
 		blog.Errorf("DeleteHostFromBusiness logcis err:%s,input:%#v,rid:%s", err.Error(), data, srvData.rid)
 		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err, Data: exceptionArr})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 	return
 }
@@ -452,6 +495,12 @@ func (s *Service) moveHostToModuleByName(req *restful.Request, resp *restful.Res
 		resp.WriteEntity(s.AuthManager.GenDeleteHostBatchNoPermissionResp(conf.HostID))
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, conf.HostID)
+	if lockErr != nil {
+		blog.Errorf("move host to module by name, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, conf, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
 	// auth: deregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, conf.HostID...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v", conf.HostID, err)
@@ -487,5 +536,6 @@ func (s *Service) moveHostToModuleByName(req *restful.Request, resp *restful.Res
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommResourceInitFailed, "audit server")})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 }
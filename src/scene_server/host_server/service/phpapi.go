@@ -69,6 +69,13 @@ func (s *Service) UpdateHost(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, hostIDArr)
+	if lockErr != nil {
+		blog.Errorf("UpdateHost update host, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
+
 	data, httpCode, errMsg := srvData.lgc.UpdateHost(srvData.ctx, input, appID)
 
 	if nil != errMsg {
@@ -76,6 +83,7 @@ func (s *Service) UpdateHost(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(httpCode, &meta.RespError{Msg: errMsg})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
 		Data:     data,
@@ -112,6 +120,12 @@ func (s *Service) UpdateHostByAppID(req *restful.Request, resp *restful.Response
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, hostIDArr)
+	if lockErr != nil {
+		blog.Errorf("updateHostByAppID update host, check host lock failed, err: %v, input:%+v, rid:%s", lockErr, input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
 
 	blog.V(5).Infof("updateHostByAppID http body data: %v,srvData.rid", input, srvData.rid)
 	result, httpCode, errMsg := srvData.lgc.UpdateHostByAppID(srvData.ctx, input, appID)
@@ -120,6 +134,7 @@ func (s *Service) UpdateHostByAppID(req *restful.Request, resp *restful.Response
 		resp.WriteError(httpCode, &meta.RespError{Msg: errMsg})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
 		Data:     result,
@@ -639,6 +654,12 @@ func (s *Service) UpdateCustomProperty(req *restful.Request, resp *restful.Respo
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, []int64{hostID})
+	if lockErr != nil {
+		blog.Errorf("UpdateCustomProperty check host lock failed, err: %v, input:%+v, rid:%s", lockErr, input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
 
 	propertyJson, ok := input["property"].(string)
 	if false == ok && "" == propertyJson {
@@ -661,6 +682,7 @@ func (s *Service) UpdateCustomProperty(req *restful.Request, resp *restful.Respo
 		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
@@ -825,6 +847,12 @@ func (s *Service) DelHostInApp(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 		return
 	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, []int64{hostID})
+	if lockErr != nil {
+		blog.Errorf("DelHostInApp check host lock failed, err: %v, input:%+v, rid:%s", lockErr, input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: lockErr})
+		return
+	}
 
 	param := make(common.KvMap)
 	param[common.BKAppIDField] = appID
@@ -840,6 +868,7 @@ func (s *Service) DelHostInApp(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(res.Code, res.ErrMsg)})
 		return
 	}
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
 
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
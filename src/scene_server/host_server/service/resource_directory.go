@@ -0,0 +1,345 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	authmeta "configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateResourceDirectory create a directory in the resource pool, creating a top level
+// directory needs the create permission, otherwise it needs to edit the parent directory.
+func (s *Service) CreateResourceDirectory(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	dir := new(metadata.ResourceDirectory)
+	if err := json.NewDecoder(req.Request.Body).Decode(dir); err != nil {
+		blog.Errorf("create resource directory failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	var authErr error
+	if 0 == dir.ParentID {
+		authErr = s.AuthManager.AuthorizeResourceCreate(srvData.ctx, srvData.header, 0, authmeta.ResourcePoolDirectory)
+	} else {
+		authErr = s.AuthManager.AuthorizeByResourceDirectoryIDs(srvData.ctx, srvData.header, authmeta.Update, dir.ParentID)
+	}
+	if authErr != nil {
+		blog.Errorf("create resource directory, authorize failed, parent: %d, err: %v, rid: %s", dir.ParentID, authErr, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	result, err := s.CoreAPI.HostController().Host().AddResourceDirectory(srvData.ctx, srvData.header, dir)
+	if err != nil {
+		blog.Errorf("create resource directory http do error, err: %v, input: %+v, rid: %s", err, dir, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		blog.Errorf("create resource directory http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, dir, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.New(result.Code, result.ErrMsg)})
+		return
+	}
+
+	// auth: register resource directory
+	if err := s.AuthManager.RegisterResourceDirectory(srvData.ctx, srvData.header, result.Data); err != nil {
+		blog.Errorf("register resource directory to iam failed, directory: %+v, err: %v, rid: %s", result.Data, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result.Data))
+}
+
+// UpdateResourceDirectory rename the directory or move it under another directory
+func (s *Service) UpdateResourceDirectory(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	dir, ok := s.getResourceDirectory(srvData, req, resp)
+	if !ok {
+		return
+	}
+
+	input := make(map[string]interface{})
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("update resource directory failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	data := make(map[string]interface{})
+	for _, field := range []string{"bk_dir_name", "bk_parent_id"} {
+		if val, exists := input[field]; exists {
+			data[field] = val
+		}
+	}
+
+	authDirIDs := []int64{dir.DirID}
+	if parent, exists := data["bk_parent_id"]; exists {
+		parentID, err := util.GetInt64ByInterface(parent)
+		if err != nil {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "bk_parent_id")})
+			return
+		}
+		if 0 != parentID {
+			authDirIDs = append(authDirIDs, parentID)
+		}
+	}
+	if err := s.AuthManager.AuthorizeByResourceDirectoryIDs(srvData.ctx, srvData.header, authmeta.Update, authDirIDs...); err != nil {
+		blog.Errorf("update resource directory, authorize failed, directories: %v, err: %v, rid: %s", authDirIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	result, err := s.CoreAPI.HostController().Host().UpdateResourceDirectory(srvData.ctx, dir.DirID, srvData.header, data)
+	if err != nil {
+		blog.Errorf("update resource directory http do error, err: %v, input: %+v, rid: %s", err, data, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		blog.Errorf("update resource directory http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, data, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.New(result.Code, result.ErrMsg)})
+		return
+	}
+
+	// auth: update registered resource directory
+	if err := s.AuthManager.UpdateRegisteredResourceDirectory(srvData.ctx, srvData.header, result.Data); err != nil {
+		blog.Errorf("update registered resource directory failed, directory: %+v, err: %v, rid: %s", result.Data, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result.Data))
+}
+
+// DeleteResourceDirectory delete an empty directory
+func (s *Service) DeleteResourceDirectory(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	dir, ok := s.getResourceDirectory(srvData, req, resp)
+	if !ok {
+		return
+	}
+	if err := s.AuthManager.AuthorizeByResourceDirectory(srvData.ctx, srvData.header, authmeta.Delete, *dir); err != nil {
+		blog.Errorf("delete resource directory, authorize failed, directory: %d, err: %v, rid: %s", dir.DirID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	result, err := s.CoreAPI.HostController().Host().DeleteResourceDirectory(srvData.ctx, dir.DirID, srvData.header)
+	if err != nil {
+		blog.Errorf("delete resource directory %d http do error, err: %v, rid: %s", dir.DirID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		blog.Errorf("delete resource directory %d http response error, err code: %d, err msg: %s, rid: %s", dir.DirID, result.Code, result.ErrMsg, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.New(result.Code, result.ErrMsg)})
+		return
+	}
+
+	// auth: deregister resource directory
+	if err := s.AuthManager.DeregisterResourceDirectory(srvData.ctx, srvData.header, *dir); err != nil {
+		blog.Errorf("deregister resource directory from iam failed, directory: %d, err: %v, rid: %s", dir.DirID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommUnRegistResourceToIAMFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// SearchResourceDirectory search the directories, the web builds the tree by parent id
+func (s *Service) SearchResourceDirectory(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(metadata.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("search resource directory failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := srvData.lgc.SearchResourceDirectory(srvData.ctx, input)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// MoveHostToResourceDirectory move the hosts of resource pool to the directory, it needs
+// to edit both the target directory and the directories the hosts are in now.
+func (s *Service) MoveHostToResourceDirectory(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(metadata.MoveHostToResourceDirectory)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("move host to resource directory failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == len(input.HostIDs) {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, common.BKHostIDField)})
+		return
+	}
+
+	srcDirIDs, err := srvData.lgc.GetHostResourceDirectoryIDs(srvData.ctx, input.HostIDs)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	authDirIDs := make([]int64, 0)
+	for _, dirID := range append(srcDirIDs, input.DirID) {
+		if 0 != dirID {
+			authDirIDs = append(authDirIDs, dirID)
+		}
+	}
+	if err := s.AuthManager.AuthorizeByResourceDirectoryIDs(srvData.ctx, srvData.header, authmeta.Update, authDirIDs...); err != nil {
+		blog.Errorf("move host to resource directory, authorize failed, directories: %v, err: %v, rid: %s", authDirIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, input.HostIDs)
+	if lockErr != nil {
+		blog.Errorf("move host to resource directory, check host lock failed, err: %v, input: %+v, rid: %s", lockErr, input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
+	if err := srvData.lgc.MoveHostToResourceDirectory(srvData.ctx, input); err != nil {
+		blog.Errorf("move host to resource directory failed, err: %v, input: %+v, rid: %s", err, input, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// AssignHostFromResourceDirectory assign the hosts in the directory to the idle module of the business
+func (s *Service) AssignHostFromResourceDirectory(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(metadata.AssignHostFromResourceDirectory)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("assign host from resource directory failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == input.DirID {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, common.BKResourceDirIDField)})
+		return
+	}
+	if 0 >= input.ApplicationID {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, common.BKAppIDField)})
+		return
+	}
+	if err := s.AuthManager.AuthorizeByResourceDirectoryIDs(srvData.ctx, srvData.header, authmeta.Update, input.DirID); err != nil {
+		blog.Errorf("assign host from resource directory, authorize failed, directory: %d, err: %v, rid: %s", input.DirID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+	// auth: the hosts are assigned to the business like host to business transfer
+	if err := s.AuthManager.AuthorizeByBusinessID(srvData.ctx, srvData.header, authmeta.Update, input.ApplicationID); err != nil {
+		blog.Errorf("assign host from resource directory, authorize on business %d failed, err: %v, rid: %s", input.ApplicationID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	dirHostIDs, err := srvData.lgc.GetResourceDirectoryHostIDs(srvData.ctx, input.DirID)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	hostIDs := input.HostIDs
+	if 0 == len(hostIDs) {
+		hostIDs = dirHostIDs
+	}
+	notInDir := make([]int64, 0)
+	for _, hostID := range hostIDs {
+		if !util.InArray(hostID, dirHostIDs) {
+			notInDir = append(notInDir, hostID)
+		}
+	}
+	if 0 != len(notInDir) {
+		blog.Errorf("assign host from resource directory %d, but hosts %v are not in it, rid: %s", input.DirID, notInDir, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrHostNotInResourceDir, util.Int64Join(notInDir, ","))})
+		return
+	}
+	if 0 == len(hostIDs) {
+		resp.WriteEntity(metadata.NewSuccessResp(nil))
+		return
+	}
+
+	conf := &metadata.DefaultModuleHostConfigParams{
+		ApplicationID: input.ApplicationID,
+		HostID:        hostIDs,
+	}
+	overrideLocks, lockErr := srvData.lgc.CheckHostLock(srvData.ctx, conf.HostID)
+	if lockErr != nil {
+		blog.Errorf("assign host from resource directory, check host lock failed, err: %v, input: %+v, rid: %s", lockErr, conf, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: lockErr})
+		return
+	}
+	// auth: deregister hosts
+	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, conf.HostID...); err != nil {
+		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v, rid: %s", conf.HostID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommUnRegistResourceToIAMFailed)})
+		return
+	}
+
+	exceptionArr, err := srvData.lgc.AssignHostToApp(srvData.ctx, conf)
+	if err != nil {
+		blog.Errorf("assign host from resource directory failed, err: %v, input: %+v, rid: %s", err, conf, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err, Data: exceptionArr})
+		return
+	}
+
+	// auth: register host to new business
+	if err := s.AuthManager.RegisterHostsByID(srvData.ctx, srvData.header, conf.HostID...); err != nil {
+		blog.Errorf("register host to iam failed, hosts: %+v, err: %v, rid: %s", conf.HostID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)})
+		return
+	}
+
+	srvData.lgc.SaveHostLockOverrideAudit(srvData.ctx, overrideLocks)
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *Service) getResourceDirectory(srvData *srvComm, req *restful.Request, resp *restful.Response) (*metadata.ResourceDirectory, bool) {
+	dirID, err := strconv.ParseInt(req.PathParameter(common.BKResourceDirIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("invalid resource directory id %s, err: %v, rid: %s", req.PathParameter(common.BKResourceDirIDField), err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKResourceDirIDField)})
+		return nil, false
+	}
+
+	dir, getErr := srvData.lgc.GetResourceDirectory(srvData.ctx, dirID)
+	if getErr != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: getErr})
+		return nil, false
+	}
+	return dir, true
+}
@@ -123,10 +123,31 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/userapi/search/{bk_biz_id}").To(s.GetUserCustomQuery))
 	api.Route(api.GET("/userapi/detail/{bk_biz_id}/{id}").To(s.GetUserCustomQueryDetail))
 	api.Route(api.GET("/userapi/data/{bk_biz_id}/{id}/{start}/{limit}").To(s.GetUserCustomQueryResult))
+	api.Route(api.POST("/userapi/evaluate/{bk_biz_id}/{id}").To(s.EvaluateUserCustomQuery))
+	api.Route(api.POST("/userapi/snapshot/search/{bk_biz_id}/{id}").To(s.SearchUserCustomQuerySnapshot))
 
 	api.Route(api.POST("/host/lock").To(s.LockHost))
 	api.Route(api.DELETE("/host/lock").To(s.UnlockHost))
 	api.Route(api.POST("/host/lock/search").To(s.QueryHostLock))
+	api.Route(api.POST("/host/lock/detail/search").To(s.QueryHostLockDetail))
+
+	api.Route(api.POST("/hosts/transfer/plan").To(s.CreateHostTransferPlan))
+	api.Route(api.PUT("/hosts/transfer/plan/{bk_plan_id}").To(s.UpdateHostTransferPlan))
+	api.Route(api.DELETE("/hosts/transfer/plan/{bk_plan_id}").To(s.DeleteHostTransferPlan))
+	api.Route(api.POST("/hosts/transfer/plan/search").To(s.SearchHostTransferPlan))
+	api.Route(api.POST("/hosts/transfer/plan/{bk_plan_id}/submit").To(s.SubmitHostTransferPlan))
+	api.Route(api.POST("/hosts/transfer/plan/{bk_plan_id}/approve").To(s.ApproveHostTransferPlan))
+	api.Route(api.POST("/hosts/transfer/plan/{bk_plan_id}/reject").To(s.RejectHostTransferPlan))
+	api.Route(api.POST("/hosts/resource/directory").To(s.CreateResourceDirectory))
+	api.Route(api.PUT("/hosts/resource/directory/{bk_dir_id}").To(s.UpdateResourceDirectory))
+	api.Route(api.DELETE("/hosts/resource/directory/{bk_dir_id}").To(s.DeleteResourceDirectory))
+	api.Route(api.POST("/hosts/resource/directory/search").To(s.SearchResourceDirectory))
+	api.Route(api.POST("/hosts/resource/directory/host/move").To(s.MoveHostToResourceDirectory))
+	api.Route(api.POST("/hosts/resource/directory/host/assign").To(s.AssignHostFromResourceDirectory))
+	api.Route(api.POST("/hosts/duplicate/detect").To(s.DetectHostDuplicates))
+	api.Route(api.POST("/hosts/duplicate/search").To(s.SearchHostDuplicates))
+	api.Route(api.PUT("/hosts/duplicate/{bk_duplicate_id}/ignore").To(s.IgnoreHostDuplicate))
+	api.Route(api.POST("/hosts/duplicate/merge").To(s.MergeHostDuplicate))
 
 	api.Route(api.GET("/host/getHostListByAppidAndField/{" + common.BKAppIDField + "}/{field}").To(s.getHostListByAppidAndField))
 	api.Route(api.PUT("/openapi/host/{" + common.BKAppIDField + "}").To(s.UpdateHost))
@@ -160,6 +181,7 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/hosts/cloud/confirmHistory/search").To(s.SearchConfirmHistory))
 	api.Route(api.POST("/hosts/cloud/accountSearch").To(s.SearchAccount))
 	api.Route(api.POST("/hosts/cloud/syncHistory").To(s.CloudSyncHistory))
+	api.Route(api.POST("/hosts/cloud/preview/{taskID}").To(s.PreviewCloudSync))
 
 	container.Add(api)
 
@@ -239,4 +261,7 @@ func (s *Service) InitBackground() {
 
 	srvData := s.newSrvComm(header)
 	go srvData.lgc.TimerTriggerCheckStatus(srvData.ctx)
+	srvData.lgc.TimerEvaluateDynamicGroup(srvData.ctx)
+	srvData.lgc.TimerExecuteHostTransferPlan(srvData.ctx)
+	srvData.lgc.TimerDetectHostDuplicates(srvData.ctx, s.Config.Duplicate.Get)
 }
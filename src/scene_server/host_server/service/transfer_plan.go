@@ -0,0 +1,293 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+
+	authmeta "configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// CreateHostTransferPlan create a host transfer plan as draft, the user must
+// have the permission to transfer the hosts when create the plan.
+func (s *Service) CreateHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	plan := new(metadata.HostTransferPlan)
+	if err := json.NewDecoder(req.Request.Body).Decode(plan); err != nil {
+		blog.Errorf("create host transfer plan failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if "" == plan.PlanName {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "bk_plan_name")})
+		return
+	}
+	if err := srvData.lgc.ValidateHostTransferPlan(srvData.ctx, plan); err != nil {
+		blog.Errorf("create host transfer plan, validate failed, err: %v, input: %+v, rid: %s", err, plan, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	if err := s.authorizeHostTransferPlan(srvData, plan); err != nil {
+		blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", plan.HostIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	result, err := s.CoreAPI.HostController().Host().AddHostTransferPlan(srvData.ctx, srvData.header, plan)
+	if err != nil {
+		blog.Errorf("create host transfer plan http do error, err: %v, input: %+v, rid: %s", err, plan, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		blog.Errorf("create host transfer plan http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, plan, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.New(result.Code, result.ErrMsg)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result.Data))
+}
+
+// UpdateHostTransferPlan update the target topology and execute time of a draft plan
+func (s *Service) UpdateHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	plan, ok := s.getHostTransferPlan(srvData, req, resp)
+	if !ok {
+		return
+	}
+	if metadata.HostTransferPlanStatusDraft != plan.Status {
+		blog.Errorf("update host transfer plan %d, but status is %s, rid: %s", plan.PlanID, plan.Status, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrHostTransferPlanStatusInvalid, plan.Status, "updated")})
+		return
+	}
+
+	// the body is decoded on the plan, so the fields not given are kept,
+	// only the plan itself is saved, the status is changed by the state machine
+	updated := *plan
+	if err := json.NewDecoder(req.Request.Body).Decode(&updated); err != nil {
+		blog.Errorf("update host transfer plan failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if "" == updated.PlanName {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "bk_plan_name")})
+		return
+	}
+	if err := srvData.lgc.ValidateHostTransferPlan(srvData.ctx, &updated); err != nil {
+		blog.Errorf("update host transfer plan %d, validate failed, err: %v, input: %+v, rid: %s", plan.PlanID, err, updated, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	if err := s.authorizeHostTransferPlan(srvData, &updated); err != nil {
+		blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", updated.HostIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	changes := mapstr.MapStr{
+		"bk_plan_name":  updated.PlanName,
+		"src_bk_biz_id": updated.SrcAppID,
+		"dst_bk_biz_id": updated.DstAppID,
+		"bk_host_id":    updated.HostIDs,
+		"bk_module_id":  updated.ModuleIDs,
+		"is_increment":  updated.IsIncrement,
+		"execute_time":  updated.ExecuteTime,
+	}
+	result, err := srvData.lgc.UpdateHostTransferPlan(srvData.ctx, plan.PlanID, []string{metadata.HostTransferPlanStatusDraft}, changes)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// DeleteHostTransferPlan delete the plan, the plan which is executing can't be deleted.
+// delete an approved plan is the way to cancel it.
+func (s *Service) DeleteHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	plan, ok := s.getHostTransferPlan(srvData, req, resp)
+	if !ok {
+		return
+	}
+	if metadata.HostTransferPlanStatusExecuting == plan.Status {
+		blog.Errorf("delete host transfer plan %d, but it's executing, rid: %s", plan.PlanID, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrHostTransferPlanStatusInvalid, plan.Status, "deleted")})
+		return
+	}
+	if err := s.AuthManager.AuthorizeByBusinessID(srvData.ctx, srvData.header, authmeta.Update, plan.SrcAppID); err != nil {
+		blog.Errorf("check business authorization failed, business: %d, err: %v, rid: %s", plan.SrcAppID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	result, err := s.CoreAPI.HostController().Host().DeleteHostTransferPlan(srvData.ctx, plan.PlanID, srvData.header)
+	if err != nil {
+		blog.Errorf("delete host transfer plan %d http do error, err: %v, rid: %s", plan.PlanID, err, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		blog.Errorf("delete host transfer plan %d http response error, err code: %d, err msg: %s, rid: %s", plan.PlanID, result.Code, result.ErrMsg, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.New(result.Code, result.ErrMsg)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// SearchHostTransferPlan search the host transfer plans by condition
+func (s *Service) SearchHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(metadata.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("search host transfer plan failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := srvData.lgc.SearchHostTransferPlan(srvData.ctx, input)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	// auth: the user must have the permission to find both the source and target business of the plans
+	bizIDs := make([]int64, 0)
+	for _, plan := range result.Info {
+		bizIDs = append(bizIDs, plan.SrcAppID, plan.DstAppID)
+	}
+	if 0 != len(bizIDs) {
+		if err := s.AuthManager.AuthorizeByBusinessID(srvData.ctx, srvData.header, authmeta.Find, bizIDs...); err != nil {
+			blog.Errorf("check business authorization failed, business: %v, err: %v, rid: %s", bizIDs, err, srvData.rid)
+			resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+			return
+		}
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// SubmitHostTransferPlan submit the draft plan to the business maintainer for approval,
+// the user must have the permission to transfer the hosts like create the plan.
+func (s *Service) SubmitHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	plan, ok := s.getHostTransferPlan(srvData, req, resp)
+	if !ok {
+		return
+	}
+	if err := s.authorizeHostTransferPlan(srvData, plan); err != nil {
+		blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", plan.HostIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	data := mapstr.MapStr{"reject_reason": ""}
+	result, err := srvData.lgc.UpdateHostTransferPlanStatus(srvData.ctx, plan, metadata.HostTransferPlanStatusPendingApproval, data)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// ApproveHostTransferPlan approve the plan, then it's executed at the execute time.
+// the approver must have the permission to update both the source and target business.
+func (s *Service) ApproveHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	plan, ok := s.getHostTransferPlan(srvData, req, resp)
+	if !ok {
+		return
+	}
+	if err := s.AuthManager.AuthorizeByBusinessID(srvData.ctx, srvData.header, authmeta.Update, plan.SrcAppID, plan.DstAppID); err != nil {
+		blog.Errorf("check business authorization failed, business: %d, %d, err: %v, rid: %s", plan.SrcAppID, plan.DstAppID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	data := mapstr.MapStr{"approver": srvData.user, "approve_time": time.Now().UTC()}
+	result, err := srvData.lgc.UpdateHostTransferPlanStatus(srvData.ctx, plan, metadata.HostTransferPlanStatusApproved, data)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// RejectHostTransferPlan reject the plan with reason, the plan goes back to draft
+func (s *Service) RejectHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	plan, ok := s.getHostTransferPlan(srvData, req, resp)
+	if !ok {
+		return
+	}
+
+	input := new(metadata.RejectHostTransferPlan)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("reject host transfer plan failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := s.AuthManager.AuthorizeByBusinessID(srvData.ctx, srvData.header, authmeta.Update, plan.SrcAppID, plan.DstAppID); err != nil {
+		blog.Errorf("check business authorization failed, business: %d, %d, err: %v, rid: %s", plan.SrcAppID, plan.DstAppID, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	data := mapstr.MapStr{"approver": srvData.user, "reject_reason": input.Reason}
+	result, err := srvData.lgc.UpdateHostTransferPlanStatus(srvData.ctx, plan, metadata.HostTransferPlanStatusDraft, data)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// getHostTransferPlan get the plan by the id in path, the error is written to response when failed
+func (s *Service) getHostTransferPlan(srvData *srvComm, req *restful.Request, resp *restful.Response) (*metadata.HostTransferPlan, bool) {
+	planID, err := strconv.ParseInt(req.PathParameter("bk_plan_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("invalid host transfer plan id %s, err: %v, rid: %s", req.PathParameter("bk_plan_id"), err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "bk_plan_id")})
+		return nil, false
+	}
+
+	plan, getErr := srvData.lgc.GetHostTransferPlan(srvData.ctx, planID)
+	if getErr != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: getErr})
+		return nil, false
+	}
+	return plan, true
+}
+
+// authorizeHostTransferPlan check the permission to transfer the hosts of the plan
+func (s *Service) authorizeHostTransferPlan(srvData *srvComm, plan *metadata.HostTransferPlan) error {
+	action := authmeta.MoveHostsToBusinessOrModule
+	if plan.IsCrossBusiness() {
+		action = authmeta.MoveHostToAnotherBizModule
+	}
+	return s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, action, plan.HostIDs...)
+}
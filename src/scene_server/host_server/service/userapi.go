@@ -48,6 +48,24 @@ func (s *Service) AddUserCustomQuery(req *restful.Request, resp *restful.Respons
 		return
 	}
 
+	if 0 > ucq.EvalInterval {
+		blog.Error("AddUserCustomQuery add user custom query parameter eval_interval is invalid,input:%+v,rid:%s", ucq, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "eval_interval")})
+		return
+	}
+
+	if err := srvData.lgc.ValidateDynamicGroupObject(srvData.ctx, ucq.ObjectID); err != nil {
+		blog.Errorf("AddUserCustomQuery add user custom query with invalid object, err: %v, input:%+v,rid:%s", err, ucq, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	if err := validateUserCustomQueryExpression(ucq.ObjectID, ucq.Info); err != nil {
+		blog.Errorf("AddUserCustomQuery add user custom query with invalid expression, err: %v, input:%+v,rid:%s", err, ucq, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrHostSearchExpressionInvalid, err.Error())})
+		return
+	}
+
 	ucq.CreateUser = srvData.user
 	result, err := s.CoreAPI.HostController().User().AddUserConfig(srvData.ctx, srvData.header, ucq)
 	if err != nil {
@@ -81,6 +99,41 @@ func (s *Service) UpdateUserCustomQuery(req *restful.Request, resp *restful.Resp
 		return
 	}
 
+	// the target model can't be changed, as the snapshots are the instances of it
+	if _, ok := params[common.BKObjIDField]; ok {
+		blog.Errorf("update user custom query failed, bk_obj_id can not be updated, input:%+v,rid:%s", params, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)})
+		return
+	}
+	delete(params, "last_eval_time")
+	if val, ok := params["eval_interval"]; ok {
+		interval, err := util.GetInt64ByInterface(val)
+		if err != nil || 0 > interval {
+			blog.Errorf("update user custom query failed, eval_interval is invalid, input:%+v,rid:%s", params, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "eval_interval")})
+			return
+		}
+	}
+
+	if info, ok := params["info"].(string); ok {
+		detail, err := s.CoreAPI.HostController().User().GetUserConfigDetail(srvData.ctx, req.PathParameter("bk_biz_id"), req.PathParameter("id"), srvData.header)
+		if err != nil {
+			blog.Errorf("update user custom query, get detail http do error, err:%s, input:%+v,rid:%s", err.Error(), params, srvData.rid)
+			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+			return
+		}
+		if !detail.Result {
+			blog.Errorf("update user custom query, get detail http response error, err code:%d,err msg:%s, input:%+v,rid:%s", detail.Code, detail.ErrMsg, params, srvData.rid)
+			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(detail.Code, detail.ErrMsg)})
+			return
+		}
+		if err := validateUserCustomQueryExpression(detail.Data.ObjectID, info); err != nil {
+			blog.Errorf("update user custom query with invalid expression, err: %v, input:%+v,rid:%s", err, params, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrHostSearchExpressionInvalid, err.Error())})
+			return
+		}
+	}
+
 	params["modify_user"] = srvData.user
 	params[common.LastTimeField] = time.Now().UTC()
 	bizID := req.PathParameter("bk_biz_id")
@@ -259,26 +312,15 @@ func (s *Service) GetUserCustomQueryResult(req *restful.Request, resp *restful.R
 		return
 	}
 
-	var input meta.HostCommonSearch
-	input.AppID = intAppID
+	result.Data.AppID = intAppID
+	page := meta.BasePage{}
+	page.Start, _ = util.GetIntByInterface(req.PathParameter("start"))
+	page.Limit, _ = util.GetIntByInterface(req.PathParameter("limit"))
 
-	err = json.Unmarshal([]byte(result.Data.Info), &input)
-	if nil != err {
-		blog.Errorf("UserAPIResult custom unmarshal failed,  err: %v, appid:%s, id:%s, logID:%s", err.Error(), appID, ID, srvData.rid)
-		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
-		return
-	}
-
-	input.Page.Start, _ = util.GetIntByInterface(req.PathParameter("start"))
-	input.Page.Limit, _ = util.GetIntByInterface(req.PathParameter("limit"))
-
-	retData, err := srvData.lgc.SearchHost(srvData.ctx, &input, false)
-	if nil != err || (nil == err && !result.Result) {
-		if nil == err {
-			err = fmt.Errorf(result.ErrMsg)
-		}
-		blog.Errorf("UserAPIResult custom query search host failed,  err: %v, appid:%s, id:%s", err.Error(), appID, ID)
-		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrGetUserCustomQueryDetailFaild, err.Error())})
+	retData, ccErr := srvData.lgc.SearchDynamicGroup(srvData.ctx, &result.Data, page)
+	if nil != ccErr {
+		blog.Errorf("UserAPIResult custom query search failed,  err: %v, appid:%s, id:%s, logID:%s", ccErr, appID, ID, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: ccErr})
 		return
 	}
 
@@ -292,3 +334,94 @@ func (s *Service) GetUserCustomQueryResult(req *restful.Request, resp *restful.R
 
 	return
 }
+
+// EvaluateUserCustomQuery evaluate the members of the dynamic group immediately
+func (s *Service) EvaluateUserCustomQuery(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	appID := req.PathParameter("bk_biz_id")
+	ID := req.PathParameter("id")
+
+	result, err := s.CoreAPI.HostController().User().GetUserConfigDetail(srvData.ctx, appID, ID, srvData.header)
+	if err != nil {
+		blog.Errorf("EvaluateUserCustomQuery http do error,err:%s, biz:%v,ID:%+v,rid:%s", err.Error(), appID, ID, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		blog.Errorf("EvaluateUserCustomQuery http response error,err code:%d,err msg:%s, bizID:%v,ID:%+v,rid:%s", result.Code, result.ErrMsg, appID, ID, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(result.Code, result.ErrMsg)})
+		return
+	}
+	if "" == result.Data.Name {
+		blog.Errorf("EvaluateUserCustomQuery custom query not found, appid:%s, id:%s, rid:%s", appID, ID, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommNotFound)})
+		return
+	}
+
+	snapshot, ccErr := srvData.lgc.EvaluateDynamicGroup(srvData.ctx, &result.Data)
+	if nil != ccErr {
+		blog.Errorf("EvaluateUserCustomQuery evaluate failed, err: %v, appid:%s, id:%s, rid:%s", ccErr, appID, ID, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: ccErr})
+		return
+	}
+
+	resp.WriteEntity(meta.Response{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     snapshot,
+	})
+}
+
+// SearchUserCustomQuerySnapshot returns the member snapshots of the dynamic group, the latest first
+func (s *Service) SearchUserCustomQuerySnapshot(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	appID := req.PathParameter("bk_biz_id")
+	ID := req.PathParameter("id")
+
+	page := new(meta.BasePage)
+	if err := json.NewDecoder(req.Request.Body).Decode(page); nil != err {
+		blog.Errorf("search user custom query snapshot failed with decode body err: %v,rid:%s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.CoreAPI.HostController().User().SearchUserConfigSnapshot(srvData.ctx, appID, ID, srvData.header, page)
+	if err != nil {
+		blog.Errorf("SearchUserCustomQuerySnapshot http do error,err:%s, biz:%v,ID:%+v,rid:%s", err.Error(), appID, ID, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)})
+		return
+	}
+	if !result.Result {
+		blog.Errorf("SearchUserCustomQuerySnapshot http response error,err code:%d,err msg:%s, bizID:%v,ID:%+v,rid:%s", result.Code, result.ErrMsg, appID, ID, srvData.rid)
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: srvData.ccErr.New(result.Code, result.ErrMsg)})
+		return
+	}
+
+	resp.WriteEntity(meta.Response{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     result.Data,
+	})
+}
+
+// validateUserCustomQueryExpression check the boolean expression saved in user custom query info,
+// the info is the json of meta.HostCommonSearch, the expression only works on host
+func validateUserCustomQueryExpression(objID, info string) error {
+	if "" == info {
+		return nil
+	}
+	query := new(struct {
+		Expression *meta.HostSearchExpression `json:"expression"`
+	})
+	if err := json.Unmarshal([]byte(info), query); err != nil {
+		// info is not limited to json format before, keep it compatible
+		return nil
+	}
+	if nil == query.Expression {
+		return nil
+	}
+	if "" != objID && common.BKInnerObjIDHost != objID {
+		return fmt.Errorf("expression is not supported by %s", objID)
+	}
+	return query.Expression.Validate()
+}
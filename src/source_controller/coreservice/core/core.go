@@ -153,6 +153,14 @@ type AuditOperation interface {
 	SearchAuditLog(ctx ContextParams, param metadata.QueryInput) ([]metadata.OperationLog, uint64, error)
 }
 
+// HostSnapOperation the attribute mappings of the host snapshot
+type HostSnapOperation interface {
+	CreateHostSnapMapping(ctx ContextParams, mapping metadata.HostSnapMapping) (*metadata.HostSnapMapping, error)
+	UpdateHostSnapMapping(ctx ContextParams, id int64, mapping metadata.HostSnapMapping) error
+	DeleteHostSnapMapping(ctx ContextParams, id int64) error
+	SearchHostSnapMapping(ctx ContextParams, cond metadata.QueryCondition) ([]metadata.HostSnapMapping, uint64, error)
+}
+
 // Core core itnerfaces methods
 type Core interface {
 	ModelOperation() ModelOperation
@@ -162,6 +170,7 @@ type Core interface {
 	DataSynchronizeOperation() DataSynchronizeOperation
 	HostOperation() HostOperation
 	AuditOperation() AuditOperation
+	HostSnapOperation() HostSnapOperation
 }
 
 type core struct {
@@ -172,10 +181,11 @@ type core struct {
 	topo            TopoOperation
 	host            HostOperation
 	audit           AuditOperation
+	hostSnap        HostSnapOperation
 }
 
 // New create core
-func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation) Core {
+func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation, hostSnap HostSnapOperation) Core {
 	return &core{
 		model:           model,
 		instance:        instance,
@@ -184,6 +194,7 @@ func New(model ModelOperation, instance InstanceOperation, association Associati
 		topo:            topo,
 		host:            host,
 		audit:           audit,
+		hostSnap:        hostSnap,
 	}
 }
 
@@ -214,3 +225,7 @@ func (m *core) HostOperation() HostOperation {
 func (m *core) AuditOperation() AuditOperation {
 	return m.audit
 }
+
+func (m *core) HostSnapOperation() HostSnapOperation {
+	return m.hostSnap
+}
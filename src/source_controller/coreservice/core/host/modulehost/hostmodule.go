@@ -149,6 +149,9 @@ func (mh *ModuleHost) GetHostModuleRelation(ctx core.ContextParams, input *metad
 	if input.ApplicationID > 0 {
 		moduleHostCond.Field(common.BKAppIDField).Eq(input.ApplicationID)
 	}
+	if len(input.AppIDArr) > 0 {
+		moduleHostCond.Field(common.BKAppIDField).In(input.AppIDArr)
+	}
 	if len(input.HostIDArr) > 0 {
 		moduleHostCond.Field(common.BKHostIDField).In(input.HostIDArr)
 	}
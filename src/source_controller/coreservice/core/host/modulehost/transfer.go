@@ -127,10 +127,30 @@ func (t *transferHostModule) Transfer(ctx core.ContextParams, hostID int64) erro
 	if err != nil {
 		return err
 	}
+	// the host left its business, so it's not in any resource pool directory now
+	if t.crossBizTransfer && t.srcBizID != t.bizID {
+		if err := t.clearResourceDirectory(ctx, hostID); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// clearResourceDirectory remove the host from the resource pool directory it's placed in
+func (t *transferHostModule) clearResourceDirectory(ctx core.ContextParams, hostID int64) errors.CCErrorCoder {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKHostIDField).Eq(hostID)
+	cond.Field(common.BKResourceDirIDField).NotEq(0)
+	condMap := util.SetModOwner(cond.ToMapStr(), ctx.SupplierAccount)
+	data := mapstr.MapStr{common.BKResourceDirIDField: 0}
+	if err := t.mh.dbProxy.Table(common.BKTableNameBaseHost).Update(ctx, condMap, data); err != nil {
+		blog.ErrorJSON("clear host resource directory error. err:%s, cond:%s, rid:%s", err.Error(), condMap, ctx.ReqID)
+		return ctx.Error.CCErrorf(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
 func (t *transferHostModule) deleteHost(ctx core.ContextParams, hostID int64) (mapstr.MapStr, errors.CCErrorCoder) {
 	hostCond := condition.CreateCondition()
 	hostCond.Field(common.BKHostIDField).Eq(hostID)
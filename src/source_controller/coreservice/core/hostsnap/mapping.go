@@ -0,0 +1,158 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+var _ core.HostSnapOperation = (*hostSnapManager)(nil)
+
+type hostSnapManager struct {
+	dbProxy dal.RDB
+}
+
+// New create a new host snapshot mapping manager instance
+func New(dbProxy dal.RDB) core.HostSnapOperation {
+	return &hostSnapManager{
+		dbProxy: dbProxy,
+	}
+}
+
+func (m *hostSnapManager) CreateHostSnapMapping(ctx core.ContextParams, mapping metadata.HostSnapMapping) (*metadata.HostSnapMapping, error) {
+	if err := m.validateMapping(ctx, 0, &mapping); err != nil {
+		return nil, err
+	}
+
+	id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameHostSnapMapping)
+	if err != nil {
+		blog.Errorf("create host snapshot mapping, generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	now := metadata.Now()
+	mapping.ID = int64(id)
+	mapping.OwnerID = ctx.SupplierAccount
+	mapping.CreateTime = &now
+	mapping.LastTime = &now
+	if err := m.dbProxy.Table(common.BKTableNameHostSnapMapping).Insert(ctx, mapping); err != nil {
+		blog.Errorf("create host snapshot mapping %+v failed, err: %v, rid: %s", mapping, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	return &mapping, nil
+}
+
+func (m *hostSnapManager) UpdateHostSnapMapping(ctx core.ContextParams, id int64, mapping metadata.HostSnapMapping) error {
+	cond := util.SetModOwner(map[string]interface{}{common.BKFieldID: id}, ctx.SupplierAccount)
+	cnt, err := m.dbProxy.Table(common.BKTableNameHostSnapMapping).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("update host snapshot mapping, find mapping %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if cnt == 0 {
+		return ctx.Error.Error(common.CCErrCommNotFound)
+	}
+	if err := m.validateMapping(ctx, id, &mapping); err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"bk_snap_path":             mapping.Path,
+		common.BKPropertyIDField:   mapping.PropertyID,
+		common.BKPropertyTypeField: mapping.PropertyType,
+		"transform":                mapping.Transform,
+		common.LastTimeField:       time.Now(),
+	}
+	if err := m.dbProxy.Table(common.BKTableNameHostSnapMapping).Update(ctx, cond, data); err != nil {
+		blog.Errorf("update host snapshot mapping %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+func (m *hostSnapManager) DeleteHostSnapMapping(ctx core.ContextParams, id int64) error {
+	cond := util.SetModOwner(map[string]interface{}{common.BKFieldID: id}, ctx.SupplierAccount)
+	if err := m.dbProxy.Table(common.BKTableNameHostSnapMapping).Delete(ctx, cond); err != nil {
+		blog.Errorf("delete host snapshot mapping %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (m *hostSnapManager) SearchHostSnapMapping(ctx core.ContextParams, param metadata.QueryCondition) ([]metadata.HostSnapMapping, uint64, error) {
+	cond := util.SetQueryOwner(param.Condition.ToMapInterface(), ctx.SupplierAccount)
+	cnt, err := m.dbProxy.Table(common.BKTableNameHostSnapMapping).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("search host snapshot mapping failed, cond: %v, err: %v, rid: %s", cond, err, ctx.ReqID)
+		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	mappings := make([]metadata.HostSnapMapping, 0)
+	query := m.dbProxy.Table(common.BKTableNameHostSnapMapping).Find(cond).Fields(param.Fields...).Sort(common.BKFieldID)
+	if param.Limit.Limit > 0 {
+		query = query.Start(uint64(param.Limit.Offset)).Limit(uint64(param.Limit.Limit))
+	}
+	if err := query.All(ctx, &mappings); err != nil {
+		blog.Errorf("search host snapshot mapping failed, cond: %v, err: %v, rid: %s", cond, err, ctx.ReqID)
+		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return mappings, cnt, nil
+}
+
+// validateMapping the mapping should be valid, its target should be an attribute of host
+// which the transformed value fits, and one host attribute has only one mapping.
+// the type of the target attribute is filled to the mapping.
+func (m *hostSnapManager) validateMapping(ctx core.ContextParams, id int64, mapping *metadata.HostSnapMapping) error {
+	if err := mapping.Validate(); err != nil {
+		blog.Errorf("invalid host snapshot mapping %+v, err: %v, rid: %s", mapping, err, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	attrCond := util.SetQueryOwner(map[string]interface{}{
+		common.BKObjIDField:      common.BKInnerObjIDHost,
+		common.BKPropertyIDField: mapping.PropertyID,
+	}, ctx.SupplierAccount)
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(attrCond).All(ctx, &attrs); err != nil {
+		blog.Errorf("find host attribute %s failed, err: %v, rid: %s", mapping.PropertyID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(attrs) == 0 {
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKPropertyIDField)
+	}
+	if err := mapping.ValidateType(attrs[0].PropertyType); err != nil {
+		blog.Errorf("invalid host snapshot mapping %+v, err: %v, rid: %s", mapping, err, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommParamsInvalid, err.Error())
+	}
+	mapping.PropertyType = attrs[0].PropertyType
+
+	dupCond := util.SetModOwner(map[string]interface{}{
+		common.BKPropertyIDField: mapping.PropertyID,
+		common.BKFieldID:         map[string]interface{}{common.BKDBNE: id},
+	}, ctx.SupplierAccount)
+	cnt, err := m.dbProxy.Table(common.BKTableNameHostSnapMapping).Find(dupCond).Count(ctx)
+	if err != nil {
+		blog.Errorf("find host snapshot mapping of %s failed, err: %v, rid: %s", mapping.PropertyID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if cnt > 0 {
+		return ctx.Error.Errorf(common.CCErrCommDuplicateItem, common.BKPropertyIDField)
+	}
+	return nil
+}
@@ -57,6 +57,11 @@ func (m *instanceManager) instCnt(ctx core.ContextParams, objID string, cond map
 func (m *instanceManager) CreateModelInstance(ctx core.ContextParams, objID string, inputParam metadata.CreateModelInstance) (*metadata.CreateOneDataResult, error) {
 	rid := util.ExtractRequestIDFromContext(ctx)
 
+	if common.BKInnerObjIDHost == objID {
+		if err := normalizeHostIP(ctx, inputParam.Data, nil); nil != err {
+			return nil, err
+		}
+	}
 	err := m.validCreateInstanceData(ctx, objID, inputParam.Data)
 	if nil != err {
 		blog.Errorf("CreateModelInstance failed, valid error: %+v, rid: %s", err, rid)
@@ -84,7 +89,13 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 	dataResult := &metadata.CreateManyDataResult{}
 	for itemIdx, item := range inputParam.Datas {
 		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
-		err := m.validCreateInstanceData(ctx, objID, item)
+		var err error
+		if common.BKInnerObjIDHost == objID {
+			err = normalizeHostIP(ctx, item, nil)
+		}
+		if nil == err {
+			err = m.validCreateInstanceData(ctx, objID, item)
+		}
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -137,6 +148,17 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		return nil, ctx.Error.Error(common.CCErrCommNotFound)
 	}
 
+	if common.BKInnerObjIDHost == objID && hasHostIPChange(inputParam.Data) {
+		// the addresses of host are derived from the origin one
+		if 1 != len(origins) {
+			blog.Errorf("UpdateModelInstance can not update the ip of %d hosts at once, rid:%s", len(origins), ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKHostInnerIPField)
+		}
+		if err := normalizeHostIP(ctx, inputParam.Data, origins[0]); nil != err {
+			return nil, err
+		}
+	}
+
 	// 处理事件数据的
 	eh := m.NewEventHandle(objID)
 
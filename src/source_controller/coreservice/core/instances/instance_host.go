@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// hostIPFields the address fields of host, they are not model attributes
+var hostIPFields = []string{
+	common.BKHostIPv4Field,
+	common.BKHostIPv6Field,
+	common.BKHostIPKeyField,
+}
+
+func isHostIPField(objID, key string) bool {
+	return common.BKInnerObjIDHost == objID && util.InStrArr(hostIPFields, key)
+}
+
+// hasHostIPChange check whether the host data changes the addresses of host
+func hasHostIPChange(data mapstr.MapStr) bool {
+	for _, key := range []string{common.BKHostInnerIPField, common.BKHostIPv4Field, common.BKHostIPv6Field} {
+		if _, ok := data[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHostIP normalize the inner ip of the host data, and fill the ipv4
+// and ipv6 address lists with the addresses of inner ip and the extra addresses
+// given by the data. origin is the host before update, it's nil when create.
+// when inner ip is empty, the first address of the lists is used as inner ip.
+func normalizeHostIP(ctx core.ContextParams, data mapstr.MapStr, origin mapstr.MapStr) error {
+	delete(data, common.BKHostIPKeyField)
+	if nil != origin && !hasHostIPChange(data) {
+		return nil
+	}
+
+	innerIPVal, ok := data[common.BKHostInnerIPField]
+	if !ok && nil != origin {
+		innerIPVal = origin[common.BKHostInnerIPField]
+	}
+	innerIPs := make([]string, 0)
+	for _, ip := range util.GetIPsByInterface(innerIPVal) {
+		normalized, ok := util.NormalizeIP(ip)
+		if !ok {
+			blog.Errorf("normalize host ip failed, invalid inner ip %s, rid: %s", ip, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKHostInnerIPField)
+		}
+		if !util.InStrArr(innerIPs, normalized) {
+			innerIPs = append(innerIPs, normalized)
+		}
+	}
+
+	// the extra addresses, keep the origin ones when not given
+	var originInnerIPs []string
+	if nil != origin {
+		originInnerIPs = util.GetIPsByInterface(origin[common.BKHostInnerIPField])
+	}
+	extraIPs := make([]string, 0)
+	for _, key := range []string{common.BKHostIPv4Field, common.BKHostIPv6Field} {
+		val, ok := data[key]
+		if !ok && nil != origin {
+			for _, ip := range util.GetIPsByInterface(origin[key]) {
+				if !util.InStrArr(originInnerIPs, ip) {
+					extraIPs = append(extraIPs, ip)
+				}
+			}
+			continue
+		}
+		ipv4, ipv6, invalid := util.NormalizeIPs(util.GetIPsByInterface(val))
+		if 0 != len(invalid) ||
+			(common.BKHostIPv4Field == key && 0 != len(ipv6)) || (common.BKHostIPv6Field == key && 0 != len(ipv4)) {
+			blog.Errorf("normalize host ip failed, invalid %s %v, rid: %s", key, val, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, key)
+		}
+		extraIPs = append(extraIPs, ipv4...)
+		extraIPs = append(extraIPs, ipv6...)
+	}
+
+	ipv4, ipv6, _ := util.NormalizeIPs(append(innerIPs, extraIPs...))
+	if 0 == len(innerIPs) {
+		if 0 != len(ipv4) {
+			innerIPs = append(innerIPs, ipv4[0])
+		} else if 0 != len(ipv6) {
+			innerIPs = append(innerIPs, ipv6[0])
+		}
+	}
+	if _, ok := data[common.BKHostInnerIPField]; (ok || nil == origin) && 0 != len(innerIPs) {
+		data[common.BKHostInnerIPField] = strings.Join(innerIPs, ",")
+	}
+	data[common.BKHostIPv4Field] = ipv4
+	data[common.BKHostIPv6Field] = ipv6
+	data[common.BKHostIPKeyField] = util.IPKeys(append(ipv4, ipv6...))
+	return nil
+}
+
+// hostIPKeys returns the ip keys of host when the unique key is inner ip,
+// so that the unique check works on any address of the host.
+func (valid *validator) hostIPKeys(key string, data mapstr.MapStr) []string {
+	if common.BKInnerObjIDHost != valid.objID || common.BKHostInnerIPField != key {
+		return nil
+	}
+	switch keys := data[common.BKHostIPKeyField].(type) {
+	case []string:
+		return keys
+	case []interface{}:
+		return util.GetIPsByInterface(keys)
+	}
+	return nil
+}
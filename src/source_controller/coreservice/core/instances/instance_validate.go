@@ -98,7 +98,7 @@ func (m *instanceManager) validCreateInstanceData(ctx core.ContextParams, objID
 			}
 			continue
 		}
-		if util.InStrArr(createIgnoreKeys, key) {
+		if util.InStrArr(createIgnoreKeys, key) || isHostIPField(objID, key) {
 			// ignore the key field
 			continue
 		}
@@ -159,7 +159,7 @@ func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID
 
 	for key, val := range instanceData {
 
-		if util.InStrArr(updateIgnoreKeys, key) {
+		if util.InStrArr(updateIgnoreKeys, key) || isHostIPField(objID, key) {
 			// ignore the key field
 			continue
 		}
@@ -63,6 +63,11 @@ func (valid *validator) validCreateUnique(ctx core.ContextParams, instanceData m
 			if !ok || isEmpty(val) {
 				anyEmpty = true
 			}
+			if ipKeys := valid.hostIPKeys(key, instanceData); 0 != len(ipKeys) {
+				// the host is duplicated when any of its addresses is used by other host
+				cond.Element(&mongo.In{Key: common.BKHostIPKeyField, Val: ipKeys})
+				continue
+			}
 			cond.Element(&mongo.Eq{Key: key, Val: val})
 		}
 
@@ -154,6 +159,11 @@ func (valid *validator) validUpdateUnique(ctx core.ContextParams, instanceData m
 			if !ok || isEmpty(val) {
 				anyEmpty = true
 			}
+			if ipKeys := valid.hostIPKeys(key, mapData); 0 != len(ipKeys) {
+				// the host is duplicated when any of its addresses is used by other host
+				cond.Element(&mongo.In{Key: common.BKHostIPKeyField, Val: ipKeys})
+				continue
+			}
 			cond.Element(&mongo.Eq{Key: key, Val: val})
 		}
 
@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) CreateHostSnapMapping(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	mapping := metadata.HostSnapMapping{}
+	if err := data.MarshalJSONInto(&mapping); nil != err {
+		blog.Errorf("CreateHostSnapMapping MarshalJSONInto error, err: %v, input: %v, rid: %s", err, data, params.ReqID)
+		return nil, err
+	}
+	return s.core.HostSnapOperation().CreateHostSnapMapping(params, mapping)
+}
+
+func (s *coreService) UpdateHostSnapMapping(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams(common.BKFieldID), 10, 64)
+	if err != nil {
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+	mapping := metadata.HostSnapMapping{}
+	if err := data.MarshalJSONInto(&mapping); nil != err {
+		blog.Errorf("UpdateHostSnapMapping MarshalJSONInto error, err: %v, input: %v, rid: %s", err, data, params.ReqID)
+		return nil, err
+	}
+	return nil, s.core.HostSnapOperation().UpdateHostSnapMapping(params, id, mapping)
+}
+
+func (s *coreService) DeleteHostSnapMapping(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams(common.BKFieldID), 10, 64)
+	if err != nil {
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+	return nil, s.core.HostSnapOperation().DeleteHostSnapMapping(params, id)
+}
+
+func (s *coreService) SearchHostSnapMapping(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	cond := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&cond); nil != err {
+		blog.Errorf("SearchHostSnapMapping MarshalJSONInto error, err: %v, input: %v, rid: %s", err, data, params.ReqID)
+		return nil, err
+	}
+	mappings, count, err := s.core.HostSnapOperation().SearchHostSnapMapping(params, cond)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.SearchHostSnapMapping{Count: count, Info: mappings}, nil
+}
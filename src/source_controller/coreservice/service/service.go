@@ -40,6 +40,7 @@ import (
 	"configcenter/src/source_controller/coreservice/core/auditlog"
 	"configcenter/src/source_controller/coreservice/core/datasynchronize"
 	"configcenter/src/source_controller/coreservice/core/host"
+	"configcenter/src/source_controller/coreservice/core/hostsnap"
 	"configcenter/src/source_controller/coreservice/core/instances"
 	"configcenter/src/source_controller/coreservice/core/mainline"
 	"configcenter/src/source_controller/coreservice/core/model"
@@ -119,6 +120,7 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 		mainline.New(db),
 		host.New(db, cache),
 		auditlog.New(db),
+		hostsnap.New(db),
 	)
 	return nil
 }
@@ -124,6 +124,13 @@ func (s *coreService) audit() {
 	s.addAction(http.MethodPost, "/read/auditlog", s.SearchAuditLog, nil)
 }
 
+func (s *coreService) hostSnap() {
+	s.addAction(http.MethodPost, "/create/hostsnap/mapping", s.CreateHostSnapMapping, nil)
+	s.addAction(http.MethodPut, "/update/hostsnap/mapping/{id}", s.UpdateHostSnapMapping, nil)
+	s.addAction(http.MethodDelete, "/delete/hostsnap/mapping/{id}", s.DeleteHostSnapMapping, nil)
+	s.addAction(http.MethodPost, "/read/hostsnap/mapping", s.SearchHostSnapMapping, nil)
+}
+
 func (s *coreService) initService() {
 	s.initModelClassification()
 	s.initModel()
@@ -136,4 +143,5 @@ func (s *coreService) initService() {
 	s.initMainline()
 	s.host()
 	s.audit()
+	s.hostSnap()
 }
@@ -31,39 +31,54 @@ func (lgc *Logics) LockHost(ctx context.Context, header http.Header, input *meta
 	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 	user := util.GetUser(header)
 
-	fields := []string{common.BKHostIDField, common.BKHostInnerIPField}
-	condition := mapstr.MapStr{common.BKCloudIDField: input.CloudID, common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: input.IPS}}
-	hostInfos := make([]mapstr.MapStr, 0)
-	err := lgc.Instance.Table(common.BKTableNameBaseHost).
-		Find(util.SetQueryOwner(condition, util.GetOwnerID(header))).Fields(fields...).Limit(uint64(len(input.IPS))).All(ctx, &hostInfos)
+	lockIPs, diffIP, err := lgc.getHostLockIPs(ctx, header, input.CloudID, input.IPS)
 	if nil != err {
 		blog.Errorf("lcok host, query host from db error, error:%s ,logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
 		return defErr.Errorf(common.CCErrCommDBSelectFailed)
 	}
-
-	diffIP := diffHostLockIP(input.IPS, hostInfos)
 	if 0 != len(diffIP) {
 		blog.Errorf("lock host, not found ip:%+v,logID:%s", diffIP, util.GetHTTPCCRequestID(header))
 		return defErr.Errorf(common.CCErrCommParamsIsInvalid, " ip_list["+strings.Join(diffIP, ",")+"]")
 	}
 
+	if err := lgc.clearExpiredHostLock(ctx, header); nil != err {
+		return defErr.Errorf(common.CCErrCommDBDeleteFailed)
+	}
+
 	var insertDataArr []interface{}
 	ts := time.Now().UTC()
-	for _, ip := range input.IPS {
+	for _, ip := range uniqueLockIPs(input.IPS, lockIPs) {
 		conds := mapstr.MapStr{common.BKHostInnerIPField: ip, common.BKCloudIDField: input.CloudID}
-		cnt, err := lgc.Instance.Table(common.BKTableNameHostLock).Find(util.SetQueryOwner(conds, util.GetOwnerID(header))).Count(ctx)
+		existLocks := make([]metadata.HostLockData, 0)
+		err := lgc.Instance.Table(common.BKTableNameHostLock).Find(util.SetQueryOwner(conds, util.GetOwnerID(header))).All(ctx, &existLocks)
 		if nil != err {
 			blog.Errorf("lcok host, query host lock from db error, error:%s, logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
 			return defErr.Errorf(common.CCErrCommDBSelectFailed)
 		}
-		if 0 == cnt {
+		if 0 == len(existLocks) {
 			insertDataArr = append(insertDataArr, metadata.HostLockData{
 				User:       user,
 				IP:         ip,
 				CloudID:    input.CloudID,
+				Reason:     input.Reason,
+				ExpireTime: input.ExpireTime,
 				CreateTime: ts,
 				OwnerID:    util.GetOwnerID(header),
 			})
+			continue
+		}
+
+		// the lock owner can renew the lock, other user can not
+		existLock := existLocks[0]
+		if existLock.User != user {
+			blog.Errorf("lock host, host %s is locked by %s, logID:%s", ip, existLock.User, util.GetHTTPCCRequestID(header))
+			return defErr.Errorf(common.CCErrHostLockedByOtherUser, ip, existLock.User, existLock.Reason)
+		}
+		data := mapstr.MapStr{"reason": input.Reason, "expire_time": input.ExpireTime}
+		err = lgc.Instance.Table(common.BKTableNameHostLock).Update(ctx, util.SetModOwner(conds, util.GetOwnerID(header)), data)
+		if nil != err {
+			blog.Errorf("lcok host, renew host lock error, error:%s, logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
+			return defErr.Errorf(common.CCErrCommDBUpdateFailed)
 		}
 	}
 
@@ -80,9 +95,42 @@ func (lgc *Logics) LockHost(ctx context.Context, header http.Header, input *meta
 func (lgc *Logics) UnlockHost(ctx context.Context, header http.Header, input *metadata.HostLockRequest) errors.CCError {
 
 	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	user := util.GetUser(header)
 
-	conds := mapstr.MapStr{common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: input.IPS}, common.BKCloudIDField: input.CloudID}
-	err := lgc.Instance.Table(common.BKTableNameHostLock).Delete(ctx, util.SetModOwner(conds, util.GetOwnerID(header)))
+	lockIPs, _, err := lgc.getHostLockIPs(ctx, header, input.CloudID, input.IPS)
+	if nil != err {
+		blog.Errorf("unlock host, query host from db error, error:%s,logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
+		return defErr.Errorf(common.CCErrCommDBSelectFailed)
+	}
+	ips := uniqueLockIPs(input.IPS, lockIPs)
+
+	conds := mapstr.MapStr{common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: ips}, common.BKCloudIDField: input.CloudID}
+	if !input.Force {
+		// only the lock owner can release the lock without force, the unexpired locks of
+		// other users are all checked, as the delete below removes every lock of the ips
+		otherConds := mapstr.MapStr{
+			common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: ips},
+			common.BKCloudIDField:     input.CloudID,
+			"bk_user":                 mapstr.MapStr{common.BKDBNE: user},
+			common.BKDBOR: []mapstr.MapStr{
+				{"expire_time": nil},
+				{"expire_time": mapstr.MapStr{common.BKDBGT: time.Now().UTC()}},
+			},
+		}
+		otherLocks := make([]metadata.HostLockData, 0)
+		err := lgc.Instance.Table(common.BKTableNameHostLock).Find(util.SetQueryOwner(otherConds, util.GetOwnerID(header))).All(ctx, &otherLocks)
+		if nil != err {
+			blog.Errorf("unlock host, query host lock from db error, error:%s,logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
+			return defErr.Errorf(common.CCErrCommDBSelectFailed)
+		}
+		if 0 != len(otherLocks) {
+			otherLock := otherLocks[0]
+			blog.Errorf("unlock host, host %s is locked by %s,logID:%s", otherLock.IP, otherLock.User, util.GetHTTPCCRequestID(header))
+			return defErr.Errorf(common.CCErrHostLockedByOtherUser, otherLock.IP, otherLock.User, otherLock.Reason)
+		}
+	}
+
+	err = lgc.Instance.Table(common.BKTableNameHostLock).Delete(ctx, util.SetModOwner(conds, util.GetOwnerID(header)))
 
 	if nil != err {
 		blog.Errorf("unlock host, delete host lock from db error, error:%s,logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
@@ -95,32 +143,118 @@ func (lgc *Logics) UnlockHost(ctx context.Context, header http.Header, input *me
 func (lgc *Logics) QueryHostLock(ctx context.Context, header http.Header, input *metadata.QueryHostLockRequest) ([]metadata.HostLockData, errors.CCError) {
 	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 
+	if err := lgc.clearExpiredHostLock(ctx, header); nil != err {
+		return nil, defErr.Errorf(common.CCErrCommDBDeleteFailed)
+	}
+
+	lockIPs, _, err := lgc.getHostLockIPs(ctx, header, input.CloudID, input.IPS)
+	if nil != err {
+		blog.Errorf("query lcok host, query host from db error, error:%s, logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
+		return nil, defErr.Errorf(common.CCErrCommDBSelectFailed)
+	}
+	ips := uniqueLockIPs(input.IPS, lockIPs)
+
 	hostLockInfoArr := make([]metadata.HostLockData, 0)
-	conds := mapstr.MapStr{common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: input.IPS}, common.BKCloudIDField: input.CloudID}
-	err := lgc.Instance.Table(common.BKTableNameHostLock).Find(util.SetModOwner(conds, util.GetOwnerID(header))).Limit(uint64(len(input.IPS))).All(ctx, &hostLockInfoArr)
+	conds := mapstr.MapStr{common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: ips}, common.BKCloudIDField: input.CloudID}
+	err = lgc.Instance.Table(common.BKTableNameHostLock).Find(util.SetModOwner(conds, util.GetOwnerID(header))).Limit(uint64(len(ips))).All(ctx, &hostLockInfoArr)
 	if nil != err {
 		blog.Errorf("query lcok host, query host lock from db error, error:%s, logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
 		return nil, defErr.Errorf(common.CCErrCommDBSelectFailed)
 	}
-	return hostLockInfoArr, err
+
+	// the lock is returned with the requested ip, so that the caller can
+	// find it with any address of the host
+	lockMap := make(map[string]metadata.HostLockData, len(hostLockInfoArr))
+	for _, lock := range hostLockInfoArr {
+		lockMap[lock.IP] = lock
+	}
+	result := make([]metadata.HostLockData, 0, len(hostLockInfoArr))
+	for _, ip := range input.IPS {
+		if lock, ok := lockMap[lockIPs[ip]]; ok {
+			lock.IP = ip
+			result = append(result, lock)
+		}
+	}
+	return result, nil
 }
 
-func diffHostLockIP(ips []string, hostInfos []mapstr.MapStr) []string {
-	mapInnerIP := make(map[string]bool, 0)
+// clearExpiredHostLock release the expired host locks, it's called before the
+// host lock is used, so that expired lock never takes effect.
+func (lgc *Logics) clearExpiredHostLock(ctx context.Context, header http.Header) error {
+	conds := mapstr.MapStr{"expire_time": mapstr.MapStr{common.BKDBLTE: time.Now().UTC()}}
+	err := lgc.Instance.Table(common.BKTableNameHostLock).Delete(ctx, util.SetModOwner(conds, util.GetOwnerID(header)))
+	if nil != err {
+		blog.Errorf("clear expired host lock from db error, error:%s,logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
+		return err
+	}
+	return nil
+}
+
+// getHostLockIPs map the requested ips to the inner ip of the hosts, the lock
+// of host is saved with its inner ip, so that it can be found by any address of
+// the host. notFound is the ips not belong to any host, they are mapped to itself.
+func (lgc *Logics) getHostLockIPs(ctx context.Context, header http.Header, cloudID int64, ips []string) (lockIPs map[string]string, notFound []string, err error) {
+	lockIPs = make(map[string]string, len(ips))
+	if 0 == len(ips) {
+		return lockIPs, nil, nil
+	}
+
+	fields := []string{common.BKHostIDField, common.BKHostInnerIPField, common.BKHostIPv4Field, common.BKHostIPv6Field}
+	condition := mapstr.MapStr{
+		common.BKCloudIDField: cloudID,
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: ips}},
+			{common.BKHostIPKeyField: mapstr.MapStr{common.BKDBIN: util.IPKeys(ips)}},
+		},
+	}
+	hostInfos := make([]mapstr.MapStr, 0)
+	err = lgc.Instance.Table(common.BKTableNameBaseHost).
+		Find(util.SetQueryOwner(condition, util.GetOwnerID(header))).Fields(fields...).All(ctx, &hostInfos)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	addrInnerIP := make(map[string]string, 0)
 	for _, hostInfo := range hostInfos {
 		innerIP, err := hostInfo.String(common.BKHostInnerIPField)
-		if nil != err {
-			blog.Warnf("different host lock IP not inner ip")
+		if nil != err || "" == innerIP {
+			blog.Warnf("host lock, host %v has no inner ip", hostInfo[common.BKHostIDField])
 			continue
 		}
-		mapInnerIP[innerIP] = true
+		addrInnerIP[innerIP] = innerIP
+		addresses := util.GetIPsByInterface(innerIP)
+		addresses = append(addresses, util.GetIPsByInterface(hostInfo[common.BKHostIPv4Field])...)
+		addresses = append(addresses, util.GetIPsByInterface(hostInfo[common.BKHostIPv6Field])...)
+		for _, addr := range addresses {
+			if normalized, ok := util.NormalizeIP(addr); ok {
+				addrInnerIP[normalized] = innerIP
+			}
+		}
 	}
-	var diffIPS []string
+
 	for _, ip := range ips {
-		_, ok := mapInnerIP[ip]
+		innerIP, ok := addrInnerIP[ip]
 		if !ok {
-			diffIPS = append(diffIPS, ip)
+			if normalized, valid := util.NormalizeIP(ip); valid {
+				innerIP, ok = addrInnerIP[normalized]
+			}
+		}
+		if !ok {
+			notFound = append(notFound, ip)
+			innerIP = ip
+		}
+		lockIPs[ip] = innerIP
+	}
+	return lockIPs, notFound, nil
+}
+
+// uniqueLockIPs returns the lock ips of the requested ips without duplicate
+func uniqueLockIPs(ips []string, lockIPs map[string]string) []string {
+	result := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if lockIP := lockIPs[ip]; !util.InStrArr(result, lockIP) {
+			result = append(result, lockIP)
 		}
 	}
-	return diffIPS
+	return result
 }
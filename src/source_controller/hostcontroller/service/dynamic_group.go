@@ -0,0 +1,309 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// userAPISnapshotLimit the max snapshots kept for each dynamic group
+const userAPISnapshotLimit = 100
+
+// SearchScheduledUserConfig returns the user configs which should be evaluated periodically
+func (s *Service) SearchScheduledUserConfig(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	condition := common.KvMap{"eval_interval": common.KvMap{common.BKDBGT: 0}}
+	condition = util.SetModOwner(condition, ownerID)
+	result := make([]meta.UserConfigMeta, 0)
+	err := s.Instance.Table(common.BKTableNameUserAPI).Find(condition).All(ctx, &result)
+	if err != nil {
+		blog.Errorf("search scheduled user api failed, err: %v, condition: %v", err, condition)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.ScheduledUserConfigResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     result,
+	})
+}
+
+// SaveUserConfigSnapshot compare the members of the dynamic group with the latest snapshot,
+// save a new snapshot and send the join and leave events when the members changed.
+// the first evaluation only saves the snapshot as the baseline, no events are sent.
+func (s *Service) SaveUserConfigSnapshot(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	id := req.PathParameter("id")
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("save user api[%s] snapshot failed, invalid appid[%s], err: %v", id, req.PathParameter(common.BKAppIDField), err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommParamsIsInvalid)})
+		return
+	}
+
+	input := new(meta.SaveDynamicGroupSnapshot)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("save user api[%s] snapshot failed with decode body, err: %v", id, err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	params := common.KvMap{"id": id, common.BKAppIDField: appID}
+	params = util.SetModOwner(params, ownerID)
+	group := new(meta.UserConfigMeta)
+	if err := s.Instance.Table(common.BKTableNameUserAPI).Find(params).One(ctx, group); err != nil {
+		if s.Instance.IsNotFoundError(err) {
+			blog.V(5).Infof("save user api snapshot, user api not exists, params:%v", params)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return
+		}
+		blog.Errorf("save user api snapshot, get user api failed, err: %v, params:%v", err, params)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	objID := group.GetObjectID()
+	instIDField := common.GetInstIDField(objID)
+	members := make(map[int64]mapstr.MapStr, len(input.Members))
+	memberIDs := make([]int64, 0, len(input.Members))
+	for _, member := range input.Members {
+		instID, err := util.GetInt64ByInterface(member[instIDField])
+		if err != nil {
+			blog.Errorf("save user api[%s] snapshot, member without valid %s, member: %v", id, instIDField, member)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, instIDField)})
+			return
+		}
+		if _, exists := members[instID]; !exists {
+			memberIDs = append(memberIDs, instID)
+		}
+		members[instID] = member
+	}
+	sort.Slice(memberIDs, func(i, j int) bool { return memberIDs[i] < memberIDs[j] })
+
+	latest := make([]meta.DynamicGroupSnapshot, 0)
+	err = s.Instance.Table(common.BKTableNameUserAPISnapshot).Find(params).Sort("-eval_time").Limit(1).All(ctx, &latest)
+	if err != nil {
+		blog.Errorf("save user api snapshot, get latest snapshot failed, err: %v, params:%v", err, params)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	now := time.Now().UTC()
+	snapshot := meta.DynamicGroupSnapshot{
+		ID:       id,
+		AppID:    appID,
+		ObjectID: objID,
+		Members:  memberIDs,
+		Joined:   make([]int64, 0),
+		Left:     make([]int64, 0),
+		EvalTime: now,
+		OwnerID:  ownerID,
+	}
+	if 0 != len(latest) {
+		snapshot.Joined, snapshot.Left = diffDynamicGroupMembers(latest[0].Members, memberIDs)
+	}
+
+	err = s.Instance.Table(common.BKTableNameUserAPI).Update(ctx, params, common.KvMap{"last_eval_time": now})
+	if err != nil {
+		blog.Errorf("save user api snapshot, update last eval time failed, err: %v, params:%v", err, params)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	if 0 != len(latest) && 0 == len(snapshot.Joined) && 0 == len(snapshot.Left) {
+		resp.WriteEntity(meta.DynamicGroupSnapshotResult{
+			BaseResp: meta.SuccessBaseResp,
+			Data:     snapshot,
+		})
+		return
+	}
+
+	// the events are pushed before the snapshot is saved, the next evaluation diffs with the
+	// previous snapshot if the push fails, so that the changes are not lost. the events may be
+	// pushed again if the snapshot fails to save, the subscribers should tolerate it.
+	events := dynamicGroupEvents(pheader, group, snapshot, members)
+	if 0 != len(events) {
+		if err := s.EventC.Push(ctx, events...); err != nil {
+			blog.Errorf("save user api snapshot, create event failed, err: %v, snapshot: %+v", err, snapshot)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrEventPushEventFailed)})
+			return
+		}
+	}
+
+	if err := s.Instance.Table(common.BKTableNameUserAPISnapshot).Insert(ctx, snapshot); err != nil {
+		blog.Errorf("save user api snapshot failed, err: %v, snapshot: %+v", err, snapshot)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+	if err := s.pruneUserConfigSnapshot(ctx, params); err != nil {
+		// the snapshot is saved, the stale ones will be pruned next time
+		blog.Warnf("prune user api snapshot failed, err: %v, params: %v", err, params)
+	}
+
+	resp.WriteEntity(meta.DynamicGroupSnapshotResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     snapshot,
+	})
+}
+
+// SearchUserConfigSnapshot returns the snapshots of the dynamic group, the latest first
+func (s *Service) SearchUserConfigSnapshot(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	id := req.PathParameter("id")
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	appID, err := strconv.ParseInt(req.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("search user api[%s] snapshot failed, invalid appid[%s], err: %v", id, req.PathParameter(common.BKAppIDField), err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommParamsIsInvalid)})
+		return
+	}
+
+	page := new(meta.BasePage)
+	if err := json.NewDecoder(req.Request.Body).Decode(page); err != nil {
+		blog.Errorf("search user api[%s] snapshot failed with decode body, err: %v", id, err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 >= page.Limit {
+		page.Limit = 20
+	}
+
+	params := common.KvMap{"id": id, common.BKAppIDField: appID}
+	params = util.SetModOwner(params, ownerID)
+	count, err := s.Instance.Table(common.BKTableNameUserAPISnapshot).Find(params).Count(ctx)
+	if err != nil {
+		blog.Errorf("search user api snapshot failed, err: %v, params: %v", err, params)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	result := make([]meta.DynamicGroupSnapshot, 0)
+	err = s.Instance.Table(common.BKTableNameUserAPISnapshot).Find(params).Sort("-eval_time").
+		Start(uint64(page.Start)).Limit(uint64(page.Limit)).All(ctx, &result)
+	if err != nil {
+		blog.Errorf("search user api snapshot failed, err: %v, params: %v", err, params)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.SearchDynamicGroupSnapshotResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data: meta.SearchDynamicGroupSnapshot{
+			Count: count,
+			Info:  result,
+		},
+	})
+}
+
+// pruneUserConfigSnapshot remove the snapshots beyond userAPISnapshotLimit
+func (s *Service) pruneUserConfigSnapshot(ctx context.Context, params common.KvMap) error {
+	stale := make([]meta.DynamicGroupSnapshot, 0)
+	err := s.Instance.Table(common.BKTableNameUserAPISnapshot).Find(params).Fields("eval_time").Sort("-eval_time").
+		Start(userAPISnapshotLimit).Limit(1).All(ctx, &stale)
+	if err != nil {
+		return err
+	}
+	if 0 == len(stale) {
+		return nil
+	}
+
+	cond := common.KvMap{"eval_time": common.KvMap{common.BKDBLTE: stale[0].EvalTime}}
+	for key, val := range params {
+		cond[key] = val
+	}
+	return s.Instance.Table(common.BKTableNameUserAPISnapshot).Delete(ctx, cond)
+}
+
+// diffDynamicGroupMembers returns the instances joined and left the dynamic group
+func diffDynamicGroupMembers(previous, current []int64) ([]int64, []int64) {
+	joined := make([]int64, 0)
+	left := make([]int64, 0)
+	previousMap := make(map[int64]bool, len(previous))
+	for _, instID := range previous {
+		previousMap[instID] = true
+	}
+	currentMap := make(map[int64]bool, len(current))
+	for _, instID := range current {
+		currentMap[instID] = true
+		if !previousMap[instID] {
+			joined = append(joined, instID)
+		}
+	}
+	for _, instID := range previous {
+		if !currentMap[instID] {
+			left = append(left, instID)
+		}
+	}
+	return joined, left
+}
+
+// dynamicGroupEvents build the join and leave events of the snapshot
+func dynamicGroupEvents(header http.Header, group *meta.UserConfigMeta, snapshot meta.DynamicGroupSnapshot, members map[int64]mapstr.MapStr) []*meta.EventInst {
+	events := make([]*meta.EventInst, 0)
+	if 0 != len(snapshot.Joined) {
+		event := eventclient.NewEventWithHeader(header)
+		event.EventType = meta.EventTypeDynamicGroup
+		event.ObjType = meta.EventObjTypeDynamicGroup
+		event.Action = meta.EventActionCreate
+		for _, instID := range snapshot.Joined {
+			event.Data = append(event.Data, meta.EventData{CurData: dynamicGroupEventData(group, snapshot.ObjectID, instID, members[instID])})
+		}
+		events = append(events, event)
+	}
+	if 0 != len(snapshot.Left) {
+		event := eventclient.NewEventWithHeader(header)
+		event.EventType = meta.EventTypeDynamicGroup
+		event.ObjType = meta.EventObjTypeDynamicGroup
+		event.Action = meta.EventActionDelete
+		for _, instID := range snapshot.Left {
+			event.Data = append(event.Data, meta.EventData{PreData: dynamicGroupEventData(group, snapshot.ObjectID, instID, nil)})
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func dynamicGroupEventData(group *meta.UserConfigMeta, objID string, instID int64, inst mapstr.MapStr) mapstr.MapStr {
+	return mapstr.MapStr{
+		"id":                 group.ID,
+		"name":               group.Name,
+		common.BKAppIDField:  group.AppID,
+		common.BKObjIDField:  objID,
+		common.BKInstIDField: instID,
+		"inst":               inst,
+	}
+}
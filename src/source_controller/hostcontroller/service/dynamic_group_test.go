@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+)
+
+func TestDiffDynamicGroupMembers(t *testing.T) {
+	tests := []struct {
+		name       string
+		previous   []int64
+		current    []int64
+		wantJoined []int64
+		wantLeft   []int64
+	}{
+		{"no change", []int64{1, 2}, []int64{1, 2}, []int64{}, []int64{}},
+		{"first members", nil, []int64{1, 2}, []int64{1, 2}, []int64{}},
+		{"all left", []int64{1, 2}, nil, []int64{}, []int64{1, 2}},
+		{"join and leave", []int64{1, 2, 3}, []int64{2, 3, 4, 5}, []int64{4, 5}, []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			joined, left := diffDynamicGroupMembers(tt.previous, tt.current)
+			if !reflect.DeepEqual(joined, tt.wantJoined) {
+				t.Errorf("diffDynamicGroupMembers() joined = %v, want %v", joined, tt.wantJoined)
+			}
+			if !reflect.DeepEqual(left, tt.wantLeft) {
+				t.Errorf("diffDynamicGroupMembers() left = %v, want %v", left, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestDynamicGroupEvents(t *testing.T) {
+	group := &meta.UserConfigMeta{ID: "group", Name: "web hosts", AppID: 2}
+	members := map[int64]mapstr.MapStr{4: {common.BKHostIDField: int64(4)}}
+
+	snapshot := meta.DynamicGroupSnapshot{ObjectID: common.BKInnerObjIDHost, Joined: []int64{}, Left: []int64{}}
+	if events := dynamicGroupEvents(http.Header{}, group, snapshot, members); len(events) != 0 {
+		t.Errorf("dynamicGroupEvents() without change got %d events, want 0", len(events))
+	}
+
+	snapshot.Joined = []int64{4}
+	snapshot.Left = []int64{1, 3}
+	events := dynamicGroupEvents(http.Header{}, group, snapshot, members)
+	if len(events) != 2 {
+		t.Fatalf("dynamicGroupEvents() got %d events, want 2", len(events))
+	}
+	if events[0].Action != meta.EventActionCreate || len(events[0].Data) != 1 {
+		t.Errorf("dynamicGroupEvents() join event = %+v", events[0])
+	}
+	if inst := events[0].Data[0].CurData.(mapstr.MapStr)["inst"]; !reflect.DeepEqual(inst, members[4]) {
+		t.Errorf("dynamicGroupEvents() join event inst = %v, want %v", inst, members[4])
+	}
+	if events[1].Action != meta.EventActionDelete || len(events[1].Data) != 2 {
+		t.Errorf("dynamicGroupEvents() leave event = %+v", events[1])
+	}
+}
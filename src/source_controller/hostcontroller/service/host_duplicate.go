@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const hostDuplicateIDField = "bk_duplicate_id"
+
+// SaveHostDuplicates replace the pending duplicates with the new detected ones,
+// the groups ignored before are skipped, the groups detected again keep their id.
+func (s *Service) SaveHostDuplicates(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.SaveHostDuplicates)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("save host duplicates failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	ignored := make([]meta.HostDuplicate, 0)
+	ignoredCond := util.SetModOwner(common.KvMap{"status": meta.HostDuplicateStatusIgnored}, ownerID)
+	if err := s.Instance.Table(common.BKTableNameHostDuplicate).Find(ignoredCond).Fields("fingerprint").All(ctx, &ignored); err != nil {
+		blog.Errorf("save host duplicates, get ignored duplicates failed, err: %v, condition: %v", err, ignoredCond)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	ignoredFingerprints := make(map[string]bool)
+	for _, dup := range ignored {
+		ignoredFingerprints[dup.Fingerprint] = true
+	}
+
+	// the pending duplicates detected again keep their id, so that the id held by clients
+	// to merge or ignore them is still valid, the ones not detected again are removed.
+	pending := make([]meta.HostDuplicate, 0)
+	pendingCond := util.SetModOwner(common.KvMap{"status": meta.HostDuplicateStatusPending}, ownerID)
+	err := s.Instance.Table(common.BKTableNameHostDuplicate).Find(pendingCond).Fields(hostDuplicateIDField, "fingerprint").All(ctx, &pending)
+	if err != nil {
+		blog.Errorf("save host duplicates, get pending duplicates failed, err: %v, condition: %v", err, pendingCond)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	pendingIDs := make(map[string]int64)
+	for _, dup := range pending {
+		pendingIDs[dup.Fingerprint] = dup.DuplicateID
+	}
+
+	now := time.Now().UTC()
+	detected := make(map[string]bool)
+	for _, dup := range input.Duplicates {
+		dup.Fingerprint = meta.HostDuplicateFingerprint(dup.HostIDs)
+		if ignoredFingerprints[dup.Fingerprint] || detected[dup.Fingerprint] {
+			continue
+		}
+		detected[dup.Fingerprint] = true
+
+		if dupID, exists := pendingIDs[dup.Fingerprint]; exists {
+			filter := util.SetModOwner(common.KvMap{hostDuplicateIDField: dupID}, ownerID)
+			data := common.KvMap{"score": dup.Score, "matched_keys": dup.MatchedKeys, "last_time": now}
+			if err := s.Instance.Table(common.BKTableNameHostDuplicate).Update(ctx, filter, data); err != nil {
+				blog.Errorf("save host duplicates, update duplicate failed, err: %v, filter: %v, data: %v", err, filter, data)
+				resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+				return
+			}
+			continue
+		}
+
+		id, err := s.Instance.NextSequence(ctx, common.BKTableNameHostDuplicate)
+		if err != nil {
+			blog.Errorf("save host duplicates, get id failed, err: %v", err)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+			return
+		}
+		dup.DuplicateID = int64(id)
+		dup.Status = meta.HostDuplicateStatusPending
+		dup.OwnerID = ownerID
+		dup.DetectTime = now
+		dup.LastTime = now
+		if err := s.Instance.Table(common.BKTableNameHostDuplicate).Insert(ctx, dup); err != nil {
+			blog.Errorf("save host duplicates failed, err: %v, duplicate: %+v", err, dup)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+			return
+		}
+	}
+
+	staleIDs := make([]int64, 0)
+	for fingerprint, dupID := range pendingIDs {
+		if !detected[fingerprint] {
+			staleIDs = append(staleIDs, dupID)
+		}
+	}
+	if 0 != len(staleIDs) {
+		staleCond := util.SetModOwner(common.KvMap{
+			"status":             meta.HostDuplicateStatusPending,
+			hostDuplicateIDField: common.KvMap{common.BKDBIN: staleIDs},
+		}, ownerID)
+		if err := s.Instance.Table(common.BKTableNameHostDuplicate).Delete(ctx, staleCond); err != nil {
+			blog.Errorf("save host duplicates, delete stale duplicates failed, err: %v, condition: %v", err, staleCond)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+			return
+		}
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// UpdateHostDuplicateStatus mark the duplicate as ignored or merged
+func (s *Service) UpdateHostDuplicateStatus(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	dupID, err := strconv.ParseInt(req.PathParameter(hostDuplicateIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("update host duplicate failed, invalid id[%s], err: %v", req.PathParameter(hostDuplicateIDField), err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, hostDuplicateIDField)})
+		return
+	}
+
+	input := struct {
+		Status string `json:"status"`
+	}{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("update host duplicate failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	switch input.Status {
+	case meta.HostDuplicateStatusIgnored, meta.HostDuplicateStatusMerged:
+	default:
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "status")})
+		return
+	}
+
+	filter := util.SetModOwner(common.KvMap{hostDuplicateIDField: dupID}, ownerID)
+	data := common.KvMap{"status": input.Status, "last_time": time.Now().UTC()}
+	if err := s.Instance.Table(common.BKTableNameHostDuplicate).Update(ctx, filter, data); err != nil {
+		blog.Errorf("update host duplicate failed, err: %v, filter: %v, data: %v", err, filter, data)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchHostDuplicates search the duplicates, the highest score first by default
+func (s *Service) SearchHostDuplicates(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("search host duplicates failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := make(map[string]interface{})
+	if nil != input.Condition {
+		cond, ok := input.Condition.(map[string]interface{})
+		if !ok {
+			blog.Errorf("search host duplicates failed, invalid condition: %v", input.Condition)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "condition")})
+			return
+		}
+		condition = cond
+	}
+	condition = util.SetModOwner(condition, ownerID)
+	if 0 == input.Limit {
+		input.Limit = common.BKNoLimit
+	}
+	if "" == input.Sort {
+		input.Sort = "-score"
+	}
+
+	count, err := s.Instance.Table(common.BKTableNameHostDuplicate).Find(condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("search host duplicates failed, err: %v, condition: %v", err, condition)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	result := make([]meta.HostDuplicate, 0)
+	err = s.Instance.Table(common.BKTableNameHostDuplicate).Find(condition).Sort(input.Sort).
+		Start(uint64(input.Start)).Limit(uint64(input.Limit)).All(ctx, &result)
+	if err != nil {
+		blog.Errorf("search host duplicates failed, err: %v, condition: %v", err, condition)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.SearchHostDuplicateResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data: meta.SearchHostDuplicate{
+			Count: count,
+			Info:  result,
+		},
+	})
+}
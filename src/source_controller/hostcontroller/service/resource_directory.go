@@ -0,0 +1,335 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// AddResourceDirectory create a directory in the resource pool
+func (s *Service) AddResourceDirectory(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	dir := new(meta.ResourceDirectory)
+	if err := json.NewDecoder(req.Request.Body).Decode(dir); err != nil {
+		blog.Errorf("add resource directory failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if "" == dir.DirName {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "bk_dir_name")})
+		return
+	}
+	if err := s.validateResourceDirectory(ctx, defErr, ownerID, 0, dir.ParentID, dir.DirName); err != nil {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	id, err := s.Instance.NextSequence(ctx, common.BKTableNameResourceDirectory)
+	if err != nil {
+		blog.Errorf("add resource directory, get id failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	now := time.Now().UTC()
+	dir.DirID = int64(id)
+	dir.OwnerID = ownerID
+	dir.CreateTime = now
+	dir.LastTime = now
+	if err := s.Instance.Table(common.BKTableNameResourceDirectory).Insert(ctx, dir); err != nil {
+		blog.Errorf("add resource directory failed, err: %v, directory: %+v", err, dir)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.ResourceDirectoryResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     *dir,
+	})
+}
+
+// UpdateResourceDirectory rename the directory or move it to another parent directory
+func (s *Service) UpdateResourceDirectory(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	dirID, err := strconv.ParseInt(req.PathParameter(common.BKResourceDirIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("update resource directory failed, invalid id[%s], err: %v", req.PathParameter(common.BKResourceDirIDField), err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKResourceDirIDField)})
+		return
+	}
+
+	input := make(map[string]interface{})
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("update resource directory failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	filter := common.KvMap{common.BKResourceDirIDField: dirID}
+	filter = util.SetModOwner(filter, ownerID)
+	dir := new(meta.ResourceDirectory)
+	if err := s.Instance.Table(common.BKTableNameResourceDirectory).Find(filter).One(ctx, dir); err != nil {
+		if s.Instance.IsNotFoundError(err) {
+			blog.Errorf("update resource directory, directory not exists, filter: %v", filter)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return
+		}
+		blog.Errorf("update resource directory, get directory failed, err: %v, filter: %v", err, filter)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	data := common.KvMap{"last_time": time.Now().UTC()}
+	if name, ok := input["bk_dir_name"]; ok {
+		dir.DirName = util.GetStrByInterface(name)
+		if "" == dir.DirName {
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "bk_dir_name")})
+			return
+		}
+		data["bk_dir_name"] = dir.DirName
+	}
+	if parent, ok := input["bk_parent_id"]; ok {
+		if dir.ParentID, err = util.GetInt64ByInterface(parent); err != nil {
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "bk_parent_id")})
+			return
+		}
+		data["bk_parent_id"] = dir.ParentID
+	}
+	if err := s.validateResourceDirectory(ctx, defErr, ownerID, dirID, dir.ParentID, dir.DirName); err != nil {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	if err := s.Instance.Table(common.BKTableNameResourceDirectory).Update(ctx, filter, data); err != nil {
+		blog.Errorf("update resource directory failed, err: %v, filter: %v, data: %v", err, filter, data)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.ResourceDirectoryResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     *dir,
+	})
+}
+
+// DeleteResourceDirectory delete the directory, only the empty directory can be deleted
+func (s *Service) DeleteResourceDirectory(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	dirID, err := strconv.ParseInt(req.PathParameter(common.BKResourceDirIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("delete resource directory failed, invalid id[%s], err: %v", req.PathParameter(common.BKResourceDirIDField), err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKResourceDirIDField)})
+		return
+	}
+
+	subCond := util.SetModOwner(common.KvMap{"bk_parent_id": dirID}, ownerID)
+	subCnt, err := s.Instance.Table(common.BKTableNameResourceDirectory).Find(subCond).Count(ctx)
+	if err != nil {
+		blog.Errorf("delete resource directory, count sub directories failed, err: %v, condition: %v", err, subCond)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	hostCond := util.SetModOwner(common.KvMap{common.BKResourceDirIDField: dirID}, ownerID)
+	hostCnt, err := s.Instance.Table(common.BKTableNameBaseHost).Find(hostCond).Count(ctx)
+	if err != nil {
+		blog.Errorf("delete resource directory, count hosts failed, err: %v, condition: %v", err, hostCond)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	if 0 != subCnt || 0 != hostCnt {
+		blog.Errorf("delete resource directory %d, but it has %d sub directories and %d hosts", dirID, subCnt, hostCnt)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrResourceDirNotEmpty)})
+		return
+	}
+
+	filter := util.SetModOwner(common.KvMap{common.BKResourceDirIDField: dirID}, ownerID)
+	if err := s.Instance.Table(common.BKTableNameResourceDirectory).Delete(ctx, filter); err != nil {
+		blog.Errorf("delete resource directory failed, err: %v, filter: %v", err, filter)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchResourceDirectory search the directories by condition
+func (s *Service) SearchResourceDirectory(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("search resource directory failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := make(map[string]interface{})
+	if nil != input.Condition {
+		cond, ok := input.Condition.(map[string]interface{})
+		if !ok {
+			blog.Errorf("search resource directory failed, invalid condition: %v", input.Condition)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "condition")})
+			return
+		}
+		condition = cond
+	}
+	condition = util.SetModOwner(condition, ownerID)
+	if 0 == input.Limit {
+		input.Limit = common.BKNoLimit
+	}
+	if "" == input.Sort {
+		input.Sort = common.BKResourceDirIDField
+	}
+
+	count, err := s.Instance.Table(common.BKTableNameResourceDirectory).Find(condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("search resource directory failed, err: %v, condition: %v", err, condition)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	result := make([]meta.ResourceDirectory, 0)
+	err = s.Instance.Table(common.BKTableNameResourceDirectory).Find(condition).Sort(input.Sort).
+		Start(uint64(input.Start)).Limit(uint64(input.Limit)).All(ctx, &result)
+	if err != nil {
+		blog.Errorf("search resource directory failed, err: %v, condition: %v", err, condition)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.SearchResourceDirectoryResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data: meta.SearchResourceDirectory{
+			Count: count,
+			Info:  result,
+		},
+	})
+}
+
+// MoveHostToResourceDirectory set the directory of the hosts, the caller should make
+// sure the hosts are in the resource pool
+func (s *Service) MoveHostToResourceDirectory(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.MoveHostToResourceDirectory)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("move host to resource directory failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if 0 == len(input.HostIDs) {
+		resp.WriteEntity(meta.NewSuccessResp(nil))
+		return
+	}
+
+	if 0 != input.DirID {
+		cond := util.SetModOwner(common.KvMap{common.BKResourceDirIDField: input.DirID}, ownerID)
+		cnt, err := s.Instance.Table(common.BKTableNameResourceDirectory).Find(cond).Count(ctx)
+		if err != nil {
+			blog.Errorf("move host to resource directory, get directory failed, err: %v, condition: %v", err, cond)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+			return
+		}
+		if 0 == cnt {
+			blog.Errorf("move host to resource directory, directory %d not exists", input.DirID)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKResourceDirIDField)})
+			return
+		}
+	}
+
+	filter := common.KvMap{common.BKHostIDField: common.KvMap{common.BKDBIN: input.HostIDs}}
+	filter = util.SetModOwner(filter, ownerID)
+	data := common.KvMap{common.BKResourceDirIDField: input.DirID}
+	if err := s.Instance.Table(common.BKTableNameBaseHost).Update(ctx, filter, data); err != nil {
+		blog.Errorf("move host to resource directory failed, err: %v, filter: %v, data: %v", err, filter, data)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// validateResourceDirectory check the parent directory exists and is not the directory
+// itself or its sub directory, and the name is unique in the parent directory.
+// dirID is 0 when create a directory.
+func (s *Service) validateResourceDirectory(ctx context.Context, defErr errors.DefaultCCErrorIf, ownerID string, dirID, parentID int64, name string) errors.CCError {
+	if 0 != parentID {
+		dirs := make([]meta.ResourceDirectory, 0)
+		cond := util.SetModOwner(common.KvMap{}, ownerID)
+		if err := s.Instance.Table(common.BKTableNameResourceDirectory).Find(cond).All(ctx, &dirs); err != nil {
+			blog.Errorf("validate resource directory, get directories failed, err: %v, condition: %v", err, cond)
+			return defErr.Error(common.CCErrCommDBSelectFailed)
+		}
+
+		exists := false
+		for _, dir := range dirs {
+			if dir.DirID == parentID {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			blog.Errorf("validate resource directory, parent directory %d not exists", parentID)
+			return defErr.Error(common.CCErrResourceDirParentInvalid)
+		}
+		if 0 != dirID && util.InArray(parentID, meta.SubDirectoryIDs(dirs, dirID)) {
+			blog.Errorf("validate resource directory, directory %d can't be moved into %d", dirID, parentID)
+			return defErr.Error(common.CCErrResourceDirParentInvalid)
+		}
+	}
+
+	cond := mapstr.MapStr{"bk_parent_id": parentID, "bk_dir_name": name}
+	if 0 != dirID {
+		cond[common.BKResourceDirIDField] = mapstr.MapStr{common.BKDBNE: dirID}
+	}
+	cnt, err := s.Instance.Table(common.BKTableNameResourceDirectory).Find(util.SetModOwner(cond, ownerID)).Count(ctx)
+	if err != nil {
+		blog.Errorf("validate resource directory, check name failed, err: %v, condition: %v", err, cond)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if 0 != cnt {
+		blog.Errorf("validate resource directory, name %s exists in parent %d", name, parentID)
+		return defErr.Errorf(common.CCErrResourceDirNameDuplicate, name)
+	}
+	return nil
+}
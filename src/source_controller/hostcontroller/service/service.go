@@ -72,6 +72,9 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.DELETE("/userapi/{bk_biz_id}/{id}").To(s.DeleteUserConfig))
 	api.Route(api.POST("/userapi/search").To(s.GetUserConfig))
 	api.Route(api.GET("/userapi/detail/{bk_biz_id}/{id}").To(s.UserConfigDetail))
+	api.Route(api.POST("/userapi/scheduled/search").To(s.SearchScheduledUserConfig))
+	api.Route(api.POST("/userapi/snapshot/{bk_biz_id}/{id}").To(s.SaveUserConfigSnapshot))
+	api.Route(api.POST("/userapi/snapshot/search/{bk_biz_id}/{id}").To(s.SearchUserConfigSnapshot))
 	api.Route(api.POST("/usercustom/{bk_user}").To(s.AddUserCustom))
 	api.Route(api.PUT("/usercustom/{bk_user}/{id}").To(s.UpdateUserCustomByID))
 	api.Route(api.POST("/usercustom/user/search/{bk_user}").To(s.GetUserCustomByUser))
@@ -82,6 +85,19 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.DELETE("/host/lock").To(s.UnlockHost))
 	api.Route(api.POST("/host/lock/search").To(s.QueryLockHost))
 
+	api.Route(api.POST("/transfer/plan").To(s.AddHostTransferPlan))
+	api.Route(api.PUT("/transfer/plan/{bk_plan_id}").To(s.UpdateHostTransferPlan))
+	api.Route(api.DELETE("/transfer/plan/{bk_plan_id}").To(s.DeleteHostTransferPlan))
+	api.Route(api.POST("/transfer/plan/search").To(s.SearchHostTransferPlan))
+	api.Route(api.POST("/resource/directory").To(s.AddResourceDirectory))
+	api.Route(api.PUT("/resource/directory/{bk_dir_id}").To(s.UpdateResourceDirectory))
+	api.Route(api.DELETE("/resource/directory/{bk_dir_id}").To(s.DeleteResourceDirectory))
+	api.Route(api.POST("/resource/directory/search").To(s.SearchResourceDirectory))
+	api.Route(api.POST("/resource/directory/host/move").To(s.MoveHostToResourceDirectory))
+	api.Route(api.POST("/duplicate/host/save").To(s.SaveHostDuplicates))
+	api.Route(api.PUT("/duplicate/host/{bk_duplicate_id}").To(s.UpdateHostDuplicateStatus))
+	api.Route(api.POST("/duplicate/host/search").To(s.SearchHostDuplicates))
+
 	//Cloud host resource sync
 	api.Route(api.POST("/hosts/cloud/add").To(s.AddCloudTask))
 	api.Route(api.POST("/hosts/cloud/confirm").To(s.ResourceConfirm))
@@ -0,0 +1,246 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const hostTransferPlanIDField = "bk_plan_id"
+
+// AddHostTransferPlan save a new host transfer plan as draft
+func (s *Service) AddHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	plan := new(meta.HostTransferPlan)
+	if err := json.NewDecoder(req.Request.Body).Decode(plan); err != nil {
+		blog.Errorf("add host transfer plan failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	id, err := s.Instance.NextSequence(ctx, common.BKTableNameHostTransferPlan)
+	if err != nil {
+		blog.Errorf("add host transfer plan, get id failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	now := time.Now().UTC()
+	plan.PlanID = int64(id)
+	plan.Status = meta.HostTransferPlanStatusDraft
+	plan.Creator = util.GetUser(pheader)
+	plan.Approver = ""
+	plan.ApproveTime = nil
+	plan.RejectReason = ""
+	plan.StartTime = nil
+	plan.FinishTime = nil
+	plan.Results = make([]meta.HostTransferResult, 0)
+	plan.OwnerID = ownerID
+	plan.CreateTime = now
+	plan.LastTime = now
+	if err := s.Instance.Table(common.BKTableNameHostTransferPlan).Insert(ctx, plan); err != nil {
+		blog.Errorf("add host transfer plan failed, err: %v, plan: %+v", err, plan)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.HostTransferPlanResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     *plan,
+	})
+}
+
+// UpdateHostTransferPlan update the plan when it's in the expected status, the
+// status check makes the state transition safe against concurrent operations.
+func (s *Service) UpdateHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	planID, err := strconv.ParseInt(req.PathParameter(hostTransferPlanIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("update host transfer plan failed, invalid plan id[%s], err: %v", req.PathParameter(hostTransferPlanIDField), err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, hostTransferPlanIDField)})
+		return
+	}
+
+	input := new(meta.UpdateHostTransferPlan)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("update host transfer plan[%d] failed with decode body err: %v", planID, err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	filter := common.KvMap{hostTransferPlanIDField: planID}
+	filter = util.SetModOwner(filter, ownerID)
+	plan := new(meta.HostTransferPlan)
+	if err := s.Instance.Table(common.BKTableNameHostTransferPlan).Find(filter).One(ctx, plan); err != nil {
+		if s.Instance.IsNotFoundError(err) {
+			blog.Errorf("update host transfer plan, plan not exists, filter: %v", filter)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return
+		}
+		blog.Errorf("update host transfer plan, get plan failed, err: %v, filter: %v", err, filter)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	if 0 != len(input.Status) {
+		if !util.InStrArr(input.Status, plan.Status) {
+			blog.Errorf("update host transfer plan[%d], status %s is not one of %v", planID, plan.Status, input.Status)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrHostTransferPlanStatusInvalid, plan.Status, "updated")})
+			return
+		}
+		filter["status"] = common.KvMap{common.BKDBIN: input.Status}
+	}
+
+	// the fields maintained by the controller can't be changed
+	data := common.KvMap{"last_time": time.Now().UTC()}
+	for key, val := range input.Data {
+		switch key {
+		case hostTransferPlanIDField, common.BKOwnerIDField, "creator", common.CreateTimeField, "last_time":
+			continue
+		case "execute_time", "approve_time", "start_time", "finish_time":
+			// the time is a string after json decode, save it as time
+			if str, ok := val.(string); ok {
+				t, err := time.Parse(time.RFC3339Nano, str)
+				if err != nil {
+					blog.Errorf("update host transfer plan[%d] failed, invalid %s: %s", planID, key, str)
+					resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, key)})
+					return
+				}
+				val = t.UTC()
+			}
+		}
+		data[key] = val
+	}
+	if err := s.Instance.Table(common.BKTableNameHostTransferPlan).Update(ctx, filter, data); err != nil {
+		blog.Errorf("update host transfer plan failed, err: %v, filter: %v, data: %v", err, filter, data)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	// read it again, the status may be changed by others between the check and the update
+	delete(filter, "status")
+	if err := s.Instance.Table(common.BKTableNameHostTransferPlan).Find(filter).One(ctx, plan); err != nil {
+		blog.Errorf("update host transfer plan, get plan failed, err: %v, filter: %v", err, filter)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	if status, ok := data["status"]; ok && status != plan.Status {
+		blog.Errorf("update host transfer plan[%d], status is changed to %s by others", planID, plan.Status)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrHostTransferPlanStatusInvalid, plan.Status, "updated")})
+		return
+	}
+
+	resp.WriteEntity(meta.HostTransferPlanResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     *plan,
+	})
+}
+
+// DeleteHostTransferPlan delete the plan by id
+func (s *Service) DeleteHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	planID, err := strconv.ParseInt(req.PathParameter(hostTransferPlanIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("delete host transfer plan failed, invalid plan id[%s], err: %v", req.PathParameter(hostTransferPlanIDField), err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, hostTransferPlanIDField)})
+		return
+	}
+
+	filter := common.KvMap{hostTransferPlanIDField: planID}
+	filter = util.SetModOwner(filter, ownerID)
+	if err := s.Instance.Table(common.BKTableNameHostTransferPlan).Delete(ctx, filter); err != nil {
+		blog.Errorf("delete host transfer plan failed, err: %v, filter: %v", err, filter)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchHostTransferPlan search the plans, the latest created first by default
+func (s *Service) SearchHostTransferPlan(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+
+	input := new(meta.QueryInput)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("search host transfer plan failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := make(map[string]interface{})
+	if nil != input.Condition {
+		cond, ok := input.Condition.(map[string]interface{})
+		if !ok {
+			blog.Errorf("search host transfer plan failed, invalid condition: %v", input.Condition)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "condition")})
+			return
+		}
+		condition = cond
+	}
+	condition = util.SetModOwner(condition, ownerID)
+	if 0 == input.Limit {
+		input.Limit = common.BKNoLimit
+	}
+	if "" == input.Sort {
+		input.Sort = "-" + common.CreateTimeField
+	}
+
+	count, err := s.Instance.Table(common.BKTableNameHostTransferPlan).Find(condition).Count(ctx)
+	if err != nil {
+		blog.Errorf("search host transfer plan failed, err: %v, condition: %v", err, condition)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	result := make([]meta.HostTransferPlan, 0)
+	err = s.Instance.Table(common.BKTableNameHostTransferPlan).Find(condition).Sort(input.Sort).
+		Start(uint64(input.Start)).Limit(uint64(input.Limit)).All(ctx, &result)
+	if err != nil {
+		blog.Errorf("search host transfer plan failed, err: %v, condition: %v", err, condition)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.SearchHostTransferPlanResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data: meta.SearchHostTransferPlan{
+			Count: count,
+			Info:  result,
+		},
+	})
+}
@@ -75,15 +75,17 @@ func (s *Service) AddUserConfig(req *restful.Request, resp *restful.Response) {
 
 	id := xid.New().String()
 	userQuery := meta.UserConfigMeta{
-		AppID:      addQuery.AppID,
-		Info:       addQuery.Info,
-		Name:       addQuery.Name,
-		ID:         id,
-		CreateTime: time.Now().UTC(),
-		CreateUser: addQuery.CreateUser,
-		OwnerID:    ownerID,
-		ModifyUser: addQuery.CreateUser,
-		UpdateTime: time.Now().UTC(),
+		AppID:        addQuery.AppID,
+		Info:         addQuery.Info,
+		Name:         addQuery.Name,
+		ID:           id,
+		CreateTime:   time.Now().UTC(),
+		CreateUser:   addQuery.CreateUser,
+		OwnerID:      ownerID,
+		ModifyUser:   addQuery.CreateUser,
+		UpdateTime:   time.Now().UTC(),
+		ObjectID:     addQuery.ObjectID,
+		EvalInterval: &addQuery.EvalInterval,
 	}
 
 	err = s.Instance.Table(common.BKTableNameUserAPI).Insert(ctx, userQuery)
@@ -199,6 +201,13 @@ func (s *Service) DeleteUserConfig(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	err = s.Instance.Table(common.BKTableNameUserAPISnapshot).Delete(ctx, params)
+	if nil != err {
+		blog.Errorf("delete user api snapshot fail, error information is %s, params:%v", err.Error(), params)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+
 	resp.WriteEntity(meta.NewSuccessResp(nil))
 }
 